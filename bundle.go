@@ -60,6 +60,38 @@ func SMTPBundle() HostFuncBundle {
 	}
 }
 
+// SecretBundle returns a bundle with secret host functions:
+// secret_get. Secrets are resolved through the given provider; pair it with
+// CapabilityMiddleware so plugins only see secrets they were granted.
+func SecretBundle(provider SecretProvider) HostFuncBundle {
+	return &staticBundle{
+		handlers: map[string]ByteHandler{
+			"secret_get": NewJSONHandler(func(ctx context.Context, req SecretGetRequest) SecretGetResponse {
+				return PerformSecretGet(ctx, req, provider)
+			}),
+		},
+	}
+}
+
+// KVBundle returns a bundle with key-value host functions:
+// kv_get, kv_set, kv_delete. Keys are namespaced per plugin; pair it with
+// CapabilityMiddleware to enforce the plugin's kv grants.
+func KVBundle(store KVStore) HostFuncBundle {
+	return &staticBundle{
+		handlers: map[string]ByteHandler{
+			"kv_get": NewJSONHandler(func(ctx context.Context, req KVGetRequest) KVGetResponse {
+				return PerformKVGet(ctx, req, store)
+			}),
+			"kv_set": NewJSONHandler(func(ctx context.Context, req KVSetRequest) KVSetResponse {
+				return PerformKVSet(ctx, req, store)
+			}),
+			"kv_delete": NewJSONHandler(func(ctx context.Context, req KVDeleteRequest) KVDeleteResponse {
+				return PerformKVDelete(ctx, req, store)
+			}),
+		},
+	}
+}
+
 // SSRFCheckRequest is the request type for SSRF validation.
 type SSRFCheckRequest struct {
 	// Address is the target address to validate (host:port format).
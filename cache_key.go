@@ -0,0 +1,93 @@
+package hostlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"math/big"
+	"sort"
+)
+
+// StableConfigHash returns a hex-encoded SHA-256 cache key for a plugin
+// manifest and its configuration.
+//
+// The config is canonicalized before hashing: map keys are sorted and numbers
+// are compared by value, so int(1), float64(1) and json.Number("1.0") hash
+// the same. Values that cannot be represented as JSON (channels, NaN, ...)
+// are hashed by their Go representation, which is stable but not normalized.
+func StableConfigHash(manifest []byte, config map[string]interface{}) string {
+	h := sha256.New()
+	writeFramed(h, 'm', manifest)
+
+	var canonical interface{}
+	raw, err := json.Marshal(config)
+	if err == nil {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		err = dec.Decode(&canonical)
+	}
+	if err != nil {
+		writeFramed(h, '!', []byte(fmt.Sprintf("%#v", config)))
+	} else {
+		writeCanonical(h, canonical)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeFramed writes a type tag and length-prefixed payload, so adjacent
+// values cannot run together and collide.
+func writeFramed(h hash.Hash, tag byte, b []byte) {
+	var n [9]byte
+	n[0] = tag
+	binary.BigEndian.PutUint64(n[1:], uint64(len(b)))
+	_, _ = h.Write(n[:])
+	_, _ = h.Write(b)
+}
+
+func writeCanonical(h hash.Hash, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		writeFramed(h, 'z', nil)
+	case bool:
+		if v {
+			writeFramed(h, 'b', []byte{1})
+		} else {
+			writeFramed(h, 'b', []byte{0})
+		}
+	case string:
+		writeFramed(h, 's', []byte(v))
+	case json.Number:
+		writeFramed(h, 'n', []byte(canonicalNumber(v)))
+	case []interface{}:
+		writeFramed(h, 'a', binary.BigEndian.AppendUint64(nil, uint64(len(v))))
+		for _, e := range v {
+			writeCanonical(h, e)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		writeFramed(h, 'o', binary.BigEndian.AppendUint64(nil, uint64(len(keys))))
+		for _, k := range keys {
+			writeFramed(h, 's', []byte(k))
+			writeCanonical(h, v[k])
+		}
+	}
+}
+
+// canonicalNumber renders n as an exact reduced fraction so that "1", "1.0"
+// and "1e0" agree.
+func canonicalNumber(n json.Number) string {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return n.String()
+	}
+	return r.RatString()
+}
@@ -0,0 +1,72 @@
+package hostlib
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStableConfigHash_MapOrder(t *testing.T) {
+	manifest := []byte(`{"name":"file"}`)
+
+	a := map[string]interface{}{}
+	b := map[string]interface{}{}
+	keys := []string{"path", "mode", "recursive", "depth", "patterns", "options"}
+	values := map[string]interface{}{
+		"path":      "/etc",
+		"mode":      "0644",
+		"recursive": true,
+		"depth":     3,
+		"patterns":  []interface{}{"*.conf", "*.yaml"},
+		"options":   map[string]interface{}{"follow": false, "limit": 10},
+	}
+	for _, k := range keys {
+		a[k] = values[k]
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		b[keys[i]] = values[keys[i]]
+	}
+
+	want := StableConfigHash(manifest, a)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, want, StableConfigHash(manifest, b))
+	}
+}
+
+func TestStableConfigHash_NormalizesNumbers(t *testing.T) {
+	manifest := []byte("m")
+	base := StableConfigHash(manifest, map[string]interface{}{"n": 1, "f": 1.5})
+
+	for _, cfg := range []map[string]interface{}{
+		{"n": int64(1), "f": float32(1.5)},
+		{"n": 1.0, "f": 1.5},
+		{"n": uint8(1), "f": json.Number("1.50")},
+		{"n": json.Number("1e0"), "f": json.Number("15e-1")},
+	} {
+		assert.Equal(t, base, StableConfigHash(manifest, cfg), "%v", cfg)
+	}
+}
+
+func TestStableConfigHash_Distinguishes(t *testing.T) {
+	manifest := []byte("m")
+	base := StableConfigHash(manifest, map[string]interface{}{"k": "1"})
+
+	assert.NotEqual(t, base, StableConfigHash([]byte("other"), map[string]interface{}{"k": "1"}))
+	assert.NotEqual(t, base, StableConfigHash(manifest, map[string]interface{}{"k": 1}))
+	assert.NotEqual(t, base, StableConfigHash(manifest, map[string]interface{}{"k": []interface{}{"1"}}))
+	assert.NotEqual(t,
+		StableConfigHash(manifest, map[string]interface{}{"a": "bc"}),
+		StableConfigHash(manifest, map[string]interface{}{"ab": "c"}))
+	assert.NotEqual(t,
+		StableConfigHash(manifest, nil),
+		StableConfigHash(manifest, map[string]interface{}{}))
+}
+
+func TestStableConfigHash_Unmarshalable(t *testing.T) {
+	cfg := map[string]interface{}{"x": math.NaN()}
+	assert.NotPanics(t, func() {
+		assert.Len(t, StableConfigHash(nil, cfg), 64)
+	})
+}
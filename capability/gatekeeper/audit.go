@@ -0,0 +1,123 @@
+package gatekeeper
+
+import (
+	"time"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability"
+)
+
+// AuditDecision is the outcome recorded for a single capability.
+type AuditDecision string
+
+const (
+	AuditGranted AuditDecision = "granted"
+	AuditDenied  AuditDecision = "denied"
+)
+
+// DecisionSource identifies what produced a decision.
+type DecisionSource string
+
+const (
+	// SourceUser means the prompter answered.
+	SourceUser DecisionSource = "user"
+	// SourcePolicy means the security level decided without prompting
+	// (permissive auto-grant or strict denial of broad capabilities).
+	SourcePolicy DecisionSource = "policy"
+	// SourceTrustAll means every capability was granted by the trustAll flag.
+	SourceTrustAll DecisionSource = "trust_all"
+	// SourcePreapproved means the capability was covered by WithPreapprovedGrants.
+	SourcePreapproved DecisionSource = "preapproved"
+	// SourceNonInteractive means the capability was denied because no prompt was possible.
+	SourceNonInteractive DecisionSource = "non_interactive"
+)
+
+// DecisionRecord describes one grant decision made by the gatekeeper.
+type DecisionRecord struct {
+	Timestamp     time.Time
+	Rule          interface{}
+	PluginName    string
+	Kind          string
+	Description   string
+	SecurityLevel SecurityLevel
+	Decision      AuditDecision
+	Source        DecisionSource
+	IsBroad       bool
+	// Saved reports whether the decision was persisted to the grant store.
+	Saved bool
+}
+
+// DecisionAuditor receives a DecisionRecord for every capability decided
+// during GrantCapabilities. Capabilities already present in the store are not
+// reported since no decision was made for them.
+type DecisionAuditor func(DecisionRecord)
+
+// WithDecisionAuditor sets a sink for grant decisions, e.g. to forward an
+// audit trail to a SIEM. Records are delivered once GrantCapabilities has
+// finished, in the order the decisions were made, including when it fails.
+func WithDecisionAuditor(auditor DecisionAuditor) Option {
+	return func(g *Gatekeeper) { g.auditor = auditor }
+}
+
+// decisionTrail collects the records of a single GrantCapabilities call.
+type decisionTrail struct {
+	records    []DecisionRecord
+	pluginName string
+	level      SecurityLevel
+	// persist indexes the records of "always" grants, which are marked
+	// Saved only once the store write succeeds.
+	persist []int
+}
+
+func (t *decisionTrail) add(req capability.Request, decision AuditDecision, source DecisionSource, always bool) {
+	if always {
+		t.persist = append(t.persist, len(t.records))
+	}
+	t.records = append(t.records, DecisionRecord{
+		Timestamp:     time.Now(),
+		Rule:          req.Rule,
+		PluginName:    req.PluginName,
+		Kind:          req.Kind,
+		Description:   req.Description,
+		SecurityLevel: t.level,
+		Decision:      decision,
+		Source:        source,
+		IsBroad:       req.IsBroad,
+	})
+}
+
+// addSet records the same decision for every capability in gs.
+func (t *decisionTrail) addSet(gs *hostfunc.GrantSet, decision AuditDecision, source DecisionSource) {
+	if gs == nil {
+		return
+	}
+	for _, req := range buildRequests(gs, t.pluginName) {
+		t.add(req, decision, source, false)
+	}
+}
+
+// markSaved sets Saved on the records of "always" grants after the store
+// write succeeded.
+func (t *decisionTrail) markSaved() {
+	for _, i := range t.persist {
+		t.records[i].Saved = true
+	}
+}
+
+func (g *Gatekeeper) recordDecisions(t *decisionTrail) {
+	if g.auditor == nil {
+		return
+	}
+	for _, rec := range t.records {
+		g.auditor(rec)
+	}
+}
+
+// decisionSource reports whether evaluateWithSecurityLevel decides req by
+// policy or by prompting.
+func (g *Gatekeeper) decisionSource(req capability.Request) DecisionSource {
+	if g.securityLevel == SecurityPermissive || (req.IsBroad && g.securityLevel == SecurityStrict) {
+		return SourcePolicy
+	}
+	return SourceUser
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
 	"github.com/reglet-dev/reglet-host-sdk/capability"
@@ -24,9 +25,12 @@ const (
 // Gatekeeper handles capability granting: loads stored grants,
 // diffs against required, prompts for missing, persists decisions.
 type Gatekeeper struct {
-	store         capability.GrantStore
-	prompter      capability.Prompter
-	securityLevel SecurityLevel
+	store          capability.GrantStore
+	prompter       capability.Prompter
+	preapproved    *hostfunc.GrantSet
+	auditor        DecisionAuditor
+	securityLevel  SecurityLevel
+	batchPrompting bool
 }
 
 // Option configures a Gatekeeper.
@@ -47,6 +51,21 @@ func WithSecurityLevel(level SecurityLevel) Option {
 	return func(g *Gatekeeper) { g.securityLevel = level }
 }
 
+// WithBatchPrompting asks for all missing capabilities in a single prompt
+// instead of one prompt per capability. Prompters implementing
+// capability.BatchPrompter may also offer to save the whole batch.
+func WithBatchPrompting(enabled bool) Option {
+	return func(g *Gatekeeper) { g.batchPrompting = enabled }
+}
+
+// WithPreapprovedGrants sets a vetted allowlist of capabilities that are
+// granted without prompting, e.g. for CI. Requests covered by the allowlist are
+// treated as already granted; anything outside it is still prompted for (or
+// rejected in non-interactive mode). The allowlist is never written to the store.
+func WithPreapprovedGrants(grants *hostfunc.GrantSet) Option {
+	return func(g *Gatekeeper) { g.preapproved = grants }
+}
+
 // NewGatekeeper creates a capability gatekeeper with pluggable store and prompter.
 func NewGatekeeper(opts ...Option) *Gatekeeper {
 	g := &Gatekeeper{
@@ -75,9 +94,13 @@ func (g *Gatekeeper) GrantCapabilities(
 		return &hostfunc.GrantSet{}, nil
 	}
 
+	trail := &decisionTrail{pluginName: g.getPluginName(capabilityInfo), level: g.securityLevel}
+	defer g.recordDecisions(trail)
+
 	// If trustAll flag is set, grant everything
 	if trustAll {
 		slog.Warn("Auto-granting all requested capabilities (--trust-plugins enabled)")
+		trail.addSet(required, AuditGranted, SourceTrustAll)
 		return required.Clone(), nil
 	}
 
@@ -87,11 +110,20 @@ func (g *Gatekeeper) GrantCapabilities(
 		existingGrants = &hostfunc.GrantSet{}
 	}
 
+	// Requested capabilities covered by the preapproved allowlist count as
+	// granted for this run only.
+	preapproved := g.preapprovedSubset(required)
+	if preapproved != nil {
+		trail.addSet(preapproved.Difference(existingGrants), AuditGranted, SourcePreapproved)
+	}
+	effective := existingGrants.Clone()
+	effective.Merge(preapproved)
+
 	// Determine which capabilities are not already granted
-	missing := required.Difference(existingGrants)
+	missing := required.Difference(effective)
 
 	if missing.IsEmpty() {
-		return existingGrants, nil
+		return effective, nil
 	}
 
 	// Deduplicate missing capabilities
@@ -99,6 +131,11 @@ func (g *Gatekeeper) GrantCapabilities(
 
 	// Non-interactive mode check
 	if !g.prompter.IsInteractive() {
+		trail.addSet(missing, AuditDenied, SourceNonInteractive)
+		if g.preapproved != nil {
+			return nil, fmt.Errorf("capabilities requested outside the preapproved allowlist: %w",
+				g.prompter.FormatNonInteractiveError(missing))
+		}
 		return nil, g.prompter.FormatNonInteractiveError(missing)
 	}
 
@@ -106,7 +143,7 @@ func (g *Gatekeeper) GrantCapabilities(
 	newGrants := existingGrants.Clone()
 	shouldSave := false
 
-	if err := g.promptForCapabilities(missing, capabilityInfo, newGrants, &shouldSave); err != nil {
+	if err := g.promptForCapabilities(missing, capabilityInfo, newGrants, &shouldSave, trail); err != nil {
 		return nil, err
 	}
 
@@ -115,11 +152,25 @@ func (g *Gatekeeper) GrantCapabilities(
 		if err := g.store.Save(newGrants); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to save config: %v\n", err)
 		} else {
+			trail.markSaved()
 			fmt.Fprintf(os.Stderr, "Permissions saved to %s\n", g.store.ConfigPath())
 		}
 	}
 
-	return newGrants, nil
+	// Preapproved grants are merged after saving so they are never persisted.
+	result := newGrants.Clone()
+	result.Merge(preapproved)
+	return result, nil
+}
+
+// preapprovedSubset returns the part of required that the preapproved
+// allowlist covers. Grant sets compare rules by equality, so the intersection
+// is what remains after removing everything the allowlist does not cover.
+func (g *Gatekeeper) preapprovedSubset(required *hostfunc.GrantSet) *hostfunc.GrantSet {
+	if g.preapproved == nil {
+		return nil
+	}
+	return required.Difference(required.Difference(g.preapproved))
 }
 
 func (g *Gatekeeper) getPluginName(info map[string]capability.CapabilityInfo) string {
@@ -131,246 +182,183 @@ func (g *Gatekeeper) getPluginName(info map[string]capability.CapabilityInfo) st
 	return ""
 }
 
-// promptForCapabilities prompts the user for each type of missing capability.
+// promptForCapabilities prompts the user for each missing capability, either
+// one at a time or, in batch mode, in a single prompt.
 func (g *Gatekeeper) promptForCapabilities(
 	missing *hostfunc.GrantSet,
 	capabilityInfo map[string]capability.CapabilityInfo,
 	newGrants *hostfunc.GrantSet,
 	shouldSave *bool,
+	trail *decisionTrail,
 ) error {
-	pluginName := g.getPluginName(capabilityInfo)
-	if err := g.promptForNetwork(missing, pluginName, newGrants, shouldSave); err != nil {
-		return err
-	}
-	if err := g.promptForFS(missing, pluginName, newGrants, shouldSave); err != nil {
-		return err
+	reqs := buildRequests(missing, g.getPluginName(capabilityInfo))
+	if g.batchPrompting {
+		return g.promptBatch(reqs, newGrants, shouldSave, trail)
 	}
-	if err := g.promptForEnv(missing, pluginName, newGrants, shouldSave); err != nil {
-		return err
-	}
-	return g.promptForExec(missing, pluginName, newGrants, shouldSave)
-}
 
-func (g *Gatekeeper) promptForNetwork(
-	missing *hostfunc.GrantSet,
-	pluginName string,
-	newGrants *hostfunc.GrantSet,
-	shouldSave *bool,
-) error {
-	if missing.Network == nil {
-		return nil
-	}
-	for _, rule := range missing.Network.Rules {
-		isBroad := len(rule.Hosts) == 1 && rule.Hosts[0] == "*" && len(rule.Ports) == 1 && rule.Ports[0] == "*"
-		gs := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{rule}}}
-
-		req := capability.Request{
-			PluginName:  pluginName,
-			Kind:        "network",
-			Rule:        rule,
-			Description: fmt.Sprintf("network %v:%v", rule.Hosts, rule.Ports),
-			IsBroad:     isBroad,
-		}
-
-		granted, always, err := g.evaluateWithSecurityLevel(req, capability.AnalyzeRisk(gs).RiskFactors)
+	for _, req := range reqs {
+		source := g.decisionSource(req)
+		granted, always, err := g.evaluateWithSecurityLevel(req)
 		if err != nil {
+			if source == SourcePolicy {
+				trail.add(req, AuditDenied, source, false)
+			}
 			return err
 		}
-		if granted {
-			toMerge := &hostfunc.GrantSet{
-				Network: &hostfunc.NetworkCapability{
-					Rules: []hostfunc.NetworkRule{rule},
-				},
-			}
-			newGrants.Merge(toMerge)
-			if always {
-				*shouldSave = true
-			}
-		} else {
-			return fmt.Errorf("capability denied by user: network %v:%v", rule.Hosts, rule.Ports)
+		if !granted {
+			trail.add(req, AuditDenied, source, false)
+			return deniedError(req)
+		}
+		trail.add(req, AuditGranted, source, always)
+		newGrants.Merge(req.GrantSet())
+		if always {
+			*shouldSave = true
 		}
 	}
 	return nil
 }
 
-func (g *Gatekeeper) promptForFS(
-	missing *hostfunc.GrantSet,
-	pluginName string,
+// promptBatch applies the security level to each request, then asks for all
+// remaining requests in one prompt.
+func (g *Gatekeeper) promptBatch(
+	reqs []capability.Request,
 	newGrants *hostfunc.GrantSet,
 	shouldSave *bool,
+	trail *decisionTrail,
 ) error {
-	if missing.FS == nil {
+	var pending []capability.Request
+	for _, req := range reqs {
+		if req.IsBroad && g.securityLevel == SecurityStrict {
+			// evaluateWithSecurityLevel never prompts for this case.
+			_, _, err := g.evaluateWithSecurityLevel(req)
+			trail.add(req, AuditDenied, SourcePolicy, false)
+			return err
+		}
+		if g.securityLevel == SecurityPermissive {
+			newGrants.Merge(req.GrantSet())
+			trail.add(req, AuditGranted, SourcePolicy, false)
+			continue
+		}
+		pending = append(pending, req)
+	}
+	if len(pending) == 0 {
 		return nil
 	}
-	for _, rule := range missing.FS.Rules {
-		for _, path := range rule.Read {
-			isBroad := path == "/**" || path == "**"
-			gs := &hostfunc.GrantSet{
-				FS: &hostfunc.FileSystemCapability{
-					Rules: []hostfunc.FileSystemRule{{Read: []string{path}}},
-				},
-			}
 
-			req := capability.Request{
-				PluginName:  pluginName,
-				Kind:        "fs",
-				Rule:        hostfunc.FileSystemRule{Read: []string{path}},
-				Description: fmt.Sprintf("fs read:%s", path),
-				IsBroad:     isBroad,
-			}
+	var (
+		granted *hostfunc.GrantSet
+		always  bool
+		err     error
+	)
+	if bp, ok := g.prompter.(capability.BatchPrompter); ok {
+		granted, always, err = bp.PromptForCapabilityBatch(pending)
+	} else {
+		granted, err = g.prompter.PromptForCapabilities(pending)
+	}
+	if err != nil {
+		return err
+	}
 
-			granted, always, err := g.evaluateWithSecurityLevel(req, capability.AnalyzeRisk(gs).RiskFactors)
-			if err != nil {
-				return err
-			}
-			if granted {
-				toMerge := &hostfunc.GrantSet{
-					FS: &hostfunc.FileSystemCapability{
-						Rules: []hostfunc.FileSystemRule{{Read: []string{path}}},
-					},
-				}
-				newGrants.Merge(toMerge)
-				if always {
-					*shouldSave = true
-				}
-			} else {
-				return fmt.Errorf("capability denied by user: fs read:%s", path)
-			}
+	var denied *capability.Request
+	for i, req := range pending {
+		if granted.Contains(req.GrantSet()) {
+			trail.add(req, AuditGranted, SourceUser, always)
+			continue
 		}
-		for _, path := range rule.Write {
-			isBroad := path == "/**" || path == "**"
-			gs := &hostfunc.GrantSet{
-				FS: &hostfunc.FileSystemCapability{
-					Rules: []hostfunc.FileSystemRule{{Write: []string{path}}},
-				},
-			}
-
-			req := capability.Request{
-				PluginName:  pluginName,
-				Kind:        "fs",
-				Rule:        hostfunc.FileSystemRule{Write: []string{path}},
-				Description: fmt.Sprintf("fs write:%s", path),
-				IsBroad:     isBroad,
-			}
-
-			granted, always, err := g.evaluateWithSecurityLevel(req, capability.AnalyzeRisk(gs).RiskFactors)
-			if err != nil {
-				return err
-			}
-			if granted {
-				toMerge := &hostfunc.GrantSet{
-					FS: &hostfunc.FileSystemCapability{
-						Rules: []hostfunc.FileSystemRule{{Write: []string{path}}},
-					},
-				}
-				newGrants.Merge(toMerge)
-				if always {
-					*shouldSave = true
-				}
-			} else {
-				return fmt.Errorf("capability denied by user: fs write:%s", path)
-			}
+		trail.add(req, AuditDenied, SourceUser, false)
+		if denied == nil {
+			denied = &pending[i]
 		}
 	}
+	if denied != nil {
+		return deniedError(*denied)
+	}
+	newGrants.Merge(granted)
+	if always {
+		*shouldSave = true
+	}
 	return nil
 }
 
-func (g *Gatekeeper) promptForEnv(
-	missing *hostfunc.GrantSet,
-	pluginName string,
-	newGrants *hostfunc.GrantSet,
-	shouldSave *bool,
-) error {
-	if missing.Env == nil {
-		return nil
+// buildRequests splits missing into one Request per network rule, filesystem
+// path, environment variable and command, in that order.
+func buildRequests(missing *hostfunc.GrantSet, pluginName string) []capability.Request {
+	var reqs []capability.Request
+	add := func(req capability.Request) {
+		req.PluginName = pluginName
+		req.RiskFactors = capability.AnalyzeRisk(req.GrantSet()).RiskFactors
+		reqs = append(reqs, req)
 	}
-	for _, v := range missing.Env.Variables {
-		isBroad := v == "*"
-		gs := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{v}}}
-
-		req := capability.Request{
-			PluginName:  pluginName,
-			Kind:        "env",
-			Rule:        v,
-			Description: fmt.Sprintf("env %s", v),
-			IsBroad:     isBroad,
-		}
 
-		granted, always, err := g.evaluateWithSecurityLevel(req, capability.AnalyzeRisk(gs).RiskFactors)
-		if err != nil {
-			return err
+	if missing.Network != nil {
+		for _, rule := range missing.Network.Rules {
+			add(capability.Request{
+				Kind:        "network",
+				Rule:        rule,
+				Description: fmt.Sprintf("network %v:%v", rule.Hosts, rule.Ports),
+				IsBroad:     len(rule.Hosts) == 1 && rule.Hosts[0] == "*" && len(rule.Ports) == 1 && rule.Ports[0] == "*",
+			})
 		}
-		if granted {
-			toMerge := &hostfunc.GrantSet{
-				Env: &hostfunc.EnvironmentCapability{
-					Variables: []string{v},
-				},
+	}
+	if missing.FS != nil {
+		for _, rule := range missing.FS.Rules {
+			for _, path := range rule.Read {
+				add(capability.Request{
+					Kind:        "fs",
+					Rule:        hostfunc.FileSystemRule{Read: []string{path}},
+					Description: fmt.Sprintf("fs read:%s", path),
+					IsBroad:     path == "/**" || path == "**",
+				})
 			}
-			newGrants.Merge(toMerge)
-			if always {
-				*shouldSave = true
+			for _, path := range rule.Write {
+				add(capability.Request{
+					Kind:        "fs",
+					Rule:        hostfunc.FileSystemRule{Write: []string{path}},
+					Description: fmt.Sprintf("fs write:%s", path),
+					IsBroad:     path == "/**" || path == "**",
+				})
 			}
-		} else {
-			return fmt.Errorf("capability denied by user: env %s", v)
 		}
 	}
-	return nil
-}
-
-func (g *Gatekeeper) promptForExec(
-	missing *hostfunc.GrantSet,
-	pluginName string,
-	newGrants *hostfunc.GrantSet,
-	shouldSave *bool,
-) error {
-	if missing.Exec == nil {
-		return nil
-	}
-	for _, cmd := range missing.Exec.Commands {
-		isBroad := cmd == "**" || cmd == "*"
-		gs := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{cmd}}}
-
-		req := capability.Request{
-			PluginName:  pluginName,
-			Kind:        "exec",
-			Rule:        cmd,
-			Description: fmt.Sprintf("exec %s", cmd),
-			IsBroad:     isBroad,
-		}
-
-		granted, always, err := g.evaluateWithSecurityLevel(req, capability.AnalyzeRisk(gs).RiskFactors)
-		if err != nil {
-			return err
+	if missing.Env != nil {
+		for _, v := range missing.Env.Variables {
+			add(capability.Request{
+				Kind:        "env",
+				Rule:        v,
+				Description: fmt.Sprintf("env %s", v),
+				IsBroad:     v == "*",
+			})
 		}
-		if granted {
-			toMerge := &hostfunc.GrantSet{
-				Exec: &hostfunc.ExecCapability{
-					Commands: []string{cmd},
-				},
-			}
-			newGrants.Merge(toMerge)
-			if always {
-				*shouldSave = true
-			}
-		} else {
-			return fmt.Errorf("capability denied by user: exec %s", cmd)
+	}
+	if missing.Exec != nil {
+		for _, cmd := range missing.Exec.Commands {
+			add(capability.Request{
+				Kind:        "exec",
+				Rule:        cmd,
+				Description: fmt.Sprintf("exec %s", cmd),
+				IsBroad:     cmd == "**" || cmd == "*",
+			})
 		}
 	}
-	return nil
+	return reqs
 }
 
-// evaluateWithSecurityLevel applies security level policy and prompts if needed.
-func (g *Gatekeeper) evaluateWithSecurityLevel(req capability.Request, riskFactors []capability.RiskFactor) (bool, bool, error) {
-	riskDesc := ""
-	if len(riskFactors) > 0 {
-		riskDesc = riskFactors[0].Description
-	}
+func deniedError(req capability.Request) error {
+	return fmt.Errorf("capability denied by user: %s", req.Description)
+}
 
+// evaluateWithSecurityLevel applies security level policy and prompts if needed.
+func (g *Gatekeeper) evaluateWithSecurityLevel(req capability.Request) (bool, bool, error) {
 	if req.IsBroad {
 		switch g.securityLevel {
 		case SecurityStrict:
-			if riskDesc == "" {
-				riskDesc = "broad access beyond what may be necessary"
+			riskDesc := "broad access beyond what may be necessary"
+			if len(req.RiskFactors) > 0 {
+				descs := make([]string, 0, len(req.RiskFactors))
+				for _, rf := range req.RiskFactors {
+					descs = append(descs, rf.Description)
+				}
+				riskDesc = strings.Join(descs, "; ")
 			}
 			slog.Error("broad capability denied by security policy",
 				"level", "strict",
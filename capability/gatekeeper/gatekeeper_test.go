@@ -0,0 +1,441 @@
+package gatekeeper_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability"
+	"github.com/reglet-dev/reglet-host-sdk/capability/gatekeeper"
+	"github.com/reglet-dev/reglet-host-sdk/capability/grantstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore is an in-memory capability.GrantStore that records saves.
+type memoryStore struct {
+	grants *hostfunc.GrantSet
+	saves  int
+}
+
+func (s *memoryStore) Load() (*hostfunc.GrantSet, error) {
+	if s.grants == nil {
+		return &hostfunc.GrantSet{}, nil
+	}
+	return s.grants.Clone(), nil
+}
+
+func (s *memoryStore) Save(grants *hostfunc.GrantSet) error {
+	s.grants = grants.Clone()
+	s.saves++
+	return nil
+}
+
+func (s *memoryStore) ConfigPath() string { return "memory" }
+
+// scriptedPrompter answers every prompt with the configured decision, except
+// for descriptions listed in refuse. Batch prompts grant the requests whose
+// descriptions are listed in selected.
+type scriptedPrompter struct {
+	selected    map[string]bool
+	refuse      map[string]bool
+	prompts     []capability.Request
+	batches     [][]capability.Request
+	interactive bool
+	grant       bool
+	always      bool
+}
+
+func (p *scriptedPrompter) IsInteractive() bool { return p.interactive }
+
+func (p *scriptedPrompter) PromptForCapability(req capability.Request) (bool, bool, error) {
+	p.prompts = append(p.prompts, req)
+	if p.refuse[req.Description] {
+		return false, false, nil
+	}
+	return p.grant, p.always, nil
+}
+
+func (p *scriptedPrompter) PromptForCapabilities(reqs []capability.Request) (*hostfunc.GrantSet, error) {
+	p.batches = append(p.batches, reqs)
+	grants := &hostfunc.GrantSet{}
+	for _, req := range reqs {
+		if p.selected[req.Description] {
+			grants.Merge(req.GrantSet())
+		}
+	}
+	return grants, nil
+}
+
+// batchScriptedPrompter adds the optional save-all answer.
+type batchScriptedPrompter struct {
+	scriptedPrompter
+}
+
+func (p *batchScriptedPrompter) PromptForCapabilityBatch(reqs []capability.Request) (*hostfunc.GrantSet, bool, error) {
+	grants, err := p.PromptForCapabilities(reqs)
+	return grants, p.always, err
+}
+
+func (p *scriptedPrompter) FormatNonInteractiveError(missing *hostfunc.GrantSet) error {
+	return gatekeeper.NewTerminalPrompter().FormatNonInteractiveError(missing)
+}
+
+func ciAllowlist() *hostfunc.GrantSet {
+	return &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com"}, Ports: []string{"443"}}},
+		},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"HOME", "CI"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/git"}},
+	}
+}
+
+func TestGatekeeper_PreapprovedGrants_Subset(t *testing.T) {
+	store := &memoryStore{}
+	prompter := &scriptedPrompter{interactive: false}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(store),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithPreapprovedGrants(ciAllowlist()),
+	)
+
+	required := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com"}, Ports: []string{"443"}}},
+		},
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"CI"}},
+	}
+
+	granted, err := g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	assert.True(t, granted.Contains(required))
+	assert.Empty(t, prompter.prompts, "preapproved capabilities must not prompt")
+	assert.Zero(t, store.saves, "preapproved capabilities must not be persisted")
+
+	// Only what was requested is granted, not the whole allowlist.
+	assert.Nil(t, granted.Exec)
+	assert.Equal(t, []string{"CI"}, granted.Env.Variables)
+}
+
+func TestGatekeeper_PreapprovedGrants_Superset(t *testing.T) {
+	store := &memoryStore{}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(store),
+		gatekeeper.WithPrompter(&scriptedPrompter{interactive: false}),
+		gatekeeper.WithPreapprovedGrants(ciAllowlist()),
+	)
+
+	required := &hostfunc.GrantSet{
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"CI", "AWS_SECRET_ACCESS_KEY"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/git"}},
+	}
+
+	_, err := g.GrantCapabilities(required, nil, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the preapproved allowlist")
+	assert.Contains(t, err.Error(), "AWS_SECRET_ACCESS_KEY")
+	assert.NotContains(t, err.Error(), "/usr/bin/git")
+	assert.Zero(t, store.saves)
+}
+
+func TestGatekeeper_PreapprovedGrants_NotPersistedAfterPrompt(t *testing.T) {
+	store := &memoryStore{}
+	prompter := &scriptedPrompter{interactive: true, grant: true, always: true}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(store),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithPreapprovedGrants(ciAllowlist()),
+	)
+
+	required := &hostfunc.GrantSet{
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"CI", "EXTRA"}},
+	}
+
+	granted, err := g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"CI", "EXTRA"}, granted.Env.Variables)
+
+	require.Len(t, prompter.prompts, 1)
+	assert.Equal(t, "env EXTRA", prompter.prompts[0].Description)
+	assert.NotEmpty(t, prompter.prompts[0].RiskFactors, "risk factors are passed to the prompter")
+
+	require.Equal(t, 1, store.saves)
+	assert.Equal(t, []string{"EXTRA"}, store.grants.Env.Variables)
+}
+
+func TestGatekeeper_RepromptsForExpiredGrants(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := grantstore.NewFileStore(
+		grantstore.WithPath(filepath.Join(t.TempDir(), "grants.yaml")),
+		grantstore.WithClock(func() time.Time { return now }),
+		grantstore.WithDefaultTTL(24*time.Hour),
+	)
+	prompter := &scriptedPrompter{interactive: true, grant: true, always: true}
+	g := gatekeeper.NewGatekeeper(gatekeeper.WithStore(store), gatekeeper.WithPrompter(prompter))
+
+	required := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}}}
+
+	_, err := g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	require.Len(t, prompter.prompts, 1)
+
+	// Still valid: no prompt.
+	now = now.Add(time.Hour)
+	_, err = g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	assert.Len(t, prompter.prompts, 1)
+
+	// Expired: prompted again.
+	now = now.Add(24 * time.Hour)
+	_, err = g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	assert.Len(t, prompter.prompts, 2)
+}
+
+func batchRequired() *hostfunc.GrantSet {
+	return &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: []string{"api.example.com"}, Ports: []string{"443"}}},
+		},
+		FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{{Read: []string{"/data/**"}}},
+		},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/git"}},
+	}
+}
+
+func TestGatekeeper_BatchPrompting_AllSelected(t *testing.T) {
+	store := &memoryStore{}
+	prompter := &scriptedPrompter{
+		interactive: true,
+		selected: map[string]bool{
+			"network [api.example.com]:[443]": true,
+			"fs read:/data/**":                true,
+			"env HOME":                        true,
+			"exec /usr/bin/git":               true,
+		},
+	}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(store),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithBatchPrompting(true),
+	)
+
+	granted, err := g.GrantCapabilities(batchRequired(), nil, false)
+	require.NoError(t, err)
+	assert.True(t, granted.Contains(batchRequired()))
+
+	require.Len(t, prompter.batches, 1, "all requests go through one prompt")
+	assert.Len(t, prompter.batches[0], 4)
+	assert.Empty(t, prompter.prompts, "no per-capability prompts in batch mode")
+	assert.Zero(t, store.saves, "plain prompters cannot ask to save")
+}
+
+func TestGatekeeper_BatchPrompting_SubsetDenied(t *testing.T) {
+	prompter := &scriptedPrompter{
+		interactive: true,
+		selected: map[string]bool{
+			"network [api.example.com]:[443]": true,
+			"env HOME":                        true,
+		},
+	}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(&memoryStore{}),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithBatchPrompting(true),
+	)
+
+	_, err := g.GrantCapabilities(batchRequired(), nil, false)
+	require.Error(t, err)
+	// Same message as the sequential flow for the first denied capability.
+	assert.Equal(t, "capability denied by user: fs read:/data/**", err.Error())
+}
+
+func TestGatekeeper_BatchPrompting_SaveAll(t *testing.T) {
+	store := &memoryStore{}
+	prompter := &batchScriptedPrompter{scriptedPrompter{
+		interactive: true,
+		always:      true,
+		selected:    map[string]bool{"env HOME": true},
+	}}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(store),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithBatchPrompting(true),
+	)
+
+	required := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}}}
+	_, err := g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, store.saves)
+	assert.Equal(t, []string{"HOME"}, store.grants.Env.Variables)
+}
+
+func TestGatekeeper_BatchPrompting_StrictDeniesBroad(t *testing.T) {
+	prompter := &scriptedPrompter{interactive: true, selected: map[string]bool{"env *": true}}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(&memoryStore{}),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithBatchPrompting(true),
+		gatekeeper.WithSecurityLevel(gatekeeper.SecurityStrict),
+	)
+
+	required := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"*"}}}
+	_, err := g.GrantCapabilities(required, nil, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "strict security policy")
+	assert.Empty(t, prompter.batches)
+}
+
+func TestGatekeeper_DecisionAuditor_MixedRun(t *testing.T) {
+	var records []gatekeeper.DecisionRecord
+	store := &memoryStore{}
+	prompter := &scriptedPrompter{
+		interactive: true,
+		grant:       true,
+		always:      true,
+		refuse:      map[string]bool{"env AWS_SECRET_ACCESS_KEY": true},
+	}
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(store),
+		gatekeeper.WithPrompter(prompter),
+		gatekeeper.WithPreapprovedGrants(ciAllowlist()),
+		gatekeeper.WithDecisionAuditor(func(rec gatekeeper.DecisionRecord) {
+			records = append(records, rec)
+		}),
+	)
+	info := map[string]capability.CapabilityInfo{"p": {PluginName: "my-plugin"}}
+
+	// First run: one preapproved, one granted with "always".
+	required := &hostfunc.GrantSet{
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"CI", "EXTRA"}},
+	}
+	_, err := g.GrantCapabilities(required, info, false)
+	require.NoError(t, err)
+
+	// Second run: EXTRA is now stored, the secret is refused and aborts the flow.
+	required = &hostfunc.GrantSet{
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"EXTRA", "AWS_SECRET_ACCESS_KEY"}},
+	}
+	_, err = g.GrantCapabilities(required, info, false)
+	require.Error(t, err)
+
+	// Third run: trustAll.
+	_, err = g.GrantCapabilities(required, info, true)
+	require.NoError(t, err)
+
+	type summary struct {
+		desc     string
+		decision gatekeeper.AuditDecision
+		source   gatekeeper.DecisionSource
+		saved    bool
+	}
+	var got []summary
+	for _, rec := range records {
+		assert.Equal(t, "my-plugin", rec.PluginName)
+		assert.Equal(t, "env", rec.Kind)
+		assert.Equal(t, gatekeeper.SecurityStandard, rec.SecurityLevel)
+		assert.False(t, rec.Timestamp.IsZero())
+		got = append(got, summary{rec.Description, rec.Decision, rec.Source, rec.Saved})
+	}
+	assert.Equal(t, []summary{
+		{"env CI", gatekeeper.AuditGranted, gatekeeper.SourcePreapproved, false},
+		{"env EXTRA", gatekeeper.AuditGranted, gatekeeper.SourceUser, true},
+		{"env AWS_SECRET_ACCESS_KEY", gatekeeper.AuditDenied, gatekeeper.SourceUser, false},
+		{"env EXTRA", gatekeeper.AuditGranted, gatekeeper.SourceTrustAll, false},
+		{"env AWS_SECRET_ACCESS_KEY", gatekeeper.AuditGranted, gatekeeper.SourceTrustAll, false},
+	}, got)
+}
+
+func TestGatekeeper_DecisionAuditor_AlwaysThenDenied(t *testing.T) {
+	required := &hostfunc.GrantSet{
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"EXTRA", "AWS_SECRET_ACCESS_KEY"}},
+	}
+
+	for _, batch := range []bool{false, true} {
+		var records []gatekeeper.DecisionRecord
+		store := &memoryStore{}
+		prompter := &batchScriptedPrompter{scriptedPrompter{
+			interactive: true,
+			grant:       true,
+			always:      true,
+			refuse:      map[string]bool{"env AWS_SECRET_ACCESS_KEY": true},
+			selected:    map[string]bool{"env EXTRA": true},
+		}}
+		g := gatekeeper.NewGatekeeper(
+			gatekeeper.WithStore(store),
+			gatekeeper.WithPrompter(prompter),
+			gatekeeper.WithBatchPrompting(batch),
+			gatekeeper.WithDecisionAuditor(func(rec gatekeeper.DecisionRecord) {
+				records = append(records, rec)
+			}),
+		)
+
+		_, err := g.GrantCapabilities(required, nil, false)
+		require.Error(t, err, "batch=%v", batch)
+		assert.Zero(t, store.saves, "batch=%v", batch)
+
+		require.Len(t, records, 2, "batch=%v", batch)
+		assert.Equal(t, gatekeeper.AuditGranted, records[0].Decision)
+		assert.False(t, records[0].Saved, "nothing was persisted, batch=%v", batch)
+		assert.Equal(t, gatekeeper.AuditDenied, records[1].Decision)
+	}
+}
+
+func TestGatekeeper_DecisionAuditor_PolicyDecisions(t *testing.T) {
+	broad := &hostfunc.GrantSet{
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"*"}},
+	}
+
+	for _, level := range []gatekeeper.SecurityLevel{gatekeeper.SecurityStrict, gatekeeper.SecurityPermissive} {
+		for _, batch := range []bool{false, true} {
+			var records []gatekeeper.DecisionRecord
+			g := gatekeeper.NewGatekeeper(
+				gatekeeper.WithStore(&memoryStore{}),
+				gatekeeper.WithPrompter(&scriptedPrompter{interactive: true, grant: true}),
+				gatekeeper.WithSecurityLevel(level),
+				gatekeeper.WithBatchPrompting(batch),
+				gatekeeper.WithDecisionAuditor(func(rec gatekeeper.DecisionRecord) {
+					records = append(records, rec)
+				}),
+			)
+			_, err := g.GrantCapabilities(broad, nil, false)
+
+			last := records[len(records)-1]
+			assert.Equal(t, "exec *", last.Description)
+			assert.True(t, last.IsBroad)
+			assert.Equal(t, gatekeeper.SourcePolicy, last.Source)
+			if level == gatekeeper.SecurityStrict {
+				require.Error(t, err)
+				assert.Equal(t, gatekeeper.AuditDenied, last.Decision, "batch=%v", batch)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, records, 2)
+				assert.Equal(t, gatekeeper.AuditGranted, last.Decision, "batch=%v", batch)
+			}
+		}
+	}
+}
+
+func TestGatekeeper_DecisionAuditor_NonInteractive(t *testing.T) {
+	var records []gatekeeper.DecisionRecord
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(&memoryStore{}),
+		gatekeeper.WithPrompter(&scriptedPrompter{interactive: false}),
+		gatekeeper.WithDecisionAuditor(func(rec gatekeeper.DecisionRecord) {
+			records = append(records, rec)
+		}),
+	)
+
+	_, err := g.GrantCapabilities(batchRequired(), nil, false)
+	require.Error(t, err)
+	require.NotEmpty(t, records)
+	for _, rec := range records {
+		assert.Equal(t, gatekeeper.AuditDenied, rec.Decision)
+		assert.Equal(t, gatekeeper.SourceNonInteractive, rec.Source)
+	}
+}
@@ -0,0 +1,170 @@
+package gatekeeper
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability"
+)
+
+// Decisions accepted from a JSONPrompter peer.
+const (
+	DecisionYes    = "yes"
+	DecisionAlways = "always"
+	DecisionNo     = "no"
+)
+
+// JSONPromptRequest is written, one per line, for each capability prompt.
+type JSONPromptRequest struct {
+	Type        string           `json:"type"`
+	PluginName  string           `json:"plugin,omitempty"`
+	Kind        string           `json:"kind"`
+	Description string           `json:"description"`
+	RiskFactors []JSONRiskFactor `json:"risk_factors,omitempty"`
+	ID          int              `json:"id"`
+	IsBroad     bool             `json:"is_broad"`
+}
+
+// JSONRiskFactor is the wire form of capability.RiskFactor.
+type JSONRiskFactor struct {
+	Level       string `json:"level"`
+	Description string `json:"description"`
+	Rule        string `json:"rule"`
+}
+
+// JSONPromptResponse is read, one per line, in answer to a JSONPromptRequest.
+// ID is optional; when set it must match the request being answered.
+type JSONPromptResponse struct {
+	Decision string `json:"decision"`
+	ID       int    `json:"id,omitempty"`
+}
+
+// JSONPrompter implements capability.Prompter over newline-delimited JSON,
+// letting an IDE or desktop front-end render its own dialog and answer over
+// a pipe. Each prompt writes a JSONPromptRequest to the writer and blocks
+// until a JSONPromptResponse line is read.
+type JSONPrompter struct {
+	w       io.Writer
+	scanner *bufio.Scanner
+	mu      sync.Mutex
+	nextID  int
+}
+
+// NewJSONPrompter creates a prompter that writes requests to w and reads
+// decisions from r.
+func NewJSONPrompter(w io.Writer, r io.Reader) *JSONPrompter {
+	return &JSONPrompter{
+		w:       w,
+		scanner: bufio.NewScanner(r),
+	}
+}
+
+// IsInteractive always returns true; the peer is expected to answer.
+func (p *JSONPrompter) IsInteractive() bool {
+	return true
+}
+
+// PromptForCapability sends req to the peer and waits for its decision.
+func (p *JSONPrompter) PromptForCapability(req capability.Request) (granted bool, always bool, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.nextID++
+	msg := JSONPromptRequest{
+		Type:        "capability_request",
+		ID:          p.nextID,
+		PluginName:  req.PluginName,
+		Kind:        req.Kind,
+		Description: req.Description,
+		IsBroad:     req.IsBroad,
+	}
+	riskFactors := req.RiskFactors
+	if len(riskFactors) == 0 {
+		riskFactors = capability.AnalyzeRisk(req.GrantSet()).RiskFactors
+	}
+	for _, rf := range riskFactors {
+		msg.RiskFactors = append(msg.RiskFactors, JSONRiskFactor{
+			Level:       rf.Level.String(),
+			Description: rf.Description,
+			Rule:        rf.Rule,
+		})
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return false, false, fmt.Errorf("marshal prompt request: %w", err)
+	}
+	if _, err := p.w.Write(append(data, '\n')); err != nil {
+		return false, false, fmt.Errorf("write prompt request: %w", err)
+	}
+
+	resp, err := p.readResponse()
+	if err != nil {
+		return false, false, err
+	}
+	if resp.ID != 0 && resp.ID != msg.ID {
+		return false, false, fmt.Errorf("prompt response id %d does not match request id %d", resp.ID, msg.ID)
+	}
+
+	switch strings.ToLower(resp.Decision) {
+	case DecisionYes:
+		return true, false, nil
+	case DecisionAlways:
+		return true, true, nil
+	case DecisionNo:
+		return false, false, nil
+	default:
+		return false, false, fmt.Errorf("invalid prompt decision %q: expected %q, %q or %q",
+			resp.Decision, DecisionYes, DecisionAlways, DecisionNo)
+	}
+}
+
+func (p *JSONPrompter) readResponse() (JSONPromptResponse, error) {
+	for p.scanner.Scan() {
+		line := strings.TrimSpace(p.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var resp JSONPromptResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			return JSONPromptResponse{}, fmt.Errorf("parse prompt response: %w", err)
+		}
+		return resp, nil
+	}
+	if err := p.scanner.Err(); err != nil {
+		return JSONPromptResponse{}, fmt.Errorf("read prompt response: %w", err)
+	}
+	return JSONPromptResponse{}, fmt.Errorf("read prompt response: %w", io.ErrUnexpectedEOF)
+}
+
+// PromptForCapabilities prompts for each request in turn and returns the granted set.
+func (p *JSONPrompter) PromptForCapabilities(reqs []capability.Request) (*hostfunc.GrantSet, error) {
+	grants := &hostfunc.GrantSet{}
+	for _, req := range reqs {
+		granted, _, err := p.PromptForCapability(req)
+		if err != nil {
+			return nil, err
+		}
+		if granted {
+			grants.Merge(req.GrantSet())
+		}
+	}
+	return grants, nil
+}
+
+// FormatNonInteractiveError lists the capabilities that still need approval.
+func (p *JSONPrompter) FormatNonInteractiveError(missing *hostfunc.GrantSet) error {
+	descs := describeGrantSet(missing)
+	if len(descs) == 0 {
+		return errors.New("plugins require additional permissions")
+	}
+	return fmt.Errorf("plugins require additional permissions: %s", strings.Join(descs, "; "))
+}
+
+var _ capability.Prompter = (*JSONPrompter)(nil)
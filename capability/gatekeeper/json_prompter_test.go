@@ -0,0 +1,113 @@
+package gatekeeper_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability"
+	"github.com/reglet-dev/reglet-host-sdk/capability/gatekeeper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeRequests(t *testing.T, out *bytes.Buffer) []gatekeeper.JSONPromptRequest {
+	t.Helper()
+	var reqs []gatekeeper.JSONPromptRequest
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		var r gatekeeper.JSONPromptRequest
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &r))
+		reqs = append(reqs, r)
+	}
+	return reqs
+}
+
+func TestJSONPrompter_PromptForCapabilities(t *testing.T) {
+	in := strings.NewReader(
+		`{"decision":"yes"}` + "\n" +
+			`{"decision":"no"}` + "\n" +
+			"\n" +
+			`{"id":3,"decision":"always"}` + "\n")
+	var out bytes.Buffer
+	p := gatekeeper.NewJSONPrompter(&out, in)
+	require.True(t, p.IsInteractive())
+
+	netRule := hostfunc.NetworkRule{Hosts: []string{"*"}, Ports: []string{"*"}}
+	reqs := []capability.Request{
+		{PluginName: "http", Kind: "network", Rule: netRule, Description: "network [*]:[*]", IsBroad: true},
+		{PluginName: "http", Kind: "exec", Rule: "/bin/sh", Description: "exec /bin/sh"},
+		{PluginName: "http", Kind: "env", Rule: "HOME", Description: "env HOME"},
+	}
+
+	grants, err := p.PromptForCapabilities(reqs)
+	require.NoError(t, err)
+	require.NotNil(t, grants.Network)
+	assert.Equal(t, []hostfunc.NetworkRule{netRule}, grants.Network.Rules)
+	assert.Nil(t, grants.Exec, "denied capability must not be granted")
+	assert.Equal(t, []string{"HOME"}, grants.Env.Variables)
+
+	sent := decodeRequests(t, &out)
+	require.Len(t, sent, 3)
+	assert.Equal(t, "capability_request", sent[0].Type)
+	assert.Equal(t, 1, sent[0].ID)
+	assert.Equal(t, "network", sent[0].Kind)
+	assert.True(t, sent[0].IsBroad)
+	require.NotEmpty(t, sent[0].RiskFactors)
+	assert.Equal(t, "critical", sent[0].RiskFactors[0].Level)
+	assert.Equal(t, "Unrestricted network access", sent[0].RiskFactors[0].Description)
+}
+
+func TestJSONPrompter_PromptForCapability_Always(t *testing.T) {
+	p := gatekeeper.NewJSONPrompter(&bytes.Buffer{}, strings.NewReader(`{"decision":"ALWAYS"}`+"\n"))
+	granted, always, err := p.PromptForCapability(capability.Request{Kind: "env", Rule: "HOME"})
+	require.NoError(t, err)
+	assert.True(t, granted)
+	assert.True(t, always)
+}
+
+func TestJSONPrompter_Errors(t *testing.T) {
+	req := capability.Request{Kind: "env", Rule: "HOME"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"invalid decision", `{"decision":"maybe"}` + "\n", "invalid prompt decision"},
+		{"malformed json", "not json\n", "parse prompt response"},
+		{"mismatched id", `{"id":7,"decision":"yes"}` + "\n", "does not match"},
+		{"eof", "", "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := gatekeeper.NewJSONPrompter(&bytes.Buffer{}, strings.NewReader(tt.input))
+			_, _, err := p.PromptForCapability(req)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestJSONPrompter_DrivesGatekeeper(t *testing.T) {
+	in := strings.NewReader(`{"decision":"yes"}` + "\n")
+	g := gatekeeper.NewGatekeeper(
+		gatekeeper.WithStore(&memoryStore{}),
+		gatekeeper.WithPrompter(gatekeeper.NewJSONPrompter(&bytes.Buffer{}, in)),
+	)
+
+	required := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/git"}}}
+	granted, err := g.GrantCapabilities(required, nil, false)
+	require.NoError(t, err)
+	assert.True(t, granted.Contains(required))
+}
+
+func TestJSONPrompter_FormatNonInteractiveError(t *testing.T) {
+	p := gatekeeper.NewJSONPrompter(&bytes.Buffer{}, strings.NewReader(""))
+	err := p.FormatNonInteractiveError(&hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"TOKEN"}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TOKEN")
+}
@@ -27,57 +27,88 @@ func (p *TerminalPrompter) IsInteractive() bool {
 	return (fileInfo.Mode() & os.ModeCharDevice) != 0
 }
 
+var (
+	_ capability.Prompter      = (*TerminalPrompter)(nil)
+	_ capability.BatchPrompter = (*TerminalPrompter)(nil)
+)
+
 // PromptForCapability asks the user to grant a capability.
 func (p *TerminalPrompter) PromptForCapability(req capability.Request) (granted bool, always bool, err error) {
-	return p.promptForCapabilityString(req.PluginName, req.Description, req.IsBroad)
+	return p.promptForCapabilityString(req.PluginName, req.Description, req.RiskFactors, req.IsBroad)
 }
 
-// PromptForCapabilities prompts for multiple capabilities at once.
+// PromptForCapabilities prompts for multiple capabilities at once using a
+// multi-select list.
 func (p *TerminalPrompter) PromptForCapabilities(reqs []capability.Request) (*hostfunc.GrantSet, error) {
+	grants, _, err := p.PromptForCapabilityBatch(reqs)
+	return grants, err
+}
+
+// PromptForCapabilityBatch renders all requests as one multi-select list with
+// a separate toggle to save the selected grants to config.
+func (p *TerminalPrompter) PromptForCapabilityBatch(reqs []capability.Request) (*hostfunc.GrantSet, bool, error) {
 	grants := &hostfunc.GrantSet{}
-	for _, req := range reqs {
-		granted, _, err := p.PromptForCapability(req)
-		if err != nil {
-			return nil, err
-		}
-		if granted {
-			switch req.Kind {
-			case "network":
-				if rule, ok := req.Rule.(hostfunc.NetworkRule); ok {
-					if grants.Network == nil {
-						grants.Network = &hostfunc.NetworkCapability{}
-					}
-					grants.Network.Rules = append(grants.Network.Rules, rule)
-				}
-			case "fs":
-				if rule, ok := req.Rule.(hostfunc.FileSystemRule); ok {
-					if grants.FS == nil {
-						grants.FS = &hostfunc.FileSystemCapability{}
-					}
-					grants.FS.Rules = append(grants.FS.Rules, rule)
-				}
-			case "env":
-				if v, ok := req.Rule.(string); ok {
-					if grants.Env == nil {
-						grants.Env = &hostfunc.EnvironmentCapability{}
-					}
-					grants.Env.Variables = append(grants.Env.Variables, v)
-				}
-			case "exec":
-				if cmd, ok := req.Rule.(string); ok {
-					if grants.Exec == nil {
-						grants.Exec = &hostfunc.ExecCapability{}
-					}
-					grants.Exec.Commands = append(grants.Exec.Commands, cmd)
-				}
-			}
-		}
+	if len(reqs) == 0 {
+		return grants, false, nil
+	}
+
+	options := make([]huh.Option[int], 0, len(reqs))
+	for i, req := range reqs {
+		options = append(options, huh.NewOption(batchOptionLabel(req), i))
+	}
+
+	title := "Plugin Requesting Permissions"
+	if reqs[0].PluginName != "" {
+		title = fmt.Sprintf("Plugin %q Requesting Permissions", reqs[0].PluginName)
+	}
+
+	var (
+		selected []int
+		saveAll  bool
+	)
+	err := huh.NewForm(huh.NewGroup(
+		huh.NewMultiSelect[int]().
+			Title(title).
+			Description("Select the permissions to grant. Unselected permissions are denied.").
+			Options(options...).
+			Value(&selected),
+		huh.NewConfirm().
+			Title("Always grant the selected permissions (save to config)?").
+			Value(&saveAll),
+	)).Run()
+	if err != nil {
+		return nil, false, err
 	}
-	return grants, nil
+
+	for _, i := range selected {
+		grants.Merge(reqs[i].GrantSet())
+	}
+	return grants, saveAll && len(selected) > 0, nil
+}
+
+// batchOptionLabel describes a request on a single line, tagged with its
+// highest risk level.
+func batchOptionLabel(req capability.Request) string {
+	label := req.Description
+	highest := capability.RiskNone
+	for _, rf := range req.RiskFactors {
+		highest = max(highest, rf.Level)
+	}
+	if highest > capability.RiskNone {
+		label = fmt.Sprintf("%s [%s risk]", label, highest)
+	}
+	if req.IsBroad {
+		label += " (broad)"
+	}
+	return label
 }
 
 // promptForCapabilityString asks the user whether to grant a capability described by a string.
-func (p *TerminalPrompter) promptForCapabilityString(pluginName, desc string, isBroad bool) (granted bool, always bool, err error) {
+func (p *TerminalPrompter) promptForCapabilityString(
+	pluginName, desc string,
+	riskFactors []capability.RiskFactor,
+	isBroad bool,
+) (granted bool, always bool, err error) {
 	if isBroad {
 		fmt.Fprintf(os.Stderr, "\n")
 		header := "Security Warning: Broad Permission Requested"
@@ -105,7 +136,7 @@ func (p *TerminalPrompter) promptForCapabilityString(pluginName, desc string, is
 
 	err = huh.NewSelect[string]().
 		Title(title).
-		Description(desc).
+		Description(renderCapabilityDescription(desc, riskFactors)).
 		Options(
 			huh.NewOption(OptionYes, OptionYes),
 			huh.NewOption(OptionAlways, OptionAlways),
@@ -127,6 +158,25 @@ func (p *TerminalPrompter) promptForCapabilityString(pluginName, desc string, is
 	}
 }
 
+// renderCapabilityDescription appends every risk factor to desc as a bulleted
+// list. Without factors the description is returned unchanged.
+func renderCapabilityDescription(desc string, riskFactors []capability.RiskFactor) string {
+	if len(riskFactors) == 0 {
+		return desc
+	}
+
+	var b strings.Builder
+	b.WriteString(desc)
+	b.WriteString("\n\nRisks:")
+	for _, rf := range riskFactors {
+		fmt.Fprintf(&b, "\n  • [%s] %s", rf.Level, rf.Description)
+		if rf.Rule != "" {
+			fmt.Fprintf(&b, " (%s)", rf.Rule)
+		}
+	}
+	return b.String()
+}
+
 // PromptForProfileTrustWithGrantSet prompts the user to trust a remote profile source.
 // Displays the profile URL and required capabilities for informed decision.
 func (p *TerminalPrompter) PromptForProfileTrustWithGrantSet(
@@ -136,7 +186,7 @@ func (p *TerminalPrompter) PromptForProfileTrustWithGrantSet(
 	// Build capability description
 	var capDescriptions []string
 	for plugin, gs := range requiredCaps {
-		descs := p.describeGrantSet(gs)
+		descs := describeGrantSet(gs)
 		for _, desc := range descs {
 			capDescriptions = append(capDescriptions, fmt.Sprintf("[%s] %s", plugin, desc))
 		}
@@ -180,8 +230,11 @@ func (p *TerminalPrompter) PromptForProfileTrustWithGrantSet(
 }
 
 // describeGrantSet returns human-readable descriptions of a GrantSet.
-func (p *TerminalPrompter) describeGrantSet(gs *hostfunc.GrantSet) []string {
+func describeGrantSet(gs *hostfunc.GrantSet) []string {
 	var descriptions []string
+	if gs == nil {
+		return descriptions
+	}
 
 	if gs.Network != nil {
 		for _, rule := range gs.Network.Rules {
@@ -0,0 +1,47 @@
+package gatekeeper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderCapabilityDescription_MultipleFactors(t *testing.T) {
+	factors := capability.AnalyzeRisk(&hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: []string{"*"}, Ports: []string{"*"}}},
+		},
+		FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{{Write: []string{"/etc/**"}}},
+		},
+	}).RiskFactors
+
+	got := renderCapabilityDescription("network [*]:[*]", factors)
+
+	assert.True(t, strings.HasPrefix(got, "network [*]:[*]\n\nRisks:"))
+	assert.Contains(t, got, "• [critical] Unrestricted network access")
+	assert.Contains(t, got, "• [high] Filesystem write access (FS Write: [/etc/**])")
+	assert.Equal(t, len(factors), strings.Count(got, "•"))
+}
+
+func TestRenderCapabilityDescription_NoFactors(t *testing.T) {
+	assert.Equal(t, "env HOME", renderCapabilityDescription("env HOME", nil))
+}
+
+func TestBatchOptionLabel(t *testing.T) {
+	req := capability.Request{
+		Kind:        "exec",
+		Rule:        "*",
+		Description: "exec *",
+		IsBroad:     true,
+		RiskFactors: []capability.RiskFactor{
+			{Level: capability.RiskLow, Description: "low"},
+			{Level: capability.RiskCritical, Description: "critical"},
+		},
+	}
+	assert.Equal(t, "exec * [critical risk] (broad)", batchOptionLabel(req))
+	assert.Equal(t, "env HOME", batchOptionLabel(capability.Request{Description: "env HOME"}))
+}
@@ -2,9 +2,12 @@
 package grantstore
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
 	"gopkg.in/yaml.v3"
@@ -12,13 +15,18 @@ import (
 
 // fileStoreConfig holds configuration for the FileStore.
 type fileStoreConfig struct {
-	path     string
-	dirPerm  os.FileMode
-	filePerm os.FileMode
+	now        func() time.Time
+	logger     *slog.Logger
+	path       string
+	defaultTTL time.Duration
+	dirPerm    os.FileMode
+	filePerm   os.FileMode
 }
 
 func defaultFileStoreConfig() fileStoreConfig {
 	return fileStoreConfig{
+		now:      time.Now,
+		logger:   slog.Default(),
 		path:     filepath.Join(os.Getenv("HOME"), ".reglet", "grants.yaml"),
 		dirPerm:  0o755,
 		filePerm: 0o600,
@@ -51,6 +59,56 @@ func WithDirPermissions(perm os.FileMode) FileStoreOption {
 	}
 }
 
+// WithDefaultTTL sets how long newly saved grants remain valid. Grants already
+// in the store keep their existing expiry. A zero TTL (the default) means
+// grants never expire.
+func WithDefaultTTL(ttl time.Duration) FileStoreOption {
+	return func(c *fileStoreConfig) {
+		c.defaultTTL = ttl
+	}
+}
+
+// WithClock overrides the time source used for grant expiry. Intended for tests.
+func WithClock(now func() time.Time) FileStoreOption {
+	return func(c *fileStoreConfig) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// WithLogger sets the logger used to report grant store recovery.
+func WithLogger(logger *slog.Logger) FileStoreOption {
+	return func(c *fileStoreConfig) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
+// ErrCorruptGrantStore is wrapped by errors for a grants file that exists but
+// cannot be parsed.
+var ErrCorruptGrantStore = errors.New("grant store is corrupt")
+
+// BackupSuffix is appended to the grants file path when a corrupt file is
+// moved aside.
+const BackupSuffix = ".bak"
+
+// storedGrants is the on-disk format. The grant set is inlined so files
+// written before expiry support still load unchanged; expiry lives in a
+// sidecar list so hostfunc.GrantSet stays free of storage concerns.
+type storedGrants struct {
+	hostfunc.GrantSet `yaml:",inline"`
+	Expiry            []grantExpiry `yaml:"expiry,omitempty"`
+}
+
+// grantExpiry records when a single grant (one rule, variable or command)
+// stops being valid.
+type grantExpiry struct {
+	ExpiresAt time.Time         `yaml:"expires_at"`
+	Grant     hostfunc.GrantSet `yaml:"grant"`
+}
+
 // FileStore provides file-based persistence for capability grants.
 // Serializes directly to/from hostfunc.GrantSet (ABI types) - no conversion needed.
 type FileStore struct {
@@ -66,21 +124,58 @@ func NewFileStore(opts ...FileStoreOption) *FileStore {
 	return &FileStore{config: cfg}
 }
 
-// Load retrieves all granted capabilities.
+// Load retrieves all granted capabilities. Expired grants are dropped.
+//
+// A grants file that cannot be parsed would otherwise block every plugin run,
+// so Load moves it to the same path with BackupSuffix, logs a warning, and
+// returns an empty set. The user is re-prompted for grants; the backup keeps
+// the old content for manual repair.
 func (s *FileStore) Load() (*hostfunc.GrantSet, error) {
+	stored, err := s.read()
+	if errors.Is(err, ErrCorruptGrantStore) {
+		stored, err = s.recoverCorrupt(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	grants := &stored.GrantSet
+	now := s.config.now()
+	for i := range stored.Expiry {
+		if stored.Expiry[i].expired(now) {
+			grants = grants.Difference(&stored.Expiry[i].Grant)
+		}
+	}
+	return grants, nil
+}
+
+func (s *FileStore) read() (*storedGrants, error) {
 	data, err := os.ReadFile(s.config.path)
 	if os.IsNotExist(err) {
-		return &hostfunc.GrantSet{}, nil
+		return &storedGrants{}, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read grant store: %w", err)
 	}
 
-	var grants hostfunc.GrantSet
-	if err := yaml.Unmarshal(data, &grants); err != nil {
-		return nil, fmt.Errorf("failed to parse grant store: %w", err)
+	var stored storedGrants
+	if err := yaml.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorruptGrantStore, s.config.path, err)
+	}
+	return &stored, nil
+}
+
+// recoverCorrupt moves a corrupt grants file aside and starts from an empty set.
+func (s *FileStore) recoverCorrupt(cause error) (*storedGrants, error) {
+	backup := s.config.path + BackupSuffix
+	if err := os.Rename(s.config.path, backup); err != nil {
+		return nil, fmt.Errorf("%w (backup to %s failed: %w)", cause, backup, err)
 	}
-	return &grants, nil
+	s.config.logger.Warn("grant store was corrupt and has been reset; previously granted capabilities will be requested again",
+		"path", s.config.path,
+		"backup", backup,
+		"error", cause)
+	return &storedGrants{}, nil
 }
 
 // Save persists the granted capabilities.
@@ -92,7 +187,18 @@ func (s *FileStore) Save(grants *hostfunc.GrantSet) error {
 	clean := grants.Clone()
 	clean.Deduplicate()
 
-	data, err := yaml.Marshal(clean)
+	previous, err := s.read()
+	if err != nil {
+		// An unreadable store is about to be overwritten; start afresh.
+		previous = &storedGrants{}
+	}
+
+	stored := storedGrants{
+		GrantSet: *clean,
+		Expiry:   s.expiryFor(clean, previous),
+	}
+
+	data, err := yaml.Marshal(stored)
 	if err != nil {
 		return fmt.Errorf("failed to marshal grants: %w", err)
 	}
@@ -108,6 +214,78 @@ func (s *FileStore) Save(grants *hostfunc.GrantSet) error {
 	return nil
 }
 
+// expiryFor assigns an expiry to each grant in grants. Grants carried over from
+// previous keep their expiry (or lack of one); new or re-granted grants get the
+// default TTL.
+func (s *FileStore) expiryFor(grants *hostfunc.GrantSet, previous *storedGrants) []grantExpiry {
+	now := s.config.now()
+	var expiry []grantExpiry
+	for _, item := range splitGrants(grants) {
+		if entry := findExpiry(previous.Expiry, item); entry != nil {
+			if !entry.expired(now) {
+				expiry = append(expiry, *entry)
+				continue
+			}
+		} else if previous.Contains(item) {
+			continue // previously granted without expiry
+		}
+
+		if s.config.defaultTTL > 0 {
+			expiry = append(expiry, grantExpiry{
+				Grant:     *item,
+				ExpiresAt: now.Add(s.config.defaultTTL).UTC(),
+			})
+		}
+	}
+	return expiry
+}
+
+func (e *grantExpiry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+func findExpiry(entries []grantExpiry, item *hostfunc.GrantSet) *grantExpiry {
+	for i := range entries {
+		g := &entries[i].Grant
+		if g.Contains(item) && item.Contains(g) {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// splitGrants breaks a grant set into single-grant sets, the unit at which
+// expiry is tracked.
+func splitGrants(gs *hostfunc.GrantSet) []*hostfunc.GrantSet {
+	var items []*hostfunc.GrantSet
+	if gs.Network != nil {
+		for _, r := range gs.Network.Rules {
+			items = append(items, &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{r}}})
+		}
+	}
+	if gs.FS != nil {
+		for _, r := range gs.FS.Rules {
+			items = append(items, &hostfunc.GrantSet{FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{r}}})
+		}
+	}
+	if gs.Env != nil {
+		for _, v := range gs.Env.Variables {
+			items = append(items, &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{v}}})
+		}
+	}
+	if gs.Exec != nil {
+		for _, c := range gs.Exec.Commands {
+			items = append(items, &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{c}}})
+		}
+	}
+	if gs.KV != nil {
+		for _, r := range gs.KV.Rules {
+			items = append(items, &hostfunc.GrantSet{KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{r}}})
+		}
+	}
+	return items
+}
+
 // ConfigPath returns the path to the backing store.
 func (s *FileStore) ConfigPath() string {
 	return s.config.path
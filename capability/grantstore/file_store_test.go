@@ -0,0 +1,192 @@
+package grantstore_test
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/capability/grantstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced time source.
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func TestFileStore_SaveLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grants.yaml")
+	store := grantstore.NewFileStore(grantstore.WithPath(path))
+
+	grants := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: []string{"example.com"}, Ports: []string{"443"}}},
+		},
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}},
+	}
+	require.NoError(t, store.Save(grants))
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.True(t, loaded.Contains(grants))
+	assert.True(t, grants.Contains(loaded))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "expiry", "no sidecar without a TTL")
+}
+
+func TestFileStore_LoadMissingFile(t *testing.T) {
+	store := grantstore.NewFileStore(grantstore.WithPath(filepath.Join(t.TempDir(), "missing.yaml")))
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.True(t, loaded.IsEmpty())
+}
+
+func TestFileStore_TTL(t *testing.T) {
+	clock := newClock()
+	path := filepath.Join(t.TempDir(), "grants.yaml")
+	store := grantstore.NewFileStore(
+		grantstore.WithPath(path),
+		grantstore.WithClock(clock.Now),
+		grantstore.WithDefaultTTL(time.Hour),
+	)
+
+	first := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}}}
+	require.NoError(t, store.Save(first))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "expires_at")
+
+	clock.Advance(30 * time.Minute)
+
+	// A second grant saved later gets its own expiry; HOME keeps its original one.
+	second := first.Clone()
+	second.Merge(&hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/git"}}})
+	require.NoError(t, store.Save(second))
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HOME"}, loaded.Env.Variables)
+	assert.Equal(t, []string{"/usr/bin/git"}, loaded.Exec.Commands)
+
+	clock.Advance(45 * time.Minute) // HOME expired, git still valid
+
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, loaded.Env, "expired grant should be pruned")
+	assert.Equal(t, []string{"/usr/bin/git"}, loaded.Exec.Commands)
+
+	clock.Advance(time.Hour)
+
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.True(t, loaded.IsEmpty())
+}
+
+func TestFileStore_TTL_RegrantRefreshesExpiry(t *testing.T) {
+	clock := newClock()
+	store := grantstore.NewFileStore(
+		grantstore.WithPath(filepath.Join(t.TempDir(), "grants.yaml")),
+		grantstore.WithClock(clock.Now),
+		grantstore.WithDefaultTTL(time.Hour),
+	)
+
+	grants := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}}}
+	require.NoError(t, store.Save(grants))
+
+	clock.Advance(2 * time.Hour)
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	require.True(t, loaded.IsEmpty())
+
+	// Granting again after expiry starts a fresh TTL.
+	require.NoError(t, store.Save(grants))
+	clock.Advance(30 * time.Minute)
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HOME"}, loaded.Env.Variables)
+}
+
+func TestFileStore_ZeroTTLNeverExpires(t *testing.T) {
+	clock := newClock()
+	store := grantstore.NewFileStore(
+		grantstore.WithPath(filepath.Join(t.TempDir(), "grants.yaml")),
+		grantstore.WithClock(clock.Now),
+		grantstore.WithDefaultTTL(0),
+	)
+
+	grants := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"/bin/ls"}}}
+	require.NoError(t, store.Save(grants))
+
+	clock.Advance(10 * 365 * 24 * time.Hour)
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/bin/ls"}, loaded.Exec.Commands)
+}
+
+func TestFileStore_LegacyFileWithoutExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grants.yaml")
+	legacy := "env:\n  vars:\n    - HOME\n"
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0o600))
+
+	clock := newClock()
+	store := grantstore.NewFileStore(
+		grantstore.WithPath(path),
+		grantstore.WithClock(clock.Now),
+		grantstore.WithDefaultTTL(time.Hour),
+	)
+
+	// Re-saving keeps pre-existing grants permanent; only new ones get a TTL.
+	grants, err := store.Load()
+	require.NoError(t, err)
+	grants.Merge(&hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"PATH"}}})
+	require.NoError(t, store.Save(grants))
+
+	clock.Advance(2 * time.Hour)
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"HOME"}, loaded.Env.Variables)
+}
+
+func TestFileStore_LoadCorruptFileRecovers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grants.yaml")
+	corrupt := []byte("network:\n  rules: [\n\t{hosts: ")
+	require.NoError(t, os.WriteFile(path, corrupt, 0o600))
+
+	var logs bytes.Buffer
+	store := grantstore.NewFileStore(
+		grantstore.WithPath(path),
+		grantstore.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+
+	loaded, err := store.Load()
+	require.NoError(t, err)
+	assert.True(t, loaded.IsEmpty())
+
+	backup, err := os.ReadFile(path + grantstore.BackupSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, corrupt, backup, "backup keeps the corrupt content")
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "corrupt file moved aside")
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "grant store was corrupt")
+
+	// The store is usable again afterwards.
+	grants := &hostfunc.GrantSet{Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME"}}}
+	require.NoError(t, store.Save(grants))
+	loaded, err = store.Load()
+	require.NoError(t, err)
+	assert.True(t, loaded.Contains(grants))
+}
@@ -17,9 +17,39 @@ type Request struct {
 	Rule        interface{}
 	Kind        string
 	Description string
+	RiskFactors []RiskFactor
 	IsBroad     bool
 }
 
+// GrantSet returns the grant the request asks for, built from Kind and Rule.
+// Requests with an unknown kind or a rule of the wrong type yield an empty set.
+func (r Request) GrantSet() *hostfunc.GrantSet {
+	gs := &hostfunc.GrantSet{}
+	switch r.Kind {
+	case "network":
+		if rule, ok := r.Rule.(hostfunc.NetworkRule); ok {
+			gs.Network = &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{rule}}
+		}
+	case "fs":
+		if rule, ok := r.Rule.(hostfunc.FileSystemRule); ok {
+			gs.FS = &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{rule}}
+		}
+	case "env":
+		if v, ok := r.Rule.(string); ok {
+			gs.Env = &hostfunc.EnvironmentCapability{Variables: []string{v}}
+		}
+	case "exec":
+		if cmd, ok := r.Rule.(string); ok {
+			gs.Exec = &hostfunc.ExecCapability{Commands: []string{cmd}}
+		}
+	case "kv":
+		if rule, ok := r.Rule.(hostfunc.KeyValueRule); ok {
+			gs.KV = &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{rule}}
+		}
+	}
+	return gs
+}
+
 // Requirement represents a request for capabilities by a plugin.
 type Requirement struct {
 	Requested  *hostfunc.GrantSet
@@ -55,3 +85,9 @@ type Prompter interface {
 	PromptForCapabilities(reqs []Request) (*hostfunc.GrantSet, error)
 	FormatNonInteractiveError(missing *hostfunc.GrantSet) error
 }
+
+// BatchPrompter is an optional Prompter extension for batch prompting that
+// also asks whether to persist the granted capabilities.
+type BatchPrompter interface {
+	PromptForCapabilityBatch(reqs []Request) (granted *hostfunc.GrantSet, always bool, err error)
+}
@@ -17,6 +17,24 @@ const (
 	RiskCritical
 )
 
+// String returns the lowercase name of the risk level.
+func (l RiskLevel) String() string {
+	switch l {
+	case RiskNone:
+		return "none"
+	case RiskLow:
+		return "low"
+	case RiskMedium:
+		return "medium"
+	case RiskHigh:
+		return "high"
+	case RiskCritical:
+		return "critical"
+	default:
+		return fmt.Sprintf("RiskLevel(%d)", int(l))
+	}
+}
+
 // RiskReport contains the overall risk assessment for a set of capabilities.
 type RiskReport struct {
 	RiskFactors []RiskFactor
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
 	"github.com/reglet-dev/reglet-host-sdk/policy"
@@ -18,8 +20,13 @@ import (
 type CapabilityChecker struct {
 	policy              policy.Policy
 	grantedCapabilities map[string]*hostfunc.GrantSet
+	secretGrants        map[string]*SecretCapability
+	dnsGrants           map[string]*policy.DNSCapability
+	kvTTLPlugins        map[string]bool
+	httpBodyLimits      map[string]int64
 	cwd                 string // Current working directory for resolving relative paths
 	denialHandler       DenialHandler
+	denialLimiter       *denialLimiter
 }
 
 // DenialHandler is called when a capability is denied.
@@ -32,7 +39,15 @@ type CapabilityCheckerOption func(*capabilityCheckerConfig)
 type capabilityCheckerConfig struct {
 	cwd               string
 	symlinkResolution bool
+	pathCaseSensitive *bool
 	denialHandler     DenialHandler
+	secretGrants      map[string]*SecretCapability
+	dnsGrants         map[string]*policy.DNSCapability
+	denyRules         *hostfunc.GrantSet
+	kvTTLPlugins      map[string]bool
+	httpBodyLimits    map[string]int64
+	denialRateMax     int
+	denialRateWindow  time.Duration
 }
 
 // WithCapabilityWorkingDirectory sets the working directory for path resolution.
@@ -49,6 +64,14 @@ func WithCapabilitySymlinkResolution(enabled bool) CapabilityCheckerOption {
 	}
 }
 
+// WithCapabilityPathCaseSensitivity overrides whether filesystem grants match
+// paths case-sensitively. By default they do everywhere except Windows.
+func WithCapabilityPathCaseSensitivity(sensitive bool) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		c.pathCaseSensitive = &sensitive
+	}
+}
+
 // WithCapabilityDenialHandler sets the handler for denied capabilities.
 func WithCapabilityDenialHandler(handler DenialHandler) CapabilityCheckerOption {
 	return func(c *capabilityCheckerConfig) {
@@ -56,6 +79,54 @@ func WithCapabilityDenialHandler(handler DenialHandler) CapabilityCheckerOption
 	}
 }
 
+// WithCapabilitySecretGrants sets the secrets each plugin may read via secret_get.
+// Secrets are granted host-side because the manifest GrantSet has no secret kind.
+func WithCapabilitySecretGrants(grants map[string]*SecretCapability) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		c.secretGrants = grants
+	}
+}
+
+// WithCapabilityDNSGrants sets the hostnames each plugin may look up via
+// dns_lookup, independently of its network rules. Plugins without DNS grants
+// keep the older behaviour: a lookup is allowed when the network rules allow
+// connecting to the hostname on port 53.
+func WithCapabilityDNSGrants(grants map[string]*policy.DNSCapability) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		c.dnsGrants = grants
+	}
+}
+
+// WithCapabilityDenyRules sets network, fs, env, exec and kv rules that no
+// plugin may use, whatever its grants allow. See policy.WithDenyRules.
+func WithCapabilityDenyRules(deny *hostfunc.GrantSet) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		c.denyRules = deny
+	}
+}
+
+// WithCapabilityKVTTL allows the named plugins to set expiring keys via kv_set.
+// This is host-side because the manifest kv rules carry no TTL flag.
+func WithCapabilityKVTTL(pluginNames ...string) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		if c.kvTTLPlugins == nil {
+			c.kvTTLPlugins = make(map[string]bool)
+		}
+		for _, name := range pluginNames {
+			c.kvTTLPlugins[name] = true
+		}
+	}
+}
+
+// WithCapabilityHTTPBodyLimits caps the HTTP response body size, in bytes, for
+// each named plugin. A plugin's cap can only lower the host-wide limit set with
+// WithHTTPMaxBodySize, never raise it.
+func WithCapabilityHTTPBodyLimits(limits map[string]int64) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		c.httpBodyLimits = limits
+	}
+}
+
 // NewCapabilityChecker creates a new capability checker with the given capabilities.
 // The cwd is obtained at construction time to avoid side-effects during capability checks.
 func NewCapabilityChecker(caps map[string]*hostfunc.GrantSet, opts ...CapabilityCheckerOption) *CapabilityChecker {
@@ -72,14 +143,30 @@ func NewCapabilityChecker(caps map[string]*hostfunc.GrantSet, opts ...Capability
 		cfg.cwd, _ = os.Getwd()
 	}
 
+	var limiter *denialLimiter
+	if cfg.denialHandler != nil && cfg.denialRateWindow > 0 {
+		limiter = newDenialLimiter(cfg.denialHandler, cfg.denialRateMax, cfg.denialRateWindow)
+	}
+
+	policyOpts := []policy.PolicyOption{
+		policy.WithWorkingDirectory(cfg.cwd),
+		policy.WithSymlinkResolution(cfg.symlinkResolution),
+		policy.WithDenyRules(cfg.denyRules),
+	}
+	if cfg.pathCaseSensitive != nil {
+		policyOpts = append(policyOpts, policy.WithPathCaseSensitivity(*cfg.pathCaseSensitive))
+	}
+
 	return &CapabilityChecker{
-		policy: policy.NewPolicy(
-			policy.WithWorkingDirectory(cfg.cwd),
-			policy.WithSymlinkResolution(cfg.symlinkResolution),
-		),
+		policy:              policy.NewPolicy(policyOpts...),
 		grantedCapabilities: caps,
 		cwd:                 cfg.cwd,
 		denialHandler:       cfg.denialHandler,
+		denialLimiter:       limiter,
+		secretGrants:        cfg.secretGrants,
+		dnsGrants:           cfg.dnsGrants,
+		kvTTLPlugins:        cfg.kvTTLPlugins,
+		httpBodyLimits:      cfg.httpBodyLimits,
 	}
 }
 
@@ -91,6 +178,41 @@ func (c *CapabilityChecker) RegisterGrants(pluginName string, grants *hostfunc.G
 	c.grantedCapabilities[pluginName] = grants
 }
 
+// RegisterSecretGrants adds or updates the secrets a specific plugin may read.
+func (c *CapabilityChecker) RegisterSecretGrants(pluginName string, grants *SecretCapability) {
+	if c.secretGrants == nil {
+		c.secretGrants = make(map[string]*SecretCapability)
+	}
+	c.secretGrants[pluginName] = grants
+}
+
+// RegisterDNSGrants adds or updates the hostnames a specific plugin may look up.
+func (c *CapabilityChecker) RegisterDNSGrants(pluginName string, grants *policy.DNSCapability) {
+	if c.dnsGrants == nil {
+		c.dnsGrants = make(map[string]*policy.DNSCapability)
+	}
+	c.dnsGrants[pluginName] = grants
+}
+
+// RegisterHTTPBodyLimit sets the HTTP response body cap for a specific plugin.
+// A limit of zero or less removes the cap.
+func (c *CapabilityChecker) RegisterHTTPBodyLimit(pluginName string, limit int64) {
+	if limit <= 0 {
+		delete(c.httpBodyLimits, pluginName)
+		return
+	}
+	if c.httpBodyLimits == nil {
+		c.httpBodyLimits = make(map[string]int64)
+	}
+	c.httpBodyLimits[pluginName] = limit
+}
+
+// HTTPBodyLimit returns the HTTP response body cap for the plugin, if any.
+func (c *CapabilityChecker) HTTPBodyLimit(pluginName string) (int64, bool) {
+	limit, ok := c.httpBodyLimits[pluginName]
+	return limit, ok && limit > 0
+}
+
 // CheckNetwork performs typed network capability check.
 func (c *CapabilityChecker) CheckNetwork(ctx context.Context, pluginName string, req hostfunc.NetworkRequest) error {
 	grants, ok := c.grantedCapabilities[pluginName]
@@ -124,6 +246,37 @@ func (c *CapabilityChecker) CheckNetworkConnection(ctx context.Context, pluginNa
 	return c.handleDeny(ctx, pluginName, "network", fmt.Sprintf("%s:%d", host, port), "network capability denied")
 }
 
+// Decision is the outcome of an Explain call.
+type Decision struct {
+	// MatchedRule is the rule that allowed the request, if any.
+	MatchedRule any
+	// Reason explains the outcome, e.g. which rule matched or why none did.
+	Reason  string
+	Allowed bool
+}
+
+// ExplainNetwork reports whether a network request would be allowed and why.
+// Unlike CheckNetwork it is read-only: the DenialHandler is not invoked.
+// MatchedRule holds a hostfunc.NetworkRule when the request is allowed.
+func (c *CapabilityChecker) ExplainNetwork(_ context.Context, pluginName string, req hostfunc.NetworkRequest) (Decision, error) {
+	explainer, ok := c.policy.(policy.NetworkExplainer)
+	if !ok {
+		return Decision{}, fmt.Errorf("policy %T cannot explain network decisions", c.policy)
+	}
+
+	grants, ok := c.grantedCapabilities[pluginName]
+	if !ok || grants == nil {
+		return Decision{Reason: "no capabilities granted"}, nil
+	}
+
+	exp := explainer.ExplainNetwork(req, grants)
+	d := Decision{Allowed: exp.Allowed, Reason: exp.Reason}
+	if exp.MatchedRule != nil {
+		d.MatchedRule = *exp.MatchedRule
+	}
+	return d, nil
+}
+
 // CheckFileSystem performs typed filesystem capability check.
 func (c *CapabilityChecker) CheckFileSystem(ctx context.Context, pluginName string, req hostfunc.FileSystemRequest) error {
 	grants, ok := c.grantedCapabilities[pluginName]
@@ -166,9 +319,74 @@ func (c *CapabilityChecker) CheckExec(ctx context.Context, pluginName string, re
 	return c.handleDeny(ctx, pluginName, "exec", req.Command, "exec capability denied")
 }
 
+// CheckKeyValue performs typed key-value capability check.
+func (c *CapabilityChecker) CheckKeyValue(ctx context.Context, pluginName string, req hostfunc.KeyValueRequest) error {
+	pattern := fmt.Sprintf("%s:%s", req.Operation, req.Key)
+	grants, ok := c.grantedCapabilities[pluginName]
+	if !ok || grants == nil {
+		return c.handleDeny(ctx, pluginName, "kv", pattern, "no capabilities granted")
+	}
+
+	if c.policy.CheckKeyValue(req, grants) {
+		return nil
+	}
+
+	return c.handleDeny(ctx, pluginName, "kv", pattern, "kv capability denied")
+}
+
+// CheckKeyValueTTL checks whether the plugin may set an expiring key.
+func (c *CapabilityChecker) CheckKeyValueTTL(ctx context.Context, pluginName, key string) error {
+	if c.kvTTLPlugins[pluginName] {
+		return nil
+	}
+	return c.handleDeny(ctx, pluginName, "kv", key, "kv ttl capability denied")
+}
+
+// CheckDNS checks whether the plugin may perform the DNS lookup. When the
+// plugin has DNS grants, the hostname is matched against them and a custom
+// nameserver additionally needs a network grant for port 53, since querying
+// it means connecting to it. Without DNS grants the hostname is checked as
+// a network connection to port 53.
+func (c *CapabilityChecker) CheckDNS(ctx context.Context, pluginName string, req hostfunc.DNSRequest) error {
+	grants, ok := c.dnsGrants[pluginName]
+	checker, canCheck := c.policy.(policy.DNSChecker)
+	if !ok || grants == nil || !canCheck {
+		return c.CheckNetwork(ctx, pluginName, hostfunc.NetworkRequest{Host: req.Hostname, Port: 53})
+	}
+
+	if !checker.CheckDNS(req, grants) {
+		return c.handleDeny(ctx, pluginName, "dns", req.Hostname, "dns capability denied")
+	}
+	if req.Nameserver != "" {
+		host, port := strings.TrimSuffix(strings.TrimPrefix(req.Nameserver, "["), "]"), 53
+		if h, p, err := net.SplitHostPort(req.Nameserver); err == nil {
+			host = h
+			port, _ = strconv.Atoi(p)
+		}
+		return c.CheckNetworkConnection(ctx, pluginName, host, port)
+	}
+	return nil
+}
+
+// CheckSecret checks whether the plugin may read the named secret.
+func (c *CapabilityChecker) CheckSecret(ctx context.Context, pluginName, name string) error {
+	grants, ok := c.secretGrants[pluginName]
+	if !ok || grants == nil {
+		return c.handleDeny(ctx, pluginName, "secret", name, "no secrets granted")
+	}
+
+	if grants.Allows(name) {
+		return nil
+	}
+
+	return c.handleDeny(ctx, pluginName, "secret", name, "secret capability denied")
+}
+
 func (c *CapabilityChecker) handleDeny(ctx context.Context, pluginName, kind, pattern, message string) error {
 	fullMsg := fmt.Sprintf("%s: %s", message, pattern)
-	if c.denialHandler != nil {
+	if c.denialLimiter != nil {
+		c.denialLimiter.deny(ctx, pluginName, kind, pattern, fullMsg)
+	} else if c.denialHandler != nil {
 		c.denialHandler(ctx, pluginName, kind, pattern, fullMsg)
 	}
 	return fmt.Errorf("%s", fullMsg)
@@ -186,8 +404,30 @@ func (c *CapabilityChecker) AllowsPrivateNetwork(pluginName string) bool {
 }
 
 // ToCapabilityGetter returns a CapabilityGetter function that uses this checker.
+// Capability strings are routed by prefix:
+//
+//	env:NAME           environment variable (falls back to an exec check)
+//	fs:read:/path      filesystem read
+//	fs:write:/path     filesystem write
+//	net:host:port      network connection; IPv6 hosts may be bracketed
+//
+// Anything else is checked as an exec command. Malformed strings are denied.
 func (c *CapabilityChecker) ToCapabilityGetter(ctx context.Context, pluginName string) CapabilityGetter {
 	return func(plugin, capability string) bool {
+		if rest, found := strings.CutPrefix(capability, "fs:"); found {
+			op, path, ok := strings.Cut(rest, ":")
+			if !ok || path == "" || (op != "read" && op != "write") {
+				return false
+			}
+			return c.CheckFileSystem(ctx, pluginName, hostfunc.FileSystemRequest{Operation: op, Path: path}) == nil
+		}
+		if rest, found := strings.CutPrefix(capability, "net:"); found {
+			host, port, ok := parseCapabilityHostPort(rest)
+			if !ok {
+				return false
+			}
+			return c.CheckNetworkConnection(ctx, pluginName, host, port) == nil
+		}
 		if varName, found := strings.CutPrefix(capability, "env:"); found {
 			if err := c.CheckEnvironment(ctx, pluginName, hostfunc.EnvironmentRequest{Variable: varName}); err == nil {
 				return true
@@ -202,12 +442,28 @@ func (c *CapabilityChecker) ToCapabilityGetter(ctx context.Context, pluginName s
 	}
 }
 
+// parseCapabilityHostPort splits "host:port", splitting on the last colon so
+// unbracketed IPv6 hosts still parse.
+func parseCapabilityHostPort(s string) (string, int, bool) {
+	idx := strings.LastIndex(s, ":")
+	if idx <= 0 {
+		return "", 0, false
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(s[:idx], "["), "]")
+	port, err := strconv.Atoi(s[idx+1:])
+	if host == "" || err != nil || port < 1 || port > 65535 {
+		return "", 0, false
+	}
+	return host, port, true
+}
+
 // CapabilityMiddleware returns a middleware that enforces capabilities for standard host functions.
 func CapabilityMiddleware(checker *CapabilityChecker) Middleware {
 	return func(next ByteHandler) ByteHandler {
 		return func(ctx context.Context, payload []byte) ([]byte, error) {
 			funcName := ""
-			if hc, ok := ctx.(HostContext); ok {
+			hc, isHostContext := ctx.(HostContext)
+			if isHostContext {
 				funcName = hc.FunctionName()
 			}
 
@@ -218,14 +474,14 @@ func CapabilityMiddleware(checker *CapabilityChecker) Middleware {
 
 			// Add SSRF protection context based on plugin capabilities
 			allowPrivate := checker.AllowsPrivateNetwork(pluginName)
-			ctx = context.WithValue(ctx, "ssrf_allow_private", allowPrivate)
+			ctx = WithSSRFAllowPrivate(ctx, allowPrivate)
 
 			// Validate capability based on function name and payload
 			switch funcName {
 			case "dns_lookup":
 				var req hostfunc.DNSRequest
 				if err := json.Unmarshal(payload, &req); err == nil {
-					if err := checker.CheckNetwork(ctx, pluginName, hostfunc.NetworkRequest{Host: req.Hostname, Port: 53}); err != nil {
+					if err := checker.CheckDNS(ctx, pluginName, req); err != nil {
 						return NewValidationError(err.Error()).ToJSON(), nil
 					}
 				}
@@ -252,6 +508,35 @@ func CapabilityMiddleware(checker *CapabilityChecker) Middleware {
 						return NewValidationError(err.Error()).ToJSON(), nil
 					}
 				}
+				if limit, ok := checker.HTTPBodyLimit(pluginName); ok {
+					ctx = WithHTTPBodyLimit(ctx, limit)
+				}
+			case "kv_get":
+				var req KVGetRequest
+				if err := json.Unmarshal(payload, &req); err == nil {
+					if err := checker.CheckKeyValue(ctx, pluginName, hostfunc.KeyValueRequest{Key: req.Key, Operation: "read"}); err != nil {
+						return NewValidationError(err.Error()).ToJSON(), nil
+					}
+				}
+			case "kv_set", "kv_delete":
+				var req KVSetRequest // kv_delete shares the key field
+				if err := json.Unmarshal(payload, &req); err == nil {
+					if err := checker.CheckKeyValue(ctx, pluginName, hostfunc.KeyValueRequest{Key: req.Key, Operation: "write"}); err != nil {
+						return NewValidationError(err.Error()).ToJSON(), nil
+					}
+					if req.TTL != 0 {
+						if err := checker.CheckKeyValueTTL(ctx, pluginName, req.Key); err != nil {
+							return NewValidationError(err.Error()).ToJSON(), nil
+						}
+					}
+				}
+			case "secret_get":
+				var req SecretGetRequest
+				if err := json.Unmarshal(payload, &req); err == nil {
+					if err := checker.CheckSecret(ctx, pluginName, req.Name); err != nil {
+						return NewValidationError(err.Error()).ToJSON(), nil
+					}
+				}
 			case "exec_command":
 				var req hostfunc.ExecRequest
 				if err := json.Unmarshal(payload, &req); err == nil {
@@ -270,6 +555,11 @@ func CapabilityMiddleware(checker *CapabilityChecker) Middleware {
 				}
 			}
 
+			// The values added above must not hide the HostContext from
+			// later middleware, which reads the function name from it.
+			if isHostContext {
+				ctx = withContext(hc, ctx)
+			}
 			return next(ctx, payload)
 		}
 	}
@@ -299,7 +589,10 @@ type capabilityContextKey struct {
 	name string
 }
 
-var pluginNameContextKey = &capabilityContextKey{name: "plugin_name"}
+var (
+	pluginNameContextKey   = &capabilityContextKey{name: "plugin_name"}
+	ssrfAllowPrivateCtxKey = &capabilityContextKey{name: "ssrf_allow_private"}
+)
 
 // WithCapabilityPluginName adds the plugin name to the context.
 func WithCapabilityPluginName(ctx context.Context, name string) context.Context {
@@ -311,3 +604,18 @@ func CapabilityPluginNameFromContext(ctx context.Context) (string, bool) {
 	name, ok := ctx.Value(pluginNameContextKey).(string)
 	return name, ok
 }
+
+// WithSSRFAllowPrivate records whether outbound connections made on behalf of
+// this context may reach private addresses. CapabilityMiddleware sets it from
+// the plugin's network grants; the HTTP, TCP and SMTP host functions enable
+// SSRF protection accordingly.
+func WithSSRFAllowPrivate(ctx context.Context, allow bool) context.Context {
+	return context.WithValue(ctx, ssrfAllowPrivateCtxKey, allow)
+}
+
+// SSRFAllowPrivateFromContext retrieves the value set by WithSSRFAllowPrivate.
+// ok is false if the context carries no SSRF decision.
+func SSRFAllowPrivateFromContext(ctx context.Context) (allow bool, ok bool) {
+	allow, ok = ctx.Value(ssrfAllowPrivateCtxKey).(bool)
+	return allow, ok
+}
@@ -2,9 +2,11 @@ package hostlib
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/policy"
 )
 
 func TestCapabilityChecker_CheckExec_NoGrants(t *testing.T) {
@@ -76,6 +78,155 @@ func TestCapabilityChecker_EnvironmentCapability(t *testing.T) {
 	}
 }
 
+func TestCapabilityChecker_KeyValueCapability(t *testing.T) {
+	grants := map[string]*hostfunc.GrantSet{
+		"test-plugin": {
+			KV: &hostfunc.KeyValueCapability{
+				Rules: []hostfunc.KeyValueRule{{Operation: "read", Keys: []string{"config/*"}}},
+			},
+		},
+	}
+	checker := NewCapabilityChecker(grants)
+
+	tests := []struct {
+		name    string
+		plugin  string
+		req     hostfunc.KeyValueRequest
+		wantErr bool
+	}{
+		{"allowed read", "test-plugin", hostfunc.KeyValueRequest{Key: "config/mode", Operation: "read"}, false},
+		{"denied write", "test-plugin", hostfunc.KeyValueRequest{Key: "config/mode", Operation: "write"}, true},
+		{"denied key", "test-plugin", hostfunc.KeyValueRequest{Key: "other", Operation: "read"}, true},
+		{"missing grants", "unknown-plugin", hostfunc.KeyValueRequest{Key: "config/mode", Operation: "read"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checker.CheckKeyValue(context.Background(), tt.plugin, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckKeyValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCapabilityMiddleware_KeyValue(t *testing.T) {
+	grants := map[string]*hostfunc.GrantSet{
+		"test-plugin": {
+			KV: &hostfunc.KeyValueCapability{
+				Rules: []hostfunc.KeyValueRule{{Operation: "read", Keys: []string{"config/*"}}},
+			},
+		},
+	}
+	var denied []string
+	checker := NewCapabilityChecker(grants, WithCapabilityDenialHandler(
+		func(_ context.Context, _, kind, pattern, _ string) {
+			denied = append(denied, kind+" "+pattern)
+		}))
+
+	calls := 0
+	next := func(ctx context.Context, payload []byte) ([]byte, error) {
+		calls++
+		return []byte(`{}`), nil
+	}
+	handler := CapabilityMiddleware(checker)(next)
+
+	tests := []struct {
+		name      string
+		plugin    string
+		funcName  string
+		payload   string
+		wantAllow bool
+	}{
+		{"allowed read", "test-plugin", "kv_get", `{"key":"config/mode"}`, true},
+		{"denied write", "test-plugin", "kv_set", `{"key":"config/mode","value":"x"}`, false},
+		{"denied delete", "test-plugin", "kv_delete", `{"key":"config/mode"}`, false},
+		{"missing grants", "other-plugin", "kv_get", `{"key":"config/mode"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			ctx := WithCapabilityPluginName(context.Background(), tt.plugin)
+			resp, err := handler(NewHostContext(ctx, tt.funcName), []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed := calls == 1; allowed != tt.wantAllow {
+				t.Errorf("allowed = %v, want %v (response %s)", allowed, tt.wantAllow, resp)
+			}
+			if !tt.wantAllow && !strings.Contains(string(resp), "VALIDATION_ERROR") {
+				t.Errorf("expected validation error, got %s", resp)
+			}
+		})
+	}
+
+	if len(denied) != 3 || denied[0] != "kv write:config/mode" {
+		t.Errorf("unexpected denials: %v", denied)
+	}
+}
+
+func TestCapabilityMiddleware_DNS(t *testing.T) {
+	grants := map[string]*hostfunc.GrantSet{
+		// Network grant for port 53 only; used by the fallback and the nameserver check.
+		"legacy-plugin": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"*.example.com"}, Ports: []string{"53"}},
+		}}},
+		"dns-plugin": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"10.0.0.53"}, Ports: []string{"53"}},
+		}}},
+	}
+	var denied []string
+	checker := NewCapabilityChecker(grants,
+		WithCapabilityDNSGrants(map[string]*policy.DNSCapability{
+			"dns-plugin": {Hosts: []string{"*.corp.test"}},
+		}),
+		WithCapabilityDenialHandler(func(_ context.Context, _, kind, pattern, _ string) {
+			denied = append(denied, kind+" "+pattern)
+		}))
+
+	calls := 0
+	next := func(ctx context.Context, payload []byte) ([]byte, error) {
+		calls++
+		return []byte(`{}`), nil
+	}
+	handler := CapabilityMiddleware(checker)(next)
+
+	tests := []struct {
+		name      string
+		plugin    string
+		payload   string
+		wantAllow bool
+	}{
+		{"dns grant allows hostname", "dns-plugin", `{"hostname":"db.corp.test"}`, true},
+		{"dns grant denies other hostname", "dns-plugin", `{"hostname":"www.example.com"}`, false},
+		{"dns grant does not imply network grant", "dns-plugin", `{"hostname":"10.0.0.53"}`, false},
+		{"granted nameserver", "dns-plugin", `{"hostname":"db.corp.test","nameserver":"10.0.0.53:53"}`, true},
+		{"ungranted nameserver", "dns-plugin", `{"hostname":"db.corp.test","nameserver":"8.8.8.8"}`, false},
+		{"fallback to network rules", "legacy-plugin", `{"hostname":"www.example.com"}`, true},
+		{"fallback denies", "legacy-plugin", `{"hostname":"db.corp.test"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			ctx := WithCapabilityPluginName(context.Background(), tt.plugin)
+			resp, err := handler(NewHostContext(ctx, "dns_lookup"), []byte(tt.payload))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed := calls == 1; allowed != tt.wantAllow {
+				t.Errorf("allowed = %v, want %v (response %s)", allowed, tt.wantAllow, resp)
+			}
+		})
+	}
+
+	want := []string{"dns www.example.com", "dns 10.0.0.53", "network 8.8.8.8:53", "network db.corp.test:53"}
+	if strings.Join(denied, ",") != strings.Join(want, ",") {
+		t.Errorf("denials = %v, want %v", denied, want)
+	}
+}
+
 func TestCapabilityChecker_ToCapabilityGetter(t *testing.T) {
 	grants := map[string]*hostfunc.GrantSet{
 		"test-plugin": {
@@ -111,6 +262,106 @@ func TestCapabilityChecker_ToCapabilityGetter(t *testing.T) {
 	}
 }
 
+func TestCapabilityChecker_ToCapabilityGetter_Prefixes(t *testing.T) {
+	grants := map[string]*hostfunc.GrantSet{
+		"test-plugin": {
+			FS: &hostfunc.FileSystemCapability{
+				Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/hosts"}, Write: []string{"/tmp/**"}}},
+			},
+			Network: &hostfunc.NetworkCapability{
+				Rules: []hostfunc.NetworkRule{
+					{Hosts: []string{"example.com"}, Ports: []string{"443"}},
+					{Hosts: []string{"::1"}, Ports: []string{"8080"}},
+				},
+			},
+			Exec: &hostfunc.ExecCapability{Commands: []string{"ls"}},
+		},
+	}
+	checker := NewCapabilityChecker(grants, WithCapabilitySymlinkResolution(false))
+	getter := checker.ToCapabilityGetter(context.Background(), "test-plugin")
+
+	tests := []struct {
+		name       string
+		capability string
+		want       bool
+	}{
+		{"fs read allowed", "fs:read:/etc/hosts", true},
+		{"fs read denied", "fs:read:/etc/shadow", false},
+		{"fs write allowed", "fs:write:/tmp/out.txt", true},
+		{"fs write denied", "fs:write:/etc/hosts", false},
+		{"fs unknown op", "fs:exec:/etc/hosts", false},
+		{"fs missing path", "fs:read:", false},
+		{"fs missing op", "fs:/etc/hosts", false},
+		{"net allowed", "net:example.com:443", true},
+		{"net wrong port", "net:example.com:80", false},
+		{"net bracketed ipv6", "net:[::1]:8080", true},
+		{"net missing port", "net:example.com", false},
+		{"net bad port", "net:example.com:https", false},
+		{"net port out of range", "net:example.com:70000", false},
+		{"net missing host", "net::443", false},
+		{"exec fallback", "ls", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getter("test-plugin", tt.capability)
+			if got != tt.want {
+				t.Errorf("CapabilityGetter(%q) = %v, want %v", tt.capability, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapabilityChecker_ExplainNetwork(t *testing.T) {
+	rule := hostfunc.NetworkRule{Hosts: []string{"api.example.com"}, Ports: []string{"443"}}
+	grants := map[string]*hostfunc.GrantSet{
+		"test-plugin": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{rule}}},
+	}
+	denials := 0
+	checker := NewCapabilityChecker(grants, WithCapabilityDenialHandler(
+		func(context.Context, string, string, string, string) { denials++ }))
+
+	tests := []struct {
+		name        string
+		plugin      string
+		req         hostfunc.NetworkRequest
+		wantAllowed bool
+		wantReason  string
+	}{
+		{"allowed", "test-plugin", hostfunc.NetworkRequest{Host: "api.example.com", Port: 443}, true, "allowed by network rule 0"},
+		{"wrong port", "test-plugin", hostfunc.NetworkRequest{Host: "api.example.com", Port: 80}, false, "port 80 not allowed for host api.example.com"},
+		{"wrong host", "test-plugin", hostfunc.NetworkRequest{Host: "evil.example.com", Port: 443}, false, "no rule matches host evil.example.com"},
+		{"no grants", "unknown-plugin", hostfunc.NetworkRequest{Host: "api.example.com", Port: 443}, false, "no capabilities granted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := checker.ExplainNetwork(context.Background(), tt.plugin, tt.req)
+			if err != nil {
+				t.Fatalf("ExplainNetwork() error = %v", err)
+			}
+			if d.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", d.Allowed, tt.wantAllowed)
+			}
+			if d.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", d.Reason, tt.wantReason)
+			}
+			if tt.wantAllowed {
+				matched, ok := d.MatchedRule.(hostfunc.NetworkRule)
+				if !ok || matched.Hosts[0] != rule.Hosts[0] {
+					t.Errorf("MatchedRule = %#v, want %#v", d.MatchedRule, rule)
+				}
+			} else if d.MatchedRule != nil {
+				t.Errorf("MatchedRule = %#v, want nil", d.MatchedRule)
+			}
+		})
+	}
+
+	if denials != 0 {
+		t.Errorf("ExplainNetwork invoked the denial handler %d times", denials)
+	}
+}
+
 func TestCapabilityPluginNameContext(t *testing.T) {
 	ctx := context.Background()
 
@@ -145,3 +396,54 @@ func TestNewCapabilityChecker_Options(t *testing.T) {
 		t.Errorf("cwd = %q, want %q", checker.cwd, "/custom/path")
 	}
 }
+
+func TestCapabilityChecker_DenyRules(t *testing.T) {
+	grants := map[string]*hostfunc.GrantSet{
+		"test-plugin": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"*.internal"}, Ports: []string{"443"}},
+		}}},
+	}
+	var denied []string
+	checker := NewCapabilityChecker(grants,
+		WithCapabilityDenyRules(&hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"secrets.internal"}, Ports: []string{"*"}},
+		}}}),
+		WithCapabilityDenialHandler(func(_ context.Context, _, _, pattern, _ string) { denied = append(denied, pattern) }),
+	)
+
+	ctx := context.Background()
+	if err := checker.CheckNetworkConnection(ctx, "test-plugin", "api.internal", 443); err != nil {
+		t.Errorf("api.internal:443 denied: %v", err)
+	}
+	if err := checker.CheckNetworkConnection(ctx, "test-plugin", "secrets.internal", 443); err == nil {
+		t.Error("secrets.internal:443 allowed despite deny rule")
+	}
+	if len(denied) != 1 || denied[0] != "secrets.internal:443" {
+		t.Errorf("denials = %v, want [secrets.internal:443]", denied)
+	}
+
+	d, err := checker.ExplainNetwork(ctx, "test-plugin", hostfunc.NetworkRequest{Host: "secrets.internal", Port: 443})
+	if err != nil || d.Allowed {
+		t.Errorf("ExplainNetwork() = %+v, %v; want denied", d, err)
+	}
+}
+
+func TestCapabilityChecker_PathCaseSensitivity(t *testing.T) {
+	grants := map[string]*hostfunc.GrantSet{
+		"test-plugin": {FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+			{Read: []string{"/Data/**"}},
+		}}},
+	}
+	req := hostfunc.FileSystemRequest{Path: "/data/file.txt", Operation: "read"}
+
+	for _, sensitive := range []bool{true, false} {
+		checker := NewCapabilityChecker(grants,
+			WithCapabilitySymlinkResolution(false),
+			WithCapabilityPathCaseSensitivity(sensitive),
+		)
+		err := checker.CheckFileSystem(context.Background(), "test-plugin", req)
+		if allowed := err == nil; allowed == sensitive {
+			t.Errorf("case sensitive = %v: allowed = %v", sensitive, allowed)
+		}
+	}
+}
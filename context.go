@@ -62,3 +62,17 @@ func HostContextFrom(ctx context.Context, funcName string) HostContext {
 	}
 	return NewHostContext(ctx, funcName)
 }
+
+// withContext returns a HostContext for ctx that keeps hc's function name
+// and a copy of its values, so middleware can derive a new context (e.g. a
+// deadline) without losing them. Values set on the copy are not visible
+// through hc.
+func withContext(hc HostContext, ctx context.Context) HostContext {
+	derived := &hostContext{Context: ctx, funcName: hc.FunctionName(), values: make(map[any]any)}
+	if c, ok := hc.(*hostContext); ok {
+		for k, v := range c.values {
+			derived.values[k] = v
+		}
+	}
+	return derived
+}
@@ -0,0 +1,133 @@
+package hostlib
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var denialCountContextKey = &capabilityContextKey{name: "denial_count"}
+
+// DenialCountFromContext returns how many denials a DenialHandler invocation
+// stands for. Without rate limiting every invocation reports a single denial;
+// with WithCapabilityDenialRateLimit a flushed invocation reports the number of
+// identical denials coalesced during the window.
+func DenialCountFromContext(ctx context.Context) int {
+	if n, ok := ctx.Value(denialCountContextKey).(int); ok {
+		return n
+	}
+	return 1
+}
+
+// WithCapabilityDenialRateLimit coalesces repeated denials for the same
+// (plugin, kind, pattern). The first denial reaches the DenialHandler
+// immediately; identical denials within window are counted and reported in a
+// single invocation when the window expires. At most maxKeys distinct denials
+// are tracked; the least recently seen is flushed early when the limit is hit.
+func WithCapabilityDenialRateLimit(maxKeys int, window time.Duration) CapabilityCheckerOption {
+	return func(c *capabilityCheckerConfig) {
+		c.denialRateMax = maxKeys
+		c.denialRateWindow = window
+	}
+}
+
+type denialKey struct {
+	pluginName string
+	kind       string
+	pattern    string
+}
+
+type denialEntry struct {
+	ctx        context.Context
+	timer      *time.Timer
+	key        denialKey
+	message    string
+	suppressed int
+}
+
+// denialLimiter is a small LRU of recently denied (plugin, kind, pattern)
+// tuples that forwards the first denial and aggregates the rest.
+type denialLimiter struct {
+	handler DenialHandler
+	entries map[denialKey]*list.Element
+	order   *list.List // front is most recently denied
+	mu      sync.Mutex
+	max     int
+	window  time.Duration
+}
+
+func newDenialLimiter(handler DenialHandler, maxKeys int, window time.Duration) *denialLimiter {
+	if maxKeys <= 0 {
+		maxKeys = 1
+	}
+	return &denialLimiter{
+		handler: handler,
+		entries: make(map[denialKey]*list.Element),
+		order:   list.New(),
+		max:     maxKeys,
+		window:  window,
+	}
+}
+
+func (l *denialLimiter) deny(ctx context.Context, pluginName, kind, pattern, message string) {
+	key := denialKey{pluginName: pluginName, kind: kind, pattern: pattern}
+
+	l.mu.Lock()
+	if elem, ok := l.entries[key]; ok {
+		entry := elem.Value.(*denialEntry)
+		entry.suppressed++
+		entry.ctx = ctx
+		entry.message = message
+		l.order.MoveToFront(elem)
+		l.mu.Unlock()
+		return
+	}
+
+	entry := &denialEntry{ctx: ctx, key: key, message: message}
+	entry.timer = time.AfterFunc(l.window, func() { l.flush(entry) })
+	l.entries[key] = l.order.PushFront(entry)
+
+	var evicted *denialEntry
+	if l.order.Len() > l.max {
+		oldest := l.order.Back()
+		evicted = oldest.Value.(*denialEntry)
+		evicted.timer.Stop()
+		l.order.Remove(oldest)
+		delete(l.entries, evicted.key)
+	}
+	l.mu.Unlock()
+
+	l.handler(ctx, pluginName, kind, pattern, message)
+	if evicted != nil {
+		l.report(evicted)
+	}
+}
+
+// flush removes entry and reports any denials it absorbed. A timer that
+// fires after its entry was evicted, possibly while a newer entry holds the
+// same key, finds a different entry in the map and does nothing.
+func (l *denialLimiter) flush(entry *denialEntry) {
+	l.mu.Lock()
+	elem, ok := l.entries[entry.key]
+	if !ok || elem.Value != entry {
+		l.mu.Unlock()
+		return
+	}
+	l.order.Remove(elem)
+	delete(l.entries, entry.key)
+	l.mu.Unlock()
+
+	l.report(entry)
+}
+
+func (l *denialLimiter) report(entry *denialEntry) {
+	if entry.suppressed == 0 {
+		return
+	}
+	// The original request may be long gone; keep its values but not its deadline.
+	ctx := context.WithValue(context.WithoutCancel(entry.ctx), denialCountContextKey, entry.suppressed)
+	msg := fmt.Sprintf("%s (repeated %d times)", entry.message, entry.suppressed)
+	l.handler(ctx, entry.key.pluginName, entry.key.kind, entry.key.pattern, msg)
+}
@@ -0,0 +1,123 @@
+package hostlib
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// denialRecorder is a thread-safe DenialHandler; flushed denials arrive on a timer goroutine.
+type denialRecorder struct {
+	messages []string
+	total    int
+	mu       sync.Mutex
+}
+
+func (r *denialRecorder) handle(ctx context.Context, _, _, _, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, message)
+	r.total += DenialCountFromContext(ctx)
+}
+
+func (r *denialRecorder) snapshot() ([]string, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.messages...), r.total
+}
+
+func TestCapabilityChecker_DenialRateLimit_Coalesces(t *testing.T) {
+	rec := &denialRecorder{}
+	checker := NewCapabilityChecker(map[string]*hostfunc.GrantSet{"p": {}},
+		WithCapabilityDenialHandler(rec.handle),
+		WithCapabilityDenialRateLimit(16, 50*time.Millisecond),
+	)
+
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		err := checker.CheckExec(ctx, "p", hostfunc.ExecCapabilityRequest{Command: "rm"})
+		require.Error(t, err)
+		assert.Equal(t, "exec capability denied: rm", err.Error(), "caller error is unchanged")
+	}
+
+	// The first denial is reported immediately.
+	msgs, total := rec.snapshot()
+	require.Len(t, msgs, 1)
+	assert.Equal(t, 1, total)
+
+	// The remaining 99 are flushed as one invocation when the window expires.
+	assert.Eventually(t, func() bool {
+		_, total := rec.snapshot()
+		return total == 100
+	}, time.Second, 5*time.Millisecond)
+
+	msgs, _ = rec.snapshot()
+	require.Len(t, msgs, 2)
+	assert.Contains(t, msgs[1], "repeated 99 times")
+
+	// After the window, the next denial is reported immediately again.
+	_ = checker.CheckExec(ctx, "p", hostfunc.ExecCapabilityRequest{Command: "rm"})
+	msgs, total = rec.snapshot()
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, 101, total)
+}
+
+func TestCapabilityChecker_DenialRateLimit_DistinctKeysAndEviction(t *testing.T) {
+	rec := &denialRecorder{}
+	checker := NewCapabilityChecker(map[string]*hostfunc.GrantSet{"p": {}},
+		WithCapabilityDenialHandler(rec.handle),
+		WithCapabilityDenialRateLimit(1, time.Hour),
+	)
+
+	ctx := context.Background()
+	_ = checker.CheckExec(ctx, "p", hostfunc.ExecCapabilityRequest{Command: "rm"})
+	_ = checker.CheckExec(ctx, "p", hostfunc.ExecCapabilityRequest{Command: "rm"})
+	_ = checker.CheckExec(ctx, "p", hostfunc.ExecCapabilityRequest{Command: "rm"})
+
+	// A different pattern is not coalesced; it evicts "rm", flushing its count early.
+	_ = checker.CheckExec(ctx, "p", hostfunc.ExecCapabilityRequest{Command: "curl"})
+
+	msgs, total := rec.snapshot()
+	require.Len(t, msgs, 3)
+	assert.Equal(t, 4, total)
+	assert.True(t, strings.HasSuffix(msgs[1], "curl"))
+	assert.Contains(t, msgs[2], "rm (repeated 2 times)")
+}
+
+func TestDenialCountFromContext_Default(t *testing.T) {
+	assert.Equal(t, 1, DenialCountFromContext(context.Background()))
+}
+
+func TestDenialLimiter_StaleTimerIgnoresNewerEntry(t *testing.T) {
+	rec := &denialRecorder{}
+	l := newDenialLimiter(rec.handle, 1, time.Hour)
+	ctx := context.Background()
+
+	l.deny(ctx, "p", "exec", "rm", "rm")
+	stale := l.order.Front().Value.(*denialEntry)
+
+	// "curl" evicts "rm", and "rm" comes back under a fresh entry that has
+	// absorbed one repeat.
+	l.deny(ctx, "p", "exec", "curl", "curl")
+	l.deny(ctx, "p", "exec", "rm", "rm")
+	l.deny(ctx, "p", "exec", "rm", "rm")
+
+	// The evicted entry's timer firing late must not flush the new one.
+	l.flush(stale)
+	msgs, _ := rec.snapshot()
+	assert.Len(t, msgs, 3)
+
+	current := l.order.Front().Value.(*denialEntry)
+	current.timer.Stop()
+	l.flush(current)
+	msgs, total := rec.snapshot()
+	require.Len(t, msgs, 4)
+	assert.Contains(t, msgs[3], "rm (repeated 1 times)")
+	assert.Equal(t, 4, total)
+}
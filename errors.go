@@ -1,7 +1,10 @@
 package hostlib
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 )
 
 // ErrorResponse represents a structured error that can be returned as JSON to plugins.
@@ -54,6 +57,15 @@ func NewInternalError(message string) ErrorResponse {
 	}
 }
 
+// NewTimeoutError creates an error response for calls that ran out of time.
+func NewTimeoutError(message string) ErrorResponse {
+	return ErrorResponse{
+		Error:   "TIMEOUT",
+		Message: message,
+		Code:    504,
+	}
+}
+
 // NewPanicError creates an error response for recovered panics.
 func NewPanicError(panicValue any) ErrorResponse {
 	var msg string
@@ -70,3 +82,38 @@ func NewPanicError(panicValue any) ErrorResponse {
 		Code:    500,
 	}
 }
+
+// ErrErrorResponse is matched by every ResponseError.
+var ErrErrorResponse = errors.New("host function returned an error response")
+
+// ResponseError reports a call that completed but answered the plugin with
+// an ErrorResponse rather than a result.
+type ResponseError struct {
+	Response ErrorResponse
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Response.Error, e.Response.Code, e.Response.Message)
+}
+
+// Is reports whether target is ErrErrorResponse.
+func (e *ResponseError) Is(target error) bool {
+	return target == ErrErrorResponse
+}
+
+// errorResponsePrefix is how every ErrorResponse.ToJSON output starts.
+var errorResponsePrefix = []byte(`{"error":"`)
+
+// responseError returns a *ResponseError when resp is an encoded
+// ErrorResponse, and nil otherwise. Successful responses are rejected by a
+// prefix check, without decoding them.
+func responseError(resp []byte) error {
+	if !bytes.HasPrefix(resp, errorResponsePrefix) {
+		return nil
+	}
+	var er ErrorResponse
+	if err := json.Unmarshal(resp, &er); err != nil || er.Code == 0 {
+		return nil
+	}
+	return &ResponseError{Response: er}
+}
@@ -109,3 +109,21 @@ func TestNewPanicError(t *testing.T) {
 		})
 	}
 }
+
+func TestNewTimeoutError(t *testing.T) {
+	err := NewTimeoutError("dns_lookup did not complete within 1s")
+	assert.Equal(t, "TIMEOUT", err.Error)
+	assert.Equal(t, "dns_lookup did not complete within 1s", err.Message)
+	assert.Equal(t, 504, err.Code)
+}
+
+func TestResponseError(t *testing.T) {
+	assert.Nil(t, responseError([]byte(`{"records":["1.2.3.4"]}`)))
+	assert.Nil(t, responseError(nil))
+	assert.Nil(t, responseError([]byte(`{"error":"not json`)))
+
+	err := responseError(NewNotFoundError("bogus").ToJSON())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrErrorResponse)
+	assert.Equal(t, "NOT_FOUND (404): unknown host function: bogus", err.Error())
+}
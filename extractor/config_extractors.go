@@ -3,6 +3,7 @@ package extractor
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
@@ -105,12 +106,15 @@ func (e *NetworkExtractor) Extract(config map[string]interface{}) *hostfunc.Gran
 }
 
 func (e *NetworkExtractor) extractFromURL(config map[string]interface{}, hosts, ports []string) ([]string, []string) {
-	if url, ok := config["url"].(string); ok && url != "" {
-		if host := extractHostFromURL(url); host != "" {
+	if rawURL, ok := config["url"].(string); ok && rawURL != "" {
+		if host, port := extractHostFromURL(rawURL); host != "" {
 			hosts = append(hosts, host)
-			if strings.HasPrefix(url, "https://") {
+			switch {
+			case port != "":
+				ports = append(ports, port)
+			case strings.HasPrefix(rawURL, "https://"):
 				ports = append(ports, "443")
-			} else if strings.HasPrefix(url, "http://") {
+			case strings.HasPrefix(rawURL, "http://"):
 				ports = append(ports, "80")
 			}
 		}
@@ -163,21 +167,15 @@ func (e *NetworkExtractor) extractPort(config map[string]interface{}, ports []st
 	return ports
 }
 
-func extractHostFromURL(url string) string {
-	parts := strings.Split(url, "://")
-	if len(parts) < 2 {
-		return ""
+// extractHostFromURL returns the host and explicit port of rawURL, or empty
+// strings if it has no host. IPv6 literals are returned without brackets,
+// matching the host the HTTP capability check sees at runtime.
+func extractHostFromURL(rawURL string) (host, port string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ""
 	}
-	remaining := parts[1]
-	// Cut at first slash
-	if idx := strings.Index(remaining, "/"); idx != -1 {
-		remaining = remaining[:idx]
-	}
-	// Cut at port
-	if idx := strings.Index(remaining, ":"); idx != -1 {
-		remaining = remaining[:idx]
-	}
-	return remaining
+	return parsed.Hostname(), parsed.Port()
 }
 
 // Ensure extractors implement the interface.
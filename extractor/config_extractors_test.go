@@ -42,6 +42,45 @@ func TestNetworkExtractor_Extract(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "HTTPS URL with explicit port uses that port",
+			config: map[string]interface{}{
+				"url": "https://example.com:8443/path",
+			},
+			expected: &hostfunc.GrantSet{
+				Network: &hostfunc.NetworkCapability{
+					Rules: []hostfunc.NetworkRule{
+						{Hosts: []string{"example.com"}, Ports: []string{"8443"}},
+					},
+				},
+			},
+		},
+		{
+			name: "IPv6 URL with port",
+			config: map[string]interface{}{
+				"url": "https://[2001:db8::1]:8443/",
+			},
+			expected: &hostfunc.GrantSet{
+				Network: &hostfunc.NetworkCapability{
+					Rules: []hostfunc.NetworkRule{
+						{Hosts: []string{"2001:db8::1"}, Ports: []string{"8443"}},
+					},
+				},
+			},
+		},
+		{
+			name: "IPv6 URL without port",
+			config: map[string]interface{}{
+				"url": "http://[::1]/health",
+			},
+			expected: &hostfunc.GrantSet{
+				Network: &hostfunc.NetworkCapability{
+					Rules: []hostfunc.NetworkRule{
+						{Hosts: []string{"::1"}, Ports: []string{"80"}},
+					},
+				},
+			},
+		},
 		{
 			name: "TCP with host and integer port",
 			config: map[string]interface{}{
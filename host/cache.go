@@ -2,6 +2,8 @@ package host
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -50,3 +52,49 @@ func NewPersistentCompilationCache(appName string) CompilationCache {
 
 	return &wazeroCacheAdapter{inner: cache}
 }
+
+// NewSharedCache returns a compilation cache backed by dir, creating the
+// directory if needed. Unlike NewPersistentCompilationCache it does not
+// fall back to memory, so callers know whether compiled code will survive
+// restarts.
+//
+// The cache is safe for concurrent use: one instance may be passed to any
+// number of Executors, including ones running in parallel, and a module
+// compiled by one is reused by the others. Close it only after every
+// Executor using it has been closed.
+func NewSharedCache(dir string) (CompilationCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create compilation cache directory: %w", err)
+	}
+	cache, err := wazero.NewCompilationCacheWithDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open compilation cache: %w", err)
+	}
+	return &wazeroCacheAdapter{inner: cache}, nil
+}
+
+// ErrNoCompilationCache is returned by Warmup on an executor created without
+// WithCompilationCache, where there is nowhere to keep the compiled code.
+var ErrNoCompilationCache = errors.New("no compilation cache configured")
+
+// Warmup compiles each module into the executor's compilation cache without
+// instantiating it, so the first LoadPlugin or NewPool for it, on this or
+// any Executor sharing the cache, skips compilation. The compiled modules
+// are kept until the executor is closed. Without a configured cache it
+// compiles nothing and returns ErrNoCompilationCache.
+func (e *Executor) Warmup(ctx context.Context, wasmBytes ...[]byte) error {
+	if e.cache == nil {
+		return ErrNoCompilationCache
+	}
+	if err := e.calls.acquire(); err != nil {
+		return err
+	}
+	defer e.calls.release()
+
+	for i, wasm := range wasmBytes {
+		if _, err := e.runtime.CompileModule(ctx, wasm); err != nil {
+			return fmt.Errorf("failed to compile module %d: %w", i, err)
+		}
+	}
+	return nil
+}
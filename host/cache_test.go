@@ -0,0 +1,126 @@
+package host
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_WarmupSharedCache(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "cache")
+	cache, err := NewSharedCache(dir)
+	require.NoError(t, err)
+	defer cache.Close(ctx)
+
+	warm, err := NewExecutor(ctx, WithCompilationCache(cache))
+	require.NoError(t, err)
+	require.NoError(t, warm.Warmup(ctx, newFixturePlugin(fixturePlugin{})))
+	assert.Zero(t, warm.Stats().InstancesCreated, "warmup does not instantiate")
+	require.NoError(t, warm.Close(ctx))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, entries, "compiled code is written to the cache directory")
+
+	// A second executor sharing the cache loads the warmed module.
+	other, err := NewExecutor(ctx, WithCompilationCache(cache))
+	require.NoError(t, err)
+	defer other.Close(ctx)
+	p, err := other.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{}))
+	require.NoError(t, err)
+	res, err := p.Check(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Message)
+}
+
+func TestExecutor_WarmupInvalidModule(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSharedCache(t.TempDir())
+	require.NoError(t, err)
+	defer cache.Close(ctx)
+
+	e, err := NewExecutor(ctx, WithCompilationCache(cache))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	err = e.Warmup(ctx, newFixturePlugin(fixturePlugin{}), []byte("not wasm"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "module 1")
+}
+
+func TestExecutor_WarmupWithoutCache(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	err = e.Warmup(ctx, newFixturePlugin(fixturePlugin{}))
+	require.ErrorIs(t, err, ErrNoCompilationCache)
+}
+
+func TestNewSharedCache_BadDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+
+	_, err := NewSharedCache(filepath.Join(file, "cache"))
+	require.Error(t, err)
+}
+
+// BenchmarkFirstLoad measures creating an executor and loading a plugin
+// into it, with a cold cache and with a cache warmed by another executor.
+func BenchmarkFirstLoad(b *testing.B) {
+	ctx := context.Background()
+	wasm := newFixturePlugin(fixturePlugin{})
+
+	load := func(b *testing.B, cache CompilationCache) {
+		e, err := NewExecutor(ctx, WithCompilationCache(cache))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := e.LoadPlugin(ctx, wasm); err != nil {
+			b.Fatal(err)
+		}
+		_ = e.Close(ctx)
+	}
+
+	b.Run("Cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			cache, err := NewSharedCache(b.TempDir())
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.StartTimer()
+			load(b, cache)
+			b.StopTimer()
+			_ = cache.Close(ctx)
+			b.StartTimer()
+		}
+	})
+
+	b.Run("Warm", func(b *testing.B) {
+		cache, err := NewSharedCache(b.TempDir())
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer cache.Close(ctx)
+		warm, err := NewExecutor(ctx, WithCompilationCache(cache))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := warm.Warmup(ctx, wasm); err != nil {
+			b.Fatal(err)
+		}
+		_ = warm.Close(ctx)
+
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			load(b, cache)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"sort"
+)
+
+// requiredExports are the functions the host calls on every plugin.
+var requiredExports = []string{"_manifest", "_observe", "allocate"}
+
+// wasiModule is the import module name used by WASI preview 1 guests.
+const wasiModule = "wasi_snapshot_preview1"
+
+// CompatibilityReport describes how well a loaded plugin matches the ABI the
+// host expects, for display in plugin-health tooling.
+type CompatibilityReport struct {
+	// ABIVersion is the SDK version the plugin was built against, as declared
+	// in its manifest. Empty when the manifest could not be read.
+	ABIVersion string
+
+	// MinHostVersion is the oldest host version the plugin declares support for.
+	MinHostVersion string
+
+	// ManifestError explains why the manifest could not be read, if it couldn't.
+	ManifestError string
+
+	// MissingExports lists required functions the plugin does not export.
+	MissingExports []string
+
+	// HostImports lists the host functions the plugin imports, as "module.name".
+	HostImports []string
+
+	// RequiresWASI is set when the plugin imports WASI preview 1 functions.
+	RequiresWASI bool
+
+	// ExportsMemory is set when the plugin exports its linear memory, which
+	// the host needs to exchange data with it.
+	ExportsMemory bool
+
+	// Compatible is true when every required export is present, memory is
+	// exported and the manifest was read.
+	Compatible bool
+}
+
+// CheckCompatibility inspects the plugin's exports and imports and reads its
+// manifest. Problems are reported in the returned report; the error is only
+// set when the executor is shutting down.
+func (p *PluginInstance) CheckCompatibility(ctx context.Context) (CompatibilityReport, error) {
+	var report CompatibilityReport
+
+	exports := p.module.ExportedFunctionDefinitions()
+	for _, name := range requiredExports {
+		if _, ok := exports[name]; !ok {
+			report.MissingExports = append(report.MissingExports, name)
+		}
+	}
+	_, report.ExportsMemory = p.module.ExportedMemoryDefinitions()["memory"]
+
+	if p.compiled != nil {
+		for _, fn := range p.compiled.ImportedFunctions() {
+			module, name, _ := fn.Import()
+			if module == wasiModule {
+				report.RequiresWASI = true
+				continue
+			}
+			report.HostImports = append(report.HostImports, module+"."+name)
+		}
+		sort.Strings(report.HostImports)
+	}
+
+	if _, ok := exports["_manifest"]; ok {
+		manifest, err := p.Manifest(ctx)
+		switch {
+		case errors.Is(err, ErrExecutorShuttingDown):
+			return CompatibilityReport{}, err
+		case err != nil:
+			report.ManifestError = err.Error()
+		default:
+			report.ABIVersion = manifest.SDKVersion
+			report.MinHostVersion = manifest.MinHostVersion
+		}
+	} else {
+		report.ManifestError = "function \"_manifest\" not found"
+	}
+
+	report.Compatible = len(report.MissingExports) == 0 && report.ExportsMemory && report.ManifestError == ""
+	return report, nil
+}
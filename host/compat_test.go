@@ -0,0 +1,73 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	hostlib "github.com/reglet-dev/reglet-host-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginInstance_CheckCompatibility(t *testing.T) {
+	ctx := context.Background()
+	reg, err := hostlib.NewRegistry(hostlib.WithByteHandler("echo", func(_ context.Context, b []byte) ([]byte, error) {
+		return b, nil
+	}))
+	require.NoError(t, err)
+	e, err := NewExecutor(ctx, WithHostFunctions(reg))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	t.Run("Compatible", func(t *testing.T) {
+		p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+			manifest: `{"name":"fixture","version":"1.0.0","sdk_version":"0.4.0","min_host_version":"0.2.0"}`,
+			imports: []wasmImport{
+				hostImport("echo"),
+				{module: wasiModule, name: "proc_exit", params: []byte{wasmI32}},
+			},
+		}))
+		require.NoError(t, err)
+
+		report, err := p.CheckCompatibility(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, CompatibilityReport{
+			ABIVersion:     "0.4.0",
+			MinHostVersion: "0.2.0",
+			HostImports:    []string{"reglet_host.echo"},
+			RequiresWASI:   true,
+			ExportsMemory:  true,
+			Compatible:     true,
+		}, report)
+	})
+
+	t.Run("MissingExports", func(t *testing.T) {
+		m := &wasmModule{
+			globals: []int32{fixtureHeapBase},
+			funcs:   []wasmFunc{allocateFunc()},
+		}
+		p, err := e.LoadPlugin(ctx, m.encode())
+		require.NoError(t, err)
+
+		report, err := p.CheckCompatibility(ctx)
+		require.NoError(t, err)
+		assert.False(t, report.Compatible)
+		assert.Equal(t, []string{"_manifest", "_observe"}, report.MissingExports)
+		assert.NotEmpty(t, report.ManifestError)
+		assert.Empty(t, report.ABIVersion)
+		assert.False(t, report.RequiresWASI)
+		assert.Empty(t, report.HostImports)
+		assert.True(t, report.ExportsMemory)
+	})
+
+	t.Run("UnreadableManifest", func(t *testing.T) {
+		p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{manifest: `not json`}))
+		require.NoError(t, err)
+
+		report, err := p.CheckCompatibility(ctx)
+		require.NoError(t, err)
+		assert.False(t, report.Compatible)
+		assert.Empty(t, report.MissingExports)
+		assert.NotEmpty(t, report.ManifestError)
+	})
+}
@@ -0,0 +1,129 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	abi "github.com/reglet-dev/reglet-abi"
+)
+
+// ConfigDefaults extracts default values declared in a manifest's JSON Schema
+// config. Defaults are read from the "default" keyword of each property and
+// from nested object properties, so a schema like
+//
+//	{"properties": {"timeout": {"type": "integer", "default": 30}}}
+//
+// yields {"timeout": 30}. An empty schema yields no defaults.
+func ConfigDefaults(schema json.RawMessage) (map[string]any, error) {
+	if len(schema) == 0 {
+		return nil, nil
+	}
+
+	var root map[string]any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("parse config schema: %w", err)
+	}
+
+	defaults, _ := schemaDefault(root).(map[string]any)
+	return defaults, nil
+}
+
+// schemaDefault returns the default value for a schema node. Object nodes
+// combine their own "default" with defaults collected from their properties,
+// property defaults taking precedence.
+func schemaDefault(node map[string]any) any {
+	props, _ := node["properties"].(map[string]any)
+	if len(props) == 0 {
+		return node["default"]
+	}
+
+	collected := make(map[string]any)
+	if base, ok := node["default"].(map[string]any); ok {
+		for k, v := range base {
+			collected[k] = v
+		}
+	}
+	for name, raw := range props {
+		prop, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		v := schemaDefault(prop)
+		if v == nil {
+			continue
+		}
+		if existing, ok := collected[name].(map[string]any); ok {
+			if nested, ok := v.(map[string]any); ok {
+				v = MergeConfigDefaults(nested, existing)
+			}
+		}
+		collected[name] = v
+	}
+
+	if len(collected) == 0 {
+		return nil
+	}
+	return collected
+}
+
+// MergeConfigDefaults returns config layered over defaults. Values present in
+// config always win; nested objects are merged key by key. Neither input is
+// modified.
+func MergeConfigDefaults(defaults, config map[string]any) map[string]any {
+	merged := make(map[string]any, len(defaults)+len(config))
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range config {
+		userObj, userIsObj := v.(map[string]any)
+		defObj, defIsObj := merged[k].(map[string]any)
+		if userIsObj && defIsObj {
+			merged[k] = MergeConfigDefaults(defObj, userObj)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// ApplyManifestDefaults merges the defaults declared in manifest's config
+// schema under config.
+func ApplyManifestDefaults(manifest abi.Manifest, config map[string]any) (map[string]any, error) {
+	defaults, err := ConfigDefaults(manifest.ConfigSchema)
+	if err != nil {
+		return nil, err
+	}
+	return MergeConfigDefaults(defaults, config), nil
+}
+
+// CheckWithDefaults calls Check with config layered over the defaults declared
+// in the plugin's manifest. The defaults are read from the manifest once per
+// instance; a failed read is not cached, so a later call tries again.
+func (p *PluginInstance) CheckWithDefaults(ctx context.Context, config map[string]any) (abi.Result, error) {
+	defaults, err := p.configDefaults(ctx)
+	if err != nil {
+		return abi.Result{}, err
+	}
+	return p.Check(ctx, MergeConfigDefaults(defaults, config))
+}
+
+// configDefaults returns the manifest's config defaults, reading them on
+// first use.
+func (p *PluginInstance) configDefaults(ctx context.Context) (map[string]any, error) {
+	p.defaultsMu.Lock()
+	defer p.defaultsMu.Unlock()
+
+	if !p.defaultsLoaded {
+		manifest, err := p.Manifest(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("reading manifest defaults: %w", err)
+		}
+		defaults, err := ConfigDefaults(manifest.ConfigSchema)
+		if err != nil {
+			return nil, err
+		}
+		p.defaults, p.defaultsLoaded = defaults, true
+	}
+	return p.defaults, nil
+}
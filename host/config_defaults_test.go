@@ -0,0 +1,129 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const defaultsSchema = `{
+	"type": "object",
+	"properties": {
+		"host":    {"type": "string"},
+		"timeout": {"type": "integer", "default": 30},
+		"retries": {"type": "integer", "default": 3},
+		"tls": {
+			"type": "object",
+			"properties": {
+				"verify":  {"type": "boolean", "default": true},
+				"min_tls": {"type": "string", "default": "1.2"}
+			}
+		}
+	}
+}`
+
+func TestApplyManifestDefaults(t *testing.T) {
+	manifest := abi.Manifest{ConfigSchema: json.RawMessage(defaultsSchema)}
+
+	t.Run("omitted field gets default", func(t *testing.T) {
+		cfg, err := ApplyManifestDefaults(manifest, map[string]any{"host": "example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "example.com", cfg["host"])
+		assert.EqualValues(t, 30, cfg["timeout"])
+		assert.EqualValues(t, 3, cfg["retries"])
+		assert.Equal(t, map[string]any{"verify": true, "min_tls": "1.2"}, cfg["tls"])
+	})
+
+	t.Run("explicit value overrides default", func(t *testing.T) {
+		user := map[string]any{
+			"timeout": 5,
+			"tls":     map[string]any{"verify": false},
+		}
+		cfg, err := ApplyManifestDefaults(manifest, user)
+		require.NoError(t, err)
+		assert.Equal(t, 5, cfg["timeout"])
+		assert.EqualValues(t, 3, cfg["retries"])
+		assert.Equal(t, map[string]any{"verify": false, "min_tls": "1.2"}, cfg["tls"])
+
+		// The caller's config is left untouched.
+		assert.Equal(t, map[string]any{"verify": false}, user["tls"])
+		assert.NotContains(t, user, "retries")
+	})
+
+	t.Run("no schema leaves config unchanged", func(t *testing.T) {
+		cfg, err := ApplyManifestDefaults(abi.Manifest{}, map[string]any{"a": 1})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": 1}, cfg)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := ApplyManifestDefaults(abi.Manifest{ConfigSchema: json.RawMessage(`[`)}, nil)
+		assert.Error(t, err)
+	})
+}
+
+// defaultsManifest declares defaults for the fields an echoing _observe
+// hands back as the result.
+const defaultsManifest = `{"name":"fixture","version":"1.0.0","config_schema":{
+	"type": "object",
+	"properties": {
+		"status": {"type": "string", "default": "success"},
+		"data": {
+			"type": "object",
+			"properties": {
+				"host":    {"type": "string"},
+				"timeout": {"type": "integer", "default": 30}
+			}
+		}
+	}
+}}`
+
+func TestPluginInstance_CheckWithDefaults(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	inst, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{manifest: defaultsManifest, observe: echoBody}))
+	require.NoError(t, err)
+
+	result, err := inst.CheckWithDefaults(ctx, map[string]any{"data": map[string]any{"host": "db"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"host": "db", "timeout": float64(30)}, result.Data)
+
+	result, err = inst.CheckWithDefaults(ctx, map[string]any{"data": map[string]any{"timeout": 5}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"timeout": float64(5)}, result.Data)
+
+	// Plain Check passes the config through untouched.
+	result, err = inst.Check(ctx, map[string]any{"status": "success"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Data)
+}
+
+func TestPluginInstance_CheckWithDefaults_RetriesManifestError(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	inst, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+		manifest:     defaultsManifest,
+		manifestBody: trapOnceBody(len(defaultsManifest)),
+		observe:      echoBody,
+		globals:      []int32{0},
+	}))
+	require.NoError(t, err)
+
+	_, err = inst.CheckWithDefaults(ctx, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading manifest defaults")
+
+	result, err := inst.CheckWithDefaults(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"timeout": float64(30)}, result.Data)
+}
@@ -0,0 +1,71 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/wazero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoContextPlugin returns a plugin that keeps the context passed to
+// _set_context and logs it back as the context of a log message from
+// _observe, the way a guest SDK does.
+func newEchoContextPlugin() []byte {
+	const logOffset = 2048
+	prefix := `{"level":"INFO","message":"observing","context":`
+	bodyOffset := int64(logOffset + len(prefix))
+	i32 := func(v int64) []byte { return append([]byte{opI32Const}, sleb(v)...) }
+
+	// _set_context(ptr, len): copy the context after the prefix, keep len in global 1.
+	setContext := i32(bodyOffset)
+	setContext = append(setContext, opLocalGet, 0x00, opLocalGet, 0x01, opPrefixFC, opMemoryCopy, 0x00, 0x00)
+	setContext = append(setContext, opLocalGet, 0x01, opGlobalSet, 0x01)
+
+	// _observe: close the object, then log_message(logOffset, len(prefix)+len+1).
+	result := `{"status":"success","message":"ok"}`
+	observe := i32(bodyOffset)
+	observe = append(observe, opGlobalGet, 0x01, opI32Add)
+	observe = append(observe, i32('}')...)
+	observe = append(observe, opI32Store8, 0x00, 0x00)
+	observe = append(observe, opI64Const)
+	observe = append(observe, sleb(int64(logOffset)<<32)...)
+	observe = append(observe, opGlobalGet, 0x01)
+	observe = append(observe, i32(int64(len(prefix)+1))...)
+	observe = append(observe, opI32Add, opI64ExtendU, opI64Or, opCall, 0x00)
+	observe = append(observe, packedConst(fixtureResultOffset, len(result))...)
+
+	return newFixturePlugin(fixturePlugin{
+		result:  result,
+		imports: []wasmImport{{module: "reglet_host", name: "log_message", params: []byte{wasmI64}}},
+		observe: observe,
+		globals: []int32{0},
+		data:    []wasmData{{offset: logOffset, bytes: []byte(prefix)}},
+		extra: []wasmFunc{{
+			export: "_set_context",
+			params: []byte{wasmI32, wasmI32},
+			body:   setContext,
+		}},
+	})
+}
+
+func TestPluginInstance_PropagatesRequestID(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	e, err := NewExecutor(ctx, WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	p, err := e.LoadPlugin(ctx, newEchoContextPlugin())
+	require.NoError(t, err)
+
+	res, err := p.Check(wazero.WithRequestID(ctx, "req-123"), map[string]any{"host": "db1"})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Message)
+
+	assert.Contains(t, logs.String(), "msg=observing")
+	assert.Contains(t, logs.String(), "request_id=req-123")
+}
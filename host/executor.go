@@ -2,10 +2,16 @@
 package host
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os" // Added for fmt.Fprintf to stderr
+	"sync"
+	"time"
 
 	abi "github.com/reglet-dev/reglet-abi"
 	hostlib "github.com/reglet-dev/reglet-host-sdk"
@@ -15,12 +21,42 @@ import (
 	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
 )
 
+// ErrExportNotFound is returned when a plugin does not export a function the
+// host tried to call.
+var ErrExportNotFound = errors.New("export not found")
+
+// ExportNotFoundError names the missing export.
+type ExportNotFoundError struct {
+	Name string
+}
+
+func (e *ExportNotFoundError) Error() string {
+	return fmt.Sprintf("function %q not found", e.Name)
+}
+
+// Is reports whether target is ErrExportNotFound.
+func (e *ExportNotFoundError) Is(target error) bool {
+	return target == ErrExportNotFound
+}
+
 // Executor manages the lifecycle of a WASM plugin.
 type Executor struct {
 	runtime  t_wazero.Runtime
 	registry *hostlib.HandlerRegistry
 	verbose  bool
 	cache    CompilationCache
+	counters executorCounters
+	calls    callTracker
+
+	// executionTimeout bounds every guest call; zero means unbounded.
+	executionTimeout time.Duration
+
+	// Guest stdout and stderr; nil forwards to slog.
+	stdout io.Writer
+	stderr io.Writer
+
+	metrics wazero.MetricSink
+	logger  *slog.Logger
 }
 
 // NewExecutor creates a new executor with the given options.
@@ -48,6 +84,12 @@ func NewExecutor(ctx context.Context, opts ...Option) (*Executor, error) {
 		}
 	}
 
+	if e.executionTimeout > 0 {
+		// Without this, wazero only observes the deadline at host calls and
+		// a guest spinning in a loop would never stop.
+		config = config.WithCloseOnContextDone(true)
+	}
+
 	rt := t_wazero.NewRuntimeWithConfig(ctx, config)
 	wasi_snapshot_preview1.MustInstantiate(ctx, rt)
 	e.runtime = rt
@@ -94,150 +136,401 @@ func (e *Executor) registerHostFunctions(ctx context.Context) error {
 		}),
 	}
 
-	// Use the adapter to register both registry functions and our custom handler
+	if e.logger != nil {
+		logHandler.Handler = wazero.NewLogMessageHandler(wazero.WithLogLogger(e.logger))
+	}
+
+	metricHandler := wazero.CustomHandler{
+		Name:        "emit_metric",
+		ParamTypes:  []api.ValueType{api.ValueTypeI64},
+		ResultTypes: []api.ValueType{},
+		Handler:     wazero.NewEmitMetricHandler(e.metrics),
+	}
+
+	// Use the adapter to register both registry functions and our custom handlers
 	return wazero.RegisterWithRuntime(ctx, e.runtime, e.registry,
 		wazero.WithCustomHandler(logHandler),
+		wazero.WithCustomHandler(metricHandler),
 	)
 }
 
-// Close releases resources held by the executor.
+// Close releases resources held by the executor immediately, aborting any
+// in-flight calls. Use Shutdown to let them finish first.
 func (e *Executor) Close(ctx context.Context) error {
 	return e.runtime.Close(ctx)
 }
 
 // PluginInstance represents an instantiated WASM plugin.
 type PluginInstance struct {
-	module api.Module
+	module   api.Module
+	compiled t_wazero.CompiledModule
+	counters *executorCounters
+	calls    *callTracker
+	verbose  bool
+	timeout  time.Duration
+
+	// missingDeallocate reports a missing "deallocate" export once.
+	missingDeallocate sync.Once
+
+	// Config schema, read from the guest on first use.
+	schemaMu      sync.Mutex
+	schema        []byte
+	schemaDialect string
+
+	// Manifest config defaults, loaded on the first CheckWithDefaults call
+	// that reads the manifest successfully.
+	defaultsMu     sync.Mutex
+	defaults       map[string]any
+	defaultsLoaded bool
 }
 
 // LoadPlugin instantiates a WASM module.
 func (e *Executor) LoadPlugin(ctx context.Context, wasmBytes []byte) (*PluginInstance, error) {
-	mod, err := e.runtime.Instantiate(ctx, wasmBytes)
+	if err := e.calls.acquire(); err != nil {
+		return nil, err
+	}
+	defer e.calls.release()
+
+	compiled, err := e.runtime.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+	p, err := e.instantiate(ctx, compiled)
+	if err != nil {
+		_ = compiled.Close(ctx)
+		return nil, err
+	}
+	return p, nil
+}
+
+// instantiate creates a new module instance from compiled and runs its
+// _initialize export, if any.
+func (e *Executor) instantiate(ctx context.Context, compiled t_wazero.CompiledModule) (*PluginInstance, error) {
+	stdout, stderr := e.guestStdio(compiled.Name())
+	config := t_wazero.NewModuleConfig().WithStdout(stdout).WithStderr(stderr)
+	mod, err := e.runtime.InstantiateModule(ctx, compiled, config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to instantiate module: %w", err)
 	}
 
 	// Initialize if needed (though Instantiate usually handles start)
 	if init := mod.ExportedFunction("_initialize"); init != nil {
-		if _, err := init.Call(ctx); err != nil {
+		if _, err := callWithTimeout(ctx, e.executionTimeout, "_initialize", init); err != nil {
+			_ = mod.Close(ctx)
 			return nil, fmt.Errorf("failed to call _initialize: %w", err)
 		}
 	}
 
-	return &PluginInstance{module: mod}, nil
+	e.counters.instancesCreated.Add(1)
+	return &PluginInstance{
+		module:   mod,
+		compiled: compiled,
+		counters: &e.counters,
+		calls:    &e.calls,
+		verbose:  e.verbose,
+		timeout:  e.executionTimeout,
+	}, nil
 }
 
 // Manifest returns the plugin manifest.
 func (p *PluginInstance) Manifest(ctx context.Context) (abi.Manifest, error) {
+	if err := p.calls.acquire(); err != nil {
+		return abi.Manifest{}, err
+	}
+	defer p.calls.release()
+
 	fn := p.module.ExportedFunction("_manifest")
 	if fn == nil {
-		return abi.Manifest{}, fmt.Errorf("function \"_manifest\" not found")
+		return abi.Manifest{}, &ExportNotFoundError{Name: "_manifest"}
 	}
 
-	res, err := fn.Call(ctx)
+	res, err := p.callFunc(ctx, "_manifest", fn)
 	if err != nil {
 		return abi.Manifest{}, fmt.Errorf("calling _manifest: %w", err)
 	}
 
 	if len(res) == 0 {
-		return abi.Manifest{}, fmt.Errorf("_manifest returned no results")
+		return abi.Manifest{}, p.protocolError("_manifest", 0, "returned no results", nil)
 	}
 
 	var manifest abi.Manifest
-	err = p.unmarshalPacked(res[0], &manifest)
+	err = p.unmarshalPacked(ctx, "_manifest", res[0], &manifest)
 	return manifest, err
 }
 
-// Schema calls the "_schema" export of the plugin.
+// Schema calls the "_schema" export of the plugin. The schema is read from
+// the guest once per instance; later calls return a copy of the cached bytes.
 func (p *PluginInstance) Schema(ctx context.Context) ([]byte, error) {
-	fn := p.module.ExportedFunction("_schema")
+	p.schemaMu.Lock()
+	defer p.schemaMu.Unlock()
+
+	if p.schema == nil {
+		data, err := p.readSchema(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.schema = data
+		p.schemaDialect = schemaDialect(data)
+	}
+	return bytes.Clone(p.schema), nil
+}
+
+// SchemaDialect returns the JSON Schema dialect the plugin's config schema
+// declares through its "$schema" keyword, such as
+// "https://json-schema.org/draft/2020-12/schema", so the host can choose a
+// matching validator. It is empty when the schema does not declare one.
+func (p *PluginInstance) SchemaDialect(ctx context.Context) (string, error) {
+	if _, err := p.Schema(ctx); err != nil {
+		return "", err
+	}
+	p.schemaMu.Lock()
+	defer p.schemaMu.Unlock()
+	return p.schemaDialect, nil
+}
+
+// readSchema calls the guest's schema export.
+func (p *PluginInstance) readSchema(ctx context.Context) ([]byte, error) {
+	if err := p.calls.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.calls.release()
+
+	name := "_schema"
+	fn := p.module.ExportedFunction(name)
 	if fn == nil {
 		// Fallback for older plugins
-		fn = p.module.ExportedFunction("schema")
+		name = "schema"
+		fn = p.module.ExportedFunction(name)
 	}
 	if fn == nil {
 		return nil, fmt.Errorf("schema function not found")
 	}
 
-	res, err := fn.Call(ctx)
+	res, err := p.callFunc(ctx, name, fn)
 	if err != nil {
 		return nil, fmt.Errorf("calling schema: %w", err)
 	}
 
 	if len(res) == 0 {
-		return nil, fmt.Errorf("schema returned no results")
+		return nil, p.protocolError(name, 0, "returned no results", nil)
 	}
 
-	packed := res[0]
-	//nolint:gosec // WASM pointers are always 32-bit
-	ptr := uint32(packed >> 32)
-	//nolint:gosec // WASM lengths are always 32-bit
-	length := uint32(packed)
-	data, ok := p.module.Memory().Read(ptr, length)
-	if !ok {
-		return nil, fmt.Errorf("failed to read schema from memory")
+	data, err := p.readPacked(ctx, name, res[0])
+	if err != nil {
+		return nil, fmt.Errorf("reading schema: %w", err)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	return data, nil
+}
+
+// schemaDialect extracts the "$schema" keyword from a JSON schema document.
+func schemaDialect(schema []byte) string {
+	var doc struct {
+		Schema string `json:"$schema"`
+	}
+	if err := json.Unmarshal(schema, &doc); err != nil {
+		return ""
 	}
-	// Copy data to avoid memory issues if wasm memory changes, though here it's read immediately
-	schemaCopy := make([]byte, length)
-	copy(schemaCopy, data)
-	return schemaCopy, nil
+	return doc.Schema
 }
 
 // Check calls the "_observe" export of the plugin.
-func (p *PluginInstance) Check(ctx context.Context, config map[string]any) (abi.Result, error) {
+// A panic while serving the call is recovered and returned as an error.
+func (p *PluginInstance) Check(ctx context.Context, config map[string]any) (result abi.Result, err error) {
+	if err := p.calls.acquire(); err != nil {
+		return abi.Result{}, err
+	}
+	defer p.calls.release()
+
+	start := time.Now()
+	panicked := false
+	defer func() {
+		if r := recover(); r != nil {
+			panicked = true
+			result, err = abi.Result{}, fmt.Errorf("plugin check panicked: %v", r)
+		}
+		p.counters.observeCheck(time.Since(start), err, panicked)
+	}()
+
+	result, panicked, err = p.invoke(ctx, "_observe", config)
+	return result, err
+}
+
+// Call invokes an arbitrary guest export that follows the same calling
+// convention as "_observe": it takes the JSON-encoded config as (ptr, len)
+// and returns a packed ptr+len pointing at a JSON abi.Result. Use it for
+// plugin verbs the host has no dedicated method for, such as "_remediate".
+// A missing export is reported as *ExportNotFoundError.
+func (p *PluginInstance) Call(ctx context.Context, exportName string, config map[string]any) (result abi.Result, err error) {
+	if err := p.calls.acquire(); err != nil {
+		return abi.Result{}, err
+	}
+	defer p.calls.release()
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = abi.Result{}, fmt.Errorf("plugin call %s panicked: %v", exportName, r)
+		}
+	}()
+
+	result, _, err = p.invoke(ctx, exportName, config)
+	return result, err
+}
+
+// invoke writes config into guest memory, calls the named export with its
+// pointer and length, and decodes the packed result. trapped reports whether
+// the guest itself failed during the call.
+func (p *PluginInstance) invoke(ctx context.Context, exportName string, config map[string]any) (result abi.Result, trapped bool, err error) {
 	configBytes, err := json.Marshal(config)
 	if err != nil {
-		return abi.Result{}, err
+		return abi.Result{}, false, err
 	}
 
-	fn := p.module.ExportedFunction("_observe")
+	fn := p.module.ExportedFunction(exportName)
 	if fn == nil {
-		return abi.Result{}, fmt.Errorf("function \"_observe\" not found")
+		return abi.Result{}, false, &ExportNotFoundError{Name: exportName}
+	}
+
+	if err := p.sendContext(ctx); err != nil {
+		return abi.Result{}, isGuestTrap(err), err
+	}
+
+	ptr, err := p.writeInput(ctx, configBytes)
+	if err != nil {
+		return abi.Result{}, false, err
 	}
 
-	// Allocate memory for config
+	// Call export(ptr, len)
+	res, err := p.callFunc(ctx, exportName, fn, uint64(ptr), uint64(len(configBytes)))
+	if err != nil {
+		return abi.Result{}, isGuestTrap(err), fmt.Errorf("calling %s: %w", exportName, err)
+	}
+
+	if len(res) == 0 {
+		return abi.Result{}, false, p.protocolError(exportName, 0, "returned no results", nil)
+	}
+
+	err = p.unmarshalPacked(ctx, exportName, res[0], &result)
+	return result, false, err
+}
+
+// writeInput copies data into a buffer allocated by the guest and returns
+// its address.
+func (p *PluginInstance) writeInput(ctx context.Context, data []byte) (uint32, error) {
 	allocate := p.module.ExportedFunction("allocate")
 	if allocate == nil {
-		return abi.Result{}, fmt.Errorf("function 'allocate' not exported")
+		return 0, fmt.Errorf("function 'allocate' not exported")
 	}
-	ares, err := allocate.Call(ctx, uint64(len(configBytes)))
+	ares, err := p.callFunc(ctx, "allocate", allocate, uint64(len(data)))
 	if err != nil {
-		return abi.Result{}, fmt.Errorf("allocate failed: %w", err)
+		return 0, fmt.Errorf("allocate failed: %w", err)
 	}
-	ptr := ares[0]
+	//nolint:gosec // WASM pointers are always 32-bit
+	ptr := uint32(ares[0])
 
-	if !p.module.Memory().Write(uint32(ptr), configBytes) {
-		return abi.Result{}, fmt.Errorf("failed to write input to memory")
+	if !p.module.Memory().Write(ptr, data) {
+		return 0, fmt.Errorf("failed to write input to memory")
 	}
+	return ptr, nil
+}
 
-	// Call _observe(ptr, len)
-	res, err := fn.Call(ctx, ptr, uint64(len(configBytes)))
+// sendContext passes the caller's context to the guest before a call, if the
+// guest exports "_set_context". The argument is the (ptr, len) of a
+// JSON-encoded hostfunc.ContextWire:
+//
+//	{"deadline":"2026-01-02T15:04:05.123Z","request_id":"req-42"}
+//
+// request_id is set from wazero.WithRequestID, deadline from the context
+// deadline, and "canceled":true is added when the context is already done;
+// empty fields are omitted. A guest SDK keeps the value for the
+// duration of the call and echoes it in the context field of its log and
+// metric messages, where CreateContextFromWire restores it on the host.
+// Plugins without the export are called as before.
+func (p *PluginInstance) sendContext(ctx context.Context) error {
+	fn := p.module.ExportedFunction("_set_context")
+	if fn == nil {
+		return nil
+	}
+	wire, err := json.Marshal(wazero.ContextToWire(ctx))
 	if err != nil {
-		return abi.Result{}, fmt.Errorf("calling _observe: %w", err)
+		return err
 	}
-
-	if len(res) == 0 {
-		return abi.Result{}, fmt.Errorf("_observe returned no results")
+	ptr, err := p.writeInput(ctx, wire)
+	if err != nil {
+		return err
 	}
+	if _, err := p.callFunc(ctx, "_set_context", fn, uint64(ptr), uint64(len(wire))); err != nil {
+		return fmt.Errorf("calling _set_context: %w", err)
+	}
+	return nil
+}
 
-	var result abi.Result
-	err = p.unmarshalPacked(res[0], &result)
-	return result, err
+// callFunc calls a guest function under the executor's execution timeout.
+func (p *PluginInstance) callFunc(ctx context.Context, name string, fn api.Function, params ...uint64) ([]uint64, error) {
+	return callWithTimeout(ctx, p.timeout, name, fn, params...)
 }
 
-// unmarshalPacked reads JSON from packed ptr+len and unmarshals it.
-func (p *PluginInstance) unmarshalPacked(packed uint64, v any) error {
+// unmarshalPacked reads JSON from packed ptr+len returned by export and
+// unmarshals it.
+func (p *PluginInstance) unmarshalPacked(ctx context.Context, export string, packed uint64, v any) error {
+	data, err := p.readPacked(ctx, export, packed)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return p.protocolError(export, packed, "returned invalid JSON", err)
+	}
+	return nil
+}
+
+// readPacked copies the bytes at packed ptr+len returned by export out of
+// guest memory and then hands the buffer back to the guest through its
+// "deallocate" export, so long-lived instances do not leak a result buffer
+// per call. A range outside guest memory is a *GuestProtocolError.
+func (p *PluginInstance) readPacked(ctx context.Context, export string, packed uint64) ([]byte, error) {
+	//nolint:gosec // WASM pointers are always 32-bit
 	ptr := uint32(packed >> 32)
+	//nolint:gosec // WASM lengths are always 32-bit
 	length := uint32(packed)
 
 	if length == 0 {
-		return nil
+		return nil, nil
 	}
 
-	data, ok := p.module.Memory().Read(ptr, length)
+	mem := p.module.Memory()
+	if mem == nil {
+		return nil, p.protocolError(export, packed, "module exports no memory", nil)
+	}
+	if uint64(ptr)+uint64(length) > uint64(mem.Size()) {
+		return nil, p.protocolError(export, packed, "result lies outside guest memory", nil)
+	}
+	data, ok := mem.Read(ptr, length)
 	if !ok {
-		return fmt.Errorf("failed to read result from memory")
+		return nil, p.protocolError(export, packed, "failed to read result from memory", nil)
 	}
+	// data aliases guest memory, which deallocate may reuse.
+	out := make([]byte, length)
+	copy(out, data)
 
-	return json.Unmarshal(data, v)
+	p.deallocate(ctx, ptr, length)
+	return out, nil
+}
+
+// deallocate frees a guest buffer. It is best-effort: plugins built before
+// the export existed keep working, and a failure only leaks the buffer.
+func (p *PluginInstance) deallocate(ctx context.Context, ptr, length uint32) {
+	fn := p.module.ExportedFunction("deallocate")
+	if fn == nil {
+		p.missingDeallocate.Do(func() {
+			if p.verbose {
+				fmt.Fprintf(os.Stderr, "host: plugin does not export deallocate; result buffers will not be freed\n")
+			}
+		})
+		return
+	}
+	if _, err := p.callFunc(ctx, "deallocate", fn, uint64(ptr), uint64(length)); err != nil && p.verbose {
+		fmt.Fprintf(os.Stderr, "host: deallocate(ptr=%d, len=%d) failed: %v\n", ptr, length, err)
+	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"github.com/reglet-dev/reglet-host-sdk/wazero"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewExecutor(t *testing.T) {
@@ -17,3 +19,153 @@ func TestNewExecutor(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestPluginInstance_Call(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	result := `{"status":"success","message":"remediated"}`
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+		extra: []wasmFunc{
+			{
+				export:  "_remediate",
+				params:  []byte{wasmI32, wasmI32},
+				results: []byte{wasmI64},
+				body:    packedConst(fixtureResultOffset+512, len(result)),
+			},
+			{
+				export:  "_broken",
+				params:  []byte{wasmI32, wasmI32},
+				results: []byte{wasmI64},
+				body:    trapBody,
+			},
+		},
+	}))
+	require.NoError(t, err)
+	// Place the custom result next to the default one.
+	require.True(t, p.module.Memory().Write(fixtureResultOffset+512, []byte(result)))
+
+	t.Run("CustomExport", func(t *testing.T) {
+		res, err := p.Call(ctx, "_remediate", map[string]any{"fix": true})
+		require.NoError(t, err)
+		assert.Equal(t, "remediated", res.Message)
+	})
+
+	t.Run("ObserveViaCall", func(t *testing.T) {
+		res, err := p.Call(ctx, "_observe", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res.Message)
+	})
+
+	t.Run("MissingExport", func(t *testing.T) {
+		_, err := p.Call(ctx, "_plan", nil)
+		require.ErrorIs(t, err, ErrExportNotFound)
+		var notFound *ExportNotFoundError
+		require.ErrorAs(t, err, &notFound)
+		assert.Equal(t, "_plan", notFound.Name)
+	})
+
+	t.Run("Trap", func(t *testing.T) {
+		_, err := p.Call(ctx, "_broken", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "calling _broken")
+	})
+
+	t.Run("NotCountedAsCheck", func(t *testing.T) {
+		assert.Zero(t, e.Stats().ChecksServed)
+	})
+}
+
+func TestPluginInstance_DeallocatesResults(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	manifest := `{"name":"fixture","version":"1.0.0"}`
+	result := `{"status":"success","message":"ok"}`
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+		manifest: manifest,
+		result:   result,
+		extra:    []wasmFunc{recordingDeallocateFunc()},
+	}))
+	require.NoError(t, err)
+
+	lastFreed := func() (uint32, uint32) {
+		ptr, ok := p.module.Memory().ReadUint32Le(fixtureDeallocRecord)
+		require.True(t, ok)
+		length, ok := p.module.Memory().ReadUint32Le(fixtureDeallocRecord + 4)
+		require.True(t, ok)
+		return ptr, length
+	}
+
+	_, err = p.Manifest(ctx)
+	require.NoError(t, err)
+	ptr, length := lastFreed()
+	assert.Equal(t, uint32(fixtureManifestOffset), ptr)
+	assert.Equal(t, uint32(len(manifest)), length)
+
+	res, err := p.Check(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Message, "result is copied out before it is freed")
+	ptr, length = lastFreed()
+	assert.Equal(t, uint32(fixtureResultOffset), ptr)
+	assert.Equal(t, uint32(len(result)), length)
+}
+
+func TestPluginInstance_WithoutDeallocate(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{}))
+	require.NoError(t, err)
+
+	// Older plugins without the export still work.
+	for i := 0; i < 2; i++ {
+		res, err := p.Check(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "ok", res.Message)
+	}
+}
+
+type recordingMetricSink struct {
+	metrics []wazero.Metric
+}
+
+func (s *recordingMetricSink) RecordMetric(_ context.Context, m wazero.Metric) {
+	s.metrics = append(s.metrics, m)
+}
+
+func TestExecutor_WithMetricSink(t *testing.T) {
+	ctx := context.Background()
+	sink := &recordingMetricSink{}
+	e, err := NewExecutor(ctx, WithMetricSink(sink))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	const metricOffset = 2048
+	metric := `{"name":"probes","type":"gauge","value":7}`
+	result := `{"status":"success","message":"ok"}`
+	observe := packedConst(metricOffset, len(metric))
+	observe = append(observe, opCall, 0x00)
+	observe = append(observe, packedConst(fixtureResultOffset, len(result))...)
+
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+		result:  result,
+		imports: []wasmImport{{module: "reglet_host", name: "emit_metric", params: []byte{wasmI64}}},
+		observe: observe,
+		data:    []wasmData{{offset: metricOffset, bytes: []byte(metric)}},
+	}))
+	require.NoError(t, err)
+
+	_, err = p.Check(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, sink.metrics, 1)
+	assert.Equal(t, "probes", sink.metrics[0].Name)
+	assert.Equal(t, wazero.MetricGauge, sink.metrics[0].Type)
+	assert.InDelta(t, 7.0, sink.metrics[0].Value, 0)
+}
@@ -1,7 +1,12 @@
 package host
 
 import (
+	"io"
+	"log/slog"
+	"time"
+
 	hostlib "github.com/reglet-dev/reglet-host-sdk"
+	"github.com/reglet-dev/reglet-host-sdk/wazero"
 )
 
 // Option defines a functional option for configuring the Executor.
@@ -27,3 +32,40 @@ func WithCompilationCache(cache CompilationCache) Option {
 		e.cache = cache
 	}
 }
+
+// WithExecutionTimeout bounds how long any single guest call may run. A call
+// that exceeds it is stopped and fails with *ExecutionTimeoutError; the
+// plugin instance is unusable afterwards and must be reloaded. Zero, the
+// default, disables the limit.
+func WithExecutionTimeout(d time.Duration) Option {
+	return func(e *Executor) {
+		e.executionTimeout = d
+	}
+}
+
+// WithGuestStdio sets where plugin writes to stdout and stderr go. A nil
+// writer keeps the default, which logs each line to slog.Default() at debug
+// level tagged with the plugin's module name.
+func WithGuestStdio(stdout, stderr io.Writer) Option {
+	return func(e *Executor) {
+		e.stdout = stdout
+		e.stderr = stderr
+	}
+}
+
+// WithMetricSink sets where metrics emitted by plugins through the
+// emit_metric host function are sent. Without a sink they are discarded.
+func WithMetricSink(sink wazero.MetricSink) Option {
+	return func(e *Executor) {
+		e.metrics = sink
+	}
+}
+
+// WithLogger sends plugin log messages to logger, with the plugin's level,
+// attributes and request ID. By default they are only printed to stderr when
+// verbose logging is enabled.
+func WithLogger(logger *slog.Logger) Option {
+	return func(e *Executor) {
+		e.logger = logger
+	}
+}
@@ -0,0 +1,195 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	abi "github.com/reglet-dev/reglet-abi"
+	t_wazero "github.com/tetratelabs/wazero"
+)
+
+// ErrPoolClosed is returned by Acquire after the pool has been closed.
+var ErrPoolClosed = errors.New("instance pool is closed")
+
+// InstancePool holds a fixed number of instances of one plugin so that
+// checks can run in parallel. A module instance is not safe for concurrent
+// calls; the pool hands each instance to one caller at a time.
+type InstancePool struct {
+	executor *Executor
+	compiled t_wazero.CompiledModule
+
+	// idle holds instances ready for use. A nil entry is a slot whose
+	// instance could not be recreated; Acquire instantiates it lazily.
+	idle chan *PluginInstance
+
+	mu     sync.Mutex
+	closed bool
+	// pending counts replacement instances being created, so that Close
+	// waits for them before closing the compiled module.
+	pending sync.WaitGroup
+}
+
+// NewPool compiles wasmBytes once and instantiates size instances of it.
+func (e *Executor) NewPool(ctx context.Context, wasmBytes []byte, size int) (*InstancePool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("pool size must be at least 1, got %d", size)
+	}
+	if err := e.calls.acquire(); err != nil {
+		return nil, err
+	}
+	defer e.calls.release()
+
+	compiled, err := e.runtime.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	pool := &InstancePool{
+		executor: e,
+		compiled: compiled,
+		idle:     make(chan *PluginInstance, size),
+	}
+	for i := 0; i < size; i++ {
+		inst, err := e.instantiate(ctx, compiled)
+		if err != nil {
+			_ = pool.Close(ctx)
+			return nil, err
+		}
+		pool.idle <- inst
+	}
+	return pool, nil
+}
+
+// Size returns the number of instances the pool manages.
+func (p *InstancePool) Size() int {
+	return cap(p.idle)
+}
+
+// Acquire takes an instance from the pool, waiting until one is free or ctx
+// is done. The caller must hand it back with Release.
+func (p *InstancePool) Acquire(ctx context.Context) (*PluginInstance, error) {
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	var inst *PluginInstance
+	select {
+	case inst = <-p.idle:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if inst != nil {
+		if p.isClosed() {
+			p.discard(ctx, inst)
+			return nil, ErrPoolClosed
+		}
+		return inst, nil
+	}
+
+	inst, err := p.replace(ctx)
+	if err != nil {
+		// Give the slot back so the next caller can retry.
+		p.put(ctx, nil)
+		return nil, err
+	}
+	return inst, nil
+}
+
+// Release returns an instance to the pool. callErr is the error from the
+// last call made on it; when set, the instance may have been left in a bad
+// state (a guest trap, a timeout that closed the module) so it is replaced
+// with a fresh one.
+func (p *InstancePool) Release(ctx context.Context, inst *PluginInstance, callErr error) {
+	if callErr != nil {
+		p.discard(ctx, inst)
+		// On failure the slot is kept as nil and retried by Acquire.
+		inst, _ = p.replace(ctx)
+	}
+	p.put(ctx, inst)
+}
+
+// Check runs a check on a pooled instance.
+func (p *InstancePool) Check(ctx context.Context, config map[string]any) (abi.Result, error) {
+	inst, err := p.Acquire(ctx)
+	if err != nil {
+		return abi.Result{}, err
+	}
+	result, err := inst.Check(ctx, config)
+	p.Release(ctx, inst, err)
+	return result, err
+}
+
+// Close closes the idle instances and the compiled module. Instances still
+// acquired are closed when they are released.
+func (p *InstancePool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	// No instance is put back once closed is set, so after the pending
+	// replacements finish the drain below sees every idle instance.
+	p.pending.Wait()
+	for {
+		select {
+		case inst := <-p.idle:
+			p.discard(ctx, inst)
+		default:
+			return p.compiled.Close(ctx)
+		}
+	}
+}
+
+func (p *InstancePool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// put hands inst back to the idle channel, or discards it once the pool is
+// closed. The closed check and the send happen under one lock so nothing
+// is sent after Close has started draining.
+func (p *InstancePool) put(ctx context.Context, inst *PluginInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		p.discard(ctx, inst)
+		return
+	}
+	p.idle <- inst
+}
+
+// replace creates an instance for a slot whose instance is gone. It fails
+// with ErrPoolClosed once Close has started, and Close waits for any
+// replacement already under way.
+func (p *InstancePool) replace(ctx context.Context) (*PluginInstance, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	p.pending.Add(1)
+	p.mu.Unlock()
+	defer p.pending.Done()
+	return p.newInstance(ctx)
+}
+
+func (p *InstancePool) newInstance(ctx context.Context) (*PluginInstance, error) {
+	if err := p.executor.calls.acquire(); err != nil {
+		return nil, err
+	}
+	defer p.executor.calls.release()
+	return p.executor.instantiate(ctx, p.compiled)
+}
+
+func (p *InstancePool) discard(ctx context.Context, inst *PluginInstance) {
+	if inst != nil {
+		_ = inst.module.Close(ctx)
+	}
+}
@@ -0,0 +1,172 @@
+package host
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstancePool_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	pool, err := e.NewPool(ctx, newFixturePlugin(fixturePlugin{}), 4)
+	require.NoError(t, err)
+	defer pool.Close(ctx)
+	assert.Equal(t, 4, pool.Size())
+
+	const workers, perWorker = 16, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				res, err := pool.Check(ctx, map[string]any{"worker": w, "i": i})
+				if err == nil && res.Message != "ok" {
+					err = assert.AnError
+				}
+				if err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	stats := e.Stats()
+	assert.Equal(t, uint64(workers*perWorker), stats.ChecksServed)
+	assert.Equal(t, uint64(4), stats.InstancesCreated)
+}
+
+func TestInstancePool_ReplacesFailedInstance(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	pool, err := e.NewPool(ctx, newFixturePlugin(fixturePlugin{observe: trapBody}), 1)
+	require.NoError(t, err)
+	defer pool.Close(ctx)
+
+	first, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	_, callErr := first.Check(ctx, nil)
+	require.Error(t, callErr)
+	pool.Release(ctx, first, callErr)
+
+	second, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.NotSame(t, first, second)
+	pool.Release(ctx, second, nil)
+
+	assert.Equal(t, uint64(2), e.Stats().InstancesCreated)
+}
+
+func TestInstancePool_AcquireWaitsForRelease(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	pool, err := e.NewPool(ctx, newFixturePlugin(fixturePlugin{}), 1)
+	require.NoError(t, err)
+	defer pool.Close(ctx)
+
+	inst, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = pool.Acquire(waitCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	pool.Release(ctx, inst, nil)
+	again, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	assert.Same(t, inst, again)
+	pool.Release(ctx, again, nil)
+}
+
+func TestInstancePool_Close(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	_, err = e.NewPool(ctx, newFixturePlugin(fixturePlugin{}), 0)
+	require.Error(t, err)
+
+	pool, err := e.NewPool(ctx, newFixturePlugin(fixturePlugin{}), 2)
+	require.NoError(t, err)
+	require.NoError(t, pool.Close(ctx))
+	require.NoError(t, pool.Close(ctx))
+
+	_, err = pool.Acquire(ctx)
+	require.ErrorIs(t, err, ErrPoolClosed)
+}
+
+func TestInstancePool_ReleaseDuringClose(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	const size = 8
+	for round := 0; round < 10; round++ {
+		pool, err := e.NewPool(ctx, newFixturePlugin(fixturePlugin{}), size)
+		require.NoError(t, err)
+
+		held := make([]*PluginInstance, size)
+		for i := range held {
+			held[i], err = pool.Acquire(ctx)
+			require.NoError(t, err)
+		}
+
+		var wg sync.WaitGroup
+		for i, inst := range held {
+			wg.Add(1)
+			go func(i int, inst *PluginInstance) {
+				defer wg.Done()
+				var callErr error
+				if i%2 == 0 {
+					callErr = assert.AnError // forces a replacement
+				}
+				pool.Release(ctx, inst, callErr)
+			}(i, inst)
+		}
+		require.NoError(t, pool.Close(ctx))
+		wg.Wait()
+
+		assert.Zero(t, len(pool.idle), "nothing may be returned to a closed pool")
+	}
+}
+
+func TestInstancePool_NoReplacementAfterClose(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	pool, err := e.NewPool(ctx, newFixturePlugin(fixturePlugin{}), 1)
+	require.NoError(t, err)
+	inst, err := pool.Acquire(ctx)
+	require.NoError(t, err)
+	require.NoError(t, pool.Close(ctx))
+
+	created := e.Stats().InstancesCreated
+	pool.Release(ctx, inst, assert.AnError)
+	assert.Equal(t, created, e.Stats().InstancesCreated)
+	assert.Zero(t, len(pool.idle))
+}
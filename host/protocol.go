@@ -0,0 +1,63 @@
+package host
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrGuestProtocol is matched by every GuestProtocolError.
+var ErrGuestProtocol = errors.New("guest protocol error")
+
+// GuestProtocolError reports a guest that returned normally but broke the
+// calling convention: no result, a ptr+len outside its memory, or a payload
+// that is not valid JSON. It usually means the plugin and host disagree on
+// the ABI, as opposed to a guest trap.
+type GuestProtocolError struct {
+	// Export is the guest function that was called.
+	Export string
+	// Reason describes the violation.
+	Reason string
+	// Err is the underlying decode error, if any.
+	Err error
+	// Packed is the raw ptr+len value the guest returned.
+	Packed uint64
+	// Ptr and Length are Packed split into its halves.
+	Ptr, Length uint32
+	// MemorySize is the size of the guest's memory in bytes at the time.
+	MemorySize uint32
+}
+
+func (e *GuestProtocolError) Error() string {
+	msg := fmt.Sprintf("%s: %s (ptr=%d len=%d packed=%#x memory=%d bytes)",
+		e.Export, e.Reason, e.Ptr, e.Length, e.Packed, e.MemorySize)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Is reports whether target is ErrGuestProtocol.
+func (e *GuestProtocolError) Is(target error) bool {
+	return target == ErrGuestProtocol
+}
+
+func (e *GuestProtocolError) Unwrap() error {
+	return e.Err
+}
+
+// protocolError builds a GuestProtocolError for a value returned by export.
+func (p *PluginInstance) protocolError(export string, packed uint64, reason string, err error) *GuestProtocolError {
+	var size uint32
+	if mem := p.module.Memory(); mem != nil {
+		size = mem.Size()
+	}
+	return &GuestProtocolError{
+		Export:     export,
+		Reason:     reason,
+		Err:        err,
+		Packed:     packed,
+		Ptr:        uint32(packed >> 32), //nolint:gosec // WASM pointers are always 32-bit
+		Length:     uint32(packed),       //nolint:gosec // WASM lengths are always 32-bit
+		MemorySize: size,
+	}
+}
@@ -0,0 +1,123 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// stubMemory is guest memory of a fixed size.
+type stubMemory struct {
+	api.Memory
+	data []byte
+}
+
+func (m *stubMemory) Size() uint32 { return uint32(len(m.data)) }
+
+func (m *stubMemory) Read(offset, byteCount uint32) ([]byte, bool) {
+	if uint64(offset)+uint64(byteCount) > uint64(len(m.data)) {
+		return nil, false
+	}
+	return m.data[offset : offset+byteCount], true
+}
+
+func (m *stubMemory) Write(offset uint32, v []byte) bool {
+	if uint64(offset)+uint64(len(v)) > uint64(len(m.data)) {
+		return false
+	}
+	copy(m.data[offset:], v)
+	return true
+}
+
+// stubFunction returns fixed results.
+type stubFunction struct {
+	api.Function
+	results []uint64
+}
+
+func (f *stubFunction) Call(context.Context, ...uint64) ([]uint64, error) {
+	return f.results, nil
+}
+
+// stubModule exports the given functions and memory.
+type stubModule struct {
+	api.Module
+	funcs map[string]api.Function
+	mem   *stubMemory
+}
+
+func (m *stubModule) ExportedFunction(name string) api.Function { return m.funcs[name] }
+func (m *stubModule) Memory() api.Memory                        { return m.mem }
+
+// newStubInstance returns an instance whose exports all return packed.
+func newStubInstance(packed ...uint64) *PluginInstance {
+	ret := &stubFunction{results: packed}
+	mod := &stubModule{
+		mem: &stubMemory{data: make([]byte, 1024)},
+		funcs: map[string]api.Function{
+			"allocate":  &stubFunction{results: []uint64{0}},
+			"_manifest": ret,
+			"_schema":   ret,
+			"_observe":  ret,
+		},
+	}
+	return &PluginInstance{module: mod, counters: &executorCounters{}, calls: &callTracker{}}
+}
+
+func TestPluginInstance_GuestProtocolErrors(t *testing.T) {
+	ctx := context.Background()
+
+	calls := map[string]func(p *PluginInstance) error{
+		"_manifest": func(p *PluginInstance) error { _, err := p.Manifest(ctx); return err },
+		"_schema":   func(p *PluginInstance) error { _, err := p.Schema(ctx); return err },
+		"_observe":  func(p *PluginInstance) error { _, err := p.Check(ctx, nil); return err },
+	}
+
+	tests := []struct {
+		name   string
+		packed []uint64
+		reason string
+	}{
+		{"PtrOutOfRange", []uint64{uint64(4096)<<32 | 8}, "outside guest memory"},
+		{"LengthPastEnd", []uint64{uint64(1000)<<32 | 100}, "outside guest memory"},
+		{"HugeLength", []uint64{uint64(0xffffffff)<<32 | 0xffffffff}, "outside guest memory"},
+		{"NoResults", nil, "returned no results"},
+	}
+
+	for export, call := range calls {
+		for _, tt := range tests {
+			t.Run(export+"/"+tt.name, func(t *testing.T) {
+				var err error
+				require.NotPanics(t, func() { err = call(newStubInstance(tt.packed...)) })
+				require.ErrorIs(t, err, ErrGuestProtocol)
+
+				var protoErr *GuestProtocolError
+				require.ErrorAs(t, err, &protoErr)
+				assert.Equal(t, export, protoErr.Export)
+				assert.Contains(t, protoErr.Reason, tt.reason)
+				assert.Equal(t, uint32(1024), protoErr.MemorySize)
+				if len(tt.packed) > 0 {
+					assert.Equal(t, tt.packed[0], protoErr.Packed)
+					assert.Equal(t, uint32(tt.packed[0]>>32), protoErr.Ptr)
+				}
+			})
+		}
+	}
+}
+
+func TestPluginInstance_GuestProtocolError_InvalidJSON(t *testing.T) {
+	ctx := context.Background()
+	p := newStubInstance(uint64(16)<<32 | 5)
+	copy(p.module.Memory().(*stubMemory).data[16:], "nope!")
+
+	_, err := p.Check(ctx, nil)
+	require.ErrorIs(t, err, ErrGuestProtocol)
+	var protoErr *GuestProtocolError
+	require.ErrorAs(t, err, &protoErr)
+	assert.Equal(t, "returned invalid JSON", protoErr.Reason)
+	assert.Error(t, protoErr.Unwrap())
+	assert.Contains(t, err.Error(), "_observe: returned invalid JSON (ptr=16 len=5")
+}
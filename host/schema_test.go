@@ -0,0 +1,82 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// countingFunction records how often it is called.
+type countingFunction struct {
+	stubFunction
+	calls int
+}
+
+func (f *countingFunction) Call(ctx context.Context, params ...uint64) ([]uint64, error) {
+	f.calls++
+	return f.stubFunction.Call(ctx, params...)
+}
+
+func newSchemaInstance(schema string) (*PluginInstance, *countingFunction) {
+	mem := &stubMemory{data: make([]byte, 1024)}
+	copy(mem.data[64:], schema)
+	fn := &countingFunction{stubFunction: stubFunction{results: []uint64{uint64(64)<<32 | uint64(len(schema))}}}
+	mod := &stubModule{mem: mem, funcs: map[string]api.Function{"_schema": fn}}
+	return &PluginInstance{module: mod, counters: &executorCounters{}, calls: &callTracker{}}, fn
+}
+
+func TestPluginInstance_SchemaIsCached(t *testing.T) {
+	ctx := context.Background()
+	schema := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object"}`
+	p, fn := newSchemaInstance(schema)
+
+	for i := 0; i < 3; i++ {
+		got, err := p.Schema(ctx)
+		require.NoError(t, err)
+		assert.JSONEq(t, schema, string(got))
+		got[0] = 'X' // callers get their own copy
+	}
+
+	dialect, err := p.SchemaDialect(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", dialect)
+	assert.Equal(t, 1, fn.calls, "the guest export is called once")
+}
+
+func TestPluginInstance_SchemaDialect(t *testing.T) {
+	ctx := context.Background()
+	tests := map[string]struct {
+		schema string
+		want   string
+	}{
+		"Draft07":    {`{"$schema":"http://json-schema.org/draft-07/schema#"}`, "http://json-schema.org/draft-07/schema#"},
+		"Undeclared": {`{"type":"object"}`, ""},
+		"NotJSON":    {`not json`, ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, _ := newSchemaInstance(tt.schema)
+			dialect, err := p.SchemaDialect(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, dialect)
+		})
+	}
+}
+
+func TestPluginInstance_SchemaErrorIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	p, fn := newSchemaInstance("{}")
+	fn.results = nil
+
+	_, err := p.Schema(ctx)
+	require.ErrorIs(t, err, ErrGuestProtocol)
+
+	fn.results = []uint64{uint64(64)<<32 | 2}
+	got, err := p.Schema(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(got))
+	assert.Equal(t, 2, fn.calls)
+}
@@ -0,0 +1,78 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrExecutorShuttingDown is returned for work submitted after Shutdown began.
+var ErrExecutorShuttingDown = errors.New("executor is shutting down")
+
+// callTracker counts in-flight plugin work so Shutdown can wait for it.
+type callTracker struct {
+	idle     chan struct{} // closed while inflight is zero and closing is set
+	mu       sync.Mutex
+	inflight int
+	closing  bool
+}
+
+// acquire registers a new call. It fails once the tracker is closing.
+func (t *callTracker) acquire() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return ErrExecutorShuttingDown
+	}
+	t.inflight++
+	return nil
+}
+
+// release marks a call as finished.
+func (t *callTracker) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inflight--
+	if t.closing && t.inflight == 0 {
+		close(t.idle)
+	}
+}
+
+// drain stops accepting calls and waits until in-flight calls finish or ctx is done.
+func (t *callTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closing {
+		t.closing = true
+		t.idle = make(chan struct{})
+		if t.inflight == 0 {
+			close(t.idle)
+		}
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown gracefully stops the executor. It rejects new LoadPlugin and plugin
+// calls with ErrExecutorShuttingDown, waits for in-flight calls to finish, then
+// closes the runtime. If ctx ends first the runtime is closed anyway, aborting
+// the remaining calls, and the context error is returned.
+func (e *Executor) Shutdown(ctx context.Context) error {
+	drainErr := e.calls.drain(ctx)
+
+	// Close with a fresh context: ctx may already be done.
+	if err := e.runtime.Close(context.WithoutCancel(ctx)); err != nil {
+		return errors.Join(drainErr, fmt.Errorf("failed to close runtime: %w", err))
+	}
+	if drainErr != nil {
+		return fmt.Errorf("shutdown interrupted with calls in flight: %w", drainErr)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package host
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hostlib "github.com/reglet-dev/reglet-host-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newBlockingExecutor returns an executor whose "block" host function waits
+// for release, and a plugin whose Check calls it.
+func newBlockingExecutor(t *testing.T, started chan<- struct{}, release <-chan struct{}) (*Executor, *PluginInstance) {
+	t.Helper()
+	ctx := context.Background()
+
+	reg, err := hostlib.NewRegistry(hostlib.WithByteHandler("block", func(ctx context.Context, _ []byte) ([]byte, error) {
+		started <- struct{}{}
+		<-release
+		return []byte(`{}`), nil
+	}))
+	require.NoError(t, err)
+
+	e, err := NewExecutor(ctx, WithHostFunctions(reg))
+	require.NoError(t, err)
+
+	result := `{"status":"success","message":"slow"}`
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+		result:  result,
+		imports: []wasmImport{hostImport("block")},
+		observe: callImportBody(len(result)),
+	}))
+	require.NoError(t, err)
+	return e, p
+}
+
+func TestExecutor_Shutdown_WaitsForInFlightCheck(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e, p := newBlockingExecutor(t, started, release)
+
+	type checkResult struct {
+		message string
+		err     error
+	}
+	checkDone := make(chan checkResult, 1)
+	go func() {
+		res, err := p.Check(context.Background(), nil)
+		checkDone <- checkResult{res.Message, err}
+	}()
+	<-started
+
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- e.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-shutdownDone:
+		t.Fatalf("Shutdown returned while a Check was in flight: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// New work is refused while draining.
+	_, err := e.LoadPlugin(context.Background(), newFixturePlugin(fixturePlugin{}))
+	assert.ErrorIs(t, err, ErrExecutorShuttingDown)
+
+	close(release)
+
+	res := <-checkDone
+	require.NoError(t, res.err)
+	assert.Equal(t, "slow", res.message)
+	require.NoError(t, <-shutdownDone)
+
+	_, err = p.Check(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrExecutorShuttingDown)
+}
+
+func TestExecutor_Shutdown_DeadlineExceeded(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	e, p := newBlockingExecutor(t, started, release)
+
+	go func() { _, _ = p.Check(context.Background(), nil) }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := e.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestExecutor_Shutdown_Idle(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, e.Shutdown(ctx))
+	// Calling Shutdown again is harmless.
+	require.NoError(t, e.Shutdown(ctx))
+}
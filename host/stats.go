@@ -0,0 +1,69 @@
+package host
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ExecutorStats is a point-in-time snapshot of executor counters.
+type ExecutorStats struct {
+	// InstancesCreated counts plugins successfully loaded via LoadPlugin.
+	InstancesCreated uint64
+	// ChecksServed counts Check calls, successful or not.
+	ChecksServed uint64
+	// CheckErrors counts Check calls that returned an error.
+	CheckErrors uint64
+	// PanicsRecovered counts Check calls where the guest trapped or the host
+	// panicked; both are turned into errors instead of crashing the host.
+	PanicsRecovered uint64
+	// AverageCheckLatency is the mean wall time of a Check call.
+	AverageCheckLatency time.Duration
+}
+
+// executorCounters are updated on the hot paths with atomic operations.
+type executorCounters struct {
+	instancesCreated atomic.Uint64
+	checksServed     atomic.Uint64
+	checkErrors      atomic.Uint64
+	panicsRecovered  atomic.Uint64
+	checkNanos       atomic.Int64
+}
+
+// observeCheck records one Check call. panicked is set when the guest trapped
+// or the host recovered from a panic during the call.
+func (c *executorCounters) observeCheck(elapsed time.Duration, err error, panicked bool) {
+	if c == nil {
+		return
+	}
+	c.checksServed.Add(1)
+	c.checkNanos.Add(int64(elapsed))
+	if err != nil {
+		c.checkErrors.Add(1)
+	}
+	if panicked {
+		c.panicsRecovered.Add(1)
+	}
+}
+
+// isGuestTrap reports whether an error from calling into the guest means it
+// trapped or a host function panicked. wazero reports both, and only those,
+// with a wasm stack trace; exits, cancellation and host-side failures such as
+// a call with the wrong arguments carry none.
+func isGuestTrap(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "\nwasm stack trace:")
+}
+
+// Stats returns a snapshot of the executor's counters.
+func (e *Executor) Stats() ExecutorStats {
+	s := ExecutorStats{
+		InstancesCreated: e.counters.instancesCreated.Load(),
+		ChecksServed:     e.counters.checksServed.Load(),
+		CheckErrors:      e.counters.checkErrors.Load(),
+		PanicsRecovered:  e.counters.panicsRecovered.Load(),
+	}
+	if s.ChecksServed > 0 {
+		s.AverageCheckLatency = time.Duration(e.counters.checkNanos.Load() / int64(s.ChecksServed))
+	}
+	return s
+}
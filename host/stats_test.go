@@ -0,0 +1,84 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_Stats(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	assert.Equal(t, ExecutorStats{}, e.Stats())
+
+	ok, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{}))
+	require.NoError(t, err)
+	trapping, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{observe: trapBody}))
+	require.NoError(t, err)
+
+	_, err = e.LoadPlugin(ctx, []byte("not wasm"))
+	require.Error(t, err)
+
+	stats := e.Stats()
+	assert.Equal(t, uint64(2), stats.InstancesCreated, "failed loads are not counted")
+	assert.Zero(t, stats.ChecksServed)
+
+	for i := 0; i < 3; i++ {
+		result, err := ok.Check(ctx, map[string]any{"i": i})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result.Message)
+	}
+
+	_, err = trapping.Check(ctx, nil)
+	require.Error(t, err)
+
+	stats = e.Stats()
+	assert.Equal(t, uint64(4), stats.ChecksServed)
+	assert.Equal(t, uint64(1), stats.CheckErrors)
+	assert.Equal(t, uint64(1), stats.PanicsRecovered)
+	assert.Positive(t, stats.AverageCheckLatency)
+}
+
+func TestExecutor_Stats_HostErrorIsNotAPanic(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{}))
+	require.NoError(t, err)
+
+	// A host-side failure before the guest runs is an error, not a panic.
+	_, err = p.Check(ctx, map[string]any{"bad": make(chan int)})
+	require.Error(t, err)
+
+	stats := e.Stats()
+	assert.Equal(t, uint64(1), stats.CheckErrors)
+	assert.Zero(t, stats.PanicsRecovered)
+}
+
+func TestExecutor_Stats_CallErrorIsNotAPanic(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	// _set_context takes no arguments, so calling it fails without the
+	// guest running at all.
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{
+		extra: []wasmFunc{{export: "_set_context"}},
+	}))
+	require.NoError(t, err)
+
+	_, err = p.Check(ctx, nil)
+	require.Error(t, err)
+
+	stats := e.Stats()
+	assert.Equal(t, uint64(1), stats.CheckErrors)
+	assert.Zero(t, stats.PanicsRecovered)
+}
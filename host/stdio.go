@@ -0,0 +1,62 @@
+package host
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"sync"
+)
+
+// unnamedPlugin tags guest output from modules without a name section.
+const unnamedPlugin = "unnamed"
+
+// guestStdio returns the stdout and stderr writers for a new instance of the
+// module called name.
+func (e *Executor) guestStdio(name string) (io.Writer, io.Writer) {
+	if name == "" {
+		name = unnamedPlugin
+	}
+	stdout, stderr := e.stdout, e.stderr
+	if stdout == nil {
+		stdout = &slogLineWriter{plugin: name, stream: "stdout"}
+	}
+	if stderr == nil {
+		stderr = &slogLineWriter{plugin: name, stream: "stderr"}
+	}
+	return stdout, stderr
+}
+
+// slogLineWriter forwards guest output to slog at debug level, one record
+// per line. Incomplete lines are held until their newline arrives.
+type slogLineWriter struct {
+	logger *slog.Logger // nil means slog.Default() at write time
+	plugin string
+	stream string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *slogLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(string(bytes.TrimSuffix(w.buf[:i], []byte{'\r'})))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *slogLineWriter) log(line string) {
+	logger := w.logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Debug(line, "plugin", w.plugin, "stream", w.stream)
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Fixture layout for the fd_write iovec and its output.
+const (
+	fixtureIovecOffset    = 2048
+	fixtureNWrittenOffset = 2056
+	fixtureOutputOffset   = 2064
+)
+
+// newWritingPlugin returns a plugin whose _observe writes output to fd
+// through WASI fd_write before returning the default result.
+func newWritingPlugin(fd int32, output string) []byte {
+	iovec := make([]byte, 8)
+	binary.LittleEndian.PutUint32(iovec, fixtureOutputOffset)
+	binary.LittleEndian.PutUint32(iovec[4:], uint32(len(output)))
+
+	i32 := func(v int32) []byte { return append([]byte{opI32Const}, sleb(int64(v))...) }
+	var body []byte
+	body = append(body, i32(fd)...)
+	body = append(body, i32(fixtureIovecOffset)...)
+	body = append(body, i32(1)...)
+	body = append(body, i32(fixtureNWrittenOffset)...)
+	body = append(body, opCall, 0x00, opDrop)
+
+	result := `{"status":"success","message":"ok"}`
+	body = append(body, packedConst(fixtureResultOffset, len(result))...)
+
+	return newFixturePlugin(fixturePlugin{
+		result: result,
+		imports: []wasmImport{{
+			module:  wasiModule,
+			name:    "fd_write",
+			params:  []byte{wasmI32, wasmI32, wasmI32, wasmI32},
+			results: []byte{wasmI32},
+		}},
+		observe: body,
+		data: []wasmData{
+			{offset: fixtureIovecOffset, bytes: iovec},
+			{offset: fixtureOutputOffset, bytes: []byte(output)},
+		},
+	})
+}
+
+func TestExecutor_WithGuestStdio(t *testing.T) {
+	ctx := context.Background()
+	var stdout, stderr bytes.Buffer
+	e, err := NewExecutor(ctx, WithGuestStdio(&stdout, &stderr))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	p, err := e.LoadPlugin(ctx, newWritingPlugin(2, "something went wrong\n"))
+	require.NoError(t, err)
+
+	res, err := p.Check(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Message)
+	assert.Equal(t, "something went wrong\n", stderr.String())
+	assert.Empty(t, stdout.String())
+}
+
+func TestSlogLineWriter(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	w := &slogLineWriter{logger: logger, plugin: "demo", stream: "stderr"}
+
+	_, err := w.Write([]byte("first line\nsecond "))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("line\r\npartial"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2, "partial lines are held back")
+	assert.Contains(t, lines[0], `msg="first line"`)
+	assert.Contains(t, lines[0], "plugin=demo")
+	assert.Contains(t, lines[0], "stream=stderr")
+	assert.Contains(t, lines[1], `msg="second line"`)
+	assert.Contains(t, lines[1], "level=DEBUG")
+}
@@ -0,0 +1,61 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// ErrExecutionTimeout is returned when a guest call exceeds the executor's
+// execution timeout.
+var ErrExecutionTimeout = errors.New("plugin execution timed out")
+
+// ExecutionTimeoutError reports a guest call that was stopped because it ran
+// longer than the configured execution timeout. The module instance is closed
+// by the runtime when this happens and cannot serve further calls.
+type ExecutionTimeoutError struct {
+	// Export is the guest function that was running.
+	Export string
+	// Timeout is the budget that was exceeded.
+	Timeout time.Duration
+	// Err is the error returned by the runtime.
+	Err error
+}
+
+func (e *ExecutionTimeoutError) Error() string {
+	return fmt.Sprintf("%s exceeded execution timeout of %s", e.Export, e.Timeout)
+}
+
+// Is reports whether target is ErrExecutionTimeout.
+func (e *ExecutionTimeoutError) Is(target error) bool {
+	return target == ErrExecutionTimeout
+}
+
+func (e *ExecutionTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// callWithTimeout calls fn, bounding it by timeout when one is set. A call
+// cut short by that deadline, rather than by the caller's own context, is
+// reported as *ExecutionTimeoutError.
+func callWithTimeout(ctx context.Context, timeout time.Duration, name string, fn api.Function, params ...uint64) ([]uint64, error) {
+	if timeout <= 0 {
+		return fn.Call(ctx, params...)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := fn.Call(callCtx, params...)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded {
+			return nil, &ExecutionTimeoutError{Export: name, Timeout: timeout, Err: err}
+		}
+	}
+	return res, err
+}
@@ -0,0 +1,68 @@
+package host
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_ExecutionTimeout(t *testing.T) {
+	ctx := context.Background()
+	const budget = 100 * time.Millisecond
+
+	e, err := NewExecutor(ctx, WithExecutionTimeout(budget))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{observe: spinBody}))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = p.Check(ctx, nil)
+	elapsed := time.Since(start)
+
+	require.ErrorIs(t, err, ErrExecutionTimeout)
+	var timeoutErr *ExecutionTimeoutError
+	require.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, "_observe", timeoutErr.Export)
+	assert.Equal(t, budget, timeoutErr.Timeout)
+	assert.Less(t, elapsed, 10*budget)
+
+	stats := e.Stats()
+	assert.Equal(t, uint64(1), stats.CheckErrors)
+	assert.Zero(t, stats.PanicsRecovered, "a timeout is not a guest panic")
+}
+
+func TestExecutor_ExecutionTimeout_FastCallsUnaffected(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx, WithExecutionTimeout(time.Second))
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{}))
+	require.NoError(t, err)
+
+	res, err := p.Check(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", res.Message)
+}
+
+func TestExecutor_ExecutionTimeout_CallerCancellation(t *testing.T) {
+	e, err := NewExecutor(context.Background(), WithExecutionTimeout(time.Minute))
+	require.NoError(t, err)
+	defer e.Close(context.Background())
+
+	p, err := e.LoadPlugin(context.Background(), newFixturePlugin(fixturePlugin{observe: spinBody}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// The caller's own deadline is not the executor's budget.
+	_, err = p.Check(ctx, nil)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrExecutionTimeout)
+}
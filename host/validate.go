@@ -0,0 +1,24 @@
+package host
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reglet-dev/reglet-host-sdk/policy"
+)
+
+// ValidateManifest reads the plugin manifest and checks that its declared
+// capabilities are structurally valid, so a malformed grant is reported at
+// load time rather than as a puzzling denial later. Every invalid rule is
+// reported; each matches policy.ErrInvalidGrant and can be inspected as
+// *policy.GrantError.
+func (p *PluginInstance) ValidateManifest(ctx context.Context) error {
+	manifest, err := p.Manifest(ctx)
+	if err != nil {
+		return err
+	}
+	if err := policy.ValidateGrantSet(&manifest.Capabilities); err != nil {
+		return fmt.Errorf("plugin %q declares invalid capabilities: %w", manifest.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginInstance_ValidateManifest(t *testing.T) {
+	ctx := context.Background()
+	e, err := NewExecutor(ctx)
+	require.NoError(t, err)
+	defer e.Close(ctx)
+
+	tests := []struct {
+		name     string
+		manifest string
+		paths    []string
+	}{
+		{
+			name:     "Valid",
+			manifest: `{"name":"ok","capabilities":{"network":{"rules":[{"hosts":["*.example.com"],"ports":["443","8000-9000"]}]},"fs":{"rules":[{"read":["/etc/**"]}]}}}`,
+		},
+		{
+			name:     "NetworkRuleWithoutHostsOrPorts",
+			manifest: `{"name":"bad","capabilities":{"network":{"rules":[{"hosts":[],"ports":[]}]}}}`,
+			paths:    []string{"network.rules[0].hosts", "network.rules[0].ports"},
+		},
+		{
+			name:     "BadPorts",
+			manifest: `{"name":"bad","capabilities":{"network":{"rules":[{"hosts":["a"],"ports":["http","70000","9-1"]}]}}}`,
+			paths:    []string{"network.rules[0].ports[0]", "network.rules[0].ports[1]", "network.rules[0].ports[2]"},
+		},
+		{
+			name:     "InvalidGlob",
+			manifest: `{"name":"bad","capabilities":{"fs":{"rules":[{"read":["/etc/[abc"]},{}]}}}`,
+			paths:    []string{"fs.rules[0].read[0]", "fs.rules[1]"},
+		},
+		{
+			name:     "UnknownKVOperation",
+			manifest: `{"name":"bad","capabilities":{"kv":{"rules":[{"op":"delete","keys":["a"]}]},"exec":{"commands":[""]}}}`,
+			paths:    []string{"kv.rules[0].op", "exec.commands[0]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := e.LoadPlugin(ctx, newFixturePlugin(fixturePlugin{manifest: tt.manifest}))
+			require.NoError(t, err)
+
+			err = p.ValidateManifest(ctx)
+			if tt.paths == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, policy.ErrInvalidGrant)
+
+			var got []string
+			var joined interface{ Unwrap() []error }
+			require.True(t, errors.As(err, &joined))
+			for _, e := range joined.Unwrap() {
+				var grantErr *policy.GrantError
+				require.ErrorAs(t, e, &grantErr)
+				got = append(got, grantErr.Path)
+			}
+			assert.ElementsMatch(t, tt.paths, got)
+		})
+	}
+}
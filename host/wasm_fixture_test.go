@@ -0,0 +1,351 @@
+package host
+
+import (
+	"bytes"
+)
+
+// This file assembles small WASM modules for executor tests, so the tests do
+// not depend on a guest toolchain or checked-in binaries.
+
+const (
+	wasmI32 byte = 0x7f
+	wasmI64 byte = 0x7e
+)
+
+// Instruction opcodes used by the fixtures.
+const (
+	opUnreachable byte = 0x00
+	opLoop        byte = 0x03
+	opIf          byte = 0x04
+	opBr          byte = 0x0c
+	opEnd         byte = 0x0b
+	opCall        byte = 0x10
+	opDrop        byte = 0x1a
+	opLocalGet    byte = 0x20
+	opGlobalGet   byte = 0x23
+	opGlobalSet   byte = 0x24
+	opI32Const    byte = 0x41
+	opI64Const    byte = 0x42
+	opI32Eqz      byte = 0x45
+	opI32Add      byte = 0x6a
+	opI32Store    byte = 0x36
+	opI32Store8   byte = 0x3a
+	opI64Or       byte = 0x84
+	opI64Shl      byte = 0x86
+	opI64ExtendU  byte = 0xad
+	opPrefixFC    byte = 0xfc
+	opMemoryCopy  byte = 0x0a // after opPrefixFC
+)
+
+type wasmImport struct {
+	module  string
+	name    string
+	params  []byte
+	results []byte
+}
+
+type wasmFunc struct {
+	export  string
+	params  []byte
+	results []byte
+	locals  []byte
+	body    []byte // instructions, without the trailing end
+}
+
+type wasmData struct {
+	bytes  []byte
+	offset int32
+}
+
+// wasmModule is a minimal module encoder: one exported memory, i32 globals,
+// imported and defined functions, and active data segments.
+type wasmModule struct {
+	imports []wasmImport
+	funcs   []wasmFunc
+	globals []int32
+	data    []wasmData
+	pages   uint32
+}
+
+func (m *wasmModule) encode() []byte {
+	var out bytes.Buffer
+	out.Write([]byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00})
+
+	// Type section: one type per import and function.
+	var types [][]byte
+	for _, imp := range m.imports {
+		types = append(types, funcType(imp.params, imp.results))
+	}
+	for _, fn := range m.funcs {
+		types = append(types, funcType(fn.params, fn.results))
+	}
+	writeSection(&out, 1, vec(types))
+
+	if len(m.imports) > 0 {
+		var imports [][]byte
+		for i, imp := range m.imports {
+			var b bytes.Buffer
+			b.Write(name(imp.module))
+			b.Write(name(imp.name))
+			b.WriteByte(0x00)
+			b.Write(uleb(uint64(i)))
+			imports = append(imports, b.Bytes())
+		}
+		writeSection(&out, 2, vec(imports))
+	}
+
+	var funcs [][]byte
+	for i := range m.funcs {
+		funcs = append(funcs, uleb(uint64(len(m.imports)+i)))
+	}
+	writeSection(&out, 3, vec(funcs))
+
+	pages := m.pages
+	if pages == 0 {
+		pages = 1
+	}
+	writeSection(&out, 5, vec([][]byte{append([]byte{0x00}, uleb(uint64(pages))...)}))
+
+	if len(m.globals) > 0 {
+		var globals [][]byte
+		for _, g := range m.globals {
+			globals = append(globals, append([]byte{wasmI32, 0x01, opI32Const}, append(sleb(int64(g)), opEnd)...))
+		}
+		writeSection(&out, 6, vec(globals))
+	}
+
+	exports := [][]byte{append(name("memory"), 0x02, 0x00)}
+	for i, fn := range m.funcs {
+		if fn.export != "" {
+			exports = append(exports, append(name(fn.export), append([]byte{0x00}, uleb(uint64(len(m.imports)+i))...)...))
+		}
+	}
+	writeSection(&out, 7, vec(exports))
+
+	var codes [][]byte
+	for _, fn := range m.funcs {
+		var body bytes.Buffer
+		var locals [][]byte
+		for _, l := range fn.locals {
+			locals = append(locals, []byte{0x01, l})
+		}
+		body.Write(vec(locals))
+		body.Write(fn.body)
+		body.WriteByte(opEnd)
+		codes = append(codes, append(uleb(uint64(body.Len())), body.Bytes()...))
+	}
+	writeSection(&out, 10, vec(codes))
+
+	if len(m.data) > 0 {
+		var segs [][]byte
+		for _, d := range m.data {
+			var b bytes.Buffer
+			b.WriteByte(0x00)
+			b.WriteByte(opI32Const)
+			b.Write(sleb(int64(d.offset)))
+			b.WriteByte(opEnd)
+			b.Write(uleb(uint64(len(d.bytes))))
+			b.Write(d.bytes)
+			segs = append(segs, b.Bytes())
+		}
+		writeSection(&out, 11, vec(segs))
+	}
+
+	return out.Bytes()
+}
+
+func funcType(params, results []byte) []byte {
+	b := []byte{0x60}
+	b = append(b, uleb(uint64(len(params)))...)
+	b = append(b, params...)
+	b = append(b, uleb(uint64(len(results)))...)
+	return append(b, results...)
+}
+
+func writeSection(out *bytes.Buffer, id byte, contents []byte) {
+	out.WriteByte(id)
+	out.Write(uleb(uint64(len(contents))))
+	out.Write(contents)
+}
+
+func vec(items [][]byte) []byte {
+	b := uleb(uint64(len(items)))
+	for _, item := range items {
+		b = append(b, item...)
+	}
+	return b
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		done := (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0)
+		if !done {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if done {
+			return b
+		}
+	}
+}
+
+// Fixture memory layout: static data lives below heapBase, allocate bumps
+// upward from heapBase.
+const (
+	fixtureManifestOffset = 1024
+	fixtureResultOffset   = 4096
+	fixtureHeapBase       = 8192
+	fixtureDeallocRecord  = 512
+)
+
+// fixturePlugin describes a test plugin built by newFixturePlugin.
+type fixturePlugin struct {
+	manifest string
+	result   string
+	// imports are available to observe as function indices 0..n-1.
+	imports []wasmImport
+	// observe replaces the default _observe body, which returns result.
+	observe []byte
+	// manifestBody replaces the default _manifest body, which returns manifest.
+	manifestBody []byte
+	// extra are additional exported functions, defined after _observe.
+	extra []wasmFunc
+	// data are additional data segments.
+	data []wasmData
+	// globals are additional i32 globals, starting at index 1.
+	globals []int32
+}
+
+// packedConst returns an instruction pushing a packed ptr/len as i64.
+func packedConst(offset, length int) []byte {
+	return append([]byte{opI64Const}, sleb(int64(offset)<<32|int64(length))...)
+}
+
+// allocateFunc is a bump allocator over global 0.
+func allocateFunc() wasmFunc {
+	return wasmFunc{
+		export:  "allocate",
+		params:  []byte{wasmI32},
+		results: []byte{wasmI32},
+		body: []byte{
+			opGlobalGet, 0x00,
+			opGlobalGet, 0x00, opLocalGet, 0x00, opI32Add, opGlobalSet, 0x00,
+		},
+	}
+}
+
+// recordingDeallocateFunc is a "deallocate" export that stores its ptr and
+// len arguments at fixtureDeallocRecord so tests can inspect the last call.
+func recordingDeallocateFunc() wasmFunc {
+	store := func(offset int32, local byte) []byte {
+		b := append([]byte{opI32Const}, sleb(int64(offset))...)
+		return append(b, opLocalGet, local, opI32Store, 0x02, 0x00)
+	}
+	return wasmFunc{
+		export: "deallocate",
+		params: []byte{wasmI32, wasmI32},
+		body:   append(store(fixtureDeallocRecord, 0x00), store(fixtureDeallocRecord+4, 0x01)...),
+	}
+}
+
+// hostImport declares a registry host function: packed request in, packed response out.
+func hostImport(name string) wasmImport {
+	return wasmImport{module: "reglet_host", name: name, params: []byte{wasmI64}, results: []byte{wasmI64}}
+}
+
+// callImportBody calls import 0 with an empty request, then returns the
+// fixture result like the default _observe body.
+func callImportBody(resultLen int) []byte {
+	body := append([]byte{opI64Const}, sleb(0)...)
+	body = append(body, opCall, 0x00, opDrop)
+	return append(body, packedConst(fixtureResultOffset, resultLen)...)
+}
+
+// spinBody loops forever, standing in for a plugin that never returns.
+var spinBody = []byte{opLoop, 0x40, opBr, 0x00, opEnd, opUnreachable}
+
+// trapBody makes a function trap, which is how guest panics surface.
+var trapBody = []byte{opUnreachable}
+
+// echoBody makes _observe return its input, so the result is the JSON
+// config the host passed in.
+var echoBody = []byte{
+	opLocalGet, 0x00, opI64ExtendU, opI64Const, 32, opI64Shl,
+	opLocalGet, 0x01, opI64ExtendU, opI64Or,
+}
+
+// trapOnceBody traps on its first call, tracked in global 1, and then
+// returns the fixture manifest.
+func trapOnceBody(manifestLen int) []byte {
+	body := []byte{
+		opGlobalGet, 0x01, opI32Eqz, opIf, 0x40,
+		opI32Const, 0x01, opGlobalSet, 0x01, opUnreachable,
+		opEnd,
+	}
+	return append(body, packedConst(fixtureManifestOffset, manifestLen)...)
+}
+
+func newFixturePlugin(p fixturePlugin) []byte {
+	if p.manifest == "" {
+		p.manifest = `{"name":"fixture","version":"1.0.0"}`
+	}
+	if p.result == "" {
+		p.result = `{"status":"success","message":"ok"}`
+	}
+	observe := p.observe
+	if observe == nil {
+		observe = packedConst(fixtureResultOffset, len(p.result))
+	}
+	manifestBody := p.manifestBody
+	if manifestBody == nil {
+		manifestBody = packedConst(fixtureManifestOffset, len(p.manifest))
+	}
+
+	m := &wasmModule{
+		imports: p.imports,
+		globals: append([]int32{fixtureHeapBase}, p.globals...),
+		funcs: []wasmFunc{
+			allocateFunc(),
+			{
+				export:  "_manifest",
+				results: []byte{wasmI64},
+				body:    manifestBody,
+			},
+			{
+				export:  "_observe",
+				params:  []byte{wasmI32, wasmI32},
+				results: []byte{wasmI64},
+				body:    observe,
+			},
+		},
+		data: []wasmData{
+			{offset: fixtureManifestOffset, bytes: []byte(p.manifest)},
+			{offset: fixtureResultOffset, bytes: []byte(p.result)},
+		},
+	}
+	m.funcs = append(m.funcs, p.extra...)
+	m.data = append(m.data, p.data...)
+	return m.encode()
+}
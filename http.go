@@ -3,9 +3,11 @@ package hostlib
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
@@ -56,10 +58,39 @@ type HTTPResponse struct {
 	// StatusCode is the HTTP status code.
 	StatusCode int `json:"status_code"`
 
+	// FinalURL is the URL of the request that produced this response: the
+	// last redirect target when redirects were followed, the request URL
+	// otherwise. Credentials are stripped.
+	FinalURL string `json:"final_url,omitempty"`
+
+	// RedirectChain lists the URLs requested, in order, when redirects were
+	// followed: the original URL first and the final URL last. It is empty
+	// when no redirect was followed. Credentials are stripped.
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+
 	// BodyTruncated indicates if the body was truncated due to size limits.
 	BodyTruncated bool `json:"body_truncated,omitempty"`
+
+	// Redirected reports whether at least one redirect was followed.
+	Redirected bool `json:"redirected,omitempty"`
+}
+
+// ErrRedirectBlocked is wrapped by the error returned when the redirect
+// policy refuses to follow a redirect.
+var ErrRedirectBlocked = errors.New("redirect blocked")
+
+// HTTPRequestSigner signs an outgoing request, typically by adding an
+// Authorization header. netutil.HMACSigner is a ready-made implementation.
+type HTTPRequestSigner interface {
+	Sign(req *http.Request) error
 }
 
+// HTTPRedirectPolicy decides whether to follow a redirect to req. via holds
+// the requests made so far, oldest first. Returning an error stops the
+// request with that error; returning http.ErrUseLastResponse stops
+// following and returns the redirect response itself.
+type HTTPRedirectPolicy func(req *http.Request, via []*http.Request) error
+
 // HTTPError represents an HTTP request error.
 type HTTPError struct {
 	Code    string `json:"code"`
@@ -75,9 +106,12 @@ func (e *HTTPError) Error() string {
 type HTTPOption func(*httpConfig)
 
 type httpConfig struct {
+	redirectPolicy  HTTPRedirectPolicy
+	signer          HTTPRequestSigner
 	timeout         time.Duration
 	maxRedirects    int
 	maxBodySize     int64
+	maxHeaderBytes  int64
 	followRedirects bool
 	ssrfProtection  bool
 	allowPrivate    bool
@@ -126,6 +160,55 @@ func WithHTTPMaxBodySize(size int64) HTTPOption {
 	}
 }
 
+// WithHTTPRedirectPolicy replaces the default redirect policy, which refuses
+// redirects from https to http and to schemes other than http and https.
+// The redirect limit still applies, and with SSRF protection enabled every
+// redirect target is checked before it is followed.
+func WithHTTPRedirectPolicy(policy HTTPRedirectPolicy) HTTPOption {
+	return func(c *httpConfig) {
+		c.redirectPolicy = policy
+	}
+}
+
+// WithHTTPRequestSigner signs every request with signer just before it is
+// sent, after the plugin's headers (including any set by middleware such as
+// UserAgentMiddleware) have been applied, so the signature covers them.
+// Signing keeps credentials on the host, out of the plugin. Requests that
+// cannot be signed fail with a SIGNING_FAILED error. Redirected requests
+// are not re-signed.
+func WithHTTPRequestSigner(signer HTTPRequestSigner) HTTPOption {
+	return func(c *httpConfig) {
+		c.signer = signer
+	}
+}
+
+// WithHTTPMaxHeaderBytes limits the size of the response headers, counted
+// as the total length of the header names and values. Larger responses fail
+// with a HEADER_TOO_LARGE error instead of being returned. Without this
+// option the net/http default applies.
+func WithHTTPMaxHeaderBytes(n int) HTTPOption {
+	return func(c *httpConfig) {
+		if n > 0 {
+			c.maxHeaderBytes = int64(n)
+		}
+	}
+}
+
+// ParseHTTPMaxBodySize returns a WithHTTPMaxBodySize option for a
+// human-readable size such as "10MB" or "512KiB", as accepted by
+// netutil.ParseSize. It is meant for sizes taken from configuration files
+// or flags, where a typo should be reported rather than ignored.
+func ParseHTTPMaxBodySize(size string) (HTTPOption, error) {
+	n, err := netutil.ParseSize(size)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("invalid max body size %q: must be positive", size)
+	}
+	return WithHTTPMaxBodySize(n), nil
+}
+
 // WithHTTPSSRFProtection enables DNS pinning and SSRF protection.
 // When enabled, each hostname's DNS is resolved ONCE, validated, and pinned
 // for all subsequent requests (preventing DNS rebinding attacks).
@@ -137,6 +220,20 @@ func WithHTTPSSRFProtection(allowPrivate bool) HTTPOption {
 	}
 }
 
+var httpBodyLimitContextKey = &capabilityContextKey{name: "http_body_limit"}
+
+// WithHTTPBodyLimit attaches a per-plugin response body cap to the context.
+// CapabilityMiddleware sets it from WithCapabilityHTTPBodyLimits.
+func WithHTTPBodyLimit(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, httpBodyLimitContextKey, limit)
+}
+
+// HTTPBodyLimitFromContext retrieves the cap set by WithHTTPBodyLimit.
+func HTTPBodyLimitFromContext(ctx context.Context) (int64, bool) {
+	limit, ok := ctx.Value(httpBodyLimitContextKey).(int64)
+	return limit, ok && limit > 0
+}
+
 // PerformHTTPRequest performs an HTTP request.
 // This is a pure Go implementation with no WASM runtime dependencies.
 //
@@ -149,7 +246,7 @@ func PerformHTTPRequest(ctx context.Context, req HTTPRequest, opts ...HTTPOption
 	cfg := defaultHTTPConfig()
 
 	// Check context for default SSRF protection based on capabilities
-	if allowPrivate, ok := ctx.Value("ssrf_allow_private").(bool); ok {
+	if allowPrivate, ok := SSRFAllowPrivateFromContext(ctx); ok {
 		WithHTTPSSRFProtection(allowPrivate)(&cfg)
 	}
 
@@ -157,6 +254,11 @@ func PerformHTTPRequest(ctx context.Context, req HTTPRequest, opts ...HTTPOption
 		opt(&cfg)
 	}
 
+	// A per-plugin cap may only tighten the host limit.
+	if limit, ok := HTTPBodyLimitFromContext(ctx); ok && limit < cfg.maxBodySize {
+		cfg.maxBodySize = limit
+	}
+
 	// Override config from request if specified
 	applyRequestConfig(&req, &cfg)
 
@@ -223,6 +325,17 @@ func executeHTTPRequest(ctx context.Context, req HTTPRequest, cfg httpConfig) HT
 		httpReq.Header.Set(k, v)
 	}
 
+	if cfg.signer != nil {
+		if err := cfg.signer.Sign(httpReq); err != nil {
+			return HTTPResponse{
+				Error: &HTTPError{
+					Code:    "SIGNING_FAILED",
+					Message: err.Error(),
+				},
+			}
+		}
+	}
+
 	// Create client with redirect policy
 	client := createHTTPClient(cfg)
 
@@ -236,7 +349,11 @@ func executeHTTPRequest(ctx context.Context, req HTTPRequest, cfg httpConfig) HT
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	return readHTTPResponse(resp, latency, cfg.maxBodySize)
+	out := readHTTPResponse(resp, latency, cfg)
+	out.FinalURL = netutil.StripCredentials(resp.Request.URL.String())
+	out.RedirectChain = redirectChain(resp)
+	out.Redirected = len(out.RedirectChain) > 0
+	return out
 }
 
 // createHTTPClient creates an HTTP client with the appropriate redirect policy.
@@ -248,6 +365,8 @@ func createHTTPClient(cfg httpConfig) *http.Client {
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
 		TLSClientConfig:       netutil.TLSConfig(),
+		// Counts the raw header block; readHTTPResponse checks the parsed headers.
+		MaxResponseHeaderBytes: cfg.maxHeaderBytes,
 	}
 	if cfg.ssrfProtection {
 		dialer := &netutil.SecureDialer{
@@ -266,32 +385,102 @@ func createHTTPClient(cfg httpConfig) *http.Client {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		}
-	} else if cfg.maxRedirects > 0 {
+	} else {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
-			if len(via) >= cfg.maxRedirects {
-				return fmt.Errorf("stopped after %d redirects", cfg.maxRedirects)
-			}
-			return nil
+			return checkRedirect(cfg, req, via)
 		}
 	}
 
 	return client
 }
 
+// checkRedirect applies the redirect limit, the redirect policy and, with
+// SSRF protection enabled, an SSRF check of the redirect target. The
+// dialer checks the address again when it connects; checking the URL here
+// stops the chain before any request is sent.
+func checkRedirect(cfg httpConfig, req *http.Request, via []*http.Request) error {
+	limit := cfg.maxRedirects
+	if limit <= 0 {
+		limit = 10 // net/http default
+	}
+	if len(via) >= limit {
+		return fmt.Errorf("stopped after %d redirects", limit)
+	}
+
+	policy := cfg.redirectPolicy
+	if policy == nil {
+		policy = defaultRedirectPolicy
+	}
+	if err := policy(req, via); err != nil {
+		if errors.Is(err, http.ErrUseLastResponse) || netutil.IsSSRFBlockedError(err) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrRedirectBlocked, err)
+	}
+
+	if cfg.ssrfProtection {
+		var opts []netutil.NetfilterOption
+		if cfg.allowPrivate {
+			opts = append(opts, netutil.WithBlockPrivate(false), netutil.WithBlockLocalhost(false))
+		}
+		// Lookup failures are left to the dialer to report.
+		if err := netutil.CheckURLSSRF(req.Context(), req.URL.String(), opts...); netutil.IsSSRFBlockedError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultRedirectPolicy refuses downgrades from https to http and
+// redirects to any scheme other than http and https.
+func defaultRedirectPolicy(req *http.Request, via []*http.Request) error {
+	from := strings.ToLower(via[len(via)-1].URL.Scheme)
+	to := strings.ToLower(req.URL.Scheme)
+	switch {
+	case to != "http" && to != "https":
+		return fmt.Errorf("redirect to unsupported scheme %q", to)
+	case from == "https" && to == "http":
+		return fmt.Errorf("redirect from https to http")
+	}
+	return nil
+}
+
+// redirectChain returns the URLs requested to obtain resp, oldest first,
+// or nil if no redirect was followed.
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for r := resp.Request; r != nil; {
+		chain = append(chain, netutil.StripCredentials(r.URL.String()))
+		if r.Response == nil {
+			break
+		}
+		r = r.Response.Request
+	}
+	if len(chain) < 2 {
+		return nil
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
 // handleHTTPError classifies and returns an error response.
 func handleHTTPError(err error, ctx context.Context, latency time.Duration) HTTPResponse {
 	code := "REQUEST_FAILED"
 	switch {
 	case strings.Contains(err.Error(), "timeout"), ctx.Err() == context.DeadlineExceeded:
 		code = "TIMEOUT"
+	case strings.Contains(err.Error(), "server response headers exceeded"):
+		code = "HEADER_TOO_LARGE"
+	case netutil.IsSSRFBlockedError(err):
+		code = "SSRF_BLOCKED"
+	case errors.Is(err, ErrRedirectBlocked):
+		code = "REDIRECT_BLOCKED"
 	case strings.Contains(err.Error(), "redirect"):
 		code = "TOO_MANY_REDIRECTS"
 	case strings.Contains(err.Error(), "no such host"):
 		code = "HOST_NOT_FOUND"
 	case strings.Contains(err.Error(), "connection refused"):
 		code = "CONNECTION_REFUSED"
-	case netutil.IsSSRFBlockedError(err):
-		code = "SSRF_BLOCKED"
 	}
 
 	return HTTPResponse{
@@ -304,9 +493,23 @@ func handleHTTPError(err error, ctx context.Context, latency time.Duration) HTTP
 }
 
 // readHTTPResponse reads and returns the HTTP response body with size limiting.
-func readHTTPResponse(resp *http.Response, latency time.Duration, maxBodySize int64) HTTPResponse {
+func readHTTPResponse(resp *http.Response, latency time.Duration, cfg httpConfig) HTTPResponse {
+	if cfg.maxHeaderBytes > 0 {
+		if size := headerSize(resp.Header); size > cfg.maxHeaderBytes {
+			return HTTPResponse{
+				StatusCode: resp.StatusCode,
+				LatencyMs:  latency.Milliseconds(),
+				Proto:      resp.Proto,
+				Error: &HTTPError{
+					Code:    "HEADER_TOO_LARGE",
+					Message: fmt.Sprintf("response headers of %d bytes exceed limit of %d bytes", size, cfg.maxHeaderBytes),
+				},
+			}
+		}
+	}
+
 	// Read response body with size limit
-	limitedReader := netutil.NewLimitedReader(resp.Body, maxBodySize)
+	limitedReader := netutil.NewLimitedReader(resp.Body, cfg.maxBodySize)
 	respBody, err := io.ReadAll(limitedReader)
 	if err != nil {
 		truncated := netutil.IsSizeLimitExceededError(err)
@@ -340,3 +543,14 @@ func readHTTPResponse(resp *http.Response, latency time.Duration, maxBodySize in
 		Proto:      resp.Proto,
 	}
 }
+
+// headerSize returns the total length of the names and values in h.
+func headerSize(h http.Header) int64 {
+	var n int64
+	for k, vs := range h {
+		for _, v := range vs {
+			n += int64(len(k) + len(v))
+		}
+	}
+	return n
+}
@@ -2,9 +2,19 @@ package hostlib
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,6 +122,21 @@ func TestHTTPOptions(t *testing.T) {
 	assert.Equal(t, int64(1024), cfg.maxBodySize)
 }
 
+func TestParseHTTPMaxBodySize(t *testing.T) {
+	cfg := defaultHTTPConfig()
+
+	opt, err := ParseHTTPMaxBodySize("2 MiB")
+	require.NoError(t, err)
+	opt(&cfg)
+	assert.Equal(t, int64(2*1024*1024), cfg.maxBodySize)
+
+	_, err = ParseHTTPMaxBodySize("10 parsecs")
+	assert.ErrorContains(t, err, "unknown unit")
+
+	_, err = ParseHTTPMaxBodySize("0MB")
+	assert.ErrorContains(t, err, "must be positive")
+}
+
 func TestHTTPOptions_IgnoresInvalid(t *testing.T) {
 	cfg := defaultHTTPConfig()
 
@@ -153,3 +178,323 @@ func TestPerformHTTPRequest_SSRFProtection_AllowPrivateWhenEnabled(t *testing.T)
 		assert.NotEqual(t, "SSRF_BLOCKED", resp.Error.Code, "Should allow private IP connection")
 	}
 }
+
+func TestPerformHTTPRequest_MaxHeaderBytes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/huge" {
+			for i := 0; i < 100; i++ {
+				w.Header().Add("Set-Cookie", fmt.Sprintf("c%d=%s", i, strings.Repeat("x", 1024)))
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	resp := PerformHTTPRequest(context.Background(),
+		HTTPRequest{Method: "GET", URL: srv.URL + "/huge"},
+		WithHTTPMaxHeaderBytes(8*1024),
+	)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "HEADER_TOO_LARGE", resp.Error.Code)
+	assert.Empty(t, resp.Headers)
+
+	resp = PerformHTTPRequest(context.Background(),
+		HTTPRequest{Method: "GET", URL: srv.URL + "/small"},
+		WithHTTPMaxHeaderBytes(8*1024),
+	)
+	require.Nil(t, resp.Error)
+	assert.Equal(t, "ok", string(resp.Body))
+}
+
+func TestReadHTTPResponse_MaxHeaderBytes(t *testing.T) {
+	cfg := defaultHTTPConfig()
+	WithHTTPMaxHeaderBytes(64)(&cfg)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		Header:     http.Header{"Set-Cookie": {strings.Repeat("x", 40), strings.Repeat("y", 40)}},
+		Body:       io.NopCloser(strings.NewReader("body")),
+	}
+
+	got := readHTTPResponse(resp, 0, cfg)
+	require.NotNil(t, got.Error)
+	assert.Equal(t, "HEADER_TOO_LARGE", got.Error.Code)
+	assert.Equal(t, "response headers of 100 bytes exceed limit of 64 bytes", got.Error.Message)
+	assert.Nil(t, got.Headers)
+	assert.Nil(t, got.Body)
+}
+
+func newRedirectServer(t *testing.T, target string) *httptest.Server {
+	t.Helper()
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/middle", http.StatusFound)
+		case "/middle":
+			http.Redirect(w, r, strings.ReplaceAll(target, "SELF", srv.URL), http.StatusFound)
+		default:
+			_, _ = w.Write([]byte("landed"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPerformHTTPRequest_RedirectChain(t *testing.T) {
+	srv := newRedirectServer(t, "SELF/end")
+
+	resp := PerformHTTPRequest(context.Background(), HTTPRequest{URL: srv.URL + "/start"})
+	require.Nil(t, resp.Error)
+	assert.Equal(t, "landed", string(resp.Body))
+	assert.Equal(t, []string{srv.URL + "/start", srv.URL + "/middle", srv.URL + "/end"}, resp.RedirectChain)
+
+	resp = PerformHTTPRequest(context.Background(), HTTPRequest{URL: srv.URL + "/end"})
+	require.Nil(t, resp.Error)
+	assert.Empty(t, resp.RedirectChain)
+}
+
+func TestPerformHTTPRequest_FinalURL(t *testing.T) {
+	srv := newRedirectServer(t, "SELF/end?page=2")
+	// Credentials in the request URL must not leak into FinalURL.
+	withCreds := strings.Replace(srv.URL, "http://", "http://user:secret@", 1)
+
+	t.Run("Redirected", func(t *testing.T) {
+		resp := PerformHTTPRequest(context.Background(), HTTPRequest{URL: withCreds + "/start"})
+		require.Nil(t, resp.Error)
+		assert.Equal(t, srv.URL+"/end?page=2", resp.FinalURL)
+		assert.True(t, resp.Redirected)
+		assert.Equal(t, srv.URL+"/start", resp.RedirectChain[0])
+	})
+
+	t.Run("NoRedirect", func(t *testing.T) {
+		resp := PerformHTTPRequest(context.Background(), HTTPRequest{URL: withCreds + "/end"})
+		require.Nil(t, resp.Error)
+		assert.Equal(t, srv.URL+"/end", resp.FinalURL)
+		assert.False(t, resp.Redirected)
+	})
+
+	t.Run("FollowRedirectsDisabled", func(t *testing.T) {
+		follow := false
+		resp := PerformHTTPRequest(context.Background(), HTTPRequest{URL: srv.URL + "/start", FollowRedirects: &follow})
+		require.Nil(t, resp.Error)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, srv.URL+"/start", resp.FinalURL)
+		assert.False(t, resp.Redirected)
+		assert.Empty(t, resp.RedirectChain)
+	})
+}
+
+func TestPerformHTTPRequest_RedirectToPrivateAddressBlocked(t *testing.T) {
+	// The server itself is on loopback, which allowPrivate permits; the
+	// metadata address it redirects to is blocked regardless.
+	srv := newRedirectServer(t, "http://169.254.169.254/latest/meta-data/")
+
+	resp := PerformHTTPRequest(context.Background(),
+		HTTPRequest{URL: srv.URL + "/start"},
+		WithHTTPSSRFProtection(true),
+	)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "SSRF_BLOCKED", resp.Error.Code)
+	assert.Contains(t, resp.Error.Message, "169.254.169.254")
+}
+
+func TestPerformHTTPRequest_RedirectPolicy(t *testing.T) {
+	srv := newRedirectServer(t, "SELF/end")
+
+	var seen []string
+	resp := PerformHTTPRequest(context.Background(),
+		HTTPRequest{URL: srv.URL + "/start"},
+		WithHTTPRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+			seen = append(seen, req.URL.Path)
+			if req.URL.Path == "/end" {
+				return fmt.Errorf("not following to %s", req.URL.Path)
+			}
+			return nil
+		}),
+	)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "REDIRECT_BLOCKED", resp.Error.Code)
+	assert.Contains(t, resp.Error.Message, "not following to /end")
+	assert.Equal(t, []string{"/middle", "/end"}, seen)
+
+	resp = PerformHTTPRequest(context.Background(),
+		HTTPRequest{URL: srv.URL + "/start"},
+		WithHTTPRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}),
+	)
+	require.Nil(t, resp.Error)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+}
+
+func TestDefaultRedirectPolicy(t *testing.T) {
+	request := func(rawURL string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		require.NoError(t, err)
+		return req
+	}
+	via := func(rawURL string) []*http.Request { return []*http.Request{request(rawURL)} }
+
+	assert.NoError(t, defaultRedirectPolicy(request("https://example.com/b"), via("https://example.com/a")))
+	assert.NoError(t, defaultRedirectPolicy(request("https://other.example/b"), via("https://example.com/a")))
+	assert.NoError(t, defaultRedirectPolicy(request("https://example.com/b"), via("http://example.com/a")), "upgrade is allowed")
+	assert.ErrorContains(t, defaultRedirectPolicy(request("http://example.com/b"), via("https://example.com/a")), "https to http")
+	assert.ErrorContains(t, defaultRedirectPolicy(request("ftp://example.com/b"), via("http://example.com/a")), "unsupported scheme")
+}
+
+func TestPerformHTTPRequest_RequestSigner(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	signer := &netutil.HMACSigner{KeyID: "host-key", Secret: []byte("s3cret")}
+	reg, err := NewRegistry(
+		WithMiddleware(UserAgentMiddleware("reglet-test/1.0")),
+		WithHandler("http_request", func(ctx context.Context, req HTTPRequest) HTTPResponse {
+			return PerformHTTPRequest(ctx, req, WithHTTPRequestSigner(signer))
+		}),
+	)
+	require.NoError(t, err)
+
+	payload, err := json.Marshal(HTTPRequest{Method: "POST", URL: srv.URL + "/hook", Body: []byte("{}")})
+	require.NoError(t, err)
+	_, err = reg.Invoke(context.Background(), "http_request", payload)
+	require.NoError(t, err)
+
+	require.NotNil(t, got)
+	assert.NotEmpty(t, got.Get("X-Date"))
+	assert.Contains(t, got.Get("Authorization"), "Credential=host-key")
+	assert.Contains(t, got.Get("Authorization"), "SignedHeaders=host;user-agent;x-date,",
+		"the User-Agent set by middleware is signed")
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(*http.Request) error { return fmt.Errorf("no credentials") }
+
+func TestPerformHTTPRequest_RequestSignerFails(t *testing.T) {
+	resp := PerformHTTPRequest(context.Background(),
+		HTTPRequest{URL: "http://127.0.0.1:1/"},
+		WithHTTPRequestSigner(failingSigner{}),
+	)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "SIGNING_FAILED", resp.Error.Code)
+	assert.Equal(t, "no credentials", resp.Error.Message)
+}
+
+func TestPerformHTTPRequest_ReadsSSRFDecisionFromMiddleware(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	checker := NewCapabilityChecker(map[string]*hostfunc.GrantSet{
+		// Wildcard port covers the private-network probe.
+		"private-ok": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"127.0.0.1"}, Ports: []string{"*"}},
+		}}},
+		// Only the server port: the request passes the capability check but
+		// private addresses stay blocked by SSRF protection.
+		"private-blocked": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"127.0.0.1"}, Ports: []string{strconv.Itoa(port)}},
+		}}},
+	})
+
+	reg, err := NewRegistry(
+		WithMiddleware(CapabilityMiddleware(checker)),
+		WithBundle(NetworkBundle()),
+	)
+	require.NoError(t, err)
+
+	payload := []byte(`{"method":"GET","url":"` + srv.URL + `"}`)
+
+	invoke := func(plugin string) HTTPResponse {
+		resp, err := reg.Invoke(WithCapabilityPluginName(context.Background(), plugin), "http_request", payload)
+		require.NoError(t, err)
+		var out HTTPResponse
+		require.NoError(t, json.Unmarshal(resp, &out))
+		return out
+	}
+
+	allowed := invoke("private-ok")
+	require.Nil(t, allowed.Error)
+	assert.Equal(t, 200, allowed.StatusCode)
+
+	blocked := invoke("private-blocked")
+	require.NotNil(t, blocked.Error)
+	assert.Equal(t, "SSRF_BLOCKED", blocked.Error.Code)
+}
+
+func TestSSRFAllowPrivateContext(t *testing.T) {
+	_, ok := SSRFAllowPrivateFromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithSSRFAllowPrivate(context.Background(), true)
+	allow, ok := SSRFAllowPrivateFromContext(ctx)
+	assert.True(t, ok)
+	assert.True(t, allow)
+
+	// A bare string key does not alias the typed key.
+	//nolint:staticcheck // deliberately using a string key
+	ctx = context.WithValue(context.Background(), "ssrf_allow_private", true)
+	_, ok = SSRFAllowPrivateFromContext(ctx)
+	assert.False(t, ok)
+}
+
+func TestPerformHTTPRequest_PluginBodyLimit(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	loopback := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+		{Hosts: []string{"127.0.0.1"}, Ports: []string{"*"}},
+	}}}
+	checker := NewCapabilityChecker(
+		map[string]*hostfunc.GrantSet{"capped": loopback, "uncapped": loopback},
+		WithCapabilityHTTPBodyLimits(map[string]int64{"capped": 1024}),
+	)
+
+	reg, err := NewRegistry(
+		WithMiddleware(CapabilityMiddleware(checker)),
+		WithBundle(NetworkBundle()),
+	)
+	require.NoError(t, err)
+
+	payload := []byte(`{"method":"GET","url":"` + srv.URL + `"}`)
+	invoke := func(plugin string) HTTPResponse {
+		resp, err := reg.Invoke(WithCapabilityPluginName(context.Background(), plugin), "http_request", payload)
+		require.NoError(t, err)
+		var out HTTPResponse
+		require.NoError(t, json.Unmarshal(resp, &out))
+		return out
+	}
+
+	capped := invoke("capped")
+	require.Nil(t, capped.Error)
+	assert.True(t, capped.BodyTruncated)
+	assert.Len(t, capped.Body, 1024)
+
+	uncapped := invoke("uncapped")
+	require.Nil(t, uncapped.Error)
+	assert.False(t, uncapped.BodyTruncated)
+	assert.Len(t, uncapped.Body, len(body))
+}
+
+func TestPerformHTTPRequest_PluginBodyLimitCannotRaiseHostLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 4096)))
+	}))
+	defer srv.Close()
+
+	ctx := WithHTTPBodyLimit(context.Background(), 1<<20)
+	resp := PerformHTTPRequest(ctx, HTTPRequest{URL: srv.URL}, WithHTTPMaxBodySize(512))
+	require.Nil(t, resp.Error)
+	assert.True(t, resp.BodyTruncated)
+	assert.Len(t, resp.Body, 512)
+}
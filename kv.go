@@ -0,0 +1,118 @@
+package hostlib
+
+import (
+	"context"
+	"time"
+)
+
+// KVGetRequest contains parameters for reading a key.
+type KVGetRequest struct {
+	Key string `json:"key"`
+}
+
+// KVGetResponse contains the result of a key read.
+type KVGetResponse struct {
+	// Error contains error information if the read failed.
+	Error *KVError `json:"error,omitempty"`
+
+	// Value is the stored value. Empty when Found is false.
+	Value string `json:"value,omitempty"`
+
+	// Found indicates whether the key exists.
+	Found bool `json:"found"`
+}
+
+// KVSetRequest contains parameters for writing a key.
+type KVSetRequest struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+
+	// TTL is the time to live in milliseconds. Zero means the key does not expire.
+	// Setting a TTL requires the kv TTL capability (see WithCapabilityKVTTL).
+	TTL int `json:"ttl_ms,omitempty"`
+}
+
+// KVSetResponse contains the result of a key write.
+type KVSetResponse struct {
+	// Error contains error information if the write failed.
+	Error *KVError `json:"error,omitempty"`
+}
+
+// KVDeleteRequest contains parameters for deleting a key.
+type KVDeleteRequest struct {
+	Key string `json:"key"`
+}
+
+// KVDeleteResponse contains the result of a key deletion.
+type KVDeleteResponse struct {
+	// Error contains error information if the deletion failed.
+	Error *KVError `json:"error,omitempty"`
+
+	// Deleted indicates whether the key existed.
+	Deleted bool `json:"deleted"`
+}
+
+// KVError represents a key-value operation error.
+type KVError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *KVError) Error() string {
+	return e.Message
+}
+
+// kvNamespace scopes keys to the calling plugin.
+func kvNamespace(ctx context.Context) string {
+	name, _ := CapabilityPluginNameFromContext(ctx)
+	return name
+}
+
+func kvInvalidKey() *KVError {
+	return &KVError{Code: "INVALID_REQUEST", Message: "key is required"}
+}
+
+func kvStoreError(err error) *KVError {
+	return &KVError{Code: "STORE_ERROR", Message: err.Error()}
+}
+
+// PerformKVGet reads a key from the calling plugin's namespace.
+// Capability enforcement is done by CapabilityMiddleware.
+func PerformKVGet(ctx context.Context, req KVGetRequest, store KVStore) KVGetResponse {
+	if req.Key == "" {
+		return KVGetResponse{Error: kvInvalidKey()}
+	}
+	value, found, err := store.Get(ctx, kvNamespace(ctx), req.Key)
+	if err != nil {
+		return KVGetResponse{Error: kvStoreError(err)}
+	}
+	return KVGetResponse{Value: value, Found: found}
+}
+
+// PerformKVSet writes a key in the calling plugin's namespace.
+func PerformKVSet(ctx context.Context, req KVSetRequest, store KVStore) KVSetResponse {
+	if req.Key == "" {
+		return KVSetResponse{Error: kvInvalidKey()}
+	}
+	if req.TTL < 0 {
+		return KVSetResponse{Error: &KVError{Code: "INVALID_REQUEST", Message: "ttl_ms must not be negative"}}
+	}
+	ttl := time.Duration(req.TTL) * time.Millisecond
+	if err := store.Set(ctx, kvNamespace(ctx), req.Key, req.Value, ttl); err != nil {
+		return KVSetResponse{Error: kvStoreError(err)}
+	}
+	return KVSetResponse{}
+}
+
+// PerformKVDelete removes a key from the calling plugin's namespace.
+func PerformKVDelete(ctx context.Context, req KVDeleteRequest, store KVStore) KVDeleteResponse {
+	if req.Key == "" {
+		return KVDeleteResponse{Error: kvInvalidKey()}
+	}
+	deleted, err := store.Delete(ctx, kvNamespace(ctx), req.Key)
+	if err != nil {
+		return KVDeleteResponse{Error: kvStoreError(err)}
+	}
+	return KVDeleteResponse{Deleted: deleted}
+}
@@ -0,0 +1,254 @@
+package hostlib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// KVStore persists small key-value state on behalf of plugins.
+// Each plugin gets its own namespace, so keys never collide across plugins.
+type KVStore interface {
+	// Get returns the value stored under key and whether it exists.
+	Get(ctx context.Context, namespace, key string) (value string, found bool, err error)
+
+	// Set stores value under key, replacing any existing value. A positive ttl
+	// makes the key expire after that long; zero keeps it until deleted.
+	Set(ctx context.Context, namespace, key, value string, ttl time.Duration) error
+
+	// Delete removes key and reports whether it existed.
+	Delete(ctx context.Context, namespace, key string) (deleted bool, err error)
+}
+
+// KVStoreOption configures the built-in KV stores.
+type KVStoreOption func(*kvStoreConfig)
+
+type kvStoreConfig struct {
+	now func() time.Time
+}
+
+// WithKVStoreClock sets the clock used to evaluate key expiry.
+func WithKVStoreClock(now func() time.Time) KVStoreOption {
+	return func(c *kvStoreConfig) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+func newKVStoreConfig(opts []KVStoreOption) kvStoreConfig {
+	cfg := kvStoreConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// kvEntry is a stored value with an optional expiry.
+type kvEntry struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Value     string     `json:"value"`
+}
+
+func newKVEntry(value string, ttl time.Duration, now time.Time) kvEntry {
+	entry := kvEntry{Value: value}
+	if ttl > 0 {
+		expiresAt := now.Add(ttl)
+		entry.ExpiresAt = &expiresAt
+	}
+	return entry
+}
+
+func (e kvEntry) expired(now time.Time) bool {
+	return e.ExpiresAt != nil && !now.Before(*e.ExpiresAt)
+}
+
+// MemoryKVStore is an in-memory KVStore. Its contents are lost when the host exits.
+type MemoryKVStore struct {
+	data   map[string]map[string]kvEntry
+	config kvStoreConfig
+	mu     sync.Mutex
+}
+
+// NewMemoryKVStore creates an empty in-memory KV store.
+func NewMemoryKVStore(opts ...KVStoreOption) *MemoryKVStore {
+	return &MemoryKVStore{
+		data:   make(map[string]map[string]kvEntry),
+		config: newKVStoreConfig(opts),
+	}
+}
+
+// Get implements KVStore.
+func (s *MemoryKVStore) Get(_ context.Context, namespace, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.lookup(namespace, key)
+	return entry.Value, ok, nil
+}
+
+// Set implements KVStore.
+func (s *MemoryKVStore) Set(_ context.Context, namespace, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ns, ok := s.data[namespace]
+	if !ok {
+		ns = make(map[string]kvEntry)
+		s.data[namespace] = ns
+	}
+	ns[key] = newKVEntry(value, ttl, s.config.now())
+	return nil
+}
+
+// Delete implements KVStore.
+func (s *MemoryKVStore) Delete(_ context.Context, namespace, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.lookup(namespace, key); !ok {
+		return false, nil
+	}
+	delete(s.data[namespace], key)
+	return true, nil
+}
+
+// lookup returns a live entry, dropping it if it has expired.
+// The caller must hold s.mu.
+func (s *MemoryKVStore) lookup(namespace, key string) (kvEntry, bool) {
+	entry, ok := s.data[namespace][key]
+	if !ok {
+		return kvEntry{}, false
+	}
+	if entry.expired(s.config.now()) {
+		delete(s.data[namespace], key)
+		return kvEntry{}, false
+	}
+	return entry, true
+}
+
+// FileKVStore is a KVStore that keeps each namespace in a JSON file under a
+// directory. Writes go to a temporary file that is renamed into place, so a
+// crash never leaves a half-written namespace behind.
+type FileKVStore struct {
+	dir    string
+	config kvStoreConfig
+	mu     sync.Mutex
+}
+
+// NewFileKVStore creates a file-backed KV store rooted at dir, creating the
+// directory if needed.
+func NewFileKVStore(dir string, opts ...KVStoreOption) (*FileKVStore, error) {
+	if dir == "" {
+		return nil, errors.New("kv store directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create kv store directory: %w", err)
+	}
+	return &FileKVStore{dir: dir, config: newKVStoreConfig(opts)}, nil
+}
+
+// Get implements KVStore.
+func (s *FileKVStore) Get(_ context.Context, namespace, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load(namespace)
+	if err != nil {
+		return "", false, err
+	}
+	entry, ok := data[key]
+	return entry.Value, ok, nil
+}
+
+// Set implements KVStore.
+func (s *FileKVStore) Set(_ context.Context, namespace, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load(namespace)
+	if err != nil {
+		return err
+	}
+	data[key] = newKVEntry(value, ttl, s.config.now())
+	return s.save(namespace, data)
+}
+
+// Delete implements KVStore.
+func (s *FileKVStore) Delete(_ context.Context, namespace, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.load(namespace)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := data[key]; !ok {
+		return false, nil
+	}
+	delete(data, key)
+	return true, s.save(namespace, data)
+}
+
+// defaultNamespaceFile names the file of the empty namespace. Escaped
+// namespaces never contain a '%' that is not followed by two hex digits, so
+// no plugin name maps to it.
+const defaultNamespaceFile = "%default"
+
+// path maps a namespace to its file. Namespaces are escaped so a plugin name
+// cannot point outside the store directory.
+func (s *FileKVStore) path(namespace string) string {
+	name := defaultNamespaceFile
+	if namespace != "" {
+		name = url.PathEscape(namespace)
+	}
+	return filepath.Join(s.dir, name+".json")
+}
+
+// load reads a namespace, leaving out expired keys. They are removed from
+// disk the next time the namespace is written.
+func (s *FileKVStore) load(namespace string) (map[string]kvEntry, error) {
+	raw, err := os.ReadFile(s.path(namespace))
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]kvEntry), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kv namespace %q: %w", namespace, err)
+	}
+	data := make(map[string]kvEntry)
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse kv namespace %q: %w", namespace, err)
+	}
+	now := s.config.now()
+	for key, entry := range data {
+		if entry.expired(now) {
+			delete(data, key)
+		}
+	}
+	return data, nil
+}
+
+func (s *FileKVStore) save(namespace string, data map[string]kvEntry) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode kv namespace %q: %w", namespace, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".kv-*")
+	if err != nil {
+		return fmt.Errorf("failed to write kv namespace %q: %w", namespace, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write kv namespace %q: %w", namespace, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write kv namespace %q: %w", namespace, err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(namespace)); err != nil {
+		return fmt.Errorf("failed to write kv namespace %q: %w", namespace, err)
+	}
+	return nil
+}
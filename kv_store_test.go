@@ -0,0 +1,126 @@
+package hostlib
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKVStoreRoundTrip(t *testing.T, store KVStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	_, found, err := store.Get(ctx, "p", "missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, store.Set(ctx, "p", "a", "1", 0))
+	require.NoError(t, store.Set(ctx, "p", "a", "2", 0))
+	require.NoError(t, store.Set(ctx, "q", "a", "other", 0))
+
+	value, found, err := store.Get(ctx, "p", "a")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "2", value)
+
+	deleted, err := store.Delete(ctx, "p", "a")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = store.Delete(ctx, "p", "a")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	value, _, err = store.Get(ctx, "q", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "other", value)
+
+	// The empty namespace is distinct from every plugin name.
+	require.NoError(t, store.Set(ctx, "", "a", "empty", 0))
+	require.NoError(t, store.Set(ctx, "_default", "a", "named", 0))
+	value, _, err = store.Get(ctx, "", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "empty", value)
+	value, _, err = store.Get(ctx, "_default", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "named", value)
+}
+
+func TestMemoryKVStore_RoundTrip(t *testing.T) {
+	testKVStoreRoundTrip(t, NewMemoryKVStore())
+}
+
+func TestFileKVStore_RoundTrip(t *testing.T) {
+	store, err := NewFileKVStore(t.TempDir())
+	require.NoError(t, err)
+	testKVStoreRoundTrip(t, store)
+}
+
+func TestFileKVStore_Persists(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	store, err := NewFileKVStore(dir)
+	require.NoError(t, err)
+	require.NoError(t, store.Set(ctx, "my/plugin", "k", "v", 0))
+
+	reopened, err := NewFileKVStore(dir)
+	require.NoError(t, err)
+	value, found, err := reopened.Get(ctx, "my/plugin", "k")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "v", value)
+
+	// Namespaces are escaped and stay inside the store directory.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "my%2Fplugin.json", entries[0].Name())
+	_, err = os.Stat(filepath.Join(dir, "my"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestKVStore_TTLExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := WithKVStoreClock(func() time.Time { return now })
+
+	fileStore, err := NewFileKVStore(t.TempDir(), clock)
+	require.NoError(t, err)
+
+	stores := map[string]KVStore{
+		"memory": NewMemoryKVStore(clock),
+		"file":   fileStore,
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+			require.NoError(t, store.Set(ctx, "p", "ephemeral", "v", time.Minute))
+			require.NoError(t, store.Set(ctx, "p", "durable", "v", 0))
+
+			_, found, err := store.Get(ctx, "p", "ephemeral")
+			require.NoError(t, err)
+			assert.True(t, found)
+
+			now = now.Add(time.Minute)
+
+			_, found, err = store.Get(ctx, "p", "ephemeral")
+			require.NoError(t, err)
+			assert.False(t, found, "expired key must be invisible")
+
+			deleted, err := store.Delete(ctx, "p", "ephemeral")
+			require.NoError(t, err)
+			assert.False(t, deleted)
+
+			_, found, err = store.Get(ctx, "p", "durable")
+			require.NoError(t, err)
+			assert.True(t, found)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package hostlib
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newKVRegistry(t *testing.T, store KVStore, opts ...CapabilityCheckerOption) *HandlerRegistry {
+	t.Helper()
+
+	checker := NewCapabilityChecker(map[string]*hostfunc.GrantSet{
+		"cache-plugin": {
+			KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{
+				{Operation: "read-write", Keys: []string{"cache/*"}},
+				{Operation: "read", Keys: []string{"config/*"}},
+			}},
+		},
+	}, opts...)
+
+	reg, err := NewRegistry(
+		WithMiddleware(CapabilityMiddleware(checker)),
+		WithBundle(KVBundle(store)),
+	)
+	require.NoError(t, err)
+	return reg
+}
+
+func invokeKV(t *testing.T, reg *HandlerRegistry, plugin, fn string, req any, out any) {
+	t.Helper()
+	payload, err := json.Marshal(req)
+	require.NoError(t, err)
+	resp, err := reg.Invoke(WithCapabilityPluginName(context.Background(), plugin), fn, payload)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(resp, out))
+}
+
+func TestKV_GrantedReadWrite(t *testing.T) {
+	reg := newKVRegistry(t, NewMemoryKVStore())
+
+	var setResp KVSetResponse
+	invokeKV(t, reg, "cache-plugin", "kv_set", KVSetRequest{Key: "cache/etag", Value: "abc123"}, &setResp)
+	assert.Nil(t, setResp.Error)
+
+	var getResp KVGetResponse
+	invokeKV(t, reg, "cache-plugin", "kv_get", KVGetRequest{Key: "cache/etag"}, &getResp)
+	require.Nil(t, getResp.Error)
+	assert.True(t, getResp.Found)
+	assert.Equal(t, "abc123", getResp.Value)
+
+	var delResp KVDeleteResponse
+	invokeKV(t, reg, "cache-plugin", "kv_delete", KVDeleteRequest{Key: "cache/etag"}, &delResp)
+	require.Nil(t, delResp.Error)
+	assert.True(t, delResp.Deleted)
+
+	getResp = KVGetResponse{}
+	invokeKV(t, reg, "cache-plugin", "kv_get", KVGetRequest{Key: "cache/etag"}, &getResp)
+	assert.False(t, getResp.Found)
+
+	// Read-only keys can be read.
+	getResp = KVGetResponse{}
+	invokeKV(t, reg, "cache-plugin", "kv_get", KVGetRequest{Key: "config/mode"}, &getResp)
+	assert.Nil(t, getResp.Error)
+	assert.False(t, getResp.Found)
+}
+
+func TestKV_DeniedOperations(t *testing.T) {
+	store := NewMemoryKVStore()
+	reg := newKVRegistry(t, store)
+
+	tests := []struct {
+		req    any
+		name   string
+		plugin string
+		fn     string
+	}{
+		{name: "write to read-only key", plugin: "cache-plugin", fn: "kv_set", req: KVSetRequest{Key: "config/mode", Value: "x"}},
+		{name: "delete read-only key", plugin: "cache-plugin", fn: "kv_delete", req: KVDeleteRequest{Key: "config/mode"}},
+		{name: "read ungranted key", plugin: "cache-plugin", fn: "kv_get", req: KVGetRequest{Key: "secrets/token"}},
+		{name: "plugin without grants", plugin: "other-plugin", fn: "kv_get", req: KVGetRequest{Key: "cache/etag"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errResp ErrorResponse
+			invokeKV(t, reg, tt.plugin, tt.fn, tt.req, &errResp)
+			assert.Equal(t, "VALIDATION_ERROR", errResp.Error)
+		})
+	}
+
+	_, found, err := store.Get(context.Background(), "cache-plugin", "config/mode")
+	require.NoError(t, err)
+	assert.False(t, found, "denied write must not reach the store")
+}
+
+func TestKV_NamespacedPerPlugin(t *testing.T) {
+	store := NewMemoryKVStore()
+	ctxA := WithCapabilityPluginName(context.Background(), "a")
+	ctxB := WithCapabilityPluginName(context.Background(), "b")
+
+	require.Nil(t, PerformKVSet(ctxA, KVSetRequest{Key: "k", Value: "from-a"}, store).Error)
+
+	resp := PerformKVGet(ctxB, KVGetRequest{Key: "k"}, store)
+	assert.False(t, resp.Found)
+
+	resp = PerformKVGet(ctxA, KVGetRequest{Key: "k"}, store)
+	assert.Equal(t, "from-a", resp.Value)
+
+	assert.Equal(t, "INVALID_REQUEST", PerformKVGet(ctxA, KVGetRequest{}, store).Error.Code)
+}
+
+func TestKV_SetWithTTLRequiresCapability(t *testing.T) {
+	req := KVSetRequest{Key: "cache/etag", Value: "abc", TTL: 60_000}
+
+	t.Run("denied without ttl capability", func(t *testing.T) {
+		store := NewMemoryKVStore()
+		reg := newKVRegistry(t, store)
+
+		var errResp ErrorResponse
+		invokeKV(t, reg, "cache-plugin", "kv_set", req, &errResp)
+		assert.Equal(t, "VALIDATION_ERROR", errResp.Error)
+		assert.Contains(t, errResp.Message, "ttl")
+
+		_, found, _ := store.Get(context.Background(), "cache-plugin", "cache/etag")
+		assert.False(t, found)
+	})
+
+	t.Run("allowed with ttl capability", func(t *testing.T) {
+		now := time.Now()
+		store := NewMemoryKVStore(WithKVStoreClock(func() time.Time { return now }))
+		reg := newKVRegistry(t, store, WithCapabilityKVTTL("cache-plugin"))
+
+		var setResp KVSetResponse
+		invokeKV(t, reg, "cache-plugin", "kv_set", req, &setResp)
+		require.Nil(t, setResp.Error)
+
+		var getResp KVGetResponse
+		invokeKV(t, reg, "cache-plugin", "kv_get", KVGetRequest{Key: "cache/etag"}, &getResp)
+		assert.True(t, getResp.Found)
+
+		now = now.Add(time.Minute)
+		getResp = KVGetResponse{}
+		invokeKV(t, reg, "cache-plugin", "kv_get", KVGetRequest{Key: "cache/etag"}, &getResp)
+		assert.False(t, getResp.Found, "value disappears after its TTL")
+	})
+}
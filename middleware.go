@@ -3,7 +3,10 @@ package hostlib
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"strings"
+	"time"
 )
 
 // Middleware is a function that wraps a ByteHandler to add cross-cutting behavior.
@@ -95,3 +98,129 @@ func LoggingMiddleware(logFn func(format string, args ...any)) Middleware {
 		}
 	}
 }
+
+// MaxPayloadSizeMiddleware returns a middleware that rejects payloads larger
+// than maxBytes with a validation error, before the handler or any later
+// middleware unmarshals them. A non-positive maxBytes disables the check.
+// Register it first so it runs ahead of middleware that decodes the payload.
+func MaxPayloadSizeMiddleware(maxBytes int) Middleware {
+	return func(next ByteHandler) ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			if maxBytes > 0 && len(payload) > maxBytes {
+				funcName := "host function"
+				if hc, ok := ctx.(HostContext); ok {
+					funcName = hc.FunctionName()
+				}
+				msg := fmt.Sprintf("%s payload of %d bytes exceeds limit of %d bytes", funcName, len(payload), maxBytes)
+				return NewValidationError(msg).ToJSON(), nil
+			}
+			return next(ctx, payload)
+		}
+	}
+}
+
+// TimeoutMiddleware returns a middleware that bounds each host function call
+// to d. The handler runs with a context that is canceled after d; if it has
+// not returned by then, the caller gets a timeout error response straight
+// away. Handlers must watch ctx.Done() and stop when it fires: a handler
+// that ignores cancellation keeps running in the background after the
+// timeout response has been sent, and its result is discarded. A
+// non-positive d disables the limit.
+func TimeoutMiddleware(d time.Duration) Middleware {
+	return func(next ByteHandler) ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			if d <= 0 {
+				return next(ctx, payload)
+			}
+
+			funcName := "host function"
+			tctx, cancel := context.WithTimeout(ctx, d)
+			var callCtx context.Context = tctx
+			if hc, ok := ctx.(HostContext); ok {
+				funcName = hc.FunctionName()
+				callCtx = withContext(hc, tctx)
+			}
+
+			type result struct {
+				resp []byte
+				err  error
+			}
+			done := make(chan result, 1)
+			go func() {
+				defer cancel()
+				defer func() {
+					// A panic here would crash the host, out of reach of
+					// PanicRecoveryMiddleware; report it instead.
+					if r := recover(); r != nil {
+						done <- result{resp: NewPanicError(r).ToJSON()}
+					}
+				}()
+				resp, err := next(callCtx, payload)
+				done <- result{resp, err}
+			}()
+
+			timedOut := func() ([]byte, error) {
+				if ctx.Err() != nil {
+					// The caller gave up; that is not our timeout.
+					return nil, ctx.Err()
+				}
+				msg := fmt.Sprintf("%s did not complete within %s", funcName, d)
+				return NewTimeoutError(msg).ToJSON(), nil
+			}
+
+			select {
+			case r := <-done:
+				// A handler that honours cancellation returns the deadline
+				// error itself, possibly before tctx.Done() is observed here.
+				if errors.Is(r.err, context.DeadlineExceeded) && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+					return timedOut()
+				}
+				return r.resp, r.err
+			case <-tctx.Done():
+				// The handler may have finished just as the deadline hit.
+				select {
+				case r := <-done:
+					if r.err == nil {
+						return r.resp, nil
+					}
+				default:
+				}
+				return timedOut()
+			}
+		}
+	}
+}
+
+// MetricRecorder receives one observation per host function call. err is
+// the error the handler returned or, when the handler answered with an
+// ErrorResponse, a *ResponseError (matching ErrErrorResponse), so recorders
+// can count both as failures or tell them apart. err is nil on success.
+// Implementations must be safe for concurrent use.
+type MetricRecorder interface {
+	RecordCall(funcName string, duration time.Duration, err error)
+}
+
+// MetricsMiddleware returns a middleware that reports each call's function
+// name, duration and outcome to recorder, e.g. to feed a Prometheus
+// histogram. Register it outermost to include time spent in other
+// middleware.
+func MetricsMiddleware(recorder MetricRecorder) Middleware {
+	return func(next ByteHandler) ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			funcName := "unknown"
+			if hc, ok := ctx.(HostContext); ok {
+				funcName = hc.FunctionName()
+			}
+			start := time.Now()
+			resp, err := next(ctx, payload)
+			elapsed := time.Since(start)
+
+			outcome := err
+			if outcome == nil {
+				outcome = responseError(resp)
+			}
+			recorder.RecordCall(funcName, elapsed, outcome)
+			return resp, err
+		}
+	}
+}
@@ -3,7 +3,10 @@ package hostlib
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -152,3 +155,279 @@ func TestLoggingMiddleware(t *testing.T) {
 	assert.Contains(t, logs[0], "invoking")
 	assert.Contains(t, logs[1], "completed")
 }
+
+func TestMaxPayloadSizeMiddleware(t *testing.T) {
+	called := false
+	handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+		called = true
+		return []byte(`{"ok":true}`), nil
+	}
+	wrapped := MaxPayloadSizeMiddleware(16)(handler)
+
+	tests := []struct {
+		name    string
+		size    int
+		allowed bool
+	}{
+		{"Under", 15, true},
+		{"At", 16, true},
+		{"Over", 17, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called = false
+			resp, err := wrapped(context.Background(), make([]byte, tt.size))
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, called)
+			if tt.allowed {
+				assert.JSONEq(t, `{"ok":true}`, string(resp))
+				return
+			}
+			var errResp ErrorResponse
+			require.NoError(t, json.Unmarshal(resp, &errResp))
+			assert.Equal(t, "VALIDATION_ERROR", errResp.Error)
+			assert.Contains(t, errResp.Message, "17 bytes exceeds limit of 16 bytes")
+		})
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		called = false
+		_, err := MaxPayloadSizeMiddleware(0)(handler)(context.Background(), make([]byte, 1<<20))
+		require.NoError(t, err)
+		assert.True(t, called)
+	})
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Run("FastHandlerCompletes", func(t *testing.T) {
+		handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+			return []byte(`{"ok":true}`), nil
+		}
+		resp, err := TimeoutMiddleware(time.Second)(handler)(context.Background(), nil)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"ok":true}`, string(resp))
+	})
+
+	t.Run("SlowHandlerTimesOut", func(t *testing.T) {
+		canceled := make(chan error, 1)
+		handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+			<-ctx.Done()
+			canceled <- ctx.Err()
+			return nil, ctx.Err()
+		}
+		ctx := NewHostContext(context.Background(), "slow_func")
+
+		start := time.Now()
+		resp, err := TimeoutMiddleware(20*time.Millisecond)(handler)(ctx, nil)
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), time.Second)
+
+		var errResp ErrorResponse
+		require.NoError(t, json.Unmarshal(resp, &errResp))
+		assert.Equal(t, "TIMEOUT", errResp.Error)
+		assert.Equal(t, "slow_func did not complete within 20ms", errResp.Message)
+
+		select {
+		case err := <-canceled:
+			assert.ErrorIs(t, err, context.DeadlineExceeded, "the handler's context is canceled")
+		case <-time.After(time.Second):
+			t.Fatal("handler context was not canceled")
+		}
+	})
+
+	t.Run("KeepsHostContext", func(t *testing.T) {
+		ctx := NewHostContext(context.Background(), "named_func")
+		ctx.SetValue("key", "value")
+		handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+			hc, ok := ctx.(HostContext)
+			require.True(t, ok)
+			assert.Equal(t, "named_func", hc.FunctionName())
+			v, _ := hc.GetValue("key")
+			assert.Equal(t, "value", v)
+			_, hasDeadline := ctx.Deadline()
+			assert.True(t, hasDeadline)
+			return nil, nil
+		}
+		_, err := TimeoutMiddleware(time.Second)(handler)(ctx, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("CallerCancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return nil, nil
+		}
+		_, err := TimeoutMiddleware(time.Second)(handler)(ctx, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("PanicIsReported", func(t *testing.T) {
+		handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+			panic("boom")
+		}
+		resp, err := TimeoutMiddleware(time.Second)(handler)(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Contains(t, string(resp), "panic: boom")
+	})
+}
+
+type recordedCall struct {
+	funcName string
+	duration time.Duration
+	err      error
+}
+
+type fakeRecorder struct {
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+func (r *fakeRecorder) RecordCall(funcName string, duration time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, recordedCall{funcName, duration, err})
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	recorder := &fakeRecorder{}
+	mw := MetricsMiddleware(recorder)
+
+	slow := mw(func(ctx context.Context, payload []byte) ([]byte, error) {
+		time.Sleep(10 * time.Millisecond)
+		return []byte(`{"ok":true}`), nil
+	})
+	resp, err := slow(NewHostContext(context.Background(), "dns_lookup"), nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"ok":true}`, string(resp))
+
+	invalid := mw(func(ctx context.Context, payload []byte) ([]byte, error) {
+		return NewValidationError("bad hostname").ToJSON(), nil
+	})
+	_, err = invalid(NewHostContext(context.Background(), "dns_lookup"), nil)
+	require.NoError(t, err)
+
+	failErr := errors.New("connection refused")
+	failing := mw(func(ctx context.Context, payload []byte) ([]byte, error) {
+		return nil, failErr
+	})
+	_, err = failing(NewHostContext(context.Background(), "tcp_connect"), nil)
+	require.ErrorIs(t, err, failErr)
+
+	require.Len(t, recorder.calls, 3)
+
+	assert.Equal(t, "dns_lookup", recorder.calls[0].funcName)
+	assert.GreaterOrEqual(t, recorder.calls[0].duration, 10*time.Millisecond)
+	assert.NoError(t, recorder.calls[0].err)
+
+	assert.Equal(t, "dns_lookup", recorder.calls[1].funcName)
+	require.ErrorIs(t, recorder.calls[1].err, ErrErrorResponse)
+	var respErr *ResponseError
+	require.ErrorAs(t, recorder.calls[1].err, &respErr)
+	assert.Equal(t, "VALIDATION_ERROR", respErr.Response.Error)
+	assert.Equal(t, 400, respErr.Response.Code)
+
+	assert.Equal(t, "tcp_connect", recorder.calls[2].funcName)
+	assert.ErrorIs(t, recorder.calls[2].err, failErr)
+	assert.NotErrorIs(t, recorder.calls[2].err, ErrErrorResponse)
+}
+
+func TestMiddlewareOrder_Named(t *testing.T) {
+	var callOrder []string
+	tag := func(name string) Middleware {
+		return func(next ByteHandler) ByteHandler {
+			return func(ctx context.Context, payload []byte) ([]byte, error) {
+				callOrder = append(callOrder, name)
+				return next(ctx, payload)
+			}
+		}
+	}
+	handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+		callOrder = append(callOrder, "handler")
+		return nil, nil
+	}
+
+	tests := []struct {
+		name string
+		opts []RegistryOption
+		want []string
+	}{
+		{
+			name: "NamedKeepsFIFO",
+			opts: []RegistryOption{
+				WithMiddlewareNamed("panic", tag("panic")),
+				WithMiddleware(tag("anon")),
+				WithMiddlewareNamed("capability", tag("capability")),
+			},
+			want: []string{"panic", "anon", "capability", "handler"},
+		},
+		{
+			name: "BeforeFirst",
+			opts: []RegistryOption{
+				WithMiddlewareNamed("capability", tag("capability")),
+				WithMiddlewareBefore("capability", "panic", tag("panic")),
+			},
+			want: []string{"panic", "capability", "handler"},
+		},
+		{
+			name: "AfterLast",
+			opts: []RegistryOption{
+				WithMiddlewareNamed("panic", tag("panic")),
+				WithMiddlewareAfter("panic", "capability", tag("capability")),
+			},
+			want: []string{"panic", "capability", "handler"},
+		},
+		{
+			name: "InsertBetween",
+			opts: []RegistryOption{
+				WithMiddlewareNamed("panic", tag("panic")),
+				WithMiddlewareNamed("http", tag("http")),
+				WithMiddlewareAfter("panic", "capability", tag("capability")),
+				WithMiddlewareBefore("http", "logging", tag("logging")),
+			},
+			want: []string{"panic", "capability", "logging", "http", "handler"},
+		},
+		{
+			name: "AppendAfterInsert",
+			opts: []RegistryOption{
+				WithMiddlewareNamed("http", tag("http")),
+				WithMiddlewareBefore("http", "panic", tag("panic")),
+				WithMiddleware(tag("anon")),
+			},
+			want: []string{"panic", "http", "anon", "handler"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callOrder = nil
+			reg, err := NewRegistry(append(tt.opts, WithByteHandler("test", handler))...)
+			require.NoError(t, err)
+
+			_, err = reg.Invoke(context.Background(), "test", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, callOrder)
+		})
+	}
+}
+
+func TestMiddlewareOrder_NamedErrors(t *testing.T) {
+	noop := func(next ByteHandler) ByteHandler { return next }
+
+	_, err := NewRegistry(WithMiddlewareBefore("capability", "panic", noop))
+	assert.ErrorContains(t, err, `unknown middleware "capability"`)
+
+	_, err = NewRegistry(WithMiddlewareAfter("", "panic", noop))
+	assert.ErrorContains(t, err, `unknown middleware ""`)
+
+	_, err = NewRegistry(
+		WithMiddlewareNamed("panic", noop),
+		WithMiddlewareAfter("panic", "panic", noop),
+	)
+	assert.ErrorContains(t, err, `duplicate middleware name: "panic"`)
+
+	_, err = NewRegistry(WithMiddlewareNamed("", noop))
+	assert.ErrorContains(t, err, "middleware name cannot be empty")
+}
@@ -0,0 +1,153 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy configures Retry. RetryTransport retries single HTTP requests;
+// a RetryPolicy retries a whole operation such as a registry pull, where a
+// failure part-way through must restart from the beginning.
+type RetryPolicy struct {
+	// Retryable reports whether err is worth another attempt.
+	// Default: every error except context cancellation and SSRF blocks.
+	// Errors wrapped with Permanent are never retried.
+	Retryable func(err error) bool
+
+	// OnRetry is called before each retry attempt.
+	// The callback receives the attempt number (1-based), the wait duration
+	// and the error that triggered the retry.
+	OnRetry func(attempt int, waitDuration time.Duration, err error)
+
+	// MaxAttempts is the total number of attempts, including the first.
+	// Default: 3 if zero. Use 1 to disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry; it doubles after
+	// each attempt.
+	// Default: 500ms if zero.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts.
+	// Default: 30s if zero.
+	MaxBackoff time.Duration
+
+	// Jitter randomizes each wait by up to this fraction of it, in [0, 1],
+	// so clients retrying the same failure do not stay in lockstep.
+	// Default: no jitter.
+	Jitter float64
+}
+
+// RetryExhaustedError is returned by Retry when every attempt failed.
+type RetryExhaustedError struct {
+	// Err is the error from the final attempt.
+	Err error
+
+	// Attempts is the number of attempts made.
+	Attempts int
+}
+
+func (e *RetryExhaustedError) Error() string {
+	return fmt.Sprintf("giving up after %d attempts: %v", e.Attempts, e.Err)
+}
+
+// Unwrap returns the final attempt's error.
+func (e *RetryExhaustedError) Unwrap() error {
+	return e.Err
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, whatever the policy's Retryable
+// says. The returned error unwraps to err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// Retry calls op until it succeeds, returns a non-retryable error, the
+// policy's attempts run out, or ctx is done. A non-retryable error is
+// returned as is; running out of attempts yields a *RetryExhaustedError.
+// Waiting between attempts stops early when ctx is cancelled, returning the
+// context's error joined with the last failure.
+func Retry(ctx context.Context, policy RetryPolicy, op func(ctx context.Context) error) error {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 3
+	}
+
+	initialBackoff := policy.InitialBackoff
+	if initialBackoff == 0 {
+		initialBackoff = 500 * time.Millisecond
+	}
+
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff == 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = defaultRetryable
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(ctx); err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) || !retryable(err) {
+			return err
+		}
+		if attempt >= maxAttempts {
+			return &RetryExhaustedError{Attempts: attempt, Err: err}
+		}
+
+		wait := policy.backoff(attempt, initialBackoff, maxBackoff)
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, wait, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the wait after the given (1-based) failed attempt.
+func (p RetryPolicy) backoff(attempt int, initial, maxDuration time.Duration) time.Duration {
+	wait := initial
+	for i := 1; i < attempt && wait < maxDuration; i++ {
+		wait *= 2
+	}
+	wait = min(wait, maxDuration)
+
+	if p.Jitter > 0 {
+		jitter := min(p.Jitter, 1)
+		delta := time.Duration(float64(wait) * jitter * (2*rand.Float64() - 1))
+		wait += delta
+	}
+	return max(wait, 0)
+}
+
+func defaultRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	return !IsSSRFBlockedError(err)
+}
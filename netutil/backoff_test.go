@@ -0,0 +1,116 @@
+package netutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+)
+
+// flakyOp fails the first failures calls with errTransient.
+type flakyOp struct {
+	failures int
+	calls    int
+}
+
+var errTransient = errors.New("connection reset")
+
+func (f *flakyOp) run(context.Context) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errTransient
+	}
+	return nil
+}
+
+func Test_Retry_SucceedsAfterFailures(t *testing.T) {
+	op := &flakyOp{failures: 2}
+	var waits []time.Duration
+	policy := netutil.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		OnRetry: func(attempt int, wait time.Duration, err error) {
+			assert.ErrorIs(t, err, errTransient)
+			waits = append(waits, wait)
+		},
+	}
+
+	require.NoError(t, netutil.Retry(context.Background(), policy, op.run))
+	assert.Equal(t, 3, op.calls)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond}, waits)
+}
+
+func Test_Retry_ExhaustsAttempts(t *testing.T) {
+	op := &flakyOp{failures: 10}
+	policy := netutil.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	err := netutil.Retry(context.Background(), policy, op.run)
+	var exhausted *netutil.RetryExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, 3, exhausted.Attempts)
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 3, op.calls)
+}
+
+func Test_Retry_PermanentAndNonRetryable(t *testing.T) {
+	calls := 0
+	permanent := errors.New("not found")
+	err := netutil.Retry(context.Background(), netutil.RetryPolicy{InitialBackoff: time.Millisecond},
+		func(context.Context) error {
+			calls++
+			return netutil.Permanent(permanent)
+		})
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	policy := netutil.RetryPolicy{
+		InitialBackoff: time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, permanent) },
+	}
+	err = netutil.Retry(context.Background(), policy, func(context.Context) error {
+		calls++
+		return permanent
+	})
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_Retry_ContextCancelledDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &flakyOp{failures: 10}
+	policy := netutil.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Hour,
+		OnRetry:        func(int, time.Duration, error) { cancel() },
+	}
+
+	start := time.Now()
+	err := netutil.Retry(ctx, policy, op.run)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, err, errTransient)
+	assert.Equal(t, 1, op.calls)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func Test_Retry_JitterStaysInBounds(t *testing.T) {
+	policy := netutil.RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 100 * time.Microsecond,
+		MaxBackoff:     200 * time.Microsecond,
+		Jitter:         0.5,
+		OnRetry: func(attempt int, wait time.Duration, err error) {
+			base := min(100*time.Microsecond<<(attempt-1), 200*time.Microsecond)
+			assert.GreaterOrEqual(t, wait, base/2)
+			assert.LessOrEqual(t, wait, base+base/2)
+		},
+	}
+	op := &flakyOp{failures: 10}
+	_ = netutil.Retry(context.Background(), policy, op.run)
+	assert.Equal(t, 4, op.calls)
+}
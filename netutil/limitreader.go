@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"strings"
 )
 
 // LimitedReader wraps an io.Reader with a maximum size limit.
@@ -94,3 +97,50 @@ func FormatSize(bytes int64) string {
 		return fmt.Sprintf("%d bytes", bytes)
 	}
 }
+
+// sizeUnits maps lowercase ParseSize suffixes to their multipliers.
+var sizeUnits = map[string]float64{
+	"":      1,
+	"b":     1,
+	"bytes": 1,
+	"kb":    1e3,
+	"mb":    1e6,
+	"gb":    1e9,
+	"kib":   1 << 10,
+	"mib":   1 << 20,
+	"gib":   1 << 30,
+}
+
+// ParseSize parses a human-readable size such as "512", "10MB", "1.5 GiB"
+// or "64 KiB" into bytes. A bare number is bytes. KB, MB and GB are decimal
+// (powers of 1000) and KiB, MiB and GiB binary (powers of 1024); units are
+// case-insensitive and may be separated from the number by spaces. Note that
+// FormatSize labels binary multiples as KB/MB/GB, so "1.0 KB" from
+// FormatSize is 1000 bytes here, not 1024.
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	i := strings.IndexFunc(trimmed, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+	})
+	if i < 0 {
+		i = len(trimmed)
+	}
+	number, unit := trimmed[:i], strings.ToLower(strings.TrimSpace(trimmed[i:]))
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, trimmed[i:])
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: not a number", s)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("invalid size %q: negative", s)
+	}
+	size := math.Round(value * multiplier)
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid size %q: too large", s)
+	}
+	return int64(size), nil
+}
@@ -143,3 +143,47 @@ func Test_FormatSize(t *testing.T) {
 		}
 	}
 }
+
+func Test_ParseSize(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"512B", 512},
+		{"512 bytes", 512},
+		{"10KB", 10_000},
+		{"10MB", 10_000_000},
+		{"2GB", 2_000_000_000},
+		{"1.5KB", 1500},
+		{"64KiB", 64 << 10},
+		{"10MiB", 10 << 20},
+		{"1GiB", 1 << 30},
+		{"1.5 MiB", 3 << 19},
+		{"  10 mb  ", 10_000_000},
+		{"4kib", 4 << 10},
+	}
+	for _, tt := range tests {
+		got, err := netutil.ParseSize(tt.input)
+		if err != nil {
+			t.Errorf("ParseSize(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func Test_ParseSize_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{"", "MB", "10XB", "10 M B", "-5MB", "-1", "1..5KB", "10TB", "1e30GB"} {
+		if got, err := netutil.ParseSize(input); err == nil {
+			t.Errorf("ParseSize(%q) = %d, want error", input, got)
+		}
+	}
+}
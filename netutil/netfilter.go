@@ -5,11 +5,52 @@ import (
 	"strings"
 )
 
+// SSRFCategory classifies why an address was blocked, for programmatic
+// handling without matching on Reason strings.
+type SSRFCategory string
+
+const (
+	// SSRFCategoryPrivate is an RFC 1918 / unique-local private address.
+	SSRFCategoryPrivate SSRFCategory = "private"
+	// SSRFCategoryLoopback is a localhost/loopback address.
+	SSRFCategoryLoopback SSRFCategory = "loopback"
+	// SSRFCategoryLinkLocal is a link-local address.
+	SSRFCategoryLinkLocal SSRFCategory = "link_local"
+	// SSRFCategoryMetadata is a cloud instance metadata endpoint.
+	SSRFCategoryMetadata SSRFCategory = "metadata"
+	// SSRFCategoryDenylistedCIDR is an address matched by the blocklist.
+	SSRFCategoryDenylistedCIDR SSRFCategory = "denylisted_cidr"
+	// SSRFCategoryDefaultDeny covers every other block: multicast and
+	// unspecified addresses, port restrictions, unparsable addresses and
+	// DNS failures.
+	SSRFCategoryDefaultDeny SSRFCategory = "default_deny"
+)
+
+// metadataIPs are well-known cloud instance metadata endpoints.
+var metadataIPs = []net.IP{
+	net.ParseIP("169.254.169.254"), // AWS, GCP, Azure, OpenStack
+	net.ParseIP("169.254.170.2"),   // AWS ECS task metadata
+	net.ParseIP("fd00:ec2::254"),   // AWS IPv6
+	net.ParseIP("100.100.100.200"), // Alibaba Cloud
+}
+
+func isMetadataIP(ip net.IP) bool {
+	for _, m := range metadataIPs {
+		if m.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 // NetfilterResult represents the result of an address validation.
 type NetfilterResult struct {
 	// Reason provides the reason if the address was blocked.
 	Reason string `json:"reason,omitempty"`
 
+	// Category classifies the block; empty when the address is allowed.
+	Category SSRFCategory `json:"category,omitempty"`
+
 	// ResolvedIP is the resolved IP address if DNS resolution was performed.
 	ResolvedIP string `json:"resolved_ip,omitempty"`
 
@@ -28,6 +69,7 @@ type netfilterConfig struct {
 	blockPrivate   bool     // Block RFC 1918 private addresses
 	blockLocalhost bool     // Block localhost/loopback
 	blockLinkLocal bool     // Block link-local addresses
+	blockMetadata  bool     // Block cloud instance metadata endpoints
 	blockMulticast bool     // Block multicast addresses
 	resolveDNS     bool     // Resolve hostnames before checking
 }
@@ -41,6 +83,7 @@ func defaultNetfilterConfig() netfilterConfig {
 		blockPrivate:   true, // Block RFC 1918 (10.x, 172.16.x, 192.168.x)
 		blockLocalhost: true, // Block 127.x, ::1
 		blockLinkLocal: true, // Block 169.254.x, fe80::
+		blockMetadata:  true, // Block metadataIPs, whatever range they fall in
 		blockMulticast: true, // Block 224.x-239.x, ff00::
 		resolveDNS:     true, // Resolve hostnames to prevent DNS rebinding
 		allowedPorts:   nil,
@@ -85,6 +128,15 @@ func WithBlockLinkLocal(block bool) NetfilterOption {
 	}
 }
 
+// WithBlockMetadata enables/disables blocking of well-known cloud metadata
+// endpoints. They are blocked independently of the range they fall in, so
+// allowing private or link-local addresses does not expose them.
+func WithBlockMetadata(block bool) NetfilterOption {
+	return func(c *netfilterConfig) {
+		c.blockMetadata = block
+	}
+}
+
 // WithResolveDNS enables/disables DNS resolution before checking.
 func WithResolveDNS(resolve bool) NetfilterOption {
 	return func(c *netfilterConfig) {
@@ -127,8 +179,9 @@ func ValidateAddress(address string, opts ...NetfilterOption) NetfilterResult {
 	host, port, err := parseAddress(address)
 	if err != nil {
 		return NetfilterResult{
-			Allowed: false,
-			Reason:  "invalid address format: " + err.Error(),
+			Allowed:  false,
+			Reason:   "invalid address format: " + err.Error(),
+			Category: SSRFCategoryDefaultDeny,
 		}
 	}
 
@@ -160,8 +213,9 @@ func checkPortRestrictions(port int, cfg netfilterConfig) NetfilterResult {
 		}
 		if !allowed {
 			return NetfilterResult{
-				Allowed: false,
-				Reason:  "port not in allowlist",
+				Allowed:  false,
+				Reason:   "port not in allowlist",
+				Category: SSRFCategoryDefaultDeny,
 			}
 		}
 	}
@@ -170,8 +224,9 @@ func checkPortRestrictions(port int, cfg netfilterConfig) NetfilterResult {
 	for _, p := range cfg.blockedPorts {
 		if p == port {
 			return NetfilterResult{
-				Allowed: false,
-				Reason:  "port is blocked",
+				Allowed:  false,
+				Reason:   "port is blocked",
+				Category: SSRFCategoryDefaultDeny,
 			}
 		}
 	}
@@ -197,8 +252,9 @@ func checkHostLists(host string, cfg netfilterConfig) NetfilterResult {
 	for _, blocked := range cfg.blocklist {
 		if matchesPattern(host, blocked) {
 			return NetfilterResult{
-				Allowed: false,
-				Reason:  "address in blocklist",
+				Allowed:  false,
+				Reason:   "address in blocklist",
+				Category: SSRFCategoryDenylistedCIDR,
 			}
 		}
 	}
@@ -216,8 +272,9 @@ func resolveAndValidateIP(host string, cfg netfilterConfig) NetfilterResult {
 			ip = net.ParseIP(host)
 			if ip == nil {
 				return NetfilterResult{
-					Allowed: false,
-					Reason:  "DNS resolution failed: " + err.Error(),
+					Allowed:  false,
+					Reason:   "DNS resolution failed: " + err.Error(),
+					Category: SSRFCategoryDefaultDeny,
 				}
 			}
 		} else if len(ips) > 0 {
@@ -251,8 +308,9 @@ func validateIP(ip net.IP, cfg netfilterConfig) NetfilterResult {
 		_, cidr, err := net.ParseCIDR(blocked)
 		if err == nil && cidr.Contains(ip) {
 			return NetfilterResult{
-				Allowed: false,
-				Reason:  "IP in blocklist CIDR",
+				Allowed:  false,
+				Reason:   "IP in blocklist CIDR",
+				Category: SSRFCategoryDenylistedCIDR,
 			}
 		}
 	}
@@ -286,40 +344,55 @@ func checkIPSecurityRestrictions(ip net.IP, cfg netfilterConfig) NetfilterResult
 	// Check localhost/loopback
 	if cfg.blockLocalhost && ip.IsLoopback() {
 		return NetfilterResult{
-			Allowed: false,
-			Reason:  "localhost/loopback addresses blocked",
+			Allowed:  false,
+			Reason:   "localhost/loopback addresses blocked",
+			Category: SSRFCategoryLoopback,
+		}
+	}
+
+	// Check cloud metadata endpoints, some of which (100.100.100.200) are
+	// neither private nor link-local
+	if cfg.blockMetadata && isMetadataIP(ip) {
+		return NetfilterResult{
+			Allowed:  false,
+			Reason:   "cloud metadata endpoint blocked",
+			Category: SSRFCategoryMetadata,
 		}
 	}
 
 	// Check private addresses (RFC 1918)
 	if cfg.blockPrivate && ip.IsPrivate() {
 		return NetfilterResult{
-			Allowed: false,
-			Reason:  "private addresses blocked (RFC 1918)",
+			Allowed:  false,
+			Reason:   "private addresses blocked (RFC 1918)",
+			Category: SSRFCategoryPrivate,
 		}
 	}
 
 	// Check link-local
 	if cfg.blockLinkLocal && ip.IsLinkLocalUnicast() {
 		return NetfilterResult{
-			Allowed: false,
-			Reason:  "link-local addresses blocked",
+			Allowed:  false,
+			Reason:   "link-local addresses blocked",
+			Category: SSRFCategoryLinkLocal,
 		}
 	}
 
 	// Check multicast
 	if cfg.blockMulticast && ip.IsMulticast() {
 		return NetfilterResult{
-			Allowed: false,
-			Reason:  "multicast addresses blocked",
+			Allowed:  false,
+			Reason:   "multicast addresses blocked",
+			Category: SSRFCategoryDefaultDeny,
 		}
 	}
 
 	// Check unspecified (0.0.0.0, ::)
 	if ip.IsUnspecified() {
 		return NetfilterResult{
-			Allowed: false,
-			Reason:  "unspecified address blocked",
+			Allowed:  false,
+			Reason:   "unspecified address blocked",
+			Category: SSRFCategoryDefaultDeny,
 		}
 	}
 
@@ -282,3 +282,50 @@ func TestValidateIP_BlocklistCIDR(t *testing.T) {
 	result := validateIP(net.ParseIP("8.8.8.8"), cfg)
 	assert.False(t, result.Allowed)
 }
+
+func TestValidateAddress_Category(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		opts    []NetfilterOption
+		want    SSRFCategory
+	}{
+		{"loopback", "127.0.0.1:80", nil, SSRFCategoryLoopback},
+		{"loopback ipv6", "::1", nil, SSRFCategoryLoopback},
+		{"private", "10.1.2.3:443", nil, SSRFCategoryPrivate},
+		{"private ipv6 unique local", "fd12::1", nil, SSRFCategoryPrivate},
+		{"link-local", "169.254.1.1", nil, SSRFCategoryLinkLocal},
+		{"metadata", "169.254.169.254:80", nil, SSRFCategoryMetadata},
+		{"metadata ipv6", "[fd00:ec2::254]:80", nil, SSRFCategoryMetadata},
+		{"metadata alibaba", "100.100.100.200:80", nil, SSRFCategoryMetadata},
+		{"metadata with private allowed", "100.100.100.200", []NetfilterOption{WithBlockPrivate(false), WithBlockLinkLocal(false)}, SSRFCategoryMetadata},
+		{"blocklist cidr", "8.8.8.8", []NetfilterOption{WithBlocklist("8.8.8.0/24")}, SSRFCategoryDenylistedCIDR},
+		{"blocklist host", "evil.example.com", []NetfilterOption{WithBlocklist("*.example.com")}, SSRFCategoryDenylistedCIDR},
+		{"multicast", "224.0.0.1", nil, SSRFCategoryDefaultDeny},
+		{"unspecified", "0.0.0.0", nil, SSRFCategoryDefaultDeny},
+		{"port not allowed", "8.8.8.8:22", []NetfilterOption{WithAllowedPorts(443)}, SSRFCategoryDefaultDeny},
+		{"port blocked", "8.8.8.8:25", []NetfilterOption{WithBlockedPorts(25)}, SSRFCategoryDefaultDeny},
+		{"invalid port", "example.com:http", nil, SSRFCategoryDefaultDeny},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]NetfilterOption{WithResolveDNS(false)}, tc.opts...)
+			result := ValidateAddress(tc.address, opts...)
+			assert.False(t, result.Allowed, "should block %s", tc.address)
+			assert.Equal(t, tc.want, result.Category)
+			assert.NotEmpty(t, result.Reason)
+		})
+	}
+
+	t.Run("allowed has no category", func(t *testing.T) {
+		result := ValidateAddress("8.8.8.8:443", WithResolveDNS(false))
+		assert.True(t, result.Allowed)
+		assert.Empty(t, result.Category)
+	})
+
+	t.Run("metadata blocking can be disabled", func(t *testing.T) {
+		result := ValidateAddress("100.100.100.200:80", WithResolveDNS(false), WithBlockMetadata(false))
+		assert.True(t, result.Allowed)
+	})
+}
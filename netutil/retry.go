@@ -8,6 +8,7 @@ import (
 
 // RetryTransport wraps an http.RoundTripper with retry logic.
 // It implements exponential backoff and respects Retry-After headers.
+// Requests whose body cannot be recreated (no GetBody) are sent once.
 type RetryTransport struct {
 	// Base is the underlying transport.
 	// Default: http.DefaultTransport if nil.
@@ -52,6 +53,12 @@ func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 		maxBackoff = 30 * time.Second
 	}
 
+	// A body that cannot be rewound has been consumed by the first attempt,
+	// so resending it would send an empty or truncated request.
+	if !canReplay(req) {
+		return base.RoundTrip(req)
+	}
+
 	var lastErr error
 	var lastResp *http.Response
 
@@ -115,6 +122,12 @@ func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return nil, lastErr
 }
 
+// canReplay reports whether req can be sent again: it has no body, or its
+// body can be recreated with GetBody.
+func canReplay(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
 // calculateBackoff determines the wait duration for the given attempt.
 // It respects Retry-After headers when present.
 func (t *RetryTransport) calculateBackoff(attempt int, initial, maxDuration time.Duration, resp *http.Response) time.Duration {
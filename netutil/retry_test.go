@@ -119,6 +119,40 @@ func Test_RetryTransport_Retries5xx(t *testing.T) {
 	}
 }
 
+func Test_RetryTransport_RequestBodies(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  func() io.Reader
+		calls int
+	}{
+		{"rewindable body is retried", func() io.Reader { return strings.NewReader("payload") }, 2},
+		{"one-shot body is sent once", func() io.Reader { return io.NopCloser(strings.NewReader("payload")) }, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockTransport{
+				responses: []*http.Response{
+					{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader("")), Header: http.Header{}},
+					{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))},
+				},
+			}
+			transport := &netutil.RetryTransport{
+				Base:           mock,
+				MaxRetries:     3,
+				InitialBackoff: time.Millisecond,
+			}
+
+			req, _ := http.NewRequest("PUT", "http://example.com", tt.body())
+			resp, err := transport.RoundTrip(req)
+
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.calls, mock.calls)
+		})
+	}
+}
+
 func Test_RetryTransport_NoRetryOn4xx(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -28,6 +29,11 @@ type SecureDialer struct {
 	// CacheTTL is the duration to cache resolved IPs. Default: 5min.
 	CacheTTL time.Duration
 
+	// NegativeCacheTTL is the duration to cache failed lookups (no such
+	// host, DNS timeouts), so repeated dials to a dead host fail fast with
+	// the cached error. Keep it shorter than CacheTTL. Default: 0, disabled.
+	NegativeCacheTTL time.Duration
+
 	// AllowPrivateNetwork allows connections to private/localhost addresses.
 	// When true, maps to WithBlockPrivate(false) and WithBlockLocalhost(false).
 	AllowPrivateNetwork bool
@@ -37,8 +43,9 @@ type SecureDialer struct {
 }
 
 type pinnedEntry struct {
-	ip        net.IP
 	timestamp time.Time
+	err       error // set for negative entries
+	ip        net.IP
 }
 
 // DialContext connects to the address with DNS pinning and SSRF protection.
@@ -51,17 +58,44 @@ func (d *SecureDialer) DialContext(ctx context.Context, network, addr string) (n
 	}
 
 	// Check cache first
-	if ip, ok := d.getCached(host); ok {
-		return d.dialIP(ctx, network, ip, port)
+	if entry, ok := d.getCached(host); ok {
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return d.dialIP(ctx, network, entry.ip, port)
+	}
+
+	ip, err := d.resolveAndValidate(ctx, host, port)
+	if err != nil {
+		// A lookup cut short by the caller says nothing about the host.
+		if d.NegativeCacheTTL > 0 && ctx.Err() == nil && isNegativeLookup(err) {
+			d.cacheError(host, err)
+		}
+		return nil, err
 	}
 
+	// Cache the validated resolution
+	d.cacheIP(host, ip)
+
+	return d.dialIP(ctx, network, ip, port)
+}
+
+// resolveAndValidate resolves host once and validates the selected IP against
+// SSRF rules. extra options are applied after the dialer's own.
+func (d *SecureDialer) resolveAndValidate(ctx context.Context, host, port string, extra ...NetfilterOption) (net.IP, error) {
 	// Check if it's already an IP address
 	if ip := net.ParseIP(host); ip != nil {
-		if err := d.validateWithNetfilter(host, port); err != nil {
+		if err := d.validateWithNetfilter(host, port, extra...); err != nil {
 			return nil, err
 		}
-		d.cacheIP(host, ip)
-		return d.dialIP(ctx, network, ip, port)
+		return ip, nil
+	}
+
+	// Hostname allow and block lists only make sense before resolution;
+	// the resolved IP is checked against the CIDR entries below.
+	listed, err := d.checkHostname(host, port, extra...)
+	if err != nil {
+		return nil, err
 	}
 
 	// Resolve DNS
@@ -76,7 +110,7 @@ func (d *SecureDialer) DialContext(ctx context.Context, network, addr string) (n
 	}
 
 	if len(ips) == 0 {
-		return nil, fmt.Errorf("no IP addresses found for %q", host)
+		return nil, fmt.Errorf("%w for %q", errNoAddresses, host)
 	}
 
 	// Prefer IPv4 for compatibility
@@ -97,82 +131,114 @@ func (d *SecureDialer) DialContext(ctx context.Context, network, addr string) (n
 		d.OnDNSPinning(host, selectedIP)
 	}
 
+	// An allowlisted hostname bypasses the IP checks, as in ValidateAddress.
+	if listed {
+		return selectedIP, nil
+	}
+
 	// Validate the resolved IP using ValidateAddress (skipping DNS since we already resolved)
-	if err := d.validateResolvedIP(selectedIP); err != nil {
+	if err := d.validateResolvedIP(selectedIP, extra...); err != nil {
 		return nil, err
 	}
-
-	// Cache the validated resolution
-	d.cacheIP(host, selectedIP)
-
-	return d.dialIP(ctx, network, selectedIP, port)
+	return selectedIP, nil
 }
 
-// validateWithNetfilter validates an address using ValidateAddress.
-func (d *SecureDialer) validateWithNetfilter(host, port string) error {
+// checkHostname applies the port restrictions and the hostname allow and
+// block lists to an unresolved host. allowed reports an allowlist match.
+func (d *SecureDialer) checkHostname(host, port string, extra ...NetfilterOption) (allowed bool, err error) {
 	addr := host
 	if port != "" {
 		addr = net.JoinHostPort(host, port)
 	}
 
-	var opts []NetfilterOption
-	opts = append(opts, WithResolveDNS(false)) // We handle DNS ourselves
+	cfg := defaultNetfilterConfig()
+	for _, opt := range d.netfilterOptions(extra) {
+		opt(&cfg)
+	}
+	portNum, _ := strconv.Atoi(port)
+	result := checkPortRestrictions(portNum, cfg)
+	if result.Allowed {
+		result = checkHostLists(host, cfg)
+		if result.Allowed {
+			return true, nil
+		}
+		if result.Reason == "" {
+			return false, nil
+		}
+	}
+
+	if d.OnBlocked != nil {
+		d.OnBlocked(addr, result.Reason)
+	}
+	return false, &SSRFBlockedError{Address: addr, Reason: result.Reason, Category: result.Category}
+}
+
+// netfilterOptions returns the ValidateAddress options for this dialer.
+func (d *SecureDialer) netfilterOptions(extra []NetfilterOption) []NetfilterOption {
+	opts := []NetfilterOption{WithResolveDNS(false)} // We handle DNS ourselves
 	if d.AllowPrivateNetwork {
 		opts = append(opts, WithBlockPrivate(false), WithBlockLocalhost(false))
 	}
+	return append(opts, extra...)
+}
 
-	result := ValidateAddress(addr, opts...)
+// validateWithNetfilter validates an address using ValidateAddress.
+func (d *SecureDialer) validateWithNetfilter(host, port string, extra ...NetfilterOption) error {
+	addr := host
+	if port != "" {
+		addr = net.JoinHostPort(host, port)
+	}
+
+	result := ValidateAddress(addr, d.netfilterOptions(extra)...)
 	if !result.Allowed {
 		if d.OnBlocked != nil {
 			d.OnBlocked(addr, result.Reason)
 		}
-		return &SSRFBlockedError{Address: addr, Reason: result.Reason}
+		return &SSRFBlockedError{Address: addr, Reason: result.Reason, Category: result.Category}
 	}
 	return nil
 }
 
 // validateResolvedIP validates a resolved IP using netfilter rules.
-func (d *SecureDialer) validateResolvedIP(ip net.IP) error {
-	var opts []NetfilterOption
-	opts = append(opts, WithResolveDNS(false))
-	if d.AllowPrivateNetwork {
-		opts = append(opts, WithBlockPrivate(false), WithBlockLocalhost(false))
-	}
-
-	result := ValidateAddress(ip.String(), opts...)
+func (d *SecureDialer) validateResolvedIP(ip net.IP, extra ...NetfilterOption) error {
+	result := ValidateAddress(ip.String(), d.netfilterOptions(extra)...)
 	if !result.Allowed {
 		if d.OnBlocked != nil {
 			d.OnBlocked(ip.String(), result.Reason)
 		}
-		return &SSRFBlockedError{Address: ip.String(), Reason: result.Reason}
+		return &SSRFBlockedError{Address: ip.String(), Reason: result.Reason, Category: result.Category}
 	}
 	return nil
 }
 
-// getCached returns a cached IP if it exists and hasn't expired.
-func (d *SecureDialer) getCached(host string) (net.IP, bool) {
+// getCached returns the cached entry for host if it exists and hasn't
+// expired. A negative entry carries the lookup error instead of an IP.
+func (d *SecureDialer) getCached(host string) (pinnedEntry, bool) {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
 	if d.cache == nil {
-		return nil, false
+		return pinnedEntry{}, false
 	}
 
 	entry, ok := d.cache[host]
 	if !ok {
-		return nil, false
+		return pinnedEntry{}, false
 	}
 
 	ttl := d.CacheTTL
 	if ttl == 0 {
 		ttl = 5 * time.Minute
 	}
+	if entry.err != nil {
+		ttl = d.NegativeCacheTTL
+	}
 
 	if time.Since(entry.timestamp) >= ttl {
-		return nil, false
+		return pinnedEntry{}, false
 	}
 
-	return entry.ip, true
+	return entry, true
 }
 
 // cacheIP stores a resolved IP in the cache.
@@ -190,6 +256,35 @@ func (d *SecureDialer) cacheIP(host string, ip net.IP) {
 	}
 }
 
+// cacheError stores a failed lookup in the cache. A later successful
+// resolution replaces it through cacheIP once it has expired.
+func (d *SecureDialer) cacheError(host string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.cache == nil {
+		d.cache = make(map[string]pinnedEntry)
+	}
+
+	d.cache[host] = pinnedEntry{
+		err:       err,
+		timestamp: time.Now(),
+	}
+}
+
+// errNoAddresses is returned when a lookup succeeds with no addresses.
+var errNoAddresses = errors.New("no IP addresses found")
+
+// isNegativeLookup reports whether err means the host does not resolve, as
+// opposed to an SSRF block or a dial failure.
+func isNegativeLookup(err error) bool {
+	if errors.Is(err, errNoAddresses) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && (dnsErr.IsNotFound || dnsErr.IsTimeout)
+}
+
 // dialIP connects to the specified IP and port.
 func (d *SecureDialer) dialIP(ctx context.Context, network string, ip net.IP, port string) (net.Conn, error) {
 	timeout := d.Timeout
@@ -206,9 +301,11 @@ func (d *SecureDialer) dialIP(ctx context.Context, network string, ip net.IP, po
 }
 
 // SSRFBlockedError is returned when SSRF protection blocks a connection.
+// Reason is human-readable; Category is the stable classification to branch on.
 type SSRFBlockedError struct {
-	Address string
-	Reason  string
+	Address  string
+	Reason   string
+	Category SSRFCategory
 }
 
 func (e *SSRFBlockedError) Error() string {
@@ -220,3 +317,12 @@ func IsSSRFBlockedError(err error) bool {
 	var ssrfErr *SSRFBlockedError
 	return errors.As(err, &ssrfErr)
 }
+
+// SSRFBlockedCategory returns the category of an SSRFBlockedError in err's chain.
+func SSRFBlockedCategory(err error) (SSRFCategory, bool) {
+	var ssrfErr *SSRFBlockedError
+	if !errors.As(err, &ssrfErr) {
+		return "", false
+	}
+	return ssrfErr.Category, true
+}
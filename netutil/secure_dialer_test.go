@@ -2,8 +2,13 @@ package netutil_test
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
 	"net"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -90,3 +95,183 @@ func Test_SSRFBlockedError(t *testing.T) {
 	assert.False(t, netutil.IsSSRFBlockedError(nil))
 	assert.False(t, netutil.IsSSRFBlockedError(assert.AnError))
 }
+
+func Test_SecureDialer_BlockedErrorCategory(t *testing.T) {
+	tests := []struct {
+		addr string
+		want netutil.SSRFCategory
+	}{
+		{"10.0.0.1:80", netutil.SSRFCategoryPrivate},
+		{"127.0.0.1:80", netutil.SSRFCategoryLoopback},
+		{"169.254.169.254:80", netutil.SSRFCategoryMetadata},
+		{"169.254.10.10:80", netutil.SSRFCategoryLinkLocal},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.addr, func(t *testing.T) {
+			dialer := &netutil.SecureDialer{}
+			_, err := dialer.DialContext(context.Background(), "tcp", tc.addr)
+			require.Error(t, err)
+
+			category, ok := netutil.SSRFBlockedCategory(fmt.Errorf("wrapped: %w", err))
+			require.True(t, ok)
+			assert.Equal(t, tc.want, category)
+		})
+	}
+
+	_, ok := netutil.SSRFBlockedCategory(assert.AnError)
+	assert.False(t, ok)
+}
+
+// fakeDNS answers A queries with 127.0.0.1, or every query with NXDOMAIN
+// while failing is set. It speaks DNS over a stream, which the Go resolver
+// uses for connections that are not a net.PacketConn.
+type fakeDNS struct {
+	mu       sync.Mutex
+	failing  bool
+	aQueries int
+}
+
+func (f *fakeDNS) resolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go f.serve(server)
+			return client, nil
+		},
+	}
+}
+
+func (f *fakeDNS) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = failing
+}
+
+func (f *fakeDNS) queries() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.aQueries
+}
+
+func (f *fakeDNS) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	for {
+		var size [2]byte
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		msg := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, msg); err != nil {
+			return
+		}
+		resp := f.answer(msg)
+		if _, err := conn.Write(binary.BigEndian.AppendUint16(nil, uint16(len(resp)))); err != nil {
+			return
+		}
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeDNS) answer(msg []byte) []byte {
+	end := 12
+	for msg[end] != 0 {
+		end += int(msg[end]) + 1
+	}
+	end += 1 + 4 // root label, qtype, qclass
+	qtype := binary.BigEndian.Uint16(msg[end-4:])
+
+	f.mu.Lock()
+	failing := f.failing
+	if qtype == 1 {
+		f.aQueries++
+	}
+	f.mu.Unlock()
+
+	var rcode, answers byte
+	switch {
+	case failing:
+		rcode = 3 // NXDOMAIN
+	case qtype == 1:
+		answers = 1
+	}
+
+	resp := []byte{msg[0], msg[1], 0x80 | msg[2]&0x01, 0x80 | rcode, 0, 1, 0, answers, 0, 0, 0, 0}
+	resp = append(resp, msg[12:end]...)
+	if answers > 0 {
+		resp = append(resp,
+			0xc0, 0x0c, // name: pointer to the question
+			0, 1, 0, 1, // type A, class IN
+			0, 0, 0, 60, // TTL
+			0, 4, 127, 0, 0, 1,
+		)
+	}
+	return resp
+}
+
+func Test_SecureDialer_NegativeCache(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = ln.Close() }()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	addr := net.JoinHostPort("flaky.test.", port)
+
+	dns := &fakeDNS{failing: true}
+	dialer := &netutil.SecureDialer{
+		Resolver:            dns.resolver(),
+		AllowPrivateNetwork: true,
+		NegativeCacheTTL:    100 * time.Millisecond,
+	}
+	ctx := context.Background()
+
+	_, err = dialer.DialContext(ctx, "tcp", addr)
+	var dnsErr *net.DNSError
+	require.ErrorAs(t, err, &dnsErr)
+	assert.True(t, dnsErr.IsNotFound)
+	assert.Equal(t, 1, dns.queries())
+
+	// Served from the negative cache, even once the host starts resolving.
+	dns.setFailing(false)
+	_, err2 := dialer.DialContext(ctx, "tcp", addr)
+	assert.Equal(t, err, err2)
+	assert.Equal(t, 1, dns.queries())
+
+	// Once the negative entry expires, a successful lookup replaces it.
+	time.Sleep(150 * time.Millisecond)
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	require.NoError(t, err)
+	_ = conn.Close()
+	assert.Equal(t, 2, dns.queries())
+
+	dns.setFailing(true)
+	conn, err = dialer.DialContext(ctx, "tcp", addr)
+	require.NoError(t, err, "positive entry is still cached")
+	_ = conn.Close()
+	assert.Equal(t, 2, dns.queries())
+}
+
+func Test_SecureDialer_NegativeCacheDisabled(t *testing.T) {
+	dns := &fakeDNS{failing: true}
+	dialer := &netutil.SecureDialer{Resolver: dns.resolver()}
+
+	for i := 0; i < 3; i++ {
+		_, err := dialer.DialContext(context.Background(), "tcp", "flaky.test.:80")
+		var dnsErr *net.DNSError
+		require.ErrorAs(t, err, &dnsErr)
+		assert.True(t, dnsErr.IsNotFound)
+	}
+	assert.Equal(t, 3, dns.queries())
+}
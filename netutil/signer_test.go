@@ -0,0 +1,76 @@
+package netutil_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+)
+
+func newSigner() *netutil.HMACSigner {
+	return &netutil.HMACSigner{
+		KeyID:  "host-key",
+		Secret: []byte("s3cret"),
+		Now:    func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+}
+
+func newSignedRequest(t *testing.T, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/items?b=2&a=1", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	require.NoError(t, newSigner().Sign(req))
+	return req
+}
+
+func Test_HMACSigner_StableSignature(t *testing.T) {
+	req := newSignedRequest(t, `{"name":"widget"}`)
+
+	assert.Equal(t, "20260102T030405Z", req.Header.Get("X-Date"))
+	assert.Equal(t,
+		"HMAC-SHA256 Credential=host-key, SignedHeaders=content-type;host;x-date, Signature=4f0115722984174203a9a45ffb526b3c32985fe59b90700ebfa57c4e930c352f",
+		req.Header.Get("Authorization"))
+
+	// The body is still readable after signing.
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"widget"}`, string(body))
+
+	// Signing the same request again gives the same signature.
+	again := newSignedRequest(t, `{"name":"widget"}`)
+	assert.Equal(t, req.Header.Get("Authorization"), again.Header.Get("Authorization"))
+}
+
+func Test_HMACSigner_CoversRequest(t *testing.T) {
+	base := newSignedRequest(t, `{"name":"widget"}`).Header.Get("Authorization")
+
+	assert.NotEqual(t, base, newSignedRequest(t, `{"name":"gadget"}`).Header.Get("Authorization"), "body")
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/items?a=1&b=2", strings.NewReader(`{"name":"widget"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	require.NoError(t, newSigner().Sign(req))
+	assert.Equal(t, base, req.Header.Get("Authorization"), "query order does not matter")
+
+	req.Header.Set("User-Agent", "reglet/1.0")
+	require.NoError(t, newSigner().Sign(req))
+	assert.NotEqual(t, base, req.Header.Get("Authorization"), "headers")
+	assert.Contains(t, req.Header.Get("Authorization"), "SignedHeaders=content-type;host;user-agent;x-date,")
+}
+
+func Test_HMACSigner_Errors(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.example.com/", nil)
+	require.NoError(t, err)
+	assert.Error(t, (&netutil.HMACSigner{}).Sign(req), "empty secret")
+
+	req.Body = io.NopCloser(strings.NewReader("stream"))
+	assert.ErrorContains(t, newSigner().Sign(req), "cannot be re-read")
+}
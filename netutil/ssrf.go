@@ -0,0 +1,100 @@
+package netutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// errMissingHost is returned when the target names no host.
+var errMissingHost = errors.New("missing host")
+
+// CheckURLSSRF reports whether a request to rawURL would be blocked by SSRF
+// protection, without opening a connection. The host is resolved and
+// validated exactly as SecureDialer would before dialing; opts are applied on
+// top of the dialer's defaults (e.g. WithBlockPrivate(false)).
+//
+// It returns an *SSRFBlockedError if the URL would be blocked, another error
+// if the URL is malformed or the host cannot be resolved, and nil otherwise.
+func CheckURLSSRF(ctx context.Context, rawURL string, opts ...NetfilterOption) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", StripCredentials(rawURL), err)
+	}
+
+	port := parsed.Port()
+	if port == "" {
+		port = defaultPort(parsed.Scheme)
+	}
+
+	err = checkHost(ctx, parsed.Hostname(), port, opts)
+	if errors.Is(err, errMissingHost) {
+		return fmt.Errorf("invalid URL %q: %w", StripCredentials(rawURL), err)
+	}
+	return err
+}
+
+// CheckSSRF reports whether connecting to host would be blocked by SSRF
+// protection, without opening a connection. host is a hostname or IP,
+// optionally with a port ("example.com:443", "[2001:db8::1]:8443"). It
+// applies the same resolution and checks as CheckURLSSRF, with opts on top
+// of the dialer's defaults; DNS lookups are bounded by ctx. With
+// WithResolveDNS(false), hostnames are only checked against the allow and
+// block lists.
+//
+// A blocked host returns allowed false and the block category (one of the
+// SSRFCategory values) with a nil error. err is set only when the check
+// could not be made, e.g. because the host does not resolve.
+func CheckSSRF(ctx context.Context, host string, opts ...NetfilterOption) (allowed bool, category string, err error) {
+	port := ""
+	if h, p, splitErr := net.SplitHostPort(host); splitErr == nil {
+		host, port = h, p
+	} else {
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	}
+
+	err = checkHost(ctx, host, port, opts)
+	if err == nil {
+		return true, "", nil
+	}
+	var ssrfErr *SSRFBlockedError
+	if errors.As(err, &ssrfErr) {
+		return false, string(ssrfErr.Category), nil
+	}
+	return false, "", err
+}
+
+// checkHost validates host and port as SecureDialer would before dialing.
+// Hostnames are resolved unless opts disable DNS resolution, in which case
+// only the allow and block lists apply to them.
+func checkHost(ctx context.Context, host, port string, opts []NetfilterOption) error {
+	if host == "" {
+		return errMissingHost
+	}
+
+	d := &SecureDialer{}
+	cfg := defaultNetfilterConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !cfg.resolveDNS && net.ParseIP(host) == nil {
+		return d.validateWithNetfilter(host, port, opts...)
+	}
+	_, err := d.resolveAndValidate(ctx, host, port, opts...)
+	return err
+}
+
+// defaultPort returns the well-known port for scheme, or "" if unknown.
+func defaultPort(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,144 @@
+package netutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+)
+
+func Test_CheckURLSSRF_BlocksPrivateURL(t *testing.T) {
+	err := netutil.CheckURLSSRF(context.Background(), "http://10.0.0.5:8080/admin")
+	require.Error(t, err)
+
+	var ssrfErr *netutil.SSRFBlockedError
+	require.True(t, errors.As(err, &ssrfErr))
+	assert.Equal(t, netutil.SSRFCategoryPrivate, ssrfErr.Category)
+	assert.Equal(t, "10.0.0.5:8080", ssrfErr.Address)
+}
+
+func Test_CheckURLSSRF_BlocksMetadataURL(t *testing.T) {
+	err := netutil.CheckURLSSRF(context.Background(), "http://169.254.169.254/latest/meta-data/")
+	category, ok := netutil.SSRFBlockedCategory(err)
+	require.True(t, ok)
+	assert.Equal(t, netutil.SSRFCategoryMetadata, category)
+}
+
+func Test_CheckURLSSRF_AllowsPublicURL(t *testing.T) {
+	// IP literal so the test does not depend on DNS.
+	assert.NoError(t, netutil.CheckURLSSRF(context.Background(), "https://93.184.216.34/index.html"))
+}
+
+func Test_CheckURLSSRF_Options(t *testing.T) {
+	ctx := context.Background()
+	assert.NoError(t, netutil.CheckURLSSRF(ctx, "http://10.0.0.5/", netutil.WithBlockPrivate(false)))
+
+	err := netutil.CheckURLSSRF(ctx, "https://93.184.216.34/", netutil.WithBlockedPorts(443))
+	assert.True(t, netutil.IsSSRFBlockedError(err))
+}
+
+func Test_CheckURLSSRF_InvalidURL(t *testing.T) {
+	err := netutil.CheckURLSSRF(context.Background(), "/relative/path")
+	require.Error(t, err)
+	assert.False(t, netutil.IsSSRFBlockedError(err))
+	assert.Contains(t, err.Error(), "missing host")
+}
+
+func Test_CheckSSRF(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		opts     []netutil.NetfilterOption
+		allowed  bool
+		category string
+	}{
+		{name: "public IP", host: "93.184.216.34", allowed: true},
+		{name: "public IP with port", host: "93.184.216.34:443", allowed: true},
+		{name: "private IP", host: "10.0.0.5", category: string(netutil.SSRFCategoryPrivate)},
+		{name: "private IP allowed by option", host: "192.168.1.10:8080", opts: []netutil.NetfilterOption{netutil.WithBlockPrivate(false)}, allowed: true},
+		{name: "loopback", host: "127.0.0.1:80", category: string(netutil.SSRFCategoryLoopback)},
+		{name: "IPv6 loopback", host: "[::1]:8443", category: string(netutil.SSRFCategoryLoopback)},
+		{name: "bracketed IPv6 without port", host: "[::1]", category: string(netutil.SSRFCategoryLoopback)},
+		{name: "metadata", host: "169.254.169.254", category: string(netutil.SSRFCategoryMetadata)},
+		{name: "blocked port", host: "93.184.216.34:25", opts: []netutil.NetfilterOption{netutil.WithBlockedPorts(25)}},
+		{name: "hostname without resolution", host: "internal.example", opts: []netutil.NetfilterOption{netutil.WithResolveDNS(false)}, allowed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, category, err := netutil.CheckSSRF(context.Background(), tt.host, tt.opts...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.allowed, allowed)
+			if tt.category != "" {
+				assert.Equal(t, tt.category, category)
+			}
+		})
+	}
+}
+
+func Test_CheckSSRF_Unresolvable(t *testing.T) {
+	// .invalid never resolves (RFC 2606).
+	allowed, category, err := netutil.CheckSSRF(context.Background(), "does-not-exist.invalid:443")
+	require.Error(t, err)
+	assert.False(t, allowed)
+	assert.Empty(t, category)
+	assert.False(t, netutil.IsSSRFBlockedError(err))
+
+	_, _, err = netutil.CheckSSRF(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func Test_CheckSSRF_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	allowed, _, err := netutil.CheckSSRF(ctx, "example.com:443")
+	require.Error(t, err)
+	assert.False(t, allowed)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_CheckURLSSRF_MatchesCheckSSRF(t *testing.T) {
+	opts := []netutil.NetfilterOption{netutil.WithResolveDNS(false), netutil.WithBlocklist("internal.example")}
+
+	allowed, category, err := netutil.CheckSSRF(context.Background(), "internal.example:443", opts...)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.NotEmpty(t, category)
+
+	err = netutil.CheckURLSSRF(context.Background(), "https://internal.example/", opts...)
+	var ssrfErr *netutil.SSRFBlockedError
+	require.ErrorAs(t, err, &ssrfErr)
+	assert.Equal(t, category, string(ssrfErr.Category))
+}
+
+func Test_CheckSSRF_HostListsWithResolution(t *testing.T) {
+	ctx := context.Background()
+
+	// A blocked hostname is refused before it is resolved.
+	allowed, category, err := netutil.CheckSSRF(ctx, "blocked.invalid:443", netutil.WithBlocklist("*.invalid"))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, string(netutil.SSRFCategoryDenylistedCIDR), category)
+
+	err = netutil.CheckURLSSRF(ctx, "https://blocked.invalid/", netutil.WithBlocklist("blocked.invalid"))
+	assert.True(t, netutil.IsSSRFBlockedError(err))
+
+	// Port restrictions apply to hostnames too.
+	allowed, _, err = netutil.CheckSSRF(ctx, "blocked.invalid:25", netutil.WithBlockedPorts(25))
+	require.NoError(t, err)
+	assert.False(t, allowed)
+
+	// An allowlisted hostname skips the IP checks, so localhost passes.
+	allowed, _, err = netutil.CheckSSRF(ctx, "localhost:80", netutil.WithAllowlist("localhost"))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	allowed, category, err = netutil.CheckSSRF(ctx, "localhost:80")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, string(netutil.SSRFCategoryLoopback), category)
+}
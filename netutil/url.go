@@ -43,11 +43,14 @@ func NormalizeURL(rawURL string) string {
 	parsed.Scheme = strings.ToLower(parsed.Scheme)
 	parsed.Host = strings.ToLower(parsed.Host)
 
-	// Remove default ports
+	// Remove default ports, keeping the brackets around IPv6 literals
 	host := parsed.Hostname()
 	port := parsed.Port()
 	if (parsed.Scheme == "https" && port == "443") ||
 		(parsed.Scheme == "http" && port == "80") {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
 		parsed.Host = host
 	}
 
@@ -65,7 +68,9 @@ func NormalizeURL(rawURL string) string {
 	return parsed.String()
 }
 
-// ExtractHost returns just the host:port from a URL.
+// ExtractHost returns just the host:port from a URL. IPv6 literals keep
+// their brackets ("[2001:db8::1]:8443"), so the result can be passed to
+// net.SplitHostPort.
 func ExtractHost(rawURL string) string {
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
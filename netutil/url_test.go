@@ -91,6 +91,21 @@ func Test_NormalizeURL(t *testing.T) {
 			input: "https://example.com/path?b=2&a=1",
 			want:  "https://example.com/path?a=1&b=2",
 		},
+		{
+			name:  "IPv6 keeps non-default port",
+			input: "https://[2001:DB8::1]:8443/path",
+			want:  "https://[2001:db8::1]:8443/path",
+		},
+		{
+			name:  "IPv6 removes default port",
+			input: "https://[2001:db8::1]:443/path",
+			want:  "https://[2001:db8::1]/path",
+		},
+		{
+			name:  "IPv6 without port",
+			input: "http://[::1]/",
+			want:  "http://[::1]/",
+		},
 	}
 
 	for _, tt := range tests {
@@ -104,6 +119,8 @@ func Test_ExtractHost(t *testing.T) {
 	assert.Equal(t, "example.com", netutil.ExtractHost("https://example.com/path"))
 	assert.Equal(t, "example.com:8443", netutil.ExtractHost("https://example.com:8443/path"))
 	assert.Equal(t, "", netutil.ExtractHost("invalid"))
+	assert.Equal(t, "[2001:db8::1]:8443", netutil.ExtractHost("https://[2001:db8::1]:8443/"))
+	assert.Equal(t, "[2001:db8::1]", netutil.ExtractHost("https://[2001:db8::1]/"))
 }
 
 func Test_IsHTTPS(t *testing.T) {
@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// CacheVerificationResult is the outcome of checking one cached plugin.
+type CacheVerificationResult struct {
+	// Plugin is the cached plugin as listed by the repository.
+	Plugin *entities.Plugin
+
+	// Signature is the verification result when a signature was checked.
+	Signature *ports.SignatureResult
+
+	// Err explains why the plugin failed verification; nil when it passed.
+	// Digest mismatches are *entities.CacheIntegrityError.
+	Err error
+
+	// Actual is the digest computed from the cached binary. It is the zero
+	// value when the binary could not be read.
+	Actual values.Digest
+}
+
+// OK reports whether the plugin passed verification.
+func (r CacheVerificationResult) OK() bool {
+	return r.Err == nil
+}
+
+// VerifyCache checks every cached plugin against its stored digest and,
+// where the integrity service requires a signature for the plugin's
+// registry, its signature. It returns one result per plugin; the error is
+// only set when the cache could not be listed.
+func (s *PluginService) VerifyCache(ctx context.Context) ([]CacheVerificationResult, error) {
+	plugins, err := s.ListCachedPlugins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list cached plugins: %w", err)
+	}
+
+	results := make([]CacheVerificationResult, 0, len(plugins))
+	for _, p := range plugins {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, s.verifyCached(ctx, p))
+	}
+	return results, nil
+}
+
+func (s *PluginService) verifyCached(ctx context.Context, p *entities.Plugin) CacheVerificationResult {
+	result := CacheVerificationResult{Plugin: p}
+	ref := p.Reference()
+
+	wasm, path, err := s.openCached(ctx, ref)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer func() { _ = wasm.Close() }()
+
+	result.Actual, err = digestReader(p.Digest(), wasm)
+	if err != nil {
+		result.Err = fmt.Errorf("hash %s: %w", ref.String(), err)
+		return result
+	}
+	if !result.Actual.Equals(p.Digest()) {
+		result.Err = &entities.CacheIntegrityError{
+			Reference: ref,
+			Path:      path,
+			Expected:  p.Digest(),
+			Actual:    result.Actual,
+		}
+		return result
+	}
+
+	if s.integrityVerifier != nil && s.integrityService.RequiresSignature(ref) {
+		result.Signature, err = s.integrityVerifier.VerifySignature(ctx, ref)
+		if err != nil {
+			result.Err = fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+	return result
+}
+
+// openCached opens a cached plugin binary, reading it from the repository
+// directly when the repository supports that.
+func (s *PluginService) openCached(ctx context.Context, ref values.PluginReference) (io.ReadCloser, string, error) {
+	_, path, err := s.repository.Find(ctx, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("locate %s: %w", ref.String(), err)
+	}
+	wasm, err := s.openBinary(ctx, ref, path)
+	return wasm, path, err
+}
+
+// openBinary opens the binary the repository reported at path.
+func (s *PluginService) openBinary(ctx context.Context, ref values.PluginReference, path string) (io.ReadCloser, error) {
+	if reader, ok := s.repository.(ports.PluginBinaryReader); ok {
+		data, err := reader.ReadWASM(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", ref.String(), err)
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", ref.String(), err)
+	}
+	return f, nil
+}
+
+// verifyOnDisk hashes the binary at path and compares it with expected.
+func (s *PluginService) verifyOnDisk(ctx context.Context, ref values.PluginReference, path string, expected values.Digest) error {
+	wasm, err := s.openBinary(ctx, ref, path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = wasm.Close() }()
+
+	actual, err := digestReader(expected, wasm)
+	if err != nil {
+		return fmt.Errorf("hash %s: %w", ref.String(), err)
+	}
+	if !actual.Equals(expected) {
+		return &entities.CacheIntegrityError{
+			Reference: ref,
+			Path:      path,
+			Expected:  expected,
+			Actual:    actual,
+		}
+	}
+	return nil
+}
+
+// digestReader streams r through the hash of expected's algorithm and
+// returns the resulting digest.
+func digestReader(expected values.Digest, r io.Reader) (values.Digest, error) {
+	h, err := expected.Hasher()
+	if err != nil {
+		return values.Digest{}, err
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return values.Digest{}, err
+	}
+	return values.NewDigest(expected.Algorithm(), hex.EncodeToString(h.Sum(nil)))
+}
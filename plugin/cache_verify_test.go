@@ -0,0 +1,84 @@
+package plugin_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/repository"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/services"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+func cachePlugin(t *testing.T, repo *repository.FSPluginRepository, name string, wasm []byte) string {
+	t.Helper()
+	ref := values.NewPluginReference("reg", "org", "repo", name, "1.0.0")
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(wasm))
+	require.NoError(t, err)
+	path, err := repo.Store(context.Background(),
+		entities.NewPlugin(ref, digest, values.NewPluginMetadata(name, "1.0.0", "", nil)), bytes.NewReader(wasm))
+	require.NoError(t, err)
+	return path
+}
+
+func TestPluginService_VerifyCache(t *testing.T) {
+	ctx := context.Background()
+	repo, err := repository.NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+
+	cachePlugin(t, repo, "good", []byte("\x00asm good"))
+	badPath := cachePlugin(t, repo, "bad", []byte("\x00asm bad"))
+	require.NoError(t, os.WriteFile(badPath, []byte("\x00asm tampered"), 0o600))
+
+	byName := func(results []plugin.CacheVerificationResult) map[string]plugin.CacheVerificationResult {
+		m := make(map[string]plugin.CacheVerificationResult)
+		for _, r := range results {
+			m[r.Plugin.Reference().Name()] = r
+		}
+		return m
+	}
+
+	t.Run("DigestsOnly", func(t *testing.T) {
+		svc := plugin.NewPluginService(repo, nil)
+		results, err := svc.VerifyCache(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		got := byName(results)
+		assert.True(t, got["good"].OK())
+		assert.Nil(t, got["good"].Signature)
+
+		bad := got["bad"]
+		assert.False(t, bad.OK())
+		var integrityErr *entities.CacheIntegrityError
+		require.ErrorAs(t, bad.Err, &integrityErr)
+		assert.Equal(t, badPath, integrityErr.Path)
+		assert.False(t, bad.Actual.Equals(bad.Plugin.Digest()))
+	})
+
+	t.Run("WithSignatures", func(t *testing.T) {
+		svc := plugin.NewPluginService(repo, nil,
+			plugin.WithIntegrityVerifier(&plugin.MockVerifier{VerifyErr: errors.New("unsigned")}),
+			plugin.WithIntegrityService(services.NewIntegrityService(true)),
+		)
+		results, err := svc.VerifyCache(ctx)
+		require.NoError(t, err)
+
+		got := byName(results)
+		assert.ErrorContains(t, got["good"].Err, "unsigned")
+		assert.ErrorIs(t, got["bad"].Err, entities.ErrIntegrityCheckFailed, "digest failure is reported first")
+	})
+
+	t.Run("ListFailure", func(t *testing.T) {
+		svc := plugin.NewPluginService(&plugin.MockRepository{ListErr: errors.New("io error")}, nil)
+		_, err := svc.VerifyCache(ctx)
+		assert.Error(t, err)
+	})
+}
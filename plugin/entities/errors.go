@@ -15,8 +15,33 @@ var (
 
 	// ErrIntegrityCheckFailed is returned when digest verification fails.
 	ErrIntegrityCheckFailed = errors.New("integrity check failed")
+
+	// ErrProfileTrustDenied is returned when the user declines to trust a
+	// remote profile whose content changed since it was locked.
+	ErrProfileTrustDenied = errors.New("profile trust denied")
+
+	// ErrInvalidPluginSource is returned when a plugin declaration or source
+	// is malformed.
+	ErrInvalidPluginSource = errors.New("invalid plugin source")
 )
 
+// InvalidPluginSourceError describes a malformed plugin source.
+type InvalidPluginSourceError struct {
+	Source string // The whole source as declared
+	Token  string // The offending part of Source
+	Reason string
+}
+
+func (e *InvalidPluginSourceError) Error() string {
+	return fmt.Sprintf("invalid plugin source %q: %s (at %q)", e.Source, e.Reason, e.Token)
+}
+
+// Is implements error matching for errors.Is() checks.
+// This allows: errors.Is(err, entities.ErrInvalidPluginSource)
+func (e *InvalidPluginSourceError) Is(target error) bool {
+	return target == ErrInvalidPluginSource
+}
+
 // IntegrityError indicates digest mismatch.
 // Provides detailed information about expected vs actual digest.
 type IntegrityError struct {
@@ -38,6 +63,31 @@ func (e *IntegrityError) Is(target error) bool {
 	return target == ErrIntegrityCheckFailed
 }
 
+// CacheIntegrityError indicates a cached plugin binary no longer matches the
+// digest recorded when it was stored.
+type CacheIntegrityError struct {
+	Reference values.PluginReference
+	Path      string
+	Expected  values.Digest
+	Actual    values.Digest
+}
+
+func (e *CacheIntegrityError) Error() string {
+	return fmt.Sprintf(
+		"cached plugin %s is corrupt: %s: expected %s, got %s",
+		e.Reference.String(),
+		e.Path,
+		e.Expected.String(),
+		e.Actual.String(),
+	)
+}
+
+// Is implements error matching for errors.Is() checks.
+// This allows: errors.Is(err, entities.ErrIntegrityCheckFailed)
+func (e *CacheIntegrityError) Is(target error) bool {
+	return target == ErrIntegrityCheckFailed
+}
+
 // PluginNotFoundError indicates plugin doesn't exist in source.
 // Provides detailed information about which plugin was not found.
 type PluginNotFoundError struct {
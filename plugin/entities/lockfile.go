@@ -14,6 +14,7 @@ import (
 // - Generated timestamp must be set
 type Lockfile struct {
 	Generated time.Time
+	Modified  time.Time // Set when the lockfile is migrated to a newer format
 	Plugins   map[string]PluginLock
 	Profiles  map[string]ProfileLock
 	Version   int
@@ -41,13 +42,57 @@ type ProfileLock struct {
 	Digest    string // Content hash (sha256:...)
 }
 
+// CurrentLockfileVersion is the newest lockfile format this package reads
+// and writes. Version 2 added profile locks.
+const CurrentLockfileVersion = 2
+
+// UnsupportedLockfileVersionError indicates a lockfile written by a newer
+// version of the tool.
+type UnsupportedLockfileVersionError struct {
+	Version   int
+	Supported int
+}
+
+func (e *UnsupportedLockfileVersionError) Error() string {
+	return fmt.Sprintf(
+		"lockfile version %d is newer than the supported version %d; upgrade reglet to use this lockfile",
+		e.Version,
+		e.Supported,
+	)
+}
+
 // NewLockfile creates a new lockfile with the current version.
 func NewLockfile() *Lockfile {
 	return &Lockfile{
-		Version:   1,
+		Version:   CurrentLockfileVersion,
 		Generated: time.Now().UTC(),
 		Plugins:   make(map[string]PluginLock),
+		Profiles:  make(map[string]ProfileLock),
+	}
+}
+
+// Migrate upgrades the lockfile in place to CurrentLockfileVersion and
+// reports whether anything changed. A missing version is treated as 1.
+// Migrating sets Modified to now. Lockfiles from a newer format return an
+// *UnsupportedLockfileVersionError and are left untouched.
+func (l *Lockfile) Migrate(now time.Time) (bool, error) {
+	if l.Version > CurrentLockfileVersion {
+		return false, &UnsupportedLockfileVersionError{Version: l.Version, Supported: CurrentLockfileVersion}
+	}
+	if l.Version == CurrentLockfileVersion {
+		return false, nil
+	}
+
+	// 1 -> 2: profile locks
+	if l.Profiles == nil {
+		l.Profiles = make(map[string]ProfileLock)
+	}
+	if l.Plugins == nil {
+		l.Plugins = make(map[string]PluginLock)
 	}
+	l.Version = CurrentLockfileVersion
+	l.Modified = now.UTC()
+	return true, nil
 }
 
 // AddPlugin adds a plugin lock entry.
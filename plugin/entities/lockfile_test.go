@@ -13,7 +13,7 @@ func TestNewLockfile(t *testing.T) {
 	t.Parallel()
 
 	lock := entities.NewLockfile()
-	assert.Equal(t, 1, lock.Version)
+	assert.Equal(t, entities.CurrentLockfileVersion, lock.Version)
 	assert.False(t, lock.Generated.IsZero())
 	assert.Empty(t, lock.Plugins)
 }
@@ -3,7 +3,11 @@ package entities
 
 import (
 	"fmt"
+	"sort"
 	"strings"
+	"unicode"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 )
 
 // PluginSpec represents a plugin declaration with optional version and source.
@@ -32,41 +36,180 @@ func (ps *PluginSpec) IsBuiltIn() bool {
 
 // PluginName returns the actual plugin name to load (without version suffix).
 func (ps *PluginSpec) PluginName() string {
-	// Extract base name from source
+	return splitSource(ps.Source).name
+}
+
+// Canonical returns a comparable identity for the plugin the spec loads:
+// the registry host lowercased, the repository path and the plugin name,
+// without tag, version or digest. Built-in plugins are identified by name.
+// Two specs with equal Canonical values name the same plugin, possibly at
+// different versions.
+//
+// Examples:
+//   - "file@1.0.0", "file:1.0.0"                   -> "file"
+//   - "GHCR.io/reglet-dev/plugins/file:1.2.0"      -> "ghcr.io/reglet-dev/plugins/file"
+//   - "ghcr.io/reglet-dev/plugins/file@sha256:abc" -> "ghcr.io/reglet-dev/plugins/file"
+func (ps *PluginSpec) Canonical() string {
+	parts := splitSource(ps.Source)
+	if parts.repository == "" {
+		return parts.name
+	}
+	host, path, _ := strings.Cut(parts.repository, "/")
+	host = strings.ToLower(host)
+	if path == "" {
+		return host + "/" + parts.name
+	}
+	return host + "/" + path + "/" + parts.name
+}
+
+// IsDigestPinned reports whether the spec pins a content digest, either in
+// Digest or in its source.
+func (ps *PluginSpec) IsDigestPinned() bool {
+	return ps.Digest != "" || splitSource(ps.Source).digest != ""
+}
+
+// ResolvedTag returns the version the spec asks for: Version when set,
+// otherwise the tag or version in Source. It is empty when the spec names no
+// version, for example when it is pinned only by digest.
+func (ps *PluginSpec) ResolvedTag() string {
+	if ps.Version != "" {
+		return ps.Version
+	}
+	return splitSource(ps.Source).tag
+}
+
+// Validate checks the syntax of the spec's source and digest. It rejects
+// whitespace in the name or digest, empty path elements, a missing name,
+// more than one "@", and an empty tag or digest. Errors are *InvalidPluginSourceError naming the
+// offending token.
+func (ps *PluginSpec) Validate() error {
 	source := ps.Source
+	invalid := func(token, reason string) error {
+		return &InvalidPluginSourceError{Source: source, Token: token, Reason: reason}
+	}
+
+	if source == "" {
+		return invalid("", "source is empty")
+	}
+	if strings.Count(source, "@") > 1 {
+		return invalid(source[strings.Index(source, "@"):], "more than one '@'")
+	}
+
+	base, pin, pinned := strings.Cut(source, "@")
+	if base == "" {
+		return invalid(source, "missing plugin name before '@'")
+	}
+	if idx := strings.IndexFunc(base, unicode.IsSpace); idx != -1 {
+		return invalid(base[idx:idx+1], "contains whitespace")
+	}
+	if pinned {
+		// Version constraints may contain spaces (">=1.2.0 <1.4.0"), but not
+		// at either end.
+		if pin == "" {
+			return invalid("@", "nothing after '@'")
+		}
+		if strings.TrimSpace(pin) != pin {
+			return invalid(pin, "whitespace around version")
+		}
+		if err := validateDigest(pin, invalid); err != nil {
+			return err
+		}
+	}
+
+	for _, segment := range strings.Split(base, "/") {
+		if segment == "" {
+			return invalid(base, "empty path element")
+		}
+	}
+
+	parts := splitSource(source)
+	if parts.name == "" {
+		return invalid(base, "missing plugin name")
+	}
+	if strings.HasSuffix(base, ":") {
+		return invalid(base, "empty tag after ':'")
+	}
+
+	if ps.Digest != "" {
+		return validateDigest(ps.Digest, invalid)
+	}
+	return nil
+}
 
-	// Remove digest if present (e.g., "file@sha256:abc" -> "file")
-	if idx := strings.Index(source, "@sha256:"); idx != -1 {
-		source = source[:idx]
+// validateDigest rejects "sha256:" style pins with nothing after the
+// algorithm. Pins that name no known algorithm are versions and pass.
+func validateDigest(pin string, invalid func(token, reason string) error) error {
+	algorithm, value, ok := strings.Cut(pin, ":")
+	if !ok || !isDigestPin(pin) {
+		return nil
+	}
+	if value == "" {
+		return invalid(pin, "empty digest after "+algorithm+":")
 	}
+	if idx := strings.IndexFunc(value, unicode.IsSpace); idx != -1 {
+		return invalid(value[idx:idx+1], "contains whitespace")
+	}
+	return nil
+}
+
+// sourceParts are the components of a plugin source string.
+type sourceParts struct {
+	repository string // registry and repository path; empty for built-ins
+	name       string
+	tag        string // ":tag" or "@version"
+	digest     string // "@sha256:..."
+}
+
+// splitSource breaks a plugin source such as "file@1.0.0" or
+// "ghcr.io/org/file:1.0.0@sha256:abc" into its components. It does not
+// validate the source.
+func splitSource(source string) sourceParts {
+	var parts sourceParts
 
-	// Remove version if present (e.g., "file@1.0.0" -> "file", "file:1.0.0" -> "file")
-	if idx := strings.LastIndex(source, "@"); idx != -1 {
-		source = source[:idx]
+	// Text after "@" is a digest when it names an algorithm, else a version.
+	if base, pin, ok := strings.Cut(source, "@"); ok {
+		if isDigestPin(pin) {
+			parts.digest = pin
+		} else {
+			parts.tag = pin
+		}
+		source = base
 	}
-	if idx := strings.LastIndex(source, ":"); idx != -1 && !strings.Contains(source, "/") {
-		// Only strip trailing version for simple names, not registry paths
-		source = source[:idx]
+
+	if idx := strings.LastIndex(source, "/"); idx != -1 {
+		parts.repository = source[:idx]
+		source = source[idx+1:]
 	}
 
-	// For OCI references, extract the plugin name from path
-	if strings.Contains(source, "/") {
-		parts := strings.Split(source, "/")
-		source = parts[len(parts)-1]
-		// Strip tag if present
-		if idx := strings.LastIndex(source, ":"); idx != -1 {
-			source = source[:idx]
+	// The last path element may carry a tag (e.g. "file:1.0.0"). A registry
+	// port never reaches here since it sits before the last slash.
+	if name, tag, ok := strings.Cut(source, ":"); ok {
+		source = name
+		if parts.tag == "" {
+			parts.tag = tag
 		}
 	}
+	parts.name = source
+	return parts
+}
 
-	return source
+func isDigestPin(pin string) bool {
+	algorithm, _, ok := strings.Cut(pin, ":")
+	return ok && values.IsSupportedAlgorithm(algorithm)
 }
 
 // PluginRegistry maps plugin aliases to their specifications.
 // This allows observations to reference plugins by alias while the runtime
 // resolves them to their actual sources.
 type PluginRegistry struct {
-	specs map[string]*PluginSpec
+	specs    map[string]*PluginSpec
+	patterns []aliasPattern // longest prefix first
+}
+
+// aliasPattern maps every alias starting with prefix to spec.
+type aliasPattern struct {
+	prefix string
+	spec   *PluginSpec
 }
 
 // NewPluginRegistry creates a new empty plugin registry.
@@ -88,12 +231,57 @@ func (pr *PluginRegistry) Register(spec *PluginSpec) error {
 	return nil
 }
 
+// RegisterPattern maps every alias starting with a prefix to spec. The
+// pattern is the prefix followed by a single trailing "*", e.g. "corp-*".
+// A "*" in spec.Source is replaced by the rest of the alias when resolving,
+// so "corp-*" with source "registry.corp.com/plugins/*" resolves "corp-scan"
+// to "registry.corp.com/plugins/scan". Registering the same pattern again
+// replaces it.
+func (pr *PluginRegistry) RegisterPattern(pattern string, spec *PluginSpec) error {
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	if !ok {
+		return fmt.Errorf("plugin pattern %q must end with '*'", pattern)
+	}
+	if prefix == "" {
+		return fmt.Errorf("plugin pattern %q needs a prefix before '*'", pattern)
+	}
+	if strings.Contains(prefix, "*") {
+		return fmt.Errorf("plugin pattern %q may only contain one trailing '*'", pattern)
+	}
+	if spec == nil || spec.Source == "" {
+		return fmt.Errorf("plugin spec source cannot be empty for pattern %q", pattern)
+	}
+
+	for i, p := range pr.patterns {
+		if p.prefix == prefix {
+			pr.patterns[i].spec = spec
+			return nil
+		}
+	}
+	pr.patterns = append(pr.patterns, aliasPattern{prefix: prefix, spec: spec})
+	// Most specific (longest) prefix first, so Resolve takes the first match.
+	sort.SliceStable(pr.patterns, func(i, j int) bool {
+		return len(pr.patterns[i].prefix) > len(pr.patterns[j].prefix)
+	})
+	return nil
+}
+
 // Resolve looks up a plugin by alias and returns its specification.
-// If the alias is not registered, it returns a default spec where name=source.
+// Exact registrations win; otherwise the longest matching pattern (see
+// RegisterPattern) is used. If nothing matches, it returns a default spec
+// where name=source.
 func (pr *PluginRegistry) Resolve(alias string) *PluginSpec {
 	if spec, ok := pr.specs[alias]; ok {
 		return spec
 	}
+	for _, p := range pr.patterns {
+		if rest, ok := strings.CutPrefix(alias, p.prefix); ok {
+			spec := *p.spec
+			spec.Name = alias
+			spec.Source = strings.ReplaceAll(spec.Source, "*", rest)
+			return &spec
+		}
+	}
 	// Return default spec for unregistered aliases (backwards compatibility)
 	return &PluginSpec{
 		Name:   alias,
@@ -102,6 +290,7 @@ func (pr *PluginRegistry) Resolve(alias string) *PluginSpec {
 }
 
 // HasPlugin reports whether a plugin with the given name is registered.
+// Patterns are not consulted.
 func (pr *PluginRegistry) HasPlugin(name string) bool {
 	_, ok := pr.specs[name]
 	return ok
@@ -122,6 +311,7 @@ func (pr *PluginRegistry) AllSpecs() []*PluginSpec {
 //   - "file@1.2.0"                              -> name=file, source=file, version=1.2.0
 //   - "ghcr.io/.../file:1.2.0"                  -> name=file, source=full path
 //   - "ghcr.io/.../file@sha256:abc..."          -> name=file, source=path, digest=sha256:abc...
+//   - "ghcr.io/.../file@sha512:abc..."          -> name=file, source=path, digest=sha512:abc...
 func ParsePluginDeclaration(declaration string) (*PluginSpec, error) {
 	if declaration == "" {
 		return nil, fmt.Errorf("empty plugin declaration")
@@ -130,38 +320,16 @@ func ParsePluginDeclaration(declaration string) (*PluginSpec, error) {
 	spec := &PluginSpec{
 		Source: declaration,
 	}
-
-	// Check for digest pin
-	if idx := strings.Index(declaration, "@sha256:"); idx != -1 {
-		spec.Digest = declaration[idx+1:] // "sha256:abc..."
-		declaration = declaration[:idx]
-		spec.Source = declaration + "@" + spec.Digest
-	}
-
-	// Check for version suffix (simple name@version format or registry/name@version)
-	// We already handled @sha256 above, so other @ is likely version.
-	if idx := strings.LastIndex(declaration, "@"); idx != -1 {
-		spec.Version = declaration[idx+1:]
-		declaration = declaration[:idx]
+	if err := spec.Validate(); err != nil {
+		return nil, err
 	}
 
-	// Determine name (alias) from the declaration
-	if strings.Contains(declaration, "/") {
-		// OCI reference: extract name from path
-		parts := strings.Split(declaration, "/")
-		name := parts[len(parts)-1]
-		// Strip tag if present
-		if idx := strings.LastIndex(name, ":"); idx != -1 {
-			spec.Version = name[idx+1:]
-			name = name[:idx]
-		}
-		spec.Name = name
-	} else {
-		// Simple name or name@version
-		spec.Name = declaration
-		if spec.Version != "" {
-			spec.Source = declaration // Without version for loading
-		}
+	parts := splitSource(declaration)
+	spec.Name = parts.name
+	spec.Version = parts.tag
+	spec.Digest = parts.digest
+	if parts.repository == "" && parts.tag != "" {
+		spec.Source = parts.name // Without version for loading
 	}
 
 	return spec, nil
@@ -204,6 +372,9 @@ func ParsePluginDeclarationWithAlias(alias string, source interface{}) (*PluginS
 			spec.Verify = verify
 		}
 
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("plugin %q: %w", alias, err)
+		}
 		return spec, nil
 
 	default:
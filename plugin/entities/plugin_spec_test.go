@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -46,11 +47,51 @@ func TestParsePluginDeclaration(t *testing.T) {
 			wantSource:  "ghcr.io/reglet-dev/reglet-plugins/file@sha256:abc123",
 			wantDigest:  "sha256:abc123",
 		},
+		{
+			name:        "OCI reference with sha512 digest",
+			declaration: "ghcr.io/reglet-dev/reglet-plugins/file@sha512:abc123",
+			wantName:    "file",
+			wantSource:  "ghcr.io/reglet-dev/reglet-plugins/file@sha512:abc123",
+			wantDigest:  "sha512:abc123",
+		},
+		{
+			name:        "OCI reference with tag and digest",
+			declaration: "ghcr.io/reglet-dev/reglet-plugins/file:1.2.0@sha256:abc123",
+			wantName:    "file",
+			wantSource:  "ghcr.io/reglet-dev/reglet-plugins/file:1.2.0@sha256:abc123",
+			wantVersion: "1.2.0",
+			wantDigest:  "sha256:abc123",
+		},
 		{
 			name:        "empty declaration",
 			declaration: "",
 			wantErr:     true,
 		},
+		{
+			name:        "registry without path",
+			declaration: "ghcr.io/",
+			wantErr:     true,
+		},
+		{
+			name:        "version without name",
+			declaration: "@1.0",
+			wantErr:     true,
+		},
+		{
+			name:        "double at",
+			declaration: "file@@bad",
+			wantErr:     true,
+		},
+		{
+			name:        "empty digest",
+			declaration: "ghcr.io/org/file@sha256:",
+			wantErr:     true,
+		},
+		{
+			name:        "whitespace",
+			declaration: "ghcr.io/org/file :1.0.0",
+			wantErr:     true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -141,6 +182,69 @@ func TestParsePluginDeclarationWithAlias(t *testing.T) {
 	}
 }
 
+func TestPluginSpec_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		spec      PluginSpec
+		wantToken string
+	}{
+		{"registry without path", PluginSpec{Source: "ghcr.io/"}, "ghcr.io/"},
+		{"empty path element", PluginSpec{Source: "ghcr.io//file:1.0.0"}, "ghcr.io//file:1.0.0"},
+		{"version without name", PluginSpec{Source: "@1.0"}, "@1.0"},
+		{"double at", PluginSpec{Source: "file@@bad"}, "@@bad"},
+		{"at after digest", PluginSpec{Source: "file@sha256:abc@1.0"}, "@sha256:abc@1.0"},
+		{"nothing after at", PluginSpec{Source: "file@"}, "@"},
+		{"empty sha256 digest", PluginSpec{Source: "ghcr.io/org/file@sha256:"}, "sha256:"},
+		{"empty sha512 digest", PluginSpec{Source: "file@sha512:"}, "sha512:"},
+		{"empty digest field", PluginSpec{Source: "file", Digest: "sha256:"}, "sha256:"},
+		{"space", PluginSpec{Source: "file @1.0"}, " "},
+		{"space around version", PluginSpec{Source: "file@ 1.0"}, " 1.0"},
+		{"space in digest", PluginSpec{Source: "file@sha256:ab cd"}, " "},
+		{"tab", PluginSpec{Source: "ghcr.io/org/\tfile"}, "\t"},
+		{"empty tag", PluginSpec{Source: "ghcr.io/org/file:"}, "ghcr.io/org/file:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := tt.spec.Validate()
+			require.Error(t, err)
+			assert.ErrorIs(t, err, ErrInvalidPluginSource)
+
+			var sourceErr *InvalidPluginSourceError
+			require.ErrorAs(t, err, &sourceErr)
+			assert.Equal(t, tt.wantToken, sourceErr.Token)
+			assert.Contains(t, err.Error(), fmt.Sprintf("%q", tt.wantToken))
+		})
+	}
+
+	for _, source := range []string{
+		"file",
+		"file@1.2.0",
+		"file@>=1.2.0 <1.4.0",
+		"file:1.2.0",
+		"ghcr.io/file:1.0.0",
+		"localhost:5000/org/file:1.0.0",
+		"ghcr.io/reglet-dev/reglet-plugins/file@sha256:abc123",
+	} {
+		t.Run("valid "+source, func(t *testing.T) {
+			t.Parallel()
+			spec := &PluginSpec{Source: source}
+			assert.NoError(t, spec.Validate())
+		})
+	}
+
+	t.Run("expanded alias format", func(t *testing.T) {
+		t.Parallel()
+		_, err := ParsePluginDeclarationWithAlias("bad", map[string]interface{}{"source": "ghcr.io/"})
+		assert.ErrorIs(t, err, ErrInvalidPluginSource)
+		_, err = ParsePluginDeclarationWithAlias("bad", "file@@bad")
+		assert.ErrorIs(t, err, ErrInvalidPluginSource)
+	})
+}
+
 func TestPluginSpec_IsBuiltIn(t *testing.T) {
 	t.Parallel()
 
@@ -186,6 +290,64 @@ func TestPluginSpec_PluginName(t *testing.T) {
 	}
 }
 
+func TestPluginSpec_Canonical(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source        string
+		wantCanonical string
+		wantTag       string
+		wantPinned    bool
+	}{
+		// Mirrors TestPluginSpec_PluginName
+		{"file", "file", "", false},
+		{"file@1.0.0", "file", "1.0.0", false},
+		{"ghcr.io/reglet-dev/reglet-plugins/file:1.2.0", "ghcr.io/reglet-dev/reglet-plugins/file", "1.2.0", false},
+		{"ghcr.io/reglet-dev/reglet-plugins/file@sha256:abc", "ghcr.io/reglet-dev/reglet-plugins/file", "", true},
+		{"registry.corp.com/security/scanner:3.0.0", "registry.corp.com/security/scanner", "3.0.0", false},
+
+		// Built-ins with a tag are the same plugin as the bare name
+		{"file:1.0.0", "file", "1.0.0", false},
+		{"file@sha256:abc", "file", "", true},
+
+		// Registry host is case-insensitive, the repository path is not
+		{"GHCR.IO/Reglet-Dev/file:1.0.0", "ghcr.io/Reglet-Dev/file", "1.0.0", false},
+
+		// Ports, short and deep repositories
+		{"localhost:5000/org/file:2.0.0", "localhost:5000/org/file", "2.0.0", false},
+		{"ghcr.io/file:1.0.0", "ghcr.io/file", "1.0.0", false},
+		{"reg.io/a/b/c/file@1.0.0", "reg.io/a/b/c/file", "1.0.0", false},
+
+		// Tag and digest together
+		{"ghcr.io/org/file:1.0.0@sha256:abc", "ghcr.io/org/file", "1.0.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			t.Parallel()
+			spec := &PluginSpec{Source: tt.source}
+			assert.Equal(t, tt.wantCanonical, spec.Canonical())
+			assert.Equal(t, tt.wantTag, spec.ResolvedTag())
+			assert.Equal(t, tt.wantPinned, spec.IsDigestPinned())
+		})
+	}
+
+	t.Run("from declarations", func(t *testing.T) {
+		t.Parallel()
+		a, err := ParsePluginDeclaration("ghcr.io/org/file:1.0.0")
+		require.NoError(t, err)
+		b, err := ParsePluginDeclaration("GHCR.io/org/file@sha256:abc")
+		require.NoError(t, err)
+		assert.Equal(t, a.Canonical(), b.Canonical())
+		assert.Equal(t, "1.0.0", a.ResolvedTag())
+		assert.True(t, b.IsDigestPinned())
+
+		v, err := ParsePluginDeclaration("file@1.2.0")
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.0", v.ResolvedTag(), "Version takes precedence over the source")
+	})
+}
+
 func TestPluginRegistry(t *testing.T) {
 	t.Parallel()
 
@@ -241,3 +403,73 @@ func TestPluginRegistry_RegisterErrors(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "source cannot be empty")
 }
+
+func TestPluginRegistry_Patterns(t *testing.T) {
+	t.Parallel()
+
+	registry := NewPluginRegistry()
+	require.NoError(t, registry.RegisterPattern("corp-*", &PluginSpec{
+		Source: "registry.corp.com/plugins/*",
+		Verify: true,
+	}))
+	require.NoError(t, registry.RegisterPattern("corp-sec-*", &PluginSpec{
+		Source: "registry.corp.com/security/*",
+	}))
+	require.NoError(t, registry.Register(&PluginSpec{
+		Name:   "corp-scanner",
+		Source: "ghcr.io/acme/scanner:1.0.0",
+	}))
+
+	t.Run("exact match wins over pattern", func(t *testing.T) {
+		spec := registry.Resolve("corp-scanner")
+		assert.Equal(t, "ghcr.io/acme/scanner:1.0.0", spec.Source)
+	})
+
+	t.Run("pattern substitutes alias suffix", func(t *testing.T) {
+		spec := registry.Resolve("corp-inventory")
+		assert.Equal(t, "corp-inventory", spec.Name)
+		assert.Equal(t, "registry.corp.com/plugins/inventory", spec.Source)
+		assert.True(t, spec.Verify)
+	})
+
+	t.Run("longest pattern wins", func(t *testing.T) {
+		spec := registry.Resolve("corp-sec-audit")
+		assert.Equal(t, "registry.corp.com/security/audit", spec.Source)
+		assert.False(t, spec.Verify)
+	})
+
+	t.Run("no match falls back to default", func(t *testing.T) {
+		spec := registry.Resolve("file")
+		assert.Equal(t, "file", spec.Source)
+	})
+
+	t.Run("resolved specs are copies", func(t *testing.T) {
+		registry.Resolve("corp-a").Source = "changed"
+		assert.Equal(t, "registry.corp.com/plugins/b", registry.Resolve("corp-b").Source)
+	})
+
+	t.Run("re-registering replaces", func(t *testing.T) {
+		local := NewPluginRegistry()
+		require.NoError(t, local.RegisterPattern("x-*", &PluginSpec{Source: "old/*"}))
+		require.NoError(t, local.RegisterPattern("x-*", &PluginSpec{Source: "new/*"}))
+		assert.Equal(t, "new/y", local.Resolve("x-y").Source)
+	})
+
+	t.Run("patterns are not plugins", func(t *testing.T) {
+		assert.False(t, registry.HasPlugin("corp-inventory"))
+		assert.Len(t, registry.AllSpecs(), 1)
+	})
+}
+
+func TestPluginRegistry_RegisterPatternErrors(t *testing.T) {
+	t.Parallel()
+
+	registry := NewPluginRegistry()
+	spec := &PluginSpec{Source: "reg/*"}
+
+	for _, pattern := range []string{"", "corp", "*", "corp-*-x", "*corp*", "co*rp*"} {
+		assert.Error(t, registry.RegisterPattern(pattern, spec), "pattern %q", pattern)
+	}
+	assert.Error(t, registry.RegisterPattern("corp-*", nil))
+	assert.Error(t, registry.RegisterPattern("corp-*", &PluginSpec{}))
+}
@@ -1,16 +1,20 @@
 package filesystem
 
 import (
+	"sort"
 	"time"
 
+	"github.com/goccy/go-yaml"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
 )
 
 // Lockfile represents the YAML structure of a lockfile.
 type Lockfile struct {
-	Generated time.Time             `yaml:"generated"`
-	Plugins   map[string]PluginLock `yaml:"plugins"`
-	Version   int                   `yaml:"lockfile_version"`
+	Generated time.Time              `yaml:"generated"`
+	Modified  time.Time              `yaml:"modified,omitempty"`
+	Plugins   map[string]PluginLock  `yaml:"plugins"`
+	Profiles  map[string]ProfileLock `yaml:"profiles,omitempty"`
+	Version   int                    `yaml:"lockfile_version"`
 }
 
 // PluginLock represents a pinned plugin version in YAML.
@@ -23,13 +27,65 @@ type PluginLock struct {
 	Digest    string    `yaml:"sha256"`
 }
 
+// ProfileLock represents a pinned remote profile in YAML (lockfile version 2).
+type ProfileLock struct {
+	Fetched   time.Time `yaml:"fetched,omitempty"`
+	Modified  time.Time `yaml:"modified,omitempty"`
+	Requested string    `yaml:"requested"`
+	Resolved  string    `yaml:"resolved"`
+	Source    string    `yaml:"source"`
+	Digest    string    `yaml:"digest"`
+}
+
+// MarshalYAML emits plugins and profiles sorted by key, so regenerating an
+// unchanged lockfile yields identical bytes and version control diffs only
+// show real changes.
+func (l Lockfile) MarshalYAML() (interface{}, error) {
+	plugins := make(yaml.MapSlice, 0, len(l.Plugins))
+	for _, name := range sortedKeys(l.Plugins) {
+		plugins = append(plugins, yaml.MapItem{Key: name, Value: l.Plugins[name]})
+	}
+	var profiles yaml.MapSlice
+	for _, url := range sortedKeys(l.Profiles) {
+		profiles = append(profiles, yaml.MapItem{Key: url, Value: l.Profiles[url]})
+	}
+
+	// Same layout as Lockfile, with the maps replaced by ordered slices.
+	return struct {
+		Generated time.Time     `yaml:"generated"`
+		Modified  time.Time     `yaml:"modified,omitempty"`
+		Plugins   yaml.MapSlice `yaml:"plugins"`
+		Profiles  yaml.MapSlice `yaml:"profiles,omitempty"`
+		Version   int           `yaml:"lockfile_version"`
+	}{
+		Generated: l.Generated,
+		Modified:  l.Modified,
+		Plugins:   plugins,
+		Profiles:  profiles,
+		Version:   l.Version,
+	}, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // ToEntity converts the lockfile to a domain entity.
 func (l *Lockfile) ToEntity() *entities.Lockfile {
 	entity := &entities.Lockfile{
 		Generated: l.Generated,
+		Modified:  l.Modified,
 		Version:   l.Version,
 		Plugins:   make(map[string]entities.PluginLock, len(l.Plugins)),
 	}
+	if l.Profiles != nil {
+		entity.Profiles = make(map[string]entities.ProfileLock, len(l.Profiles))
+	}
 
 	for name, lock := range l.Plugins {
 		entity.Plugins[name] = entities.PluginLock{
@@ -42,6 +98,10 @@ func (l *Lockfile) ToEntity() *entities.Lockfile {
 		}
 	}
 
+	for url, lock := range l.Profiles {
+		entity.Profiles[url] = entities.ProfileLock(lock)
+	}
+
 	return entity
 }
 
@@ -53,9 +113,13 @@ func FromEntity(entity *entities.Lockfile) *Lockfile {
 
 	l := &Lockfile{
 		Generated: entity.Generated,
+		Modified:  entity.Modified,
 		Version:   entity.Version,
 		Plugins:   make(map[string]PluginLock, len(entity.Plugins)),
 	}
+	if len(entity.Profiles) > 0 {
+		l.Profiles = make(map[string]ProfileLock, len(entity.Profiles))
+	}
 
 	for name, lock := range entity.Plugins {
 		l.Plugins[name] = PluginLock{
@@ -68,5 +132,9 @@ func FromEntity(entity *entities.Lockfile) *Lockfile {
 		}
 	}
 
+	for url, lock := range entity.Profiles {
+		l.Profiles[url] = ProfileLock(lock)
+	}
+
 	return l
 }
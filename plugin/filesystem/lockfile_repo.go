@@ -3,16 +3,24 @@ package filesystem
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/goccy/go-yaml"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
 )
 
 // FileLockfileRepository implements ports.LockfileRepository using the local filesystem.
-type FileLockfileRepository struct{}
+type FileLockfileRepository struct {
+	// wrapWriter, when set, wraps the file Save writes to. Tests use it to
+	// inject write failures.
+	wrapWriter func(io.Writer) io.Writer
+}
 
 // NewFileLockfileRepository creates a new FileLockfileRepository.
 func NewFileLockfileRepository() *FileLockfileRepository {
@@ -55,6 +63,11 @@ func (r *FileLockfileRepository) Load(ctx context.Context, path string) (*entiti
 	// Convert to domain entity
 	lock := out.ToEntity()
 
+	// Upgrade older formats; the migrated lockfile is written on next Save
+	if _, err := lock.Migrate(time.Now()); err != nil {
+		return nil, fmt.Errorf("lockfile %q: %w", path, err)
+	}
+
 	// Validate loaded lockfile
 	if err := lock.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid lockfile: %w", err)
@@ -64,7 +77,10 @@ func (r *FileLockfileRepository) Load(ctx context.Context, path string) (*entiti
 }
 
 // Save writes a lockfile to the given path.
-func (r *FileLockfileRepository) Save(ctx context.Context, lockfile *entities.Lockfile, path string) error {
+// The YAML is written to a temporary file in the same directory, synced, and
+// renamed over the target, so a crash or full disk mid-write leaves the
+// previous lockfile intact rather than truncated.
+func (r *FileLockfileRepository) Save(ctx context.Context, lockfile *entities.Lockfile, path string) (err error) {
 	dir := filepath.Dir(path)
 
 	// Ensure directory exists
@@ -72,11 +88,7 @@ func (r *FileLockfileRepository) Save(ctx context.Context, lockfile *entities.Lo
 		return fmt.Errorf("creating directory %q: %w", dir, err)
 	}
 
-	// We use standard os.OpenFile for writing as OpenRoot implies read-only usually or directory access.
-	// For writing, atomic write is preferred (write temp + rename), but simple write is okay for phase 2.5
-	// actually standard library doesn't easily do OpenRoot for writing in a "root constrained" way easily for generic paths?
-	// os.Root has Create/OpenFile.
-
+	// os.Root keeps both the temporary file and the rename inside dir.
 	root, err := os.OpenRoot(dir)
 	if err != nil {
 		return fmt.Errorf("opening directory for write %q: %w", dir, err)
@@ -84,24 +96,53 @@ func (r *FileLockfileRepository) Save(ctx context.Context, lockfile *entities.Lo
 	defer func() { _ = root.Close() }()
 
 	base := filepath.Base(path)
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("creating temporary lockfile name: %w", err)
+	}
+	tmp := "." + base + ".tmp-" + hex.EncodeToString(suffix)
 
-	// Create/Truncate file
-	file, err := root.OpenFile(base, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	file, err := root.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
 	if err != nil {
-		return fmt.Errorf("creating lockfile %q: %w", base, err)
+		return fmt.Errorf("creating temporary lockfile %q: %w", tmp, err)
 	}
-	defer func() { _ = file.Close() }()
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+		if err != nil {
+			_ = root.Remove(tmp)
+		}
+	}()
 
 	// Convert domain entity to YAML representation
 	out := FromEntity(lockfile)
 
-	encoder := yaml.NewEncoder(file)
-	defer func() { _ = encoder.Close() }()
-
-	if err := encoder.Encode(out); err != nil {
+	data, err := yaml.Marshal(out)
+	if err != nil {
 		return fmt.Errorf("encoding lockfile: %w", err)
 	}
 
+	var w io.Writer = file
+	if r.wrapWriter != nil {
+		w = r.wrapWriter(w)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("writing lockfile: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("syncing lockfile: %w", err)
+	}
+	closeErr := file.Close()
+	file = nil
+	if closeErr != nil {
+		return fmt.Errorf("closing lockfile: %w", closeErr)
+	}
+
+	if err := root.Rename(tmp, base); err != nil {
+		return fmt.Errorf("replacing lockfile %q: %w", base, err)
+	}
 	return nil
 }
 
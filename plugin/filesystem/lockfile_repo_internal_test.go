@@ -0,0 +1,61 @@
+package filesystem
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+)
+
+// failingWriter accepts limit bytes and then fails, like a disk filling up.
+type failingWriter struct {
+	w     io.Writer
+	limit int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n, _ := f.w.Write(p[:f.limit])
+		f.limit = 0
+		return n, errors.New("no space left on device")
+	}
+	f.limit -= len(p)
+	return f.w.Write(p)
+}
+
+func TestFileLockfileRepository_SaveIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reglet.lock")
+	ctx := context.Background()
+
+	lock := entities.NewLockfile()
+	require.NoError(t, lock.AddPlugin("file", entities.PluginLock{Requested: "1.0", Resolved: "1.0.0", Digest: "sha256:abc"}))
+
+	repo := NewFileLockfileRepository()
+	require.NoError(t, repo.Save(ctx, lock, path))
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	require.NoError(t, lock.AddPlugin("http", entities.PluginLock{Requested: "2.0", Resolved: "2.0.0", Digest: "sha256:def"}))
+	repo.wrapWriter = func(w io.Writer) io.Writer { return &failingWriter{w: w, limit: 16} }
+	require.Error(t, repo.Save(ctx, lock, path))
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, after, "failed save must leave the previous lockfile intact")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary file should be removed")
+
+	loaded, err := repo.Load(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loaded.PluginCount())
+}
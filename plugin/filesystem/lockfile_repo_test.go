@@ -2,7 +2,9 @@ package filesystem_test
 
 import (
 	"context"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -78,3 +80,112 @@ func TestFileLockfileRepository(t *testing.T) {
 		assert.True(t, exists)
 	})
 }
+
+func TestFileLockfileRepository_Migration(t *testing.T) {
+	t.Parallel()
+
+	repo := filesystem.NewFileLockfileRepository()
+	ctx := context.Background()
+
+	t.Run("v1 upgrades to v2", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "reglet.lock")
+		v1 := `lockfile_version: 1
+generated: 2025-01-01T00:00:00Z
+plugins:
+  file:
+    requested: "1.0"
+    resolved: 1.0.0
+    source: ghcr.io/reglet-dev/reglet-plugins/file
+    sha256: sha256:abc
+`
+		require.NoError(t, os.WriteFile(path, []byte(v1), 0o600))
+
+		before := time.Now().Add(-time.Second)
+		loaded, err := repo.Load(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, entities.CurrentLockfileVersion, loaded.Version)
+		assert.NotNil(t, loaded.Profiles)
+		assert.True(t, loaded.Modified.After(before), "migration records a modified time")
+		assert.Equal(t, "1.0.0", loaded.GetPlugin("file").Resolved)
+
+		// Profiles survive a save and reload once migrated.
+		require.NoError(t, loaded.AddProfile("https://example.com/p.yaml", entities.ProfileLock{
+			Requested: "https://example.com/p.yaml",
+			Resolved:  "v1",
+			Digest:    "sha256:def",
+		}))
+		require.NoError(t, repo.Save(ctx, loaded, path))
+
+		reloaded, err := repo.Load(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, entities.CurrentLockfileVersion, reloaded.Version)
+		assert.Equal(t, loaded.Modified.Unix(), reloaded.Modified.Unix(), "already current; not migrated again")
+		profile := reloaded.GetProfile("https://example.com/p.yaml")
+		require.NotNil(t, profile)
+		assert.Equal(t, "sha256:def", profile.Digest)
+	})
+
+	t.Run("future version is rejected", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "reglet.lock")
+		require.NoError(t, os.WriteFile(path, []byte("lockfile_version: 3\ngenerated: 2025-01-01T00:00:00Z\nplugins: {}\n"), 0o600))
+
+		_, err := repo.Load(ctx, path)
+		var versionErr *entities.UnsupportedLockfileVersionError
+		require.ErrorAs(t, err, &versionErr)
+		assert.Equal(t, 3, versionErr.Version)
+		assert.Contains(t, err.Error(), "upgrade reglet")
+	})
+}
+
+func TestFileLockfileRepository_DeterministicOutput(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	repo := filesystem.NewFileLockfileRepository()
+	names := []string{"zeta", "alpha", "mid", "beta", "omega", "gamma", "delta", "kappa"}
+
+	build := func(order []string) *entities.Lockfile {
+		lock := entities.NewLockfile()
+		lock.Generated = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, name := range order {
+			require.NoError(t, lock.AddPlugin(name, entities.PluginLock{
+				Requested: "1.0",
+				Resolved:  "1.0.0",
+				Source:    "registry.example.com/org/" + name,
+				Digest:    "sha256:" + name,
+			}))
+			lock.Profiles["https://profiles.example.com/"+name+".yaml"] = entities.ProfileLock{
+				Requested: "https://profiles.example.com/" + name + ".yaml",
+				Resolved:  "https://profiles.example.com/" + name + ".yaml",
+				Digest:    "sha256:" + name,
+			}
+		}
+		return lock
+	}
+
+	save := func(lock *entities.Lockfile) []byte {
+		path := filepath.Join(t.TempDir(), "reglet.lock")
+		require.NoError(t, repo.Save(ctx, lock, path))
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("same lockfile twice", func(t *testing.T) {
+		lock := build(names)
+		assert.Equal(t, string(save(lock)), string(save(lock)))
+	})
+
+	t.Run("different insertion order", func(t *testing.T) {
+		reversed := make([]string, len(names))
+		for i, name := range names {
+			reversed[len(names)-1-i] = name
+		}
+		first := save(build(names))
+		assert.Equal(t, string(first), string(save(build(reversed))))
+
+		alpha := strings.Index(string(first), "alpha:")
+		zeta := strings.Index(string(first), "zeta:")
+		assert.Less(t, alpha, zeta, "plugins should be sorted by name")
+	})
+}
@@ -0,0 +1,113 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+)
+
+// DriftReport lists how a lockfile differs from the plugins currently
+// declared. An empty report means the lockfile is up to date.
+type DriftReport struct {
+	// Added are declared plugins missing from the lockfile.
+	Added []PluginDrift
+
+	// Removed are locked plugins that are no longer declared.
+	Removed []PluginDrift
+
+	// Changed are plugins whose declaration now resolves differently.
+	Changed []PluginDrift
+}
+
+// PluginDrift describes one drifted plugin. Locked is nil for added plugins
+// and Wanted is nil for removed ones.
+type PluginDrift struct {
+	Locked *entities.PluginLock
+	Wanted *entities.PluginLock
+	Name   string
+}
+
+// HasDrift reports whether the lockfile is out of date.
+func (r DriftReport) HasDrift() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// CheckDrift computes what ResolvePlugins would lock for pluginDeclarations
+// and compares it with the lockfile at lockfilePath, without writing
+// anything. It suits a CI check such as "reglet lock --check".
+//
+// Declarations are compared by what they resolve to, not only by the
+// constraint text: a "latest" or range constraint whose newest match has
+// moved on counts as changed even though the requested string is the same.
+// Resolving requires a version source (see WithVersionSource); without one
+// only the requested constraint and source are compared.
+func (s *LockfileService) CheckDrift(
+	ctx context.Context,
+	pluginDeclarations []string,
+	lockfilePath string,
+) (DriftReport, error) {
+	lock, err := s.repo.Load(ctx, lockfilePath)
+	if err != nil {
+		return DriftReport{}, fmt.Errorf("loading lockfile: %w", err)
+	}
+	if lock == nil {
+		lock = entities.NewLockfile()
+	}
+
+	wanted := make(map[string]entities.PluginLock)
+	for _, pluginDecl := range pluginDeclarations {
+		spec, err := entities.ParsePluginDeclaration(pluginDecl)
+		if err != nil {
+			return DriftReport{}, fmt.Errorf("parsing plugin declaration %q: %w", pluginDecl, err)
+		}
+
+		constraint := spec.Version
+		if constraint == "" {
+			constraint = "latest"
+		}
+
+		w := entities.PluginLock{Requested: constraint, Source: spec.Source}
+		if s.versions != nil {
+			w.Resolved, w.Digest, err = s.resolvePlugin(ctx, spec, constraint)
+			if err != nil {
+				return DriftReport{}, fmt.Errorf("resolving plugin %q: %w", spec.Name, err)
+			}
+		}
+		wanted[spec.Name] = w
+	}
+
+	var report DriftReport
+	for name, w := range wanted {
+		locked := lock.GetPlugin(name)
+		switch {
+		case locked == nil:
+			report.Added = append(report.Added, PluginDrift{Name: name, Wanted: &w})
+		case s.lockDiffers(locked, &w):
+			report.Changed = append(report.Changed, PluginDrift{Name: name, Locked: locked, Wanted: &w})
+		}
+	}
+	for name := range lock.Plugins {
+		if _, ok := wanted[name]; !ok {
+			report.Removed = append(report.Removed, PluginDrift{Name: name, Locked: lock.GetPlugin(name)})
+		}
+	}
+
+	for _, drifts := range [][]PluginDrift{report.Added, report.Removed, report.Changed} {
+		sort.Slice(drifts, func(i, j int) bool { return drifts[i].Name < drifts[j].Name })
+	}
+	return report, nil
+}
+
+// lockDiffers compares a locked entry with a freshly resolved one. Resolved
+// version and digest are only meaningful when a version source is set.
+func (s *LockfileService) lockDiffers(locked, wanted *entities.PluginLock) bool {
+	if locked.Requested != wanted.Requested || locked.Source != wanted.Source {
+		return true
+	}
+	if s.versions == nil {
+		return false
+	}
+	return locked.Resolved != wanted.Resolved || locked.Digest != wanted.Digest
+}
@@ -0,0 +1,129 @@
+package plugin_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/resolvers"
+)
+
+func lockedAt(source, requested, version string) entities.PluginLock {
+	return entities.PluginLock{
+		Requested: requested,
+		Resolved:  version,
+		Source:    source,
+		Digest:    fakeDigester{}.DigestBytes([]byte("/cache/file/" + version + "/plugin.wasm")),
+	}
+}
+
+func TestLockfileService_CheckDrift(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	available := []string{"1.0.0", "1.2.0", "2.0.0"}
+
+	check := func(t *testing.T, lock *entities.Lockfile, declarations ...string) plugin.DriftReport {
+		t.Helper()
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, "reglet.lock").Return(lock, nil).Once()
+		svc := plugin.NewLockfileService(mockRepo, resolvers.NewSemverResolver(), fakeDigester{},
+			plugin.WithVersionSource(&fakeVersionSource{versions: available}))
+
+		report, err := svc.CheckDrift(ctx, declarations, "reglet.lock")
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+		return report
+	}
+
+	upToDate := func() *entities.Lockfile {
+		lock := entities.NewLockfile()
+		require.NoError(t, lock.AddPlugin("file", lockedAt("reglet/file@^1.0.0", "^1.0.0", "1.2.0")))
+		return lock
+	}
+
+	t.Run("no drift", func(t *testing.T) {
+		report := check(t, upToDate(), "reglet/file@^1.0.0")
+		assert.False(t, report.HasDrift(), "%+v", report)
+	})
+
+	t.Run("added", func(t *testing.T) {
+		report := check(t, upToDate(), "reglet/file@^1.0.0", "reglet/http@1.0.0")
+		require.Len(t, report.Added, 1)
+		assert.Equal(t, "http", report.Added[0].Name)
+		assert.Nil(t, report.Added[0].Locked)
+		assert.Equal(t, "1.0.0", report.Added[0].Wanted.Resolved)
+		assert.Empty(t, report.Removed)
+		assert.Empty(t, report.Changed)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		report := check(t, upToDate())
+		require.Len(t, report.Removed, 1)
+		assert.Equal(t, "file", report.Removed[0].Name)
+		assert.Nil(t, report.Removed[0].Wanted)
+		assert.True(t, report.HasDrift())
+	})
+
+	t.Run("changed constraint", func(t *testing.T) {
+		report := check(t, upToDate(), "reglet/file@^2.0.0")
+		require.Len(t, report.Changed, 1)
+		assert.Equal(t, "1.2.0", report.Changed[0].Locked.Resolved)
+		assert.Equal(t, "2.0.0", report.Changed[0].Wanted.Resolved)
+	})
+
+	t.Run("latest moved on", func(t *testing.T) {
+		lock := entities.NewLockfile()
+		require.NoError(t, lock.AddPlugin("file", lockedAt("reglet/file", "latest", "1.2.0")))
+
+		report := check(t, lock, "reglet/file")
+		require.Len(t, report.Changed, 1, "same requested string, newer resolution")
+		assert.Equal(t, "2.0.0", report.Changed[0].Wanted.Resolved)
+	})
+
+	t.Run("latest unchanged", func(t *testing.T) {
+		lock := entities.NewLockfile()
+		require.NoError(t, lock.AddPlugin("file", lockedAt("reglet/file", "latest", "2.0.0")))
+
+		report := check(t, lock, "reglet/file")
+		assert.False(t, report.HasDrift(), "%+v", report)
+	})
+
+	t.Run("missing lockfile", func(t *testing.T) {
+		report := check(t, nil, "reglet/file@^1.0.0")
+		require.Len(t, report.Added, 1)
+	})
+}
+
+func TestLockfileService_CheckDrift_WithoutVersionSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lock := entities.NewLockfile()
+	require.NoError(t, lock.AddPlugin("file", lockedAt("reglet/file", "latest", "1.2.0")))
+
+	mockRepo := new(MockRepo)
+	mockRepo.On("Load", ctx, "reglet.lock").Return(lock, nil).Times(2)
+	svc := plugin.NewLockfileService(mockRepo, nil, nil)
+
+	// Only the constraint and source are compared, so a stale "latest"
+	// is not reported.
+	report, err := svc.CheckDrift(ctx, []string{"reglet/file"}, "reglet.lock")
+	require.NoError(t, err)
+	assert.False(t, report.HasDrift(), "%+v", report)
+
+	report, err = svc.CheckDrift(ctx, []string{"reglet/file@^2.0.0", "reglet/http"}, "reglet.lock")
+	require.NoError(t, err)
+	require.Len(t, report.Changed, 1)
+	assert.Equal(t, "^2.0.0", report.Changed[0].Wanted.Requested)
+	assert.Empty(t, report.Changed[0].Wanted.Resolved)
+	require.Len(t, report.Added, 1)
+	assert.Equal(t, "http", report.Added[0].Name)
+	mockRepo.AssertExpectations(t)
+}
@@ -1,19 +1,79 @@
 package plugin
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 )
 
+// ErrNoVersionSource is returned by ResolvePlugins when a plugin needs
+// locking but no version source is configured, so there is no binary to
+// take its digest from.
+var ErrNoVersionSource = errors.New("no plugin version source configured")
+
 // LockfileService orchestrates plugin version resolution and locking.
 type LockfileService struct {
-	repo     ports.LockfileRepository
-	resolver ports.VersionResolver
-	digester ports.PluginDigester
+	repo          ports.LockfileRepository
+	resolver      ports.VersionResolver
+	digester      ports.PluginDigester
+	trustPrompter ports.ProfileTrustPrompter
+	versions      ports.PluginVersionSource
+	profiles      ports.ProfileSource
+	profileRetry  *netutil.RetryPolicy
+}
+
+// LockfileServiceOption configures a LockfileService.
+type LockfileServiceOption func(*LockfileService)
+
+// WithProfileTrustPrompter sets the prompter used to re-approve remote
+// profiles whose content changed since they were locked.
+func WithProfileTrustPrompter(p ports.ProfileTrustPrompter) LockfileServiceOption {
+	return func(s *LockfileService) { s.trustPrompter = p }
+}
+
+// WithVersionSource sets where ResolvePlugins finds available versions and
+// binaries. Without one, ResolvePlugins can only reuse existing lock entries;
+// any plugin it would have to lock fails with ErrNoVersionSource.
+func WithVersionSource(src ports.PluginVersionSource) LockfileServiceOption {
+	return func(s *LockfileService) { s.versions = src }
+}
+
+// WithProfileSource sets where LockProfileFromURL fetches profiles from.
+func WithProfileSource(src ports.ProfileSource) LockfileServiceOption {
+	return func(s *LockfileService) { s.profiles = src }
+}
+
+// WithProfileFetchRetry retries LockProfileFromURL's fetch according to
+// policy. Failed integrity checks are never retried; other failures are
+// retried when policy.Retryable allows it, or by default unless ctx is done,
+// the request was blocked by SSRF protection, or the error is
+// netutil.Permanent.
+func WithProfileFetchRetry(policy netutil.RetryPolicy) LockfileServiceOption {
+	return func(s *LockfileService) {
+		retryable := policy.Retryable
+		policy.Retryable = func(err error) bool {
+			if errors.Is(err, entities.ErrIntegrityCheckFailed) {
+				return false
+			}
+			if retryable != nil {
+				return retryable(err)
+			}
+			return !errors.Is(err, context.Canceled) &&
+				!errors.Is(err, context.DeadlineExceeded) &&
+				!netutil.IsSSRFBlockedError(err)
+		}
+		s.profileRetry = &policy
+	}
 }
 
 // NewLockfileService creates a new LockfileService.
@@ -21,12 +81,17 @@ func NewLockfileService(
 	repo ports.LockfileRepository,
 	resolver ports.VersionResolver,
 	digester ports.PluginDigester,
+	opts ...LockfileServiceOption,
 ) *LockfileService {
-	return &LockfileService{
+	s := &LockfileService{
 		repo:     repo,
 		resolver: resolver,
 		digester: digester,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // ResolvePlugins resolves plugin versions using the lockfile if available,
@@ -71,16 +136,17 @@ func (s *LockfileService) ResolvePlugins(
 		}
 
 		updated = true
-		// Mock logic for "available" - in real code this comes from registry
-		// For now we'll just lock the constraint as the version if it looks exact.
-		resolvedVersion := constraint // Fallback
+		resolvedVersion, digest, err := s.resolvePlugin(ctx, spec, constraint)
+		if err != nil {
+			return nil, fmt.Errorf("resolving plugin %q: %w", name, err)
+		}
 
 		// Update lock
 		newLock := entities.PluginLock{
 			Requested: constraint,
 			Resolved:  resolvedVersion,
 			Source:    spec.Source,
-			Digest:    "sha256:placeholder", // Placeholder until we have digester integrated
+			Digest:    digest,
 			Fetched:   time.Now().UTC(),
 		}
 
@@ -100,6 +166,56 @@ func (s *LockfileService) ResolvePlugins(
 	return lock, nil
 }
 
+// resolvePlugin picks the highest available version satisfying constraint and
+// digests its binary. A digest pinned in the declaration must match.
+func (s *LockfileService) resolvePlugin(
+	ctx context.Context,
+	spec *entities.PluginSpec,
+	constraint string,
+) (string, string, error) {
+	if s.versions == nil {
+		return "", "", fmt.Errorf("%w: cannot digest %s@%s", ErrNoVersionSource, spec.Name, constraint)
+	}
+	if s.resolver == nil || s.digester == nil {
+		return "", "", errors.New("a version resolver and plugin digester are required with a version source")
+	}
+
+	var expected values.Digest
+	if spec.Digest != "" {
+		var err error
+		if expected, err = values.ParseDigest(spec.Digest); err != nil {
+			return "", "", fmt.Errorf("pinned digest: %w", err)
+		}
+	}
+
+	available, err := s.versions.AvailableVersions(ctx, spec)
+	if err != nil {
+		return "", "", fmt.Errorf("listing versions: %w", err)
+	}
+	version, err := s.resolver.Resolve(constraint, available)
+	if err != nil {
+		return "", "", err
+	}
+
+	path, err := s.versions.Locate(ctx, spec, version)
+	if err != nil {
+		return "", "", fmt.Errorf("locating %s: %w", version, err)
+	}
+	digest, err := s.digester.DigestFile(ctx, path)
+	if err != nil {
+		return "", "", fmt.Errorf("digesting %s: %w", version, err)
+	}
+	actual, err := values.ParseDigest(digest)
+	if err != nil {
+		return "", "", fmt.Errorf("digesting %s: %w", version, err)
+	}
+
+	if spec.Digest != "" && !expected.Equals(actual) {
+		return "", "", &entities.IntegrityError{Expected: expected, Actual: actual}
+	}
+	return version, digest, nil
+}
+
 // LockProfile adds a remote profile to the lockfile with its resolved version and digest.
 // This enables reproducible builds by pinning profile versions.
 func (s *LockfileService) LockProfile(
@@ -108,6 +224,82 @@ func (s *LockfileService) LockProfile(
 	profileURL string,
 	version string,
 	digest string,
+) error {
+	return s.saveProfileLock(ctx, lockfilePath, profileURL, entities.ProfileLock{
+		Requested: profileURL,
+		Resolved:  version,
+		Source:    profileURL,
+		Digest:    digest,
+		Fetched:   time.Now().UTC(),
+	})
+}
+
+// LockProfileFromURL fetches a remote profile and locks it. requestedURL may
+// name a version in its fragment, as in "https://example.com/p.yaml#v1.2.0";
+// without one the latest version is locked. The entry is keyed by
+// requestedURL, with the fragment-free URL as its Source. It requires a
+// profile source (see WithProfileSource).
+func (s *LockfileService) LockProfileFromURL(
+	ctx context.Context,
+	lockfilePath string,
+	requestedURL string,
+) (*entities.ProfileLock, error) {
+	if s.profiles == nil {
+		return nil, errors.New("a profile source is required to lock profiles by URL")
+	}
+
+	source, version, _ := strings.Cut(requestedURL, "#")
+	if source == "" {
+		return nil, fmt.Errorf("profile URL %q has no source", requestedURL)
+	}
+	if version == "" {
+		version = "latest"
+	}
+
+	content, resolved, err := s.fetchProfile(ctx, source, version)
+	if err != nil {
+		return nil, fmt.Errorf("fetching profile %q: %w", requestedURL, err)
+	}
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", requestedURL, err)
+	}
+
+	profileLock := entities.ProfileLock{
+		Requested: requestedURL,
+		Resolved:  resolved,
+		Source:    source,
+		Digest:    digest.String(),
+		Fetched:   time.Now().UTC(),
+	}
+	if err := s.saveProfileLock(ctx, lockfilePath, requestedURL, profileLock); err != nil {
+		return nil, err
+	}
+	return &profileLock, nil
+}
+
+// fetchProfile fetches a profile, retrying when a profile retry policy is set.
+func (s *LockfileService) fetchProfile(ctx context.Context, source, version string) ([]byte, string, error) {
+	if s.profileRetry == nil {
+		return s.profiles.FetchProfile(ctx, source, version)
+	}
+
+	var content []byte
+	var resolved string
+	err := netutil.Retry(ctx, *s.profileRetry, func(ctx context.Context) error {
+		var err error
+		content, resolved, err = s.profiles.FetchProfile(ctx, source, version)
+		return err
+	})
+	return content, resolved, err
+}
+
+// saveProfileLock adds or replaces a profile entry and saves the lockfile.
+func (s *LockfileService) saveProfileLock(
+	ctx context.Context,
+	lockfilePath string,
+	key string,
+	profileLock entities.ProfileLock,
 ) error {
 	// Load existing lockfile
 	lock, err := s.repo.Load(ctx, lockfilePath)
@@ -124,16 +316,7 @@ func (s *LockfileService) LockProfile(
 		lock.Profiles = make(map[string]entities.ProfileLock)
 	}
 
-	// Add or update the profile lock
-	profileLock := entities.ProfileLock{
-		Requested: profileURL,
-		Resolved:  version,
-		Source:    profileURL,
-		Digest:    digest,
-		Fetched:   time.Now().UTC(),
-	}
-
-	if err := lock.AddProfile(profileURL, profileLock); err != nil {
+	if err := lock.AddProfile(key, profileLock); err != nil {
 		return fmt.Errorf("adding profile lock: %w", err)
 	}
 
@@ -165,3 +348,83 @@ func (s *LockfileService) GetLockedProfile(
 
 	return lock.GetProfile(profileURL), nil
 }
+
+// VerifyLockedProfile checks fetched profile content against its lockfile
+// entry. It returns the entry when the content matches, and nil with no error
+// when the profile is not locked. A mismatch means the remote profile was
+// changed or tampered with since it was locked, and is reported as an
+// *entities.IntegrityError.
+func (s *LockfileService) VerifyLockedProfile(
+	ctx context.Context,
+	lockfilePath string,
+	profileURL string,
+	content []byte,
+) (*entities.ProfileLock, error) {
+	locked, err := s.GetLockedProfile(ctx, lockfilePath, profileURL)
+	if err != nil {
+		return nil, err
+	}
+	if locked == nil {
+		return nil, nil
+	}
+
+	expected, err := values.ParseDigest(locked.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: invalid locked digest: %w", profileURL, err)
+	}
+	actual, err := values.ComputeDigest(expected.Algorithm(), content)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", profileURL, err)
+	}
+	if !actual.Equals(expected) {
+		return nil, fmt.Errorf("profile %q: %w", profileURL, &entities.IntegrityError{
+			Expected: expected,
+			Actual:   actual,
+		})
+	}
+	return locked, nil
+}
+
+// RefreshProfileLock records freshly fetched profile content in the lockfile.
+// An unchanged profile is returned as locked without prompting, and a profile
+// with no lock entry is locked as-is. When the content differs from the
+// locked digest the user must trust the profile again through the
+// ProfileTrustPrompter before the lock is updated; a refusal returns
+// entities.ErrProfileTrustDenied, and without a prompter the mismatch is
+// reported as an *entities.IntegrityError.
+func (s *LockfileService) RefreshProfileLock(
+	ctx context.Context,
+	lockfilePath string,
+	profileURL string,
+	version string,
+	content []byte,
+	requiredCaps map[string]*hostfunc.GrantSet,
+) (*entities.ProfileLock, error) {
+	locked, err := s.VerifyLockedProfile(ctx, lockfilePath, profileURL, content)
+	if err == nil && locked != nil {
+		return locked, nil
+	}
+
+	var integrityErr *entities.IntegrityError
+	if err != nil {
+		if !errors.As(err, &integrityErr) || s.trustPrompter == nil {
+			return nil, err
+		}
+		trusted, promptErr := s.trustPrompter.PromptForProfileTrustWithGrantSet(profileURL, requiredCaps)
+		if promptErr != nil {
+			return nil, fmt.Errorf("profile %q changed since it was locked: %w", profileURL, promptErr)
+		}
+		if !trusted {
+			return nil, fmt.Errorf("profile %q changed since it was locked: %w", profileURL, entities.ErrProfileTrustDenied)
+		}
+	}
+
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", profileURL, err)
+	}
+	if err := s.LockProfile(ctx, lockfilePath, profileURL, version, digest.String()); err != nil {
+		return nil, err
+	}
+	return s.GetLockedProfile(ctx, lockfilePath, profileURL)
+}
@@ -1,12 +1,20 @@
 package plugin_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
 	"github.com/reglet-dev/reglet-host-sdk/plugin"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/resolvers"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -40,7 +48,8 @@ func TestLockfileService_ResolvePlugins(t *testing.T) {
 
 	// Setup
 	mockRepo := new(MockRepo)
-	svc := plugin.NewLockfileService(mockRepo, nil, nil) // Resolver/Digester unused for now
+	svc := plugin.NewLockfileService(mockRepo, resolvers.NewSemverResolver(), fakeDigester{},
+		plugin.WithVersionSource(&fakeVersionSource{versions: []string{"1.0.0", "2.0.0"}}))
 
 	ctx := context.Background()
 	lockPath := "reglet.lock"
@@ -61,6 +70,8 @@ func TestLockfileService_ResolvePlugins(t *testing.T) {
 		plugin := lock.GetPlugin("test") // "reglet/test" -> name="test"
 		require.NotNil(t, plugin)
 		assert.Equal(t, "1.0", plugin.Requested)
+		_, err = values.ParseDigest(plugin.Digest)
+		assert.NoError(t, err, "locked digests must be loadable")
 
 		mockRepo.AssertExpectations(t)
 	})
@@ -107,4 +118,391 @@ func TestLockfileService_ResolvePlugins(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, "2.0", lock.GetPlugin("test").Requested)
 	})
+
+	t.Run("fails without a version source", func(t *testing.T) {
+		repo := new(MockRepo)
+		repo.On("Load", ctx, lockPath).Return(nil, nil).Once()
+		svc := plugin.NewLockfileService(repo, nil, nil)
+
+		_, err := svc.ResolvePlugins(ctx, []string{"reglet/test@1.0"}, lockPath)
+		assert.ErrorIs(t, err, plugin.ErrNoVersionSource)
+		repo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+// fakeVersionSource serves a fixed set of versions whose "binaries" are
+// paths the fakeDigester hashes by name.
+type fakeVersionSource struct {
+	versions []string
+	located  []string
+}
+
+func (f *fakeVersionSource) AvailableVersions(ctx context.Context, spec *entities.PluginSpec) ([]string, error) {
+	return f.versions, nil
+}
+
+func (f *fakeVersionSource) Locate(ctx context.Context, spec *entities.PluginSpec, version string) (string, error) {
+	f.located = append(f.located, version)
+	return "/cache/" + spec.Name + "/" + version + "/plugin.wasm", nil
+}
+
+type fakeDigester struct{}
+
+func (fakeDigester) DigestBytes(data []byte) string {
+	d, _ := values.ComputeDigest("sha256", data)
+	return d.String()
+}
+
+func (f fakeDigester) DigestFile(ctx context.Context, path string) (string, error) {
+	return f.DigestBytes([]byte(path)), nil
+}
+
+func TestLockfileService_ResolvePlugins_Constraints(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	available := []string{"0.9.0", "1.0.0", "1.2.0", "1.4.1", "1.10.0-rc.1", "2.0.0"}
+
+	tests := []struct {
+		declaration string
+		want        string
+	}{
+		{"reglet/file@^1.0.0", "1.4.1"},
+		{"reglet/file@>=1.2.0 <1.4.0", "1.2.0"},
+		{"reglet/file@~1.0", "1.0.0"},
+		{"reglet/file", "2.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.declaration, func(t *testing.T) {
+			mockRepo := new(MockRepo)
+			mockRepo.On("Load", ctx, "reglet.lock").Return(nil, nil).Once()
+			mockRepo.On("Save", ctx, mock.AnythingOfType("*entities.Lockfile"), "reglet.lock").Return(nil).Once()
+
+			source := &fakeVersionSource{versions: available}
+			svc := plugin.NewLockfileService(mockRepo, resolvers.NewSemverResolver(), fakeDigester{},
+				plugin.WithVersionSource(source))
+
+			lock, err := svc.ResolvePlugins(ctx, []string{tt.declaration}, "reglet.lock")
+			require.NoError(t, err)
+
+			locked := lock.GetPlugin("file")
+			require.NotNil(t, locked)
+			assert.Equal(t, tt.want, locked.Resolved)
+			assert.Equal(t, []string{tt.want}, source.located)
+			assert.Equal(t, fakeDigester{}.DigestBytes([]byte("/cache/file/"+tt.want+"/plugin.wasm")), locked.Digest)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("unsatisfiable", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, "reglet.lock").Return(nil, nil).Once()
+
+		svc := plugin.NewLockfileService(mockRepo, resolvers.NewSemverResolver(), fakeDigester{},
+			plugin.WithVersionSource(&fakeVersionSource{versions: available}))
+
+		_, err := svc.ResolvePlugins(ctx, []string{"reglet/file@^3.0.0"}, "reglet.lock")
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("malformed pinned digest", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, "reglet.lock").Return(nil, nil).Once()
+
+		source := &fakeVersionSource{versions: available}
+		svc := plugin.NewLockfileService(mockRepo, resolvers.NewSemverResolver(), fakeDigester{},
+			plugin.WithVersionSource(source))
+
+		_, err := svc.ResolvePlugins(ctx, []string{"reglet/file@sha256:nothex"}, "reglet.lock")
+		require.Error(t, err)
+		var integrityErr *entities.IntegrityError
+		assert.False(t, errors.As(err, &integrityErr), "a malformed pin is a parse error, not a mismatch")
+		assert.Empty(t, source.located)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("mismatched pinned digest", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, "reglet.lock").Return(nil, nil).Once()
+
+		svc := plugin.NewLockfileService(mockRepo, resolvers.NewSemverResolver(), fakeDigester{},
+			plugin.WithVersionSource(&fakeVersionSource{versions: available}))
+
+		pinned := "sha256:" + strings.Repeat("0", 64)
+		_, err := svc.ResolvePlugins(ctx, []string{"reglet/file@" + pinned}, "reglet.lock")
+		var integrityErr *entities.IntegrityError
+		require.ErrorAs(t, err, &integrityErr)
+		assert.Equal(t, pinned, integrityErr.Expected.String())
+		assert.Equal(t, fakeDigester{}.DigestBytes([]byte("/cache/file/2.0.0/plugin.wasm")), integrityErr.Actual.String())
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestLockfileService_VerifyLockedProfile(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lockPath := "reglet.lock"
+	profileURL := "oci://ghcr.io/org/profiles/baseline:1.0.0"
+	content := []byte("name: baseline\n")
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	lock := entities.NewLockfile()
+	require.NoError(t, lock.AddProfile(profileURL, entities.ProfileLock{
+		Requested: profileURL,
+		Resolved:  "1.0.0",
+		Source:    profileURL,
+		Digest:    digest.String(),
+	}))
+
+	t.Run("matching content", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lock, nil).Once()
+		svc := plugin.NewLockfileService(mockRepo, nil, nil)
+
+		locked, err := svc.VerifyLockedProfile(ctx, lockPath, profileURL, content)
+		require.NoError(t, err)
+		require.NotNil(t, locked)
+		assert.Equal(t, digest.String(), locked.Digest)
+	})
+
+	t.Run("changed content", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lock, nil).Once()
+		svc := plugin.NewLockfileService(mockRepo, nil, nil)
+
+		_, err := svc.VerifyLockedProfile(ctx, lockPath, profileURL, []byte("name: tampered\n"))
+		require.Error(t, err)
+		assert.ErrorIs(t, err, entities.ErrIntegrityCheckFailed)
+
+		var integrityErr *entities.IntegrityError
+		require.ErrorAs(t, err, &integrityErr)
+		assert.True(t, integrityErr.Expected.Equals(digest))
+	})
+
+	t.Run("unlocked profile", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lock, nil).Once()
+		svc := plugin.NewLockfileService(mockRepo, nil, nil)
+
+		locked, err := svc.VerifyLockedProfile(ctx, lockPath, "oci://ghcr.io/org/profiles/other:1.0.0", content)
+		require.NoError(t, err)
+		assert.Nil(t, locked)
+	})
+}
+
+type mockTrustPrompter struct {
+	calls   int
+	trusted bool
+}
+
+func (p *mockTrustPrompter) PromptForProfileTrustWithGrantSet(string, map[string]*hostfunc.GrantSet) (bool, error) {
+	p.calls++
+	return p.trusted, nil
+}
+
+func TestLockfileService_RefreshProfileLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lockPath := "reglet.lock"
+	profileURL := "oci://ghcr.io/org/profiles/baseline:1.0.0"
+	original := []byte("name: baseline\n")
+	changed := []byte("name: baseline\ncontrols: [new]\n")
+	originalDigest, err := values.ComputeDigestSHA256(bytes.NewReader(original))
+	require.NoError(t, err)
+	changedDigest, err := values.ComputeDigestSHA256(bytes.NewReader(changed))
+	require.NoError(t, err)
+
+	lockedFile := func(t *testing.T) *entities.Lockfile {
+		lock := entities.NewLockfile()
+		require.NoError(t, lock.AddProfile(profileURL, entities.ProfileLock{
+			Requested: profileURL,
+			Resolved:  "1.0.0",
+			Source:    profileURL,
+			Digest:    originalDigest.String(),
+		}))
+		return lock
+	}
+
+	t.Run("unchanged profile is not re-prompted", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lockedFile(t), nil)
+		prompter := &mockTrustPrompter{}
+		svc := plugin.NewLockfileService(mockRepo, nil, nil, plugin.WithProfileTrustPrompter(prompter))
+
+		locked, err := svc.RefreshProfileLock(ctx, lockPath, profileURL, "1.0.0", original, nil)
+		require.NoError(t, err)
+		assert.Equal(t, originalDigest.String(), locked.Digest)
+		assert.Zero(t, prompter.calls)
+		mockRepo.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("changed profile is re-prompted and relocked", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lockedFile(t), nil)
+		mockRepo.On("Save", ctx, mock.MatchedBy(func(l *entities.Lockfile) bool {
+			return l.GetProfile(profileURL).Digest == changedDigest.String()
+		}), lockPath).Return(nil).Once()
+		prompter := &mockTrustPrompter{trusted: true}
+		svc := plugin.NewLockfileService(mockRepo, nil, nil, plugin.WithProfileTrustPrompter(prompter))
+
+		locked, err := svc.RefreshProfileLock(ctx, lockPath, profileURL, "1.0.0", changed, nil)
+		require.NoError(t, err)
+		assert.Equal(t, changedDigest.String(), locked.Digest)
+		assert.Equal(t, 1, prompter.calls)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("changed profile is rejected when trust is declined", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lockedFile(t), nil)
+		prompter := &mockTrustPrompter{trusted: false}
+		svc := plugin.NewLockfileService(mockRepo, nil, nil, plugin.WithProfileTrustPrompter(prompter))
+
+		_, err := svc.RefreshProfileLock(ctx, lockPath, profileURL, "1.0.0", changed, nil)
+		assert.ErrorIs(t, err, entities.ErrProfileTrustDenied)
+		assert.Equal(t, 1, prompter.calls)
+		mockRepo.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("changed profile without a prompter fails integrity", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(lockedFile(t), nil)
+		svc := plugin.NewLockfileService(mockRepo, nil, nil)
+
+		_, err := svc.RefreshProfileLock(ctx, lockPath, profileURL, "1.0.0", changed, nil)
+		assert.ErrorIs(t, err, entities.ErrIntegrityCheckFailed)
+		mockRepo.AssertNotCalled(t, "Save")
+	})
+}
+
+type fakeProfileSource struct {
+	content  map[string][]byte // keyed by "source#version"
+	latest   string
+	failures int // number of initial fetches that fail with err
+	err      error
+	requests []string
+}
+
+func (f *fakeProfileSource) FetchProfile(_ context.Context, source, version string) ([]byte, string, error) {
+	f.requests = append(f.requests, source+"#"+version)
+	if len(f.requests) <= f.failures {
+		return nil, "", f.err
+	}
+	if version == "latest" {
+		version = f.latest
+	}
+	content, ok := f.content[source+"#"+version]
+	if !ok {
+		return nil, "", errors.New("not found")
+	}
+	return content, version, nil
+}
+
+func TestLockfileService_LockProfileFromURL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lockPath := "reglet.lock"
+	source := "https://profiles.example.com/baseline.yaml"
+	v1 := []byte("name: baseline\nversion: 1\n")
+	v2 := []byte("name: baseline\nversion: 2\n")
+	v1Digest, err := values.ComputeDigestSHA256(bytes.NewReader(v1))
+	require.NoError(t, err)
+	v2Digest, err := values.ComputeDigestSHA256(bytes.NewReader(v2))
+	require.NoError(t, err)
+
+	newSource := func() *fakeProfileSource {
+		return &fakeProfileSource{
+			content: map[string][]byte{source + "#v1.0.0": v1, source + "#v2.0.0": v2},
+			latest:  "v2.0.0",
+		}
+	}
+
+	t.Run("with fragment", func(t *testing.T) {
+		requested := source + "#v1.0.0"
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(nil, nil)
+		mockRepo.On("Save", ctx, mock.MatchedBy(func(l *entities.Lockfile) bool {
+			p := l.GetProfile(requested)
+			return p != nil && p.Digest == v1Digest.String()
+		}), lockPath).Return(nil).Once()
+		profiles := newSource()
+		svc := plugin.NewLockfileService(mockRepo, nil, nil, plugin.WithProfileSource(profiles))
+
+		locked, err := svc.LockProfileFromURL(ctx, lockPath, requested)
+		require.NoError(t, err)
+		assert.Equal(t, []string{source + "#v1.0.0"}, profiles.requests)
+		assert.Equal(t, requested, locked.Requested)
+		assert.Equal(t, source, locked.Source)
+		assert.Equal(t, "v1.0.0", locked.Resolved)
+		assert.Equal(t, v1Digest.String(), locked.Digest)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("without fragment resolves latest", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(nil, nil)
+		mockRepo.On("Save", ctx, mock.Anything, lockPath).Return(nil).Once()
+		profiles := newSource()
+		svc := plugin.NewLockfileService(mockRepo, nil, nil, plugin.WithProfileSource(profiles))
+
+		locked, err := svc.LockProfileFromURL(ctx, lockPath, source)
+		require.NoError(t, err)
+		assert.Equal(t, []string{source + "#latest"}, profiles.requests)
+		assert.Equal(t, source, locked.Requested)
+		assert.Equal(t, source, locked.Source)
+		assert.Equal(t, "v2.0.0", locked.Resolved)
+		assert.Equal(t, v2Digest.String(), locked.Digest)
+	})
+
+	t.Run("fetch failure does not save", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		svc := plugin.NewLockfileService(mockRepo, nil, nil, plugin.WithProfileSource(newSource()))
+
+		_, err := svc.LockProfileFromURL(ctx, lockPath, source+"#v9.9.9")
+		require.Error(t, err)
+		mockRepo.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("retries transient fetch failures", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		mockRepo.On("Load", ctx, lockPath).Return(nil, nil)
+		mockRepo.On("Save", ctx, mock.Anything, lockPath).Return(nil).Once()
+		profiles := newSource()
+		profiles.failures = 2
+		profiles.err = errors.New("connection reset")
+		svc := plugin.NewLockfileService(mockRepo, nil, nil,
+			plugin.WithProfileSource(profiles),
+			plugin.WithProfileFetchRetry(netutil.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+		locked, err := svc.LockProfileFromURL(ctx, lockPath, source+"#v1.0.0")
+		require.NoError(t, err)
+		assert.Len(t, profiles.requests, 3)
+		assert.Equal(t, v1Digest.String(), locked.Digest)
+	})
+
+	t.Run("does not retry integrity failures", func(t *testing.T) {
+		mockRepo := new(MockRepo)
+		profiles := newSource()
+		profiles.failures = 10
+		profiles.err = fmt.Errorf("profile layer: %w", entities.ErrIntegrityCheckFailed)
+		svc := plugin.NewLockfileService(mockRepo, nil, nil,
+			plugin.WithProfileSource(profiles),
+			plugin.WithProfileFetchRetry(netutil.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}))
+
+		_, err := svc.LockProfileFromURL(ctx, lockPath, source+"#v1.0.0")
+		require.ErrorIs(t, err, entities.ErrIntegrityCheckFailed)
+		assert.Len(t, profiles.requests, 1)
+		mockRepo.AssertNotCalled(t, "Save")
+	})
+
+	t.Run("no profile source", func(t *testing.T) {
+		svc := plugin.NewLockfileService(new(MockRepo), nil, nil)
+		_, err := svc.LockProfileFromURL(ctx, lockPath, source)
+		require.Error(t, err)
+	})
 }
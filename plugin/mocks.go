@@ -93,8 +93,8 @@ func (m *MockRegistry) Push(ctx context.Context, artifact *dto.PluginArtifactDTO
 }
 
 func (m *MockRegistry) Resolve(ctx context.Context, ref values.PluginReference) (values.Digest, error) {
-	// Dummy digest for mock
-	d, _ := values.NewDigest("sha256", "mockdigest")
+	// Dummy digest for mock: the SHA-256 of no content
+	d, _ := values.NewDigest("sha256", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
 	return d, nil
 }
 
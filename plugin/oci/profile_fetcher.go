@@ -0,0 +1,193 @@
+package oci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/errdef"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// ProfileLayerMediaType identifies the profile document layer of a profile artifact.
+const ProfileLayerMediaType = "application/vnd.reglet.profile.v1+yaml"
+
+// DefaultMaxProfileSize is the largest profile layer fetched unless configured
+// otherwise with WithMaxProfileSize.
+const DefaultMaxProfileSize int64 = 4 << 20
+
+// ErrProfileNotFound is returned when a profile reference does not exist in
+// the registry. Like ErrRegistryAuth it is marked netutil.Permanent.
+var ErrProfileNotFound = netutil.Permanent(errors.New("profile not found"))
+
+// FetchedProfile is a profile document pulled from a registry.
+type FetchedProfile struct {
+	// Source is the reference the profile was fetched from, without the
+	// oci:// scheme, e.g. "ghcr.io/org/profiles/baseline:1.0.0".
+	Source string
+
+	// Version is the tag or digest the reference named.
+	Version string
+
+	// Data is the profile document.
+	Data []byte
+
+	// Digest is the content digest of Data, suitable for a profile lock.
+	Digest values.Digest
+
+	// ManifestDigest is the digest of the manifest the reference resolved to.
+	ManifestDigest values.Digest
+}
+
+// ProfileFetcher pulls remote profiles published as OCI artifacts, addressed
+// by oci:// URLs. It shares registry access (credentials, transport, retries)
+// with the OCIRegistryAdapter it is built from.
+type ProfileFetcher struct {
+	registry *OCIRegistryAdapter
+	retry    *netutil.RetryPolicy
+	maxSize  int64
+}
+
+// ProfileFetcherOption configures a ProfileFetcher.
+type ProfileFetcherOption func(*ProfileFetcher)
+
+// WithMaxProfileSize limits the size of profile layers.
+// Non-positive values keep the default.
+func WithMaxProfileSize(n int64) ProfileFetcherOption {
+	return func(f *ProfileFetcher) {
+		if n > 0 {
+			f.maxSize = n
+		}
+	}
+}
+
+// WithProfileRetry retries a whole fetch according to policy. Missing
+// profiles, rejected credentials and digest mismatches are never retried;
+// other failures are retried when policy.Retryable allows it, or by default
+// unless ctx is done or the request was blocked by SSRF protection.
+func WithProfileRetry(policy netutil.RetryPolicy) ProfileFetcherOption {
+	return func(f *ProfileFetcher) {
+		retryable := policy.Retryable
+		policy.Retryable = func(err error) bool {
+			if errors.Is(err, ErrProfileNotFound) || errors.Is(err, entities.ErrIntegrityCheckFailed) {
+				return false
+			}
+			if retryable != nil {
+				return retryable(err)
+			}
+			return !errors.Is(err, context.Canceled) &&
+				!errors.Is(err, context.DeadlineExceeded) &&
+				!netutil.IsSSRFBlockedError(err)
+		}
+		f.retry = &policy
+	}
+}
+
+// NewProfileFetcher creates a profile fetcher using registry's connection settings.
+func NewProfileFetcher(registry *OCIRegistryAdapter, opts ...ProfileFetcherOption) *ProfileFetcher {
+	f := &ProfileFetcher{
+		registry: registry,
+		maxSize:  DefaultMaxProfileSize,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Fetch pulls the profile at rawURL, for example
+// "oci://ghcr.io/org/profiles/baseline:1.0.0" or a digest-pinned
+// "oci://ghcr.io/org/profiles/baseline@sha256:...". The profile layer is
+// verified against its digest before it is returned.
+func (f *ProfileFetcher) Fetch(ctx context.Context, rawURL string) (*FetchedProfile, error) {
+	if f.retry == nil {
+		return f.fetch(ctx, rawURL)
+	}
+
+	var profile *FetchedProfile
+	err := netutil.Retry(ctx, *f.retry, func(ctx context.Context) error {
+		var err error
+		profile, err = f.fetch(ctx, rawURL)
+		return err
+	})
+	return profile, err
+}
+
+func (f *ProfileFetcher) fetch(ctx context.Context, rawURL string) (*FetchedProfile, error) {
+	if !netutil.IsOCI(rawURL) {
+		return nil, fmt.Errorf("not an oci:// profile URL: %s", netutil.StripCredentials(rawURL))
+	}
+	reference := rawURL[len("oci://"):]
+
+	repo, err := f.registry.newRepository(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	version := repo.Reference.Reference
+	if version == "" {
+		return nil, fmt.Errorf("profile reference %s has no tag or digest", reference)
+	}
+
+	manifestDesc, manifestBytes, err := oras.FetchBytes(ctx, repo, version, oras.DefaultFetchBytesOptions)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrProfileNotFound, reference)
+		}
+		return nil, classifyRegistryError(fmt.Sprintf("fetch profile %s", reference), err)
+	}
+
+	manifest, err := f.registry.parseManifest(manifestBytes)
+	if err != nil {
+		return nil, err
+	}
+	layer, err := findProfileLayer(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: %w", reference, err)
+	}
+	if layer.Size > f.maxSize {
+		return nil, fmt.Errorf("profile layer is %d bytes, exceeding the %d byte limit: %w",
+			layer.Size, f.maxSize, &netutil.SizeLimitExceededError{Limit: f.maxSize, Read: layer.Size})
+	}
+
+	rc, err := repo.Fetch(ctx, layer)
+	if err != nil {
+		return nil, classifyRegistryError("fetch profile layer", err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+
+	var data bytes.Buffer
+	digest, err := copyVerified(&data, rc, layer, f.maxSize, "profile layer")
+	if err != nil {
+		return nil, err
+	}
+
+	manifestDigest, err := values.ParseDigest(string(manifestDesc.Digest))
+	if err != nil {
+		return nil, fmt.Errorf("profile %s: invalid manifest digest: %w", reference, err)
+	}
+
+	return &FetchedProfile{
+		Source:         repo.Reference.String(),
+		Version:        version,
+		Data:           data.Bytes(),
+		Digest:         digest,
+		ManifestDigest: manifestDigest,
+	}, nil
+}
+
+func findProfileLayer(manifest *ocispec.Manifest) (ocispec.Descriptor, error) {
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == ProfileLayerMediaType {
+			return layer, nil
+		}
+	}
+	return ocispec.Descriptor{}, fmt.Errorf("no profile layer found")
+}
@@ -0,0 +1,169 @@
+package oci_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/oci"
+)
+
+const testProfile = `name: baseline
+version: 1.0.0
+controls:
+  - id: ssh-root-login
+`
+
+func putProfile(t *testing.T, reg *fakeRegistry, repo, tag string, data []byte) string {
+	t.Helper()
+	return reg.putManifest(t, repo, tag, ocispec.MediaTypeEmptyJSON, []byte("{}"), ocispec.Descriptor{
+		MediaType: oci.ProfileLayerMediaType,
+		Digest:    godigest.Digest(sha256Digest(data)),
+		Size:      int64(len(data)),
+	}, data)
+}
+
+func TestProfileFetcher_Fetch(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.username, reg.password = "alice", "s3cret"
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	manifestDigest := putProfile(t, reg, "org/profiles/baseline", "1.0.0", []byte(testProfile))
+	fetcher := oci.NewProfileFetcher(oci.NewOCIRegistryAdapter(staticAuth{"alice", "s3cret"}, oci.WithPlainHTTP(true)))
+
+	t.Run("Tag", func(t *testing.T) {
+		profile, err := fetcher.Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:1.0.0")
+		require.NoError(t, err)
+
+		assert.Equal(t, testProfile, string(profile.Data))
+		assert.Equal(t, sha256Digest([]byte(testProfile)), profile.Digest.String())
+		assert.Equal(t, manifestDigest, profile.ManifestDigest.String())
+		assert.Equal(t, "1.0.0", profile.Version)
+		assert.Equal(t, host+"/org/profiles/baseline:1.0.0", profile.Source)
+	})
+
+	t.Run("DigestPinned", func(t *testing.T) {
+		profile, err := fetcher.Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline@"+manifestDigest)
+		require.NoError(t, err)
+		assert.Equal(t, testProfile, string(profile.Data))
+		assert.Equal(t, manifestDigest, profile.Version)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := fetcher.Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:9.9.9")
+		assert.ErrorIs(t, err, oci.ErrProfileNotFound)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		data := []byte(testProfile)
+		tampered := []byte(strings.Replace(testProfile, "ssh", "xxx", 1))
+		reg.mu.Lock()
+		reg.tampered[sha256Digest(data)] = tampered
+		reg.mu.Unlock()
+		defer func() {
+			reg.mu.Lock()
+			delete(reg.tampered, sha256Digest(data))
+			reg.mu.Unlock()
+		}()
+
+		_, err := fetcher.Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:1.0.0")
+		assert.ErrorIs(t, err, oci.ErrContentDigestMismatch)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		small := oci.NewProfileFetcher(
+			oci.NewOCIRegistryAdapter(staticAuth{"alice", "s3cret"}, oci.WithPlainHTTP(true)),
+			oci.WithMaxProfileSize(16),
+		)
+		_, err := small.Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:1.0.0")
+		var sizeErr *netutil.SizeLimitExceededError
+		assert.ErrorAs(t, err, &sizeErr)
+	})
+
+	t.Run("NotOCI", func(t *testing.T) {
+		_, err := fetcher.Fetch(context.Background(), "https://example.com/profile.yaml")
+		assert.Error(t, err)
+	})
+
+	t.Run("MissingTag", func(t *testing.T) {
+		_, err := fetcher.Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline")
+		assert.Error(t, err)
+	})
+}
+
+// droppingTransport fails the first failures manifest requests at the
+// network level, as if the registry were briefly unreachable.
+type droppingTransport struct {
+	base     http.RoundTripper
+	failures int
+	requests int
+	mu       sync.Mutex
+}
+
+func (d *droppingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if strings.Contains(req.URL.Path, "/manifests/") {
+		d.mu.Lock()
+		d.requests++
+		fail := d.requests <= d.failures
+		d.mu.Unlock()
+		if fail {
+			return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+		}
+	}
+	return d.base.RoundTrip(req)
+}
+
+func TestProfileFetcher_Retry(t *testing.T) {
+	reg := newFakeRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	putProfile(t, reg, "org/profiles/baseline", "1.0.0", []byte(testProfile))
+
+	policy := netutil.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	newFetcher := func(transport http.RoundTripper) *oci.ProfileFetcher {
+		return oci.NewProfileFetcher(oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithRegistryRetry(0, 0),
+			oci.WithRegistryTransport(transport),
+		), oci.WithProfileRetry(policy))
+	}
+
+	t.Run("RecoversAfterTwoFailures", func(t *testing.T) {
+		dropping := &droppingTransport{base: http.DefaultTransport, failures: 2}
+		profile, err := newFetcher(dropping).Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:1.0.0")
+		require.NoError(t, err)
+		assert.Equal(t, testProfile, string(profile.Data))
+		assert.Equal(t, 3, dropping.requests)
+	})
+
+	t.Run("ExhaustsRetries", func(t *testing.T) {
+		dropping := &droppingTransport{base: http.DefaultTransport, failures: 10}
+		_, err := newFetcher(dropping).Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:1.0.0")
+		var exhausted *netutil.RetryExhaustedError
+		require.ErrorAs(t, err, &exhausted)
+		assert.ErrorIs(t, err, oci.ErrRegistryUnreachable)
+		assert.Equal(t, 3, dropping.requests)
+	})
+
+	t.Run("NotFoundIsNotRetried", func(t *testing.T) {
+		dropping := &droppingTransport{base: http.DefaultTransport}
+		_, err := newFetcher(dropping).Fetch(context.Background(), "oci://"+host+"/org/profiles/baseline:9.9.9")
+		assert.ErrorIs(t, err, oci.ErrProfileNotFound)
+		assert.Equal(t, 1, dropping.requests)
+	})
+}
@@ -4,74 +4,197 @@ package oci
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
+	"net/http"
+	"time"
 
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
 	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/errdef"
 	"oras.land/oras-go/v2/registry/remote"
 	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
 
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 )
 
+// Media types of the artifacts produced by Push.
+const (
+	// WASMLayerMediaType identifies the plugin's WebAssembly module layer.
+	WASMLayerMediaType = "application/vnd.reglet.plugin.wasm.v1"
+
+	// ConfigMediaType identifies the plugin metadata config blob.
+	ConfigMediaType = "application/vnd.reglet.plugin.config.v1+json"
+)
+
+var (
+	// ErrRegistryAuth is returned when the registry rejects the credentials.
+	// It is marked netutil.Permanent, so retry loops never repeat a request
+	// the registry has refused.
+	ErrRegistryAuth = netutil.Permanent(errors.New("registry authentication failed"))
+
+	// ErrRegistryUnreachable is returned when the registry cannot be contacted.
+	ErrRegistryUnreachable = errors.New("registry unreachable")
+
+	// ErrContentDigestMismatch is returned when pulled content does not hash
+	// to the digest the registry advertised for it. It wraps
+	// entities.ErrIntegrityCheckFailed.
+	ErrContentDigestMismatch = fmt.Errorf("content digest mismatch: %w", entities.ErrIntegrityCheckFailed)
+)
+
+// DefaultMaxPluginSize is the largest WASM layer Pull accepts unless
+// configured otherwise with WithMaxPluginSize.
+const DefaultMaxPluginSize int64 = 100 << 20
+
 // OCIRegistryAdapter implements ports.PluginRegistry using oras-go.
 type OCIRegistryAdapter struct {
-	auth ports.AuthProvider
+	auth          ports.AuthProvider
+	transport     http.RoundTripper
+	httpClient    *http.Client
+	maxPluginSize int64
+	maxRetries    int
+	retryBackoff  time.Duration
+	plainHTTP     bool
+}
+
+// OCIRegistryOption configures an OCIRegistryAdapter.
+type OCIRegistryOption func(*OCIRegistryAdapter)
+
+// WithPlainHTTP talks to registries over HTTP instead of HTTPS.
+// Intended for local development registries.
+func WithPlainHTTP(plain bool) OCIRegistryOption {
+	return func(a *OCIRegistryAdapter) { a.plainHTTP = plain }
+}
+
+// WithMaxPluginSize limits the size of WASM layers accepted by Pull and PullTo.
+// Non-positive values keep the default.
+func WithMaxPluginSize(n int64) OCIRegistryOption {
+	return func(a *OCIRegistryAdapter) {
+		if n > 0 {
+			a.maxPluginSize = n
+		}
+	}
+}
+
+// WithRegistryRetry configures how registry requests are retried after
+// network errors and 429/502/503/504 responses. backoff is the initial delay,
+// doubled on each attempt; a Retry-After header from the registry takes
+// precedence. Other 4xx responses, including 401, 403 and 404, are never
+// retried. maxRetries <= 0 disables retries.
+// Default: 3 retries starting at 1s.
+func WithRegistryRetry(maxRetries int, backoff time.Duration) OCIRegistryOption {
+	return func(a *OCIRegistryAdapter) {
+		a.maxRetries = maxRetries
+		a.retryBackoff = backoff
+	}
+}
+
+// WithRegistryTransport sets the HTTP transport used to reach registries.
+// Retries are layered on top of it.
+func WithRegistryTransport(rt http.RoundTripper) OCIRegistryOption {
+	return func(a *OCIRegistryAdapter) { a.transport = rt }
 }
 
 // NewOCIRegistryAdapter creates an OCI registry adapter.
-func NewOCIRegistryAdapter(auth ports.AuthProvider) *OCIRegistryAdapter {
-	return &OCIRegistryAdapter{
-		auth: auth,
+func NewOCIRegistryAdapter(auth ports.AuthProvider, opts ...OCIRegistryOption) *OCIRegistryAdapter {
+	a := &OCIRegistryAdapter{
+		auth:          auth,
+		maxPluginSize: DefaultMaxPluginSize,
+		maxRetries:    3,
+		retryBackoff:  time.Second,
 	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	transport := a.transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if a.maxRetries > 0 {
+		transport = &netutil.RetryTransport{
+			Base:           transport,
+			MaxRetries:     a.maxRetries,
+			InitialBackoff: a.retryBackoff,
+		}
+	}
+	a.httpClient = &http.Client{Transport: transport}
+	return a
 }
 
-// Pull downloads a plugin from OCI registry.
-func (a *OCIRegistryAdapter) Pull(ctx context.Context, ref values.PluginReference) (*dto.PluginArtifactDTO, error) {
-	// Create repository client
-	repo, err := remote.NewRepository(ref.String())
+// repository creates a client for ref's repository, authenticated with the
+// credentials the auth provider has for its registry.
+func (a *OCIRegistryAdapter) repository(ctx context.Context, ref values.PluginReference) (*remote.Repository, error) {
+	return a.newRepository(ctx, ref.String())
+}
+
+// newRepository creates a client for an OCI reference string such as
+// "ghcr.io/org/repo:tag".
+func (a *OCIRegistryAdapter) newRepository(ctx context.Context, reference string) (*remote.Repository, error) {
+	repo, err := remote.NewRepository(reference)
 	if err != nil {
 		return nil, fmt.Errorf("create repository: %w", err)
 	}
+	repo.PlainHTTP = a.plainHTTP
+
+	client := &auth.Client{Client: a.httpClient}
+	repo.Client = client
 
-	// Set credentials
-	username, password, err := a.auth.GetCredentials(ctx, ref.Registry())
+	if a.auth == nil {
+		return repo, nil
+	}
+	username, password, err := a.auth.GetCredentials(ctx, repo.Reference.Registry)
 	if err == nil && username != "" {
-		repo.Client = &auth.Client{
-			Credential: func(ctx context.Context, registry string) (auth.Credential, error) {
-				return auth.Credential{
-					Username: username,
-					Password: password,
-				}, nil
-			},
+		client.Credential = func(ctx context.Context, registry string) (auth.Credential, error) {
+			return auth.Credential{
+				Username: username,
+				Password: password,
+			}, nil
 		}
 	}
+	return repo, nil
+}
 
-	// Pull manifest and layers
-	memoryStore := memory.New()
-	manifestDesc, err := oras.Copy(ctx, repo, ref.Version(), memoryStore, ref.Version(), oras.CopyOptions{})
+// Pull downloads a plugin from OCI registry.
+// The WASM module is buffered in memory; use PullTo to stream it elsewhere.
+func (a *OCIRegistryAdapter) Pull(ctx context.Context, ref values.PluginReference) (*dto.PluginArtifactDTO, error) {
+	var wasm bytes.Buffer
+	plugin, err := a.PullTo(ctx, ref, &wasm)
 	if err != nil {
-		return nil, fmt.Errorf("pull artifact: %w", err)
+		return nil, err
 	}
+	return dto.NewPluginArtifactDTO(plugin, io.NopCloser(&wasm)), nil
+}
 
-	// Parse manifest
-	manifestRC, err := memoryStore.Fetch(ctx, manifestDesc)
+// PullTo downloads a plugin and streams its WASM module into dst, hashing it
+// on the way. Layers larger than the configured maximum size are rejected.
+// On error dst may already hold part of the module, or all of a module that
+// failed verification, so callers should write to a temporary location and
+// only keep it when PullTo succeeds.
+func (a *OCIRegistryAdapter) PullTo(ctx context.Context, ref values.PluginReference, dst io.Writer) (*entities.Plugin, error) {
+	repo, err := a.repository(ctx, ref)
 	if err != nil {
-		return nil, fmt.Errorf("fetch manifest: %w", err)
+		return nil, err
 	}
-	defer func() {
-		_ = manifestRC.Close()
-	}()
 
-	manifestBytes, err := io.ReadAll(manifestRC)
+	// Fetch and parse manifest
+	_, manifestBytes, err := oras.FetchBytes(ctx, repo, manifestReference(ref), oras.DefaultFetchBytesOptions)
 	if err != nil {
-		return nil, fmt.Errorf("read manifest: %w", err)
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, &entities.PluginNotFoundError{Reference: ref}
+		}
+		return nil, classifyRegistryError(fmt.Sprintf("pull %s", ref.String()), err)
 	}
 
 	manifest, err := a.parseManifest(manifestBytes)
@@ -80,17 +203,12 @@ func (a *OCIRegistryAdapter) Pull(ctx context.Context, ref values.PluginReferenc
 	}
 
 	// Extract metadata from config layer
-	configRC, err := memoryStore.Fetch(ctx, manifest.Config)
-	if err != nil {
-		return nil, fmt.Errorf("fetch config: %w", err)
-	}
-	defer func() {
-		_ = configRC.Close()
-	}()
-
-	configBytes, err := io.ReadAll(configRC)
+	configBytes, err := content.FetchAll(ctx, repo, manifest.Config)
 	if err != nil {
-		return nil, fmt.Errorf("read config: %w", err)
+		if errors.Is(err, content.ErrMismatchedDigest) {
+			return nil, fmt.Errorf("fetch config: %w: %w", ErrContentDigestMismatch, err)
+		}
+		return nil, classifyRegistryError("fetch config", err)
 	}
 
 	metadata, err := a.parseMetadata(configBytes)
@@ -103,45 +221,256 @@ func (a *OCIRegistryAdapter) Pull(ctx context.Context, ref values.PluginReferenc
 	if err != nil {
 		return nil, err
 	}
+	if wasmDesc.Size > a.maxPluginSize {
+		return nil, fmt.Errorf("wasm layer is %d bytes, exceeding the %d byte limit: %w",
+			wasmDesc.Size, a.maxPluginSize, &netutil.SizeLimitExceededError{Limit: a.maxPluginSize, Read: wasmDesc.Size})
+	}
 
-	// Fetch WASM binary
-	wasmRC, err := memoryStore.Fetch(ctx, wasmDesc)
+	// Stream WASM binary, verifying it against the advertised digest
+	wasmRC, err := repo.Fetch(ctx, wasmDesc)
 	if err != nil {
-		return nil, fmt.Errorf("fetch wasm: %w", err)
+		return nil, classifyRegistryError("fetch wasm", err)
 	}
 	defer func() {
 		_ = wasmRC.Close()
 	}()
 
-	wasmBytes, err := io.ReadAll(wasmRC)
+	digest, err := copyVerified(dst, wasmRC, wasmDesc, a.maxPluginSize, "wasm layer")
 	if err != nil {
-		return nil, fmt.Errorf("read wasm: %w", err)
+		return nil, err
 	}
 
-	// Create domain entities
-	digest, _ := values.ParseDigest(string(wasmDesc.Digest))
-	plugin := entities.NewPlugin(ref, digest, metadata)
-
-	// Create DTO with I/O
-	artifact := dto.NewPluginArtifactDTO(plugin, io.NopCloser(bytes.NewReader(wasmBytes)))
-
-	return artifact, nil
+	return entities.NewPlugin(ref, digest, metadata), nil
 }
 
 // Push uploads a plugin to OCI registry.
+// The artifact is staged in memory as a manifest with a metadata config blob
+// and a single WASM layer, then copied to the registry under the reference's
+// version tag. Failures are wrapped with ErrRegistryAuth or
+// ErrRegistryUnreachable where the cause is known.
 func (a *OCIRegistryAdapter) Push(ctx context.Context, artifact *dto.PluginArtifactDTO) error {
-	// Implementation similar to Pull but reversed
-	// Use oras.Copy to push layers
+	staged, err := a.stage(ctx, artifact)
+	if err != nil {
+		return err
+	}
+
+	// Copy to the remote repository
+	repo, err := a.repository(ctx, staged.ref)
+	if err != nil {
+		return err
+	}
+	tag := staged.ref.Version()
+	if _, err := oras.Copy(ctx, staged.store, tag, repo, tag, oras.CopyOptions{}); err != nil {
+		return classifyRegistryError(fmt.Sprintf("push %s", staged.ref.String()), err)
+	}
 	return nil
 }
 
+// PushPlan describes what Push would do for an artifact.
+type PushPlan struct {
+	// Reference is the plugin reference being published.
+	Reference string
+
+	// Tag is the tag the manifest would be pushed under.
+	Tag string
+
+	// ExistingDigest is the manifest the tag currently points to, or the
+	// zero value when the tag does not exist yet.
+	ExistingDigest values.Digest
+
+	// Manifest, Config and Layers are the blobs that make up the artifact.
+	Manifest PlannedBlob
+	Config   PlannedBlob
+	Layers   []PlannedBlob
+}
+
+// PlannedBlob is one blob of a PushPlan.
+type PlannedBlob struct {
+	MediaType string
+	Digest    values.Digest
+	Size      int64
+
+	// Exists is true when the registry already has the blob, so Push would
+	// skip uploading it.
+	Exists bool
+}
+
+// UploadSize returns the number of bytes Push would upload.
+func (p *PushPlan) UploadSize() int64 {
+	var n int64
+	for _, b := range append([]PlannedBlob{p.Manifest, p.Config}, p.Layers...) {
+		if !b.Exists {
+			n += b.Size
+		}
+	}
+	return n
+}
+
+// PlanPush is a dry run of Push. It assembles the manifest exactly as Push
+// would and asks the registry which blobs it already has, requesting push
+// scope so that registries with token auth reject credentials that could
+// not publish. Only read requests are sent; nothing is uploaded or tagged.
+// Registries using basic auth cannot confirm push rights without writing, so
+// a plan succeeding there proves read access only.
+func (a *OCIRegistryAdapter) PlanPush(ctx context.Context, artifact *dto.PluginArtifactDTO) (*PushPlan, error) {
+	staged, err := a.stage(ctx, artifact)
+	if err != nil {
+		return nil, err
+	}
+	repo, err := a.repository(ctx, staged.ref)
+	if err != nil {
+		return nil, err
+	}
+	ctx = auth.AppendRepositoryScope(ctx, repo.Reference, auth.ActionPull, auth.ActionPush)
+	op := fmt.Sprintf("plan push %s", staged.ref.String())
+
+	plan := &PushPlan{
+		Reference: staged.ref.String(),
+		Tag:       staged.ref.Version(),
+	}
+	existing, err := repo.Resolve(ctx, plan.Tag)
+	switch {
+	case err == nil:
+		if plan.ExistingDigest, err = values.ParseDigest(string(existing.Digest)); err != nil {
+			return nil, fmt.Errorf("%s: invalid manifest digest: %w", op, err)
+		}
+	case !errors.Is(err, errdef.ErrNotFound):
+		return nil, classifyRegistryError(op, err)
+	}
+
+	planBlob := func(desc ocispec.Descriptor) (PlannedBlob, error) {
+		exists, err := repo.Exists(ctx, desc)
+		if err != nil {
+			return PlannedBlob{}, classifyRegistryError(op, err)
+		}
+		digest, err := values.ParseDigest(string(desc.Digest))
+		if err != nil {
+			return PlannedBlob{}, fmt.Errorf("%s: %w", op, err)
+		}
+		return PlannedBlob{MediaType: desc.MediaType, Digest: digest, Size: desc.Size, Exists: exists}, nil
+	}
+	if plan.Manifest, err = planBlob(staged.manifest); err != nil {
+		return nil, err
+	}
+	if plan.Config, err = planBlob(staged.config); err != nil {
+		return nil, err
+	}
+	layer, err := planBlob(staged.layer)
+	if err != nil {
+		return nil, err
+	}
+	plan.Layers = []PlannedBlob{layer}
+	return plan, nil
+}
+
+// stagedArtifact is an artifact assembled in memory, ready to copy.
+type stagedArtifact struct {
+	store    *memory.Store
+	ref      values.PluginReference
+	config   ocispec.Descriptor
+	layer    ocispec.Descriptor
+	manifest ocispec.Descriptor
+}
+
+// stage builds the manifest with a metadata config blob and a single WASM
+// layer, tagged with the reference's version.
+func (a *OCIRegistryAdapter) stage(ctx context.Context, artifact *dto.PluginArtifactDTO) (*stagedArtifact, error) {
+	if artifact == nil || artifact.Plugin == nil {
+		return nil, fmt.Errorf("push: artifact has no plugin")
+	}
+	if artifact.WASM == nil {
+		return nil, fmt.Errorf("push: artifact has no WASM content")
+	}
+	ref := artifact.Plugin.Reference()
+	if ref.IsEmbedded() {
+		return nil, fmt.Errorf("push: %s is not a registry reference", ref.String())
+	}
+	if ref.IsDigestPinned() {
+		return nil, fmt.Errorf("push: %s names a digest; push needs a version tag", ref.String())
+	}
+
+	wasmBytes, err := io.ReadAll(artifact.WASM)
+	if err != nil {
+		return nil, fmt.Errorf("read wasm: %w", err)
+	}
+	configBytes, err := a.marshalMetadata(artifact.Plugin.Metadata())
+	if err != nil {
+		return nil, err
+	}
+
+	// Stage config, layer and manifest locally
+	memoryStore := memory.New()
+	configDesc := content.NewDescriptorFromBytes(ConfigMediaType, configBytes)
+	if err := memoryStore.Push(ctx, configDesc, bytes.NewReader(configBytes)); err != nil {
+		return nil, fmt.Errorf("stage config: %w", err)
+	}
+	wasmDesc := content.NewDescriptorFromBytes(WASMLayerMediaType, wasmBytes)
+	if err := memoryStore.Push(ctx, wasmDesc, bytes.NewReader(wasmBytes)); err != nil {
+		return nil, fmt.Errorf("stage wasm: %w", err)
+	}
+
+	manifest := ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    configDesc,
+		Layers:    []ocispec.Descriptor{wasmDesc},
+	}
+	manifest.SchemaVersion = 2
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	manifestDesc := content.NewDescriptorFromBytes(ocispec.MediaTypeImageManifest, manifestBytes)
+	if err := memoryStore.Push(ctx, manifestDesc, bytes.NewReader(manifestBytes)); err != nil {
+		return nil, fmt.Errorf("stage manifest: %w", err)
+	}
+	if err := memoryStore.Tag(ctx, manifestDesc, ref.Version()); err != nil {
+		return nil, fmt.Errorf("tag manifest: %w", err)
+	}
+
+	return &stagedArtifact{
+		store:    memoryStore,
+		ref:      ref,
+		config:   configDesc,
+		layer:    wasmDesc,
+		manifest: manifestDesc,
+	}, nil
+}
+
 // Resolve resolves a reference to its digest.
+// The digest is that of the manifest the version tag currently points to.
+// A missing tag yields an *entities.PluginNotFoundError; rejected credentials
+// are wrapped with ErrRegistryAuth.
 func (a *OCIRegistryAdapter) Resolve(ctx context.Context, ref values.PluginReference) (values.Digest, error) {
-	// Use oras to resolve tag to digest
-	return values.Digest{}, nil
+	repo, err := a.repository(ctx, ref)
+	if err != nil {
+		return values.Digest{}, err
+	}
+
+	desc, err := repo.Resolve(ctx, manifestReference(ref))
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return values.Digest{}, &entities.PluginNotFoundError{Reference: ref}
+		}
+		return values.Digest{}, classifyRegistryError(fmt.Sprintf("resolve %s", ref.String()), err)
+	}
+
+	digest, err := values.ParseDigest(string(desc.Digest))
+	if err != nil {
+		return values.Digest{}, fmt.Errorf("resolve %s: invalid digest from registry: %w", ref.String(), err)
+	}
+	return digest, nil
 }
 
 // Helper methods
+
+// manifestReference returns the tag or digest that selects ref's manifest.
+func manifestReference(ref values.PluginReference) string {
+	if ref.IsDigestPinned() {
+		return ref.Digest().String()
+	}
+	return ref.Version()
+}
+
 func (a *OCIRegistryAdapter) parseManifest(data []byte) (*ocispec.Manifest, error) {
 	var manifest ocispec.Manifest
 	if err := json.Unmarshal(data, &manifest); err != nil {
@@ -165,9 +494,75 @@ func (a *OCIRegistryAdapter) parseMetadata(data []byte) (values.PluginMetadata,
 	return values.NewPluginMetadata(meta.Name, meta.Version, meta.Description, meta.Capabilities), nil
 }
 
+// copyVerified copies the layer described by desc from src to dst, enforcing
+// limit and checking the content against the descriptor's digest. sha256,
+// sha512 and sha512-256 descriptors are supported. label names the layer in errors.
+func copyVerified(dst io.Writer, src io.Reader, desc ocispec.Descriptor, limit int64, label string) (values.Digest, error) {
+	expected, err := values.ParseDigest(string(desc.Digest))
+	if err != nil {
+		return values.Digest{}, fmt.Errorf("%s: %w", label, err)
+	}
+	h, err := expected.Hasher()
+	if err != nil {
+		return values.Digest{}, fmt.Errorf("%s: %w", label, err)
+	}
+
+	// Read one byte past the limit so content of exactly limit bytes passes.
+	n, err := io.Copy(io.MultiWriter(dst, h), io.LimitReader(src, limit+1))
+	if err != nil {
+		return values.Digest{}, fmt.Errorf("read %s: %w", label, err)
+	}
+	if n > limit {
+		return values.Digest{}, fmt.Errorf("read %s: %w", label, &netutil.SizeLimitExceededError{Limit: limit, Read: n})
+	}
+	if n != desc.Size {
+		return values.Digest{}, fmt.Errorf("%s: %w: expected %d bytes, got %d", label, ErrContentDigestMismatch, desc.Size, n)
+	}
+
+	sum := h.Sum(nil)
+	if !expected.Matches(sum) {
+		return values.Digest{}, fmt.Errorf("%s: %w: expected %s, got %s:%s",
+			label, ErrContentDigestMismatch, expected, expected.Algorithm(), hex.EncodeToString(sum))
+	}
+	return expected, nil
+}
+
+func (a *OCIRegistryAdapter) marshalMetadata(meta values.PluginMetadata) ([]byte, error) {
+	data, err := json.Marshal(struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		Description  string   `json:"description,omitempty"`
+		Capabilities []string `json:"capabilities,omitempty"`
+	}{
+		Name:         meta.Name(),
+		Version:      meta.Version(),
+		Description:  meta.Description(),
+		Capabilities: meta.Capabilities(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return data, nil
+}
+
+// classifyRegistryError wraps err with ErrRegistryAuth or ErrRegistryUnreachable
+// when the failure came from rejected credentials or the network.
+func classifyRegistryError(op string, err error) error {
+	var respErr *errcode.ErrorResponse
+	if errors.As(err, &respErr) &&
+		(respErr.StatusCode == http.StatusUnauthorized || respErr.StatusCode == http.StatusForbidden) {
+		return fmt.Errorf("%s: %w: %w", op, ErrRegistryAuth, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return fmt.Errorf("%s: %w: %w", op, ErrRegistryUnreachable, err)
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
 func (a *OCIRegistryAdapter) findWASMLayer(manifest *ocispec.Manifest) (ocispec.Descriptor, error) {
 	for _, layer := range manifest.Layers {
-		if layer.MediaType == "application/vnd.reglet.plugin.wasm.v1" {
+		if layer.MediaType == WASMLayerMediaType {
 			return layer, nil
 		}
 	}
@@ -0,0 +1,644 @@
+package oci_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	godigest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/oci"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// fakeRegistry is a minimal in-memory OCI distribution registry, enough for
+// oras to push and pull a single-layer artifact.
+type fakeRegistry struct {
+	blobs     map[string][]byte
+	manifests map[string]fakeManifest // keyed by repo + "@" + tag or digest
+	tampered  map[string][]byte       // blob digest -> bytes served instead
+	username  string
+	password  string
+	uploads   int
+	writes    int // requests other than GET and HEAD
+	mu        sync.Mutex
+}
+
+type fakeManifest struct {
+	mediaType string
+	data      []byte
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]fakeManifest),
+		tampered:  make(map[string][]byte),
+	}
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.username != "" {
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != r.username || pass != r.password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="fake"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		r.writes++
+	}
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case req.URL.Path == "/v2/":
+		w.WriteHeader(http.StatusOK)
+	case strings.Contains(path, "/blobs/uploads/"):
+		r.serveUpload(w, req, path)
+	case strings.Contains(path, "/blobs/"):
+		digest := path[strings.LastIndex(path, "/")+1:]
+		data, ok := r.blobs[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if bad, ok := r.tampered[digest]; ok && req.Method == http.MethodGet {
+			data = bad
+		}
+		r.writeContent(w, req, "application/octet-stream", digest, data)
+	case strings.Contains(path, "/manifests/"):
+		i := strings.Index(path, "/manifests/")
+		repo, ref := path[:i], path[i+len("/manifests/"):]
+		r.serveManifest(w, req, repo, ref)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) serveUpload(w http.ResponseWriter, req *http.Request, path string) {
+	switch req.Method {
+	case http.MethodPost:
+		r.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s%d", path, r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodPut:
+		data, _ := io.ReadAll(req.Body)
+		digest := req.URL.Query().Get("digest")
+		if digest != sha256Digest(data) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[digest] = data
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repo, ref string) {
+	if req.Method == http.MethodPut {
+		data, _ := io.ReadAll(req.Body)
+		m := fakeManifest{mediaType: req.Header.Get("Content-Type"), data: data}
+		digest := sha256Digest(data)
+		r.manifests[repo+"@"+ref] = m
+		r.manifests[repo+"@"+digest] = m
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	m, ok := r.manifests[repo+"@"+ref]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	r.writeContent(w, req, m.mediaType, sha256Digest(m.data), m.data)
+}
+
+func (r *fakeRegistry) writeContent(w http.ResponseWriter, req *http.Request, mediaType, digest string, data []byte) {
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+func (r *fakeRegistry) manifest(repo, tag string) (fakeManifest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.manifests[repo+"@"+tag]
+	return m, ok
+}
+
+// putArtifact stores a plugin artifact directly, bypassing Push, so tests can
+// control the descriptors the registry advertises.
+func (r *fakeRegistry) putArtifact(t *testing.T, repo, tag string, wasmDesc ocispec.Descriptor, wasm []byte) {
+	t.Helper()
+	config := []byte(`{"name":"file","version":"1.2.0"}`)
+	r.putManifest(t, repo, tag, oci.ConfigMediaType, config, wasmDesc, wasm)
+}
+
+// putManifest stores a config blob, one layer and a manifest tying them
+// together, and returns the manifest digest.
+func (r *fakeRegistry) putManifest(t *testing.T, repo, tag, configMediaType string, config []byte, layerDesc ocispec.Descriptor, layer []byte) string {
+	t.Helper()
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{
+			MediaType: configMediaType,
+			Digest:    godigest.Digest(sha256Digest(config)),
+			Size:      int64(len(config)),
+		},
+		Layers: []ocispec.Descriptor{layerDesc},
+	})
+	require.NoError(t, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blobs[sha256Digest(config)] = config
+	r.blobs[string(layerDesc.Digest)] = layer
+	m := fakeManifest{mediaType: ocispec.MediaTypeImageManifest, data: manifest}
+	r.manifests[repo+"@"+tag] = m
+	r.manifests[repo+"@"+sha256Digest(manifest)] = m
+	return sha256Digest(manifest)
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func sha512Digest(data []byte) string {
+	sum := sha512.Sum512(data)
+	return "sha512:" + hex.EncodeToString(sum[:])
+}
+
+type staticAuth struct {
+	username, password string
+}
+
+func (a staticAuth) GetCredentials(context.Context, string) (string, string, error) {
+	return a.username, a.password, nil
+}
+
+func testArtifact(t *testing.T, registryHost string, wasm []byte) *dto.PluginArtifactDTO {
+	t.Helper()
+	ref := values.NewPluginReference(registryHost, "org", "plugins", "file", "1.2.0")
+	meta := values.NewPluginMetadata("file", "1.2.0", "File checks", []string{"fs:read:/etc/**"})
+	digest, err := values.ComputeDigestSHA256(strings.NewReader(string(wasm)))
+	require.NoError(t, err)
+	return dto.NewPluginArtifactDTO(entities.NewPlugin(ref, digest, meta), io.NopCloser(strings.NewReader(string(wasm))))
+}
+
+func TestOCIRegistryAdapter_Push(t *testing.T) {
+	reg := newFakeRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	adapter := oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true))
+	require.NoError(t, adapter.Push(context.Background(), testArtifact(t, host, wasm)))
+
+	stored, ok := reg.manifest("org/plugins/file", "1.2.0")
+	require.True(t, ok, "manifest should be tagged with the plugin version")
+	assert.Equal(t, ocispec.MediaTypeImageManifest, stored.mediaType)
+
+	var manifest ocispec.Manifest
+	require.NoError(t, json.Unmarshal(stored.data, &manifest))
+	assert.Equal(t, oci.ConfigMediaType, manifest.Config.MediaType)
+	require.Len(t, manifest.Layers, 1)
+	assert.Equal(t, oci.WASMLayerMediaType, manifest.Layers[0].MediaType)
+	assert.Equal(t, sha256Digest(wasm), string(manifest.Layers[0].Digest))
+
+	// Pulling the pushed artifact returns the same plugin
+	ref := values.NewPluginReference(host, "org", "plugins", "file", "1.2.0")
+	pulled, err := adapter.Pull(context.Background(), ref)
+	require.NoError(t, err)
+	defer pulled.Close()
+
+	got, err := io.ReadAll(pulled.WASM)
+	require.NoError(t, err)
+	assert.Equal(t, wasm, got)
+	assert.Equal(t, "file", pulled.Plugin.Metadata().Name())
+	assert.Equal(t, "File checks", pulled.Plugin.Metadata().Description())
+	assert.Equal(t, []string{"fs:read:/etc/**"}, pulled.Plugin.Metadata().Capabilities())
+}
+
+func TestOCIRegistryAdapter_Push_Auth(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.username, reg.password = "alice", "s3cret"
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	t.Run("ValidCredentials", func(t *testing.T) {
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{"alice", "s3cret"}, oci.WithPlainHTTP(true))
+		assert.NoError(t, adapter.Push(context.Background(), testArtifact(t, host, []byte("wasm"))))
+	})
+
+	t.Run("RejectedCredentials", func(t *testing.T) {
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{"alice", "wrong"}, oci.WithPlainHTTP(true))
+		err := adapter.Push(context.Background(), testArtifact(t, host, []byte("wasm")))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, oci.ErrRegistryAuth), "got %v", err)
+	})
+}
+
+func TestOCIRegistryAdapter_PlanPush(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.username, reg.password = "alice", "s3cret"
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	adapter := oci.NewOCIRegistryAdapter(staticAuth{"alice", "s3cret"}, oci.WithPlainHTTP(true))
+
+	t.Run("NewTag", func(t *testing.T) {
+		plan, err := adapter.PlanPush(context.Background(), testArtifact(t, host, wasm))
+		require.NoError(t, err)
+
+		assert.Equal(t, host+"/org/plugins/file:1.2.0", plan.Reference)
+		assert.Equal(t, "1.2.0", plan.Tag)
+		assert.Empty(t, plan.ExistingDigest.Value())
+		assert.Equal(t, ocispec.MediaTypeImageManifest, plan.Manifest.MediaType)
+		assert.Equal(t, oci.ConfigMediaType, plan.Config.MediaType)
+		require.Len(t, plan.Layers, 1)
+		assert.Equal(t, sha256Digest(wasm), plan.Layers[0].Digest.String())
+		assert.Equal(t, int64(len(wasm)), plan.Layers[0].Size)
+		assert.False(t, plan.Layers[0].Exists)
+		assert.Equal(t, plan.Manifest.Size+plan.Config.Size+int64(len(wasm)), plan.UploadSize())
+
+		assert.Zero(t, reg.writes, "dry run must not write to the registry")
+		_, ok := reg.manifest("org/plugins/file", "1.2.0")
+		assert.False(t, ok)
+	})
+
+	t.Run("AlreadyPublished", func(t *testing.T) {
+		require.NoError(t, adapter.Push(context.Background(), testArtifact(t, host, wasm)))
+		stored, ok := reg.manifest("org/plugins/file", "1.2.0")
+		require.True(t, ok)
+		reg.mu.Lock()
+		reg.writes = 0
+		reg.mu.Unlock()
+
+		plan, err := adapter.PlanPush(context.Background(), testArtifact(t, host, wasm))
+		require.NoError(t, err)
+		assert.Equal(t, sha256Digest(stored.data), plan.ExistingDigest.String())
+		assert.True(t, plan.Layers[0].Exists)
+		assert.True(t, plan.Config.Exists)
+		assert.Zero(t, plan.UploadSize())
+		assert.Zero(t, reg.writes)
+	})
+
+	t.Run("RejectedCredentials", func(t *testing.T) {
+		bad := oci.NewOCIRegistryAdapter(staticAuth{"alice", "wrong"}, oci.WithPlainHTTP(true))
+		_, err := bad.PlanPush(context.Background(), testArtifact(t, host, wasm))
+		assert.ErrorIs(t, err, oci.ErrRegistryAuth)
+	})
+}
+
+func TestOCIRegistryAdapter_Push_Unreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	host := strings.TrimPrefix(srv.URL, "http://")
+	srv.Close()
+
+	adapter := oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true), oci.WithRegistryRetry(0, 0))
+	err := adapter.Push(context.Background(), testArtifact(t, host, []byte("wasm")))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, oci.ErrRegistryUnreachable), "got %v", err)
+}
+
+func TestOCIRegistryAdapter_Push_InvalidArtifact(t *testing.T) {
+	adapter := oci.NewOCIRegistryAdapter(staticAuth{})
+
+	assert.Error(t, adapter.Push(context.Background(), nil))
+
+	ref := values.NewPluginReference("", "", "", "file", "")
+	embedded := dto.NewPluginArtifactDTO(
+		entities.NewPlugin(ref, values.Digest{}, values.NewPluginMetadata("file", "", "", nil)),
+		io.NopCloser(strings.NewReader("wasm")),
+	)
+	assert.Error(t, adapter.Push(context.Background(), embedded))
+}
+
+func TestOCIRegistryAdapter_Resolve(t *testing.T) {
+	reg := newFakeRegistry()
+	reg.username, reg.password = "alice", "s3cret"
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	adapter := oci.NewOCIRegistryAdapter(staticAuth{"alice", "s3cret"}, oci.WithPlainHTTP(true))
+	require.NoError(t, adapter.Push(context.Background(), testArtifact(t, host, []byte("wasm"))))
+
+	t.Run("Tag", func(t *testing.T) {
+		ref := values.NewPluginReference(host, "org", "plugins", "file", "1.2.0")
+		digest, err := adapter.Resolve(context.Background(), ref)
+		require.NoError(t, err)
+
+		stored, ok := reg.manifest("org/plugins/file", "1.2.0")
+		require.True(t, ok)
+		assert.Equal(t, sha256Digest(stored.data), digest.String())
+	})
+
+	t.Run("MissingTag", func(t *testing.T) {
+		ref := values.NewPluginReference(host, "org", "plugins", "file", "9.9.9")
+		_, err := adapter.Resolve(context.Background(), ref)
+		require.Error(t, err)
+
+		var notFound *entities.PluginNotFoundError
+		assert.ErrorAs(t, err, &notFound)
+		assert.ErrorIs(t, err, entities.ErrPluginNotFound)
+		assert.NotErrorIs(t, err, oci.ErrRegistryAuth)
+	})
+
+	t.Run("RejectedCredentials", func(t *testing.T) {
+		denied := oci.NewOCIRegistryAdapter(staticAuth{"alice", "wrong"}, oci.WithPlainHTTP(true))
+		ref := values.NewPluginReference(host, "org", "plugins", "file", "1.2.0")
+		_, err := denied.Resolve(context.Background(), ref)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, oci.ErrRegistryAuth)
+		assert.NotErrorIs(t, err, entities.ErrPluginNotFound)
+	})
+}
+
+func TestOCIRegistryAdapter_Pull_VerifiesDigest(t *testing.T) {
+	reg := newFakeRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	adapter := oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true))
+
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	ref := values.NewPluginReference(host, "org", "plugins", "file", "1.2.0")
+
+	t.Run("TamperedLayer", func(t *testing.T) {
+		require.NoError(t, adapter.Push(context.Background(), testArtifact(t, host, wasm)))
+		tampered := append([]byte(nil), wasm...)
+		tampered[len(tampered)-1] ^= 0xff
+		reg.mu.Lock()
+		reg.tampered[sha256Digest(wasm)] = tampered
+		reg.mu.Unlock()
+		defer func() {
+			reg.mu.Lock()
+			delete(reg.tampered, sha256Digest(wasm))
+			reg.mu.Unlock()
+		}()
+
+		_, err := adapter.Pull(context.Background(), ref)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, oci.ErrContentDigestMismatch)
+	})
+
+	t.Run("SHA512Layer", func(t *testing.T) {
+		ref := values.NewPluginReference(host, "org", "plugins", "file", "2.0.0")
+		reg.putArtifact(t, "org/plugins/file", "2.0.0", ocispec.Descriptor{
+			MediaType: oci.WASMLayerMediaType,
+			Digest:    godigest.Digest(sha512Digest(wasm)),
+			Size:      int64(len(wasm)),
+		}, wasm)
+
+		pulled, err := adapter.Pull(context.Background(), ref)
+		require.NoError(t, err)
+		defer pulled.Close()
+		assert.Equal(t, sha512Digest(wasm), pulled.Plugin.Digest().String())
+	})
+}
+
+func TestOCIRegistryAdapter_PullTo(t *testing.T) {
+	reg := newFakeRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	wasm := []byte(strings.Repeat("\x00asm\x01\x00\x00\x00", 4096))
+	ref := values.NewPluginReference(host, "org", "plugins", "file", "1.2.0")
+	require.NoError(t, oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true)).
+		Push(context.Background(), testArtifact(t, host, wasm)))
+
+	t.Run("Streams", func(t *testing.T) {
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true))
+		var dst strings.Builder
+		p, err := adapter.PullTo(context.Background(), ref, &dst)
+		require.NoError(t, err)
+		assert.Equal(t, string(wasm), dst.String())
+		assert.Equal(t, sha256Digest(wasm), p.Digest().String())
+		assert.Equal(t, "file", p.Metadata().Name())
+	})
+
+	t.Run("RejectsOversizeLayer", func(t *testing.T) {
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true), oci.WithMaxPluginSize(1024))
+		var dst strings.Builder
+		_, err := adapter.PullTo(context.Background(), ref, &dst)
+		require.Error(t, err)
+
+		var sizeErr *netutil.SizeLimitExceededError
+		assert.ErrorAs(t, err, &sizeErr)
+		assert.Zero(t, dst.Len(), "nothing should be written for a layer known to be too large")
+	})
+
+	t.Run("AcceptsLayerAtLimit", func(t *testing.T) {
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithMaxPluginSize(int64(len(wasm))),
+			oci.WithRegistryTransport(lateEOFTransport{base: http.DefaultTransport}))
+		var dst strings.Builder
+		p, err := adapter.PullTo(context.Background(), ref, &dst)
+		require.NoError(t, err)
+		assert.Equal(t, len(wasm), dst.Len())
+		assert.Equal(t, sha256Digest(wasm), p.Digest().String())
+	})
+
+	t.Run("MissingTag", func(t *testing.T) {
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true))
+		missing := values.NewPluginReference(host, "org", "plugins", "file", "0.0.1")
+		_, err := adapter.PullTo(context.Background(), missing, io.Discard)
+		assert.ErrorIs(t, err, entities.ErrPluginNotFound)
+	})
+}
+
+// lateEOFTransport delivers blob bodies so that EOF comes from a Read of its
+// own rather than with the last bytes, as many readers do.
+type lateEOFTransport struct {
+	base http.RoundTripper
+}
+
+func (l lateEOFTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := l.base.RoundTrip(req)
+	if err == nil && strings.Contains(req.URL.Path, "/blobs/") {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(resp.Body, strings.NewReader("")), resp.Body}
+	}
+	return resp, err
+}
+
+// flakyTransport fails the first failures matching requests with 503 and
+// counts every matching request it sees. By default it matches manifest
+// requests.
+type flakyTransport struct {
+	base     http.RoundTripper
+	match    func(*http.Request) bool
+	failures int
+	requests int
+	mu       sync.Mutex
+}
+
+func (f *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	match := f.match
+	if match == nil {
+		match = func(r *http.Request) bool { return strings.Contains(r.URL.Path, "/manifests/") }
+	}
+	if !match(req) {
+		return f.base.RoundTrip(req)
+	}
+	f.mu.Lock()
+	f.requests++
+	fail := f.requests <= f.failures
+	f.mu.Unlock()
+	if fail {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Header:     http.Header{"Retry-After": []string{"0"}},
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}
+	return f.base.RoundTrip(req)
+}
+
+func TestOCIRegistryAdapter_Retry(t *testing.T) {
+	reg := newFakeRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	wasm := []byte("wasm")
+	require.NoError(t, oci.NewOCIRegistryAdapter(staticAuth{}, oci.WithPlainHTTP(true)).
+		Push(context.Background(), testArtifact(t, host, wasm)))
+	ref := values.NewPluginReference(host, "org", "plugins", "file", "1.2.0")
+
+	t.Run("RecoversFromTransient503", func(t *testing.T) {
+		flaky := &flakyTransport{base: http.DefaultTransport, failures: 2}
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithRegistryTransport(flaky),
+			oci.WithRegistryRetry(3, time.Millisecond),
+		)
+
+		pulled, err := adapter.Pull(context.Background(), ref)
+		require.NoError(t, err)
+		defer pulled.Close()
+		got, err := io.ReadAll(pulled.WASM)
+		require.NoError(t, err)
+		assert.Equal(t, wasm, got)
+		assert.Equal(t, 3, flaky.requests)
+	})
+
+	t.Run("GivesUp", func(t *testing.T) {
+		flaky := &flakyTransport{base: http.DefaultTransport, failures: 10}
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithRegistryTransport(flaky),
+			oci.WithRegistryRetry(2, time.Millisecond),
+		)
+
+		_, err := adapter.Pull(context.Background(), ref)
+		require.Error(t, err)
+		assert.Equal(t, 3, flaky.requests)
+	})
+
+	t.Run("NoRetryOnNotFound", func(t *testing.T) {
+		counting := &flakyTransport{base: http.DefaultTransport}
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithRegistryTransport(counting),
+			oci.WithRegistryRetry(3, time.Millisecond),
+		)
+
+		missing := values.NewPluginReference(host, "org", "plugins", "file", "0.0.1")
+		_, err := adapter.Resolve(context.Background(), missing)
+		assert.ErrorIs(t, err, entities.ErrPluginNotFound)
+		assert.Equal(t, 1, counting.requests)
+	})
+}
+
+func TestOCIRegistryAdapter_Push_Retry(t *testing.T) {
+	reg := newFakeRegistry()
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+
+	t.Run("RecoversFromTransient503", func(t *testing.T) {
+		// Opening an upload session has no body, so it is safe to resend.
+		flaky := &flakyTransport{
+			base:     http.DefaultTransport,
+			failures: 1,
+			match: func(r *http.Request) bool {
+				return r.Method == http.MethodPost && strings.Contains(r.URL.Path, "/blobs/uploads/")
+			},
+		}
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithRegistryTransport(flaky),
+			oci.WithRegistryRetry(3, time.Millisecond),
+		)
+
+		require.NoError(t, adapter.Push(context.Background(), testArtifact(t, host, wasm)))
+		assert.Greater(t, flaky.requests, 1, "the failed upload request was retried")
+		_, ok := reg.manifest("org/plugins/file", "1.2.0")
+		assert.True(t, ok)
+	})
+
+	t.Run("BlobUploadIsNotResent", func(t *testing.T) {
+		// A streamed blob body cannot be rewound; resending it would upload
+		// an empty blob, so the 503 is returned instead.
+		flaky := &flakyTransport{
+			base:     http.DefaultTransport,
+			failures: 1,
+			match: func(r *http.Request) bool {
+				return r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/blobs/uploads/") && r.GetBody == nil
+			},
+		}
+		adapter := oci.NewOCIRegistryAdapter(staticAuth{},
+			oci.WithPlainHTTP(true),
+			oci.WithRegistryTransport(flaky),
+			oci.WithRegistryRetry(3, time.Millisecond),
+		)
+
+		other := testArtifact(t, host, []byte("\x00asm\x01\x00\x00\x00other"))
+		err := adapter.Push(context.Background(), other)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "503")
+		assert.Equal(t, 1, flaky.requests, "the consumed body was not resent")
+	})
+}
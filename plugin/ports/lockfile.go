@@ -3,6 +3,8 @@ package ports
 import (
 	"context"
 
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
 )
 
@@ -11,6 +13,26 @@ type VersionResolver interface {
 	Resolve(constraint string, available []string) (string, error)
 }
 
+// PluginVersionSource lists published plugin versions and provides their
+// binaries, so a LockfileService can resolve constraints to exact, digested
+// versions.
+type PluginVersionSource interface {
+	// AvailableVersions returns the versions published for spec.
+	AvailableVersions(ctx context.Context, spec *entities.PluginSpec) ([]string, error)
+
+	// Locate returns the local path of the plugin binary for the given
+	// version, fetching it first if needed.
+	Locate(ctx context.Context, spec *entities.PluginSpec, version string) (string, error)
+}
+
+// ProfileSource fetches remote profile content for locking.
+type ProfileSource interface {
+	// FetchProfile returns the content of the profile at source for the
+	// requested version, and the exact version it resolved to. The version
+	// is "latest" when the profile URL did not name one.
+	FetchProfile(ctx context.Context, source, version string) (content []byte, resolved string, err error)
+}
+
 // LockfileRepository manages lockfile persistence.
 type LockfileRepository interface {
 	Load(ctx context.Context, path string) (*entities.Lockfile, error)
@@ -23,3 +45,9 @@ type PluginDigester interface {
 	DigestBytes(data []byte) string
 	DigestFile(ctx context.Context, path string) (string, error)
 }
+
+// ProfileTrustPrompter asks the user whether to trust a remote profile.
+// gatekeeper.TerminalPrompter implements it.
+type ProfileTrustPrompter interface {
+	PromptForProfileTrustWithGrantSet(url string, requiredCaps map[string]*hostfunc.GrantSet) (bool, error)
+}
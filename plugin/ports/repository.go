@@ -27,3 +27,11 @@ type PluginRepository interface {
 	// Delete removes a specific plugin from cache.
 	Delete(ctx context.Context, ref values.PluginReference) error
 }
+
+// PluginBinaryReader is implemented by repositories that can return a cached
+// plugin's WASM binary directly. Repositories whose Find path does not name a
+// file on disk, such as in-memory ones, implement it so callers can load
+// the plugin without touching the filesystem.
+type PluginBinaryReader interface {
+	ReadWASM(ctx context.Context, ref values.PluginReference) ([]byte, error)
+}
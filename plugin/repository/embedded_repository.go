@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// ErrReadOnlyRepository is returned when mutating a repository that cannot be written.
+var ErrReadOnlyRepository = errors.New("repository is read-only")
+
+// EmbeddedPluginRepository implements ports.PluginRepository on top of an
+// fs.FS such as an embed.FS, for deployments that ship plugins inside the
+// host binary.
+//
+// Layout mirrors FSPluginRepository, keyed by the embedded plugin name:
+//
+//	<name>/plugin.wasm
+//	<name>/metadata.json (optional)
+//
+// Only embedded references (see values.PluginReference.IsEmbedded) are
+// served. The digest is computed from the embedded bytes.
+type EmbeddedPluginRepository struct {
+	fsys fs.FS
+}
+
+// NewEmbeddedPluginRepository creates a read-only repository backed by fsys.
+func NewEmbeddedPluginRepository(fsys fs.FS) *EmbeddedPluginRepository {
+	return &EmbeddedPluginRepository{fsys: fsys}
+}
+
+// Find returns the embedded plugin and its path within the fs.FS.
+func (r *EmbeddedPluginRepository) Find(ctx context.Context, ref values.PluginReference) (*entities.Plugin, string, error) {
+	wasmPath, err := r.wasmPath(ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	f, err := r.fsys.Open(wasmPath)
+	if err != nil {
+		return nil, "", &entities.PluginNotFoundError{Reference: ref}
+	}
+	defer func() { _ = f.Close() }()
+
+	digest, err := values.ComputeDigestSHA256(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("read embedded wasm: %w", err)
+	}
+
+	metadata, err := r.loadMetadata(ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return entities.NewPlugin(ref, digest, metadata), wasmPath, nil
+}
+
+// Open returns a reader for the embedded plugin's WASM binary.
+func (r *EmbeddedPluginRepository) Open(ctx context.Context, ref values.PluginReference) (io.ReadCloser, error) {
+	wasmPath, err := r.wasmPath(ref)
+	if err != nil {
+		return nil, err
+	}
+	f, err := r.fsys.Open(wasmPath)
+	if err != nil {
+		return nil, &entities.PluginNotFoundError{Reference: ref}
+	}
+	return f, nil
+}
+
+// ReadWASM returns the embedded plugin's WASM binary.
+func (r *EmbeddedPluginRepository) ReadWASM(ctx context.Context, ref values.PluginReference) ([]byte, error) {
+	f, err := r.Open(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return io.ReadAll(f)
+}
+
+// Store is not supported; embedded plugins are fixed at build time.
+func (r *EmbeddedPluginRepository) Store(ctx context.Context, plugin *entities.Plugin, wasm io.Reader) (string, error) {
+	return "", ErrReadOnlyRepository
+}
+
+// List returns all embedded plugins.
+func (r *EmbeddedPluginRepository) List(ctx context.Context) ([]*entities.Plugin, error) {
+	entries, err := fs.ReadDir(r.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []*entities.Plugin
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		ref, err := values.ParsePluginReference(entry.Name())
+		if err != nil || !ref.IsEmbedded() {
+			continue
+		}
+		plugin, _, err := r.Find(ctx, ref)
+		if err == nil {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins, nil
+}
+
+// Prune is a no-op; embedded plugins have a single version.
+func (r *EmbeddedPluginRepository) Prune(ctx context.Context, keepVersions int) error {
+	return nil
+}
+
+// Delete is not supported; embedded plugins are fixed at build time.
+func (r *EmbeddedPluginRepository) Delete(ctx context.Context, ref values.PluginReference) error {
+	return ErrReadOnlyRepository
+}
+
+func (r *EmbeddedPluginRepository) wasmPath(ref values.PluginReference) (string, error) {
+	if !ref.IsEmbedded() {
+		return "", &entities.PluginNotFoundError{Reference: ref}
+	}
+	p := path.Join(ref.Name(), "plugin.wasm")
+	// fs.ValidPath rejects "..", absolute and empty elements.
+	if ref.Name() == "" || !fs.ValidPath(p) || path.Dir(p) != ref.Name() {
+		return "", fmt.Errorf("security violation: invalid embedded plugin name %q", ref.Name())
+	}
+	return p, nil
+}
+
+func (r *EmbeddedPluginRepository) loadMetadata(ref values.PluginReference) (values.PluginMetadata, error) {
+	data, err := fs.ReadFile(r.fsys, path.Join(ref.Name(), "metadata.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return values.NewPluginMetadata(ref.Name(), ref.Version(), "", nil), nil
+	}
+	if err != nil {
+		return values.PluginMetadata{}, err
+	}
+
+	var meta struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		Description  string   `json:"description"`
+		Capabilities []string `json:"capabilities"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return values.PluginMetadata{}, fmt.Errorf("parse embedded metadata: %w", err)
+	}
+	return values.NewPluginMetadata(meta.Name, meta.Version, meta.Description, meta.Capabilities), nil
+}
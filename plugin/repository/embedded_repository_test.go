@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedPluginRepository(t *testing.T) {
+	wasm := []byte("\x00asm embedded file plugin")
+	fsys := fstest.MapFS{
+		"file/plugin.wasm":     {Data: wasm},
+		"file/metadata.json":   {Data: []byte(`{"name":"file","version":"1.2.0","description":"file checks"}`)},
+		"http/plugin.wasm":     {Data: []byte("\x00asm http")},
+		"broken/metadata.json": {Data: []byte(`{}`)},
+	}
+	repo := NewEmbeddedPluginRepository(fsys)
+	ctx := context.Background()
+
+	t.Run("Find", func(t *testing.T) {
+		ref, err := values.ParsePluginReference("file")
+		require.NoError(t, err)
+
+		plugin, path, err := repo.Find(ctx, ref)
+		require.NoError(t, err)
+		assert.Equal(t, "file/plugin.wasm", path)
+		assert.Equal(t, "file checks", plugin.Metadata().Description())
+		assert.NoError(t, plugin.Digest().Verify(wasm))
+	})
+
+	t.Run("FindWithoutMetadata", func(t *testing.T) {
+		ref, _ := values.ParsePluginReference("http")
+		plugin, _, err := repo.Find(ctx, ref)
+		require.NoError(t, err)
+		assert.Equal(t, "http", plugin.Metadata().Name())
+	})
+
+	t.Run("ReadWASM", func(t *testing.T) {
+		ref, _ := values.ParsePluginReference("file")
+		data, err := repo.ReadWASM(ctx, ref)
+		require.NoError(t, err)
+		assert.Equal(t, wasm, data)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ref, _ := values.ParsePluginReference("missing")
+		_, _, err := repo.Find(ctx, ref)
+		assert.True(t, errors.Is(err, entities.ErrPluginNotFound))
+	})
+
+	t.Run("RejectsRegistryReference", func(t *testing.T) {
+		ref := values.NewPluginReference("reg", "org", "repo", "file", "1.0")
+		_, _, err := repo.Find(ctx, ref)
+		assert.True(t, errors.Is(err, entities.ErrPluginNotFound))
+	})
+
+	t.Run("RejectsTraversal", func(t *testing.T) {
+		ref := values.NewPluginReference("", "", "", "..", "")
+		_, _, err := repo.Find(ctx, ref)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "security violation")
+	})
+
+	t.Run("List", func(t *testing.T) {
+		plugins, err := repo.List(ctx)
+		require.NoError(t, err)
+		var names []string
+		for _, p := range plugins {
+			names = append(names, p.Reference().Name())
+		}
+		assert.ElementsMatch(t, []string{"file", "http"}, names)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		ref, _ := values.ParsePluginReference("file")
+		_, err := repo.Store(ctx, nil, nil)
+		assert.ErrorIs(t, err, ErrReadOnlyRepository)
+		assert.ErrorIs(t, repo.Delete(ctx, ref), ErrReadOnlyRepository)
+	})
+}
@@ -3,24 +3,43 @@ package repository
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/Masterminds/semver/v3"
+
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 )
 
 // FSPluginRepository implements ports.PluginRepository using filesystem.
 type FSPluginRepository struct {
-	root string // ~/.reglet/plugins
+	root         string // ~/.reglet/plugins
+	verifyOnFind bool
+}
+
+// FSRepositoryOption configures an FSPluginRepository.
+type FSRepositoryOption func(*FSPluginRepository)
+
+// WithVerifyOnFind makes Find re-hash plugin.wasm and compare it to the
+// stored digest, catching bit-rot or tampering of the cache at load time.
+// It is off by default because it reads the whole binary on every lookup;
+// security-sensitive deployments should enable it.
+func WithVerifyOnFind(enabled bool) FSRepositoryOption {
+	return func(r *FSPluginRepository) {
+		r.verifyOnFind = enabled
+	}
 }
 
 // NewFSPluginRepository creates a filesystem-based repository.
-func NewFSPluginRepository(root string) (*FSPluginRepository, error) {
+func NewFSPluginRepository(root string, opts ...FSRepositoryOption) (*FSPluginRepository, error) {
 	if root == "" {
 		home, _ := os.UserHomeDir()
 		root = filepath.Join(home, ".reglet", "plugins")
@@ -30,7 +49,11 @@ func NewFSPluginRepository(root string) (*FSPluginRepository, error) {
 		return nil, fmt.Errorf("create cache directory: %w", err)
 	}
 
-	return &FSPluginRepository{root: root}, nil
+	r := &FSPluginRepository{root: root}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
 }
 
 // Find retrieves a plugin from cache.
@@ -58,50 +81,156 @@ func (r *FSPluginRepository) Find(ctx context.Context, ref values.PluginReferenc
 		return nil, "", err
 	}
 
+	if r.verifyOnFind {
+		if err := verifyCachedWasm(ref, wasmPath, digest); err != nil {
+			return nil, "", err
+		}
+	}
+
 	plugin := entities.NewPlugin(ref, digest, metadata)
 	return plugin, wasmPath, nil
 }
 
+// verifyCachedWasm hashes the binary at wasmPath with the algorithm of the
+// stored digest and reports a CacheIntegrityError on mismatch.
+func verifyCachedWasm(ref values.PluginReference, wasmPath string, expected values.Digest) error {
+	f, err := os.Open(filepath.Clean(wasmPath))
+	if err != nil {
+		return fmt.Errorf("open cached wasm: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h, err := expected.Hasher()
+	if err != nil {
+		return fmt.Errorf("hash cached wasm: %w", err)
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("hash cached wasm: %w", err)
+	}
+
+	sum := h.Sum(nil)
+	if !expected.Matches(sum) {
+		actual, _ := values.NewDigest(expected.Algorithm(), hex.EncodeToString(sum))
+		return &entities.CacheIntegrityError{
+			Reference: ref,
+			Path:      wasmPath,
+			Expected:  expected,
+			Actual:    actual,
+		}
+	}
+	return nil
+}
+
+// stagingPrefix marks directories Store is still writing. List skips them.
+const stagingPrefix = ".staging-"
+
 // Store persists a plugin and its WASM binary.
+// The files are written to a staging directory next to the final location and
+// renamed into place, so readers never observe a partially stored plugin.
 func (r *FSPluginRepository) Store(ctx context.Context, plugin *entities.Plugin, wasm io.Reader) (string, error) {
 	path, err := r.pluginPath(plugin.Reference())
 	if err != nil {
 		return "", err
 	}
 
-	// Create directory
-	if err := os.MkdirAll(path, 0o750); err != nil {
+	// Create staging directory on the same filesystem as the destination
+	parent := filepath.Dir(path)
+	if err := os.MkdirAll(parent, 0o750); err != nil {
 		return "", err
 	}
-
-	// Write WASM binary
-	wasmPath := filepath.Join(path, "plugin.wasm")
-	wasmFile, err := os.Create(filepath.Clean(wasmPath))
+	staging, err := os.MkdirTemp(parent, stagingPrefix)
 	if err != nil {
 		return "", err
 	}
-	defer func() { _ = wasmFile.Close() }()
+	defer func() { _ = os.RemoveAll(staging) }()
 
-	if _, err := io.Copy(wasmFile, wasm); err != nil {
+	// Write WASM binary
+	if err := writeFile(filepath.Join(staging, "plugin.wasm"), wasm); err != nil {
 		return "", fmt.Errorf("write wasm: %w", err)
 	}
 
 	// Write metadata
-	if err := r.saveMetadata(path, plugin.Metadata()); err != nil {
+	if err := r.saveMetadata(staging, plugin.Metadata()); err != nil {
 		return "", err
 	}
 
 	// Write digest
-	if err := r.saveDigest(path, plugin.Digest()); err != nil {
+	if err := r.saveDigest(staging, plugin.Digest()); err != nil {
 		return "", err
 	}
 
-	return wasmPath, nil
+	if err := replaceDir(staging, path); err != nil {
+		return "", fmt.Errorf("store %s: %w", plugin.Reference().String(), err)
+	}
+	return filepath.Join(path, "plugin.wasm"), nil
+}
+
+func writeFile(path string, src io.Reader) error {
+	f, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replaceDir moves src to dst, replacing any existing dst. An existing
+// directory is moved aside first, into a uniquely named staging directory so
+// concurrent replacements of dst cannot collide, and restored if the final
+// rename fails.
+func replaceDir(src, dst string) error {
+	aside := ""
+	if _, err := os.Stat(dst); err == nil {
+		parent, err := os.MkdirTemp(filepath.Dir(dst), stagingPrefix+"old-")
+		if err != nil {
+			return err
+		}
+		defer func() { _ = os.RemoveAll(parent) }()
+		aside = filepath.Join(parent, filepath.Base(dst))
+		if err := os.Rename(dst, aside); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if aside != "" {
+			_ = os.Rename(aside, dst)
+		}
+		return err
+	}
+	return nil
 }
 
-// List returns all cached plugins.
+// List returns all cached plugins. Entries that cannot be loaded are left
+// out; use Scan to see them.
 func (r *FSPluginRepository) List(ctx context.Context) ([]*entities.Plugin, error) {
+	plugins, _, err := r.Scan(ctx)
+	return plugins, err
+}
+
+// DegradedEntry is a cache entry that exists on disk but could not be loaded,
+// typically because the cache was corrupted.
+type DegradedEntry struct {
+	// Err is why the entry could not be loaded.
+	Err error
+
+	// Path is the entry's directory.
+	Path string
+
+	// Reference is the plugin the entry belongs to. It is the zero value
+	// when the directory layout does not map to a reference.
+	Reference values.PluginReference
+}
+
+// Scan walks the cache and returns the plugins it holds along with entries it
+// could not load. Directories that Store is still writing are not reported.
+func (r *FSPluginRepository) Scan(ctx context.Context) ([]*entities.Plugin, []DegradedEntry, error) {
 	var plugins []*entities.Plugin
+	var degraded []DegradedEntry
 
 	// Walk cache directory
 	err := filepath.Walk(r.root, func(path string, info os.FileInfo, err error) error {
@@ -109,30 +238,113 @@ func (r *FSPluginRepository) List(ctx context.Context) ([]*entities.Plugin, erro
 			return err
 		}
 
+		if info.IsDir() && path != r.root && strings.HasPrefix(info.Name(), stagingPrefix) {
+			return filepath.SkipDir
+		}
+
 		// Check if this is a plugin.wasm file
 		if info.Name() == "plugin.wasm" {
+			dir := filepath.Dir(path)
+
 			// Parse reference from path structure
-			ref, err := r.parseRefFromPath(filepath.Dir(path))
+			ref, err := r.parseRefFromPath(dir)
 			if err != nil {
-				return nil //nolint:nilerr // Skip invalid entries
+				degraded = append(degraded, DegradedEntry{Path: dir, Err: err})
+				return nil
 			}
 
 			plugin, _, err := r.Find(ctx, ref)
-			if err == nil {
-				plugins = append(plugins, plugin)
+			if err != nil {
+				degraded = append(degraded, DegradedEntry{Path: dir, Reference: ref, Err: err})
+				return nil
 			}
+			plugins = append(plugins, plugin)
 		}
 
 		return nil
 	})
 
-	return plugins, err
+	return plugins, degraded, err
 }
 
-// Prune removes old versions.
+// Prune removes old versions, keeping the newest keepVersions of each plugin.
 func (r *FSPluginRepository) Prune(ctx context.Context, keepVersions int) error {
-	// Group by plugin name, sort by version, delete old ones
-	return nil
+	return r.PruneExcept(ctx, keepVersions)
+}
+
+// PruneExcept removes old versions like Prune, but never deletes a version
+// whose digest is in pinned (for example, digests referenced by a lockfile).
+// Versions are ordered by semantic version; tags that are not valid semver
+// sort below all semver tags. Deletion continues past failures, which are
+// returned together.
+func (r *FSPluginRepository) PruneExcept(ctx context.Context, keepVersions int, pinned ...values.Digest) error {
+	if keepVersions < 0 {
+		return fmt.Errorf("keepVersions must not be negative, got %d", keepVersions)
+	}
+
+	plugins, err := r.List(ctx)
+	if err != nil {
+		return fmt.Errorf("list cached plugins: %w", err)
+	}
+
+	var errs []error
+	for _, p := range pruneCandidates(plugins, keepVersions, pinned) {
+		if err := r.Delete(ctx, p.Reference()); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", p.Reference().String(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pruneCandidates returns the plugins to delete so that at most keepVersions
+// of each registry/org/repo/name remain, newest first. Pinned digests are
+// never returned.
+func pruneCandidates(plugins []*entities.Plugin, keepVersions int, pinned []values.Digest) []*entities.Plugin {
+	// Group by registry/org/repo/name
+	groups := make(map[string][]*entities.Plugin)
+	for _, p := range plugins {
+		ref := p.Reference()
+		key := ref.Repository()
+		groups[key] = append(groups[key], p)
+	}
+
+	var stale []*entities.Plugin
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			return compareVersions(group[i].Reference().Version(), group[j].Reference().Version()) > 0
+		})
+		for _, p := range group[min(keepVersions, len(group)):] {
+			if !isPinned(p.Digest(), pinned) {
+				stale = append(stale, p)
+			}
+		}
+	}
+	return stale
+}
+
+// compareVersions orders version tags, newest first when sorted descending.
+func compareVersions(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	switch {
+	case errA == nil && errB == nil:
+		return va.Compare(vb)
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isPinned(digest values.Digest, pinned []values.Digest) bool {
+	for _, d := range pinned {
+		if d.Equals(digest) {
+			return true
+		}
+	}
+	return false
 }
 
 // Delete removes a plugin.
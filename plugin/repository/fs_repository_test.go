@@ -28,7 +28,7 @@ func TestFSPluginRepository(t *testing.T) {
 	}
 
 	ref := values.NewPluginReference("reg", "org", "repo", "name", "1.0")
-	digest, _ := values.NewDigest("sha256", "abc")
+	digest, _ := values.NewDigest("sha256", strings.Repeat("ab", 32))
 	meta := values.NewPluginMetadata("name", "1.0", "desc", []string{"net"})
 	plugin := entities.NewPlugin(ref, digest, meta)
 	wasmContent := []byte("fake wasm content")
@@ -188,7 +188,7 @@ func TestFSPluginRepository_Find_PathTraversal(t *testing.T) {
 
 	// Attempt to store plugin with traversal in reference
 	maliciousRef := values.NewPluginReference("", "", "", "../../malicious", "1.0.0")
-	digest, _ := values.NewDigest("sha256", "abc123")
+	digest, _ := values.NewDigest("sha256", strings.Repeat("a1", 32))
 	meta := values.NewPluginMetadata("malicious", "1.0.0", "bad", []string{})
 	plugin := entities.NewPlugin(maliciousRef, digest, meta)
 
@@ -204,3 +204,198 @@ func TestFSPluginRepository_Find_PathTraversal(t *testing.T) {
 	_, _, err = repo.Find(context.Background(), maliciousRef)
 	require.Error(t, err, "Find should reject path traversal")
 }
+
+func storeVersion(t *testing.T, repo *FSPluginRepository, name, version, digestFill string) values.PluginReference {
+	t.Helper()
+	ref := values.NewPluginReference("reg", "org", "repo", name, version)
+	digest, err := values.NewDigest("sha256", strings.Repeat(digestFill, 32))
+	require.NoError(t, err)
+	_, err = repo.Store(context.Background(), entities.NewPlugin(ref, digest, values.NewPluginMetadata(name, version, "", nil)),
+		bytes.NewReader([]byte("wasm "+version)))
+	require.NoError(t, err)
+	return ref
+}
+
+func cachedVersions(t *testing.T, repo *FSPluginRepository, name string) []string {
+	t.Helper()
+	plugins, err := repo.List(context.Background())
+	require.NoError(t, err)
+	var versions []string
+	for _, p := range plugins {
+		if p.Reference().Name() == name {
+			versions = append(versions, p.Reference().Version())
+		}
+	}
+	return versions
+}
+
+func TestFSPluginRepository_Prune(t *testing.T) {
+	repo, err := NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+
+	// 1.10.0 is newest; a lexical sort would get this wrong.
+	storeVersion(t, repo, "file", "1.2.0", "aa")
+	storeVersion(t, repo, "file", "1.10.0", "bb")
+	storeVersion(t, repo, "file", "1.9.0", "cc")
+	storeVersion(t, repo, "http", "2.0.0", "dd")
+
+	require.NoError(t, repo.Prune(context.Background(), 2))
+
+	assert.ElementsMatch(t, []string{"1.10.0", "1.9.0"}, cachedVersions(t, repo, "file"))
+	assert.ElementsMatch(t, []string{"2.0.0"}, cachedVersions(t, repo, "http"))
+}
+
+func TestFSPluginRepository_PruneExcept_Pinned(t *testing.T) {
+	repo, err := NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+
+	storeVersion(t, repo, "file", "1.0.0", "aa")
+	storeVersion(t, repo, "file", "1.1.0", "bb")
+	storeVersion(t, repo, "file", "1.2.0", "cc")
+
+	pinned, err := values.NewDigest("sha256", strings.Repeat("aa", 32))
+	require.NoError(t, err)
+	require.NoError(t, repo.PruneExcept(context.Background(), 1, pinned))
+
+	assert.ElementsMatch(t, []string{"1.2.0", "1.0.0"}, cachedVersions(t, repo, "file"))
+}
+
+func TestFSPluginRepository_Prune_Negative(t *testing.T) {
+	repo, err := NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+	assert.Error(t, repo.Prune(context.Background(), -1))
+}
+
+func TestFSPluginRepository_Store_Replace(t *testing.T) {
+	repo, err := NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+
+	ref := storeVersion(t, repo, "file", "1.0.0", "aa")
+	storeVersion(t, repo, "file", "1.0.0", "bb")
+
+	plugin, path, err := repo.Find(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("bb", 32), plugin.Digest().Value())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "wasm 1.0.0", string(data))
+
+	entries, err := os.ReadDir(filepath.Dir(filepath.Dir(path)))
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.False(t, strings.HasPrefix(e.Name(), stagingPrefix), "leftover staging dir %s", e.Name())
+	}
+}
+
+func TestFSPluginRepository_Store_ReplaceKeepsOtherStaging(t *testing.T) {
+	root := t.TempDir()
+	repo, err := NewFSPluginRepository(root)
+	require.NoError(t, err)
+
+	ref := storeVersion(t, repo, "file", "1.0.0", "aa")
+
+	// Another Store replacing the same version has moved its old copy aside.
+	other := filepath.Join(root, "reg", "org", "repo", stagingPrefix+"old-"+filepath.Base(ref.String()))
+	require.NoError(t, os.MkdirAll(other, 0o750))
+
+	storeVersion(t, repo, "file", "1.0.0", "bb")
+
+	assert.DirExists(t, other)
+	plugin, _, err := repo.Find(context.Background(), ref)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("bb", 32), plugin.Digest().Value())
+}
+
+func TestFSPluginRepository_Scan_HalfWritten(t *testing.T) {
+	root := t.TempDir()
+	repo, err := NewFSPluginRepository(root)
+	require.NoError(t, err)
+
+	storeVersion(t, repo, "file", "1.0.0", "aa")
+
+	// A plugin whose wasm landed but whose metadata never did.
+	broken := values.NewPluginReference("reg", "org", "repo", "file", "2.0.0")
+	brokenDir := filepath.Join(root, broken.String())
+	require.NoError(t, os.MkdirAll(brokenDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(brokenDir, "plugin.wasm"), []byte("wasm"), 0o600))
+
+	// A store still in progress is invisible.
+	staging := filepath.Join(root, "reg", "org", "repo", stagingPrefix+"123")
+	require.NoError(t, os.MkdirAll(staging, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(staging, "plugin.wasm"), []byte("wasm"), 0o600))
+
+	plugins, degraded, err := repo.Scan(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "1.0.0", plugins[0].Reference().Version())
+
+	require.Len(t, degraded, 1)
+	assert.Equal(t, brokenDir, degraded[0].Path)
+	assert.Equal(t, broken.String(), degraded[0].Reference.String())
+	assert.Error(t, degraded[0].Err)
+
+	listed, err := repo.List(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, listed, 1)
+}
+
+func TestFSPluginRepository_VerifyOnFind(t *testing.T) {
+	root := t.TempDir()
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(wasm))
+	require.NoError(t, err)
+	ref := values.NewPluginReference("reg", "org", "repo", "file", "1.0.0")
+
+	plain, err := NewFSPluginRepository(root)
+	require.NoError(t, err)
+	verifying, err := NewFSPluginRepository(root, WithVerifyOnFind(true))
+	require.NoError(t, err)
+
+	wasmPath, err := plain.Store(context.Background(),
+		entities.NewPlugin(ref, digest, values.NewPluginMetadata("file", "1.0.0", "", nil)), bytes.NewReader(wasm))
+	require.NoError(t, err)
+
+	_, _, err = verifying.Find(context.Background(), ref)
+	require.NoError(t, err, "intact cache entry should verify")
+
+	// Flip a byte on disk.
+	corrupt := append([]byte(nil), wasm...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(wasmPath, corrupt, 0o600))
+
+	_, _, err = plain.Find(context.Background(), ref)
+	assert.NoError(t, err, "verification is off by default")
+
+	_, _, err = verifying.Find(context.Background(), ref)
+	var integrityErr *entities.CacheIntegrityError
+	require.ErrorAs(t, err, &integrityErr)
+	assert.ErrorIs(t, err, entities.ErrIntegrityCheckFailed)
+	assert.Equal(t, wasmPath, integrityErr.Path)
+	assert.True(t, integrityErr.Expected.Equals(digest))
+}
+
+func TestFSPluginRepository_DigestPinned(t *testing.T) {
+	ctx := context.Background()
+	repo, err := NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+
+	pin, err := values.NewDigest("sha256", strings.Repeat("ab", 32))
+	require.NoError(t, err)
+	ref := values.NewDigestPluginReference("ghcr.io", "org", "repo", "file", pin)
+	wasmDigest, err := values.NewDigest("sha256", strings.Repeat("cc", 32))
+	require.NoError(t, err)
+
+	plugin := entities.NewPlugin(ref, wasmDigest, values.NewPluginMetadata("file", "1.0.0", "", nil))
+	_, err = repo.Store(ctx, plugin, bytes.NewReader([]byte("wasm")))
+	require.NoError(t, err)
+
+	found, _, err := repo.Find(ctx, ref)
+	require.NoError(t, err)
+	assert.True(t, found.Reference().Equals(ref))
+
+	plugins, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	assert.True(t, plugins[0].Reference().IsDigestPinned())
+	assert.True(t, plugins[0].Reference().Equals(ref))
+}
@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// MemoryPathPrefix prefixes the paths returned by MemoryPluginRepository.
+// They identify an entry but do not name a file; use ReadWASM for the bytes.
+const MemoryPathPrefix = "memory://"
+
+// MemoryPluginRepository implements ports.PluginRepository in memory, for
+// tests and ephemeral hosts that should not touch disk. When full, storing a
+// new plugin evicts the least recently used one; Find and Store count as use.
+// It is safe for concurrent use.
+type MemoryPluginRepository struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List // front is most recently used
+	entries    map[string]*list.Element
+}
+
+type memoryEntry struct {
+	plugin *entities.Plugin
+	wasm   []byte
+}
+
+// NewMemoryRepository creates an in-memory repository holding at most
+// maxEntries plugins. Non-positive values mean no limit.
+func NewMemoryRepository(maxEntries int) *MemoryPluginRepository {
+	return &MemoryPluginRepository{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Find retrieves a plugin and marks it as recently used. The returned path
+// is MemoryPathPrefix followed by the reference.
+func (r *MemoryPluginRepository) Find(ctx context.Context, ref values.PluginReference) (*entities.Plugin, string, error) {
+	key, err := memoryKey(ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil, "", &entities.PluginNotFoundError{Reference: ref}
+	}
+	r.lru.MoveToFront(elem)
+	return elem.Value.(*memoryEntry).plugin, MemoryPathPrefix + key, nil
+}
+
+// ReadWASM returns a copy of the plugin's WASM binary and marks it as
+// recently used.
+func (r *MemoryPluginRepository) ReadWASM(ctx context.Context, ref values.PluginReference) ([]byte, error) {
+	key, err := memoryKey(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elem, ok := r.entries[key]
+	if !ok {
+		return nil, &entities.PluginNotFoundError{Reference: ref}
+	}
+	r.lru.MoveToFront(elem)
+	return append([]byte(nil), elem.Value.(*memoryEntry).wasm...), nil
+}
+
+// Store buffers the WASM binary and keeps the plugin, replacing any entry
+// for the same reference and evicting the least recently used entries if
+// the repository is full.
+func (r *MemoryPluginRepository) Store(ctx context.Context, plugin *entities.Plugin, wasm io.Reader) (string, error) {
+	key, err := memoryKey(plugin.Reference())
+	if err != nil {
+		return "", err
+	}
+
+	data, err := io.ReadAll(wasm)
+	if err != nil {
+		return "", fmt.Errorf("read wasm: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := &memoryEntry{plugin: plugin, wasm: data}
+	if elem, ok := r.entries[key]; ok {
+		elem.Value = entry
+		r.lru.MoveToFront(elem)
+	} else {
+		r.entries[key] = r.lru.PushFront(entry)
+	}
+
+	for r.maxEntries > 0 && r.lru.Len() > r.maxEntries {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.entries, oldest.Value.(*memoryEntry).plugin.Reference().String())
+	}
+
+	return MemoryPathPrefix + key, nil
+}
+
+// List returns all plugins, most recently used first. It does not affect
+// eviction order.
+func (r *MemoryPluginRepository) List(ctx context.Context) ([]*entities.Plugin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	plugins := make([]*entities.Plugin, 0, r.lru.Len())
+	for elem := r.lru.Front(); elem != nil; elem = elem.Next() {
+		plugins = append(plugins, elem.Value.(*memoryEntry).plugin)
+	}
+	return plugins, nil
+}
+
+// Prune removes old versions, keeping the newest keepVersions of each plugin.
+func (r *MemoryPluginRepository) Prune(ctx context.Context, keepVersions int) error {
+	if keepVersions < 0 {
+		return fmt.Errorf("keepVersions must not be negative, got %d", keepVersions)
+	}
+
+	plugins, err := r.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, p := range pruneCandidates(plugins, keepVersions, nil) {
+		if err := r.Delete(ctx, p.Reference()); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", p.Reference().String(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Delete removes a plugin. Deleting an absent plugin is not an error.
+func (r *MemoryPluginRepository) Delete(ctx context.Context, ref values.PluginReference) error {
+	key, err := memoryKey(ref)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if elem, ok := r.entries[key]; ok {
+		r.lru.Remove(elem)
+		delete(r.entries, key)
+	}
+	return nil
+}
+
+// memoryKey validates ref and returns its map key. Nothing here touches a
+// filesystem, but references that could escape a directory on disk are
+// rejected anyway, so tests written against this repository behave like
+// FSPluginRepository: no separators, dot segments or control characters.
+func memoryKey(ref values.PluginReference) (string, error) {
+	components := []string{ref.Name()}
+	if !ref.IsEmbedded() {
+		if ns := ref.Namespace(); ns != "" {
+			components = append(components, strings.Split(ns, "/")...)
+		}
+		if ref.IsDigestPinned() {
+			components = append(components, ref.Digest().Algorithm(), ref.Digest().Value())
+		} else {
+			components = append(components, ref.Version())
+		}
+		// The registry may carry a port.
+		host, port, _ := strings.Cut(ref.Registry(), ":")
+		components = append(components, host)
+		if port != "" {
+			components = append(components, port)
+		}
+	}
+	for _, c := range components {
+		if !validKeyComponent(c) {
+			return "", fmt.Errorf("security violation: invalid plugin reference component %q in %q", c, ref.String())
+		}
+	}
+	return ref.String(), nil
+}
+
+func validKeyComponent(c string) bool {
+	if c == "" || c == "." || c == ".." {
+		return false
+	}
+	if strings.ContainsAny(c, `/\:`) {
+		return false
+	}
+	for _, r := range c {
+		if unicode.IsControl(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
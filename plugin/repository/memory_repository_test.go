@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	_ ports.PluginRepository   = (*MemoryPluginRepository)(nil)
+	_ ports.PluginBinaryReader = (*MemoryPluginRepository)(nil)
+	_ ports.PluginBinaryReader = (*EmbeddedPluginRepository)(nil)
+)
+
+func storeMemory(t *testing.T, repo *MemoryPluginRepository, name, version string) values.PluginReference {
+	t.Helper()
+	ref := values.NewPluginReference("reg", "org", "repo", name, version)
+	wasm := []byte("wasm " + name + " " + version)
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(wasm))
+	require.NoError(t, err)
+	_, err = repo.Store(context.Background(),
+		entities.NewPlugin(ref, digest, values.NewPluginMetadata(name, version, "", nil)), bytes.NewReader(wasm))
+	require.NoError(t, err)
+	return ref
+}
+
+func TestMemoryPluginRepository_FindAndRead(t *testing.T) {
+	repo := NewMemoryRepository(0)
+	ctx := context.Background()
+	ref := storeMemory(t, repo, "file", "1.0.0")
+
+	plugin, path, err := repo.Find(ctx, ref)
+	require.NoError(t, err)
+	assert.Equal(t, MemoryPathPrefix+ref.String(), path)
+
+	data, err := repo.ReadWASM(ctx, ref)
+	require.NoError(t, err)
+	assert.Equal(t, "wasm file 1.0.0", string(data))
+	assert.NoError(t, plugin.Digest().Verify(data))
+
+	// Callers can't mutate the stored binary.
+	data[0] = 'X'
+	again, err := repo.ReadWASM(ctx, ref)
+	require.NoError(t, err)
+	assert.Equal(t, "wasm file 1.0.0", string(again))
+
+	require.NoError(t, repo.Delete(ctx, ref))
+	_, _, err = repo.Find(ctx, ref)
+	assert.True(t, errors.Is(err, entities.ErrPluginNotFound))
+}
+
+func TestMemoryPluginRepository_EvictsLeastRecentlyUsed(t *testing.T) {
+	repo := NewMemoryRepository(2)
+	ctx := context.Background()
+
+	a := storeMemory(t, repo, "a", "1.0.0")
+	b := storeMemory(t, repo, "b", "1.0.0")
+
+	// Touch a so b becomes the eviction candidate.
+	_, _, err := repo.Find(ctx, a)
+	require.NoError(t, err)
+
+	c := storeMemory(t, repo, "c", "1.0.0")
+
+	_, _, err = repo.Find(ctx, b)
+	assert.True(t, errors.Is(err, entities.ErrPluginNotFound), "b should have been evicted")
+
+	plugins, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, plugins, 2)
+	assert.Equal(t, c.String(), plugins[0].Reference().String())
+	assert.Equal(t, a.String(), plugins[1].Reference().String())
+
+	// Re-storing an existing reference does not evict anything.
+	storeMemory(t, repo, "a", "1.0.0")
+	plugins, err = repo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, plugins, 2)
+	assert.Equal(t, a.String(), plugins[0].Reference().String())
+}
+
+func TestMemoryPluginRepository_Prune(t *testing.T) {
+	repo := NewMemoryRepository(0)
+	storeMemory(t, repo, "file", "1.2.0")
+	storeMemory(t, repo, "file", "1.10.0")
+	storeMemory(t, repo, "file", "1.9.0")
+
+	require.NoError(t, repo.Prune(context.Background(), 1))
+
+	plugins, err := repo.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plugins, 1)
+	assert.Equal(t, "1.10.0", plugins[0].Reference().Version())
+	assert.Error(t, repo.Prune(context.Background(), -1))
+}
+
+func TestMemoryPluginRepository_KeyValidation(t *testing.T) {
+	repo := NewMemoryRepository(0)
+	ctx := context.Background()
+	digest, err := values.NewDigest("sha256", strings.Repeat("aa", 32))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		ref  values.PluginReference
+	}{
+		{"dot-dot org", values.NewPluginReference("reg", "..", "repo", "file", "1.0.0")},
+		{"slash in name", values.NewPluginReference("reg", "org", "repo", "../../etc/passwd", "1.0.0")},
+		{"slash in version", values.NewPluginReference("reg", "org", "repo", "file", "1.0/../x")},
+		{"empty namespace segment", values.NewPluginReference("reg", "org/", "repo", "file", "1.0.0")},
+		{"control character", values.NewPluginReference("reg", "org", "repo", "fi\x00le", "1.0.0")},
+		{"backslash", values.NewPluginReference("reg", "org", "repo", `..\file`, "1.0.0")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := entities.NewPlugin(tt.ref, digest, values.NewPluginMetadata("file", "1.0.0", "", nil))
+			_, err := repo.Store(ctx, plugin, bytes.NewReader(nil))
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), "security violation"))
+
+			_, _, err = repo.Find(ctx, tt.ref)
+			assert.Error(t, err)
+		})
+	}
+
+	// Registries with ports, short namespaces and embedded names are fine.
+	ported := values.NewPluginReference("localhost:5000", "org", "repo", "file", "1.0.0")
+	_, err = repo.Store(ctx, entities.NewPlugin(ported, digest, values.NewPluginMetadata("file", "1.0.0", "", nil)), bytes.NewReader(nil))
+	assert.NoError(t, err)
+	short := values.NewPluginReference("docker.io", "library", "", "file", "1.0.0")
+	_, err = repo.Store(ctx, entities.NewPlugin(short, digest, values.NewPluginMetadata("file", "1.0.0", "", nil)), bytes.NewReader(nil))
+	assert.NoError(t, err)
+	embedded, err := values.ParsePluginReference("file")
+	require.NoError(t, err)
+	_, err = repo.Store(ctx, entities.NewPlugin(embedded, digest, values.NewPluginMetadata("file", "", "", nil)), bytes.NewReader(nil))
+	assert.NoError(t, err)
+}
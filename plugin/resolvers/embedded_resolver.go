@@ -0,0 +1,42 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/services"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// EmbeddedPluginResolver serves built-in plugins from an embedded repository
+// (see repository.EmbeddedPluginRepository). Non-embedded references and
+// embedded names that are not present are delegated to the next resolver.
+type EmbeddedPluginResolver struct {
+	services.BaseResolver
+	repository ports.PluginRepository
+}
+
+// NewEmbeddedPluginResolver creates an embedded plugin resolver.
+func NewEmbeddedPluginResolver(repository ports.PluginRepository) *EmbeddedPluginResolver {
+	return &EmbeddedPluginResolver{
+		repository: repository,
+	}
+}
+
+// Resolve returns the embedded plugin, otherwise delegates to next.
+func (r *EmbeddedPluginResolver) Resolve(ctx context.Context, ref values.PluginReference) (*entities.Plugin, error) {
+	if !ref.IsEmbedded() {
+		return r.ResolveNext(ctx, ref)
+	}
+
+	plugin, _, err := r.repository.Find(ctx, ref)
+	if err == nil {
+		return plugin, nil
+	}
+	if errors.Is(err, entities.ErrPluginNotFound) {
+		return r.ResolveNext(ctx, ref)
+	}
+	return nil, err
+}
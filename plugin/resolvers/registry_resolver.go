@@ -2,11 +2,15 @@ package resolvers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
-	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/services"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 )
@@ -17,6 +21,19 @@ type RegistryPluginResolver struct {
 	registry   ports.PluginRegistry
 	repository ports.PluginRepository
 	logger     *slog.Logger
+	pullRetry  *netutil.RetryPolicy
+}
+
+// RegistryResolverOption configures a RegistryPluginResolver.
+type RegistryResolverOption func(*RegistryPluginResolver)
+
+// WithPullRetry retries a failed pull as a whole according to policy. This
+// complements transport-level retries, which cannot restart a pull that
+// fails part-way through. A plugin that does not exist or fails integrity
+// checks is never retried, nor is any error marked netutil.Permanent, such
+// as a registry rejecting the credentials.
+func WithPullRetry(policy netutil.RetryPolicy) RegistryResolverOption {
+	return func(r *RegistryPluginResolver) { r.pullRetry = &policy }
 }
 
 // NewRegistryPluginResolver creates a registry resolver.
@@ -24,12 +41,17 @@ func NewRegistryPluginResolver(
 	registry ports.PluginRegistry,
 	repository ports.PluginRepository,
 	logger *slog.Logger,
+	opts ...RegistryResolverOption,
 ) *RegistryPluginResolver {
-	return &RegistryPluginResolver{
+	r := &RegistryPluginResolver{
 		registry:   registry,
 		repository: repository,
 		logger:     logger,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // Resolve pulls from registry and caches.
@@ -37,7 +59,7 @@ func (r *RegistryPluginResolver) Resolve(ctx context.Context, ref values.PluginR
 	r.logger.Info("pulling plugin from registry", "ref", ref.String())
 
 	// Pull artifact from registry
-	artifact, err := r.registry.Pull(ctx, ref)
+	artifact, err := r.pull(ctx, ref)
 	if err != nil {
 		return nil, fmt.Errorf("registry pull failed: %w", err)
 	}
@@ -57,3 +79,39 @@ func (r *RegistryPluginResolver) Resolve(ctx context.Context, ref values.PluginR
 
 	return artifact.Plugin, nil
 }
+
+func (r *RegistryPluginResolver) pull(ctx context.Context, ref values.PluginReference) (*dto.PluginArtifactDTO, error) {
+	if r.pullRetry == nil {
+		return r.registry.Pull(ctx, ref)
+	}
+
+	policy := *r.pullRetry
+	retryable := policy.Retryable
+	policy.Retryable = func(err error) bool {
+		if errors.Is(err, entities.ErrPluginNotFound) || errors.Is(err, entities.ErrIntegrityCheckFailed) {
+			return false
+		}
+		if retryable != nil {
+			return retryable(err)
+		}
+		return !errors.Is(err, context.Canceled) &&
+			!errors.Is(err, context.DeadlineExceeded) &&
+			!netutil.IsSSRFBlockedError(err)
+	}
+	onRetry := policy.OnRetry
+	policy.OnRetry = func(attempt int, wait time.Duration, err error) {
+		r.logger.Warn("registry pull failed, retrying",
+			"ref", ref.String(), "attempt", attempt, "wait", wait, "error", err)
+		if onRetry != nil {
+			onRetry(attempt, wait, err)
+		}
+	}
+
+	var artifact *dto.PluginArtifactDTO
+	err := netutil.Retry(ctx, policy, func(ctx context.Context) error {
+		var err error
+		artifact, err = r.registry.Pull(ctx, ref)
+		return err
+	})
+	return artifact, err
+}
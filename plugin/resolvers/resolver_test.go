@@ -3,11 +3,17 @@ package resolvers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
 	"github.com/reglet-dev/reglet-host-sdk/plugin"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/oci"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/repository"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
 )
 
@@ -83,3 +89,128 @@ func TestRegistryPluginResolver(t *testing.T) {
 		}
 	})
 }
+
+// flakyRegistry fails the first failures pulls with err.
+type flakyRegistry struct {
+	plugin.MockRegistry
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *flakyRegistry) Pull(ctx context.Context, ref values.PluginReference) (*dto.PluginArtifactDTO, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	return f.MockRegistry.Pull(ctx, ref)
+}
+
+func TestRegistryPluginResolver_PullRetry(t *testing.T) {
+	logger := plugin.NewTestLogger()
+	ref := values.NewPluginReference("reg", "org", "repo", "name", "1.0")
+	p := entities.NewPlugin(ref, values.Digest{}, values.PluginMetadata{})
+	policy := netutil.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Jitter: 0.5}
+
+	t.Run("SucceedsAfterTransientFailures", func(t *testing.T) {
+		registry := &flakyRegistry{
+			MockRegistry: plugin.MockRegistry{PullArtifact: dto.NewPluginArtifactDTO(p, nil)},
+			err:          errors.New("connection reset"),
+			failures:     2,
+		}
+		resolver := NewRegistryPluginResolver(registry, &plugin.MockRepository{}, logger, WithPullRetry(policy))
+
+		got, err := resolver.Resolve(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if got != p || registry.calls != 3 {
+			t.Errorf("expected plugin after 3 pulls, got %d pulls", registry.calls)
+		}
+	})
+
+	t.Run("ExhaustsRetries", func(t *testing.T) {
+		registry := &flakyRegistry{err: errors.New("connection reset"), failures: 10}
+		resolver := NewRegistryPluginResolver(registry, &plugin.MockRepository{}, logger, WithPullRetry(policy))
+
+		_, err := resolver.Resolve(context.Background(), ref)
+		var exhausted *netutil.RetryExhaustedError
+		if !errors.As(err, &exhausted) || exhausted.Attempts != 3 || registry.calls != 3 {
+			t.Errorf("expected 3 attempts, got %d pulls: %v", registry.calls, err)
+		}
+	})
+
+	t.Run("NotFoundIsNotRetried", func(t *testing.T) {
+		registry := &flakyRegistry{err: &entities.PluginNotFoundError{Reference: ref}, failures: 10}
+		resolver := NewRegistryPluginResolver(registry, &plugin.MockRepository{}, logger, WithPullRetry(policy))
+
+		_, err := resolver.Resolve(context.Background(), ref)
+		if !errors.Is(err, entities.ErrPluginNotFound) || registry.calls != 1 {
+			t.Errorf("expected a single pull, got %d: %v", registry.calls, err)
+		}
+	})
+
+	for name, pullErr := range map[string]error{
+		"DigestMismatchIsNotRetried": fmt.Errorf("fetch wasm: %w", oci.ErrContentDigestMismatch),
+		"AuthFailureIsNotRetried":    fmt.Errorf("pull %s: %w", ref.String(), oci.ErrRegistryAuth),
+	} {
+		t.Run(name, func(t *testing.T) {
+			registry := &flakyRegistry{err: pullErr, failures: 10}
+			resolver := NewRegistryPluginResolver(registry, &plugin.MockRepository{}, logger, WithPullRetry(policy))
+
+			_, err := resolver.Resolve(context.Background(), ref)
+			if !errors.Is(err, pullErr) || registry.calls != 1 {
+				t.Errorf("expected a single pull, got %d: %v", registry.calls, err)
+			}
+		})
+	}
+}
+
+func TestEmbeddedPluginResolver(t *testing.T) {
+	fsys := fstest.MapFS{
+		"file/plugin.wasm": {Data: []byte("\x00asm")},
+	}
+	repo := repository.NewEmbeddedPluginRepository(fsys)
+
+	t.Run("ResolvesEmbeddedPlugin", func(t *testing.T) {
+		resolver := NewEmbeddedPluginResolver(repo)
+		ref, _ := values.ParsePluginReference("file")
+
+		got, err := resolver.Resolve(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if got.Reference().Name() != "file" {
+			t.Errorf("expected embedded plugin 'file', got %q", got.Reference().Name())
+		}
+		if err := got.Digest().Verify([]byte("\x00asm")); err != nil {
+			t.Errorf("digest should match embedded bytes: %v", err)
+		}
+	})
+
+	t.Run("DelegatesMissingEmbeddedPlugin", func(t *testing.T) {
+		resolver := NewEmbeddedPluginResolver(repo)
+		resolver.SetNext(&plugin.MockResolver{Err: errors.New("delegated")})
+		ref, _ := values.ParsePluginReference("missing")
+
+		_, err := resolver.Resolve(context.Background(), ref)
+		if err == nil || err.Error() != "delegated" {
+			t.Errorf("expected delegation error, got %v", err)
+		}
+	})
+
+	t.Run("DelegatesRegistryReference", func(t *testing.T) {
+		ref := values.NewPluginReference("reg", "org", "repo", "file", "1.0")
+		p := entities.NewPlugin(ref, values.Digest{}, values.PluginMetadata{})
+		resolver := NewEmbeddedPluginResolver(repo)
+		resolver.SetNext(&plugin.MockResolver{FoundPlugin: p})
+
+		got, err := resolver.Resolve(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if got != p {
+			t.Error("expected plugin from next resolver")
+		}
+	})
+}
@@ -22,6 +22,8 @@ type PluginService struct {
 	integrityVerifier ports.IntegrityVerifier
 	integrityService  *services.IntegrityService
 	logger            *slog.Logger
+
+	enforceOnDiskDigest bool
 }
 
 // PluginServiceOption configures a PluginService.
@@ -61,6 +63,15 @@ func WithIntegrityService(is *services.IntegrityService) PluginServiceOption {
 	return func(s *PluginService) { s.integrityService = is }
 }
 
+// WithEnforceOnDiskDigest makes LoadPlugin hash the binary it is about to
+// return and compare it with the pinned digest. The resolved plugin's digest
+// may come from stored metadata rather than the bytes on disk, so without
+// this a lockfile pin does not prove what will run. It costs a full read of
+// the binary per load, so it is off by default.
+func WithEnforceOnDiskDigest(enforce bool) PluginServiceOption {
+	return func(s *PluginService) { s.enforceOnDiskDigest = enforce }
+}
+
 // WithLogger sets the logger.
 func WithLogger(l *slog.Logger) PluginServiceOption {
 	return func(s *PluginService) { s.logger = l }
@@ -94,7 +105,7 @@ func (s *PluginService) LoadPlugin(ctx context.Context, spec *dto.PluginSpecDTO)
 	}
 
 	// Verify signature if required by policy
-	if s.integrityService.ShouldVerifySignature() {
+	if s.integrityService.RequiresSignature(ref) {
 		result, err := s.integrityVerifier.VerifySignature(ctx, ref)
 		if err != nil {
 			return "", fmt.Errorf("signature verification failed: %w", err)
@@ -111,6 +122,13 @@ func (s *PluginService) LoadPlugin(ctx context.Context, spec *dto.PluginSpecDTO)
 		return "", fmt.Errorf("failed to locate plugin binary: %w", err)
 	}
 
+	// Verify the bytes that will actually run, not just the resolved metadata
+	if s.enforceOnDiskDigest && expectedDigest.Value() != "" {
+		if err := s.verifyOnDisk(ctx, ref, wasmPath, expectedDigest); err != nil {
+			return "", fmt.Errorf("integrity verification failed: %w", err)
+		}
+	}
+
 	return wasmPath, nil
 }
 
@@ -124,7 +142,7 @@ func (s *PluginService) Pull(ctx context.Context, ref values.PluginReference) (*
 	}
 
 	// Verify signature if required by policy
-	if s.integrityService.ShouldVerifySignature() {
+	if s.integrityService.RequiresSignature(ref) {
 		_, err := s.integrityVerifier.VerifySignature(ctx, ref)
 		if err != nil {
 			return nil, fmt.Errorf("signature verification failed: %w", err)
@@ -1,22 +1,28 @@
 package plugin_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/reglet-dev/reglet-host-sdk/plugin"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/dto"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/resolvers"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/services"
 	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestPluginService_LoadPlugin(t *testing.T) {
 	ref := values.NewPluginReference("reg", "org", "repo", "name", "1.0")
 	meta := values.NewPluginMetadata("name", "1.0", "desc", nil)
-	digest, _ := values.NewDigest("sha256", "abc")
+	digest, _ := values.NewDigest("sha256", strings.Repeat("ab", 32))
 	p := entities.NewPlugin(ref, digest, meta)
 
 	// Mock strategy that returns a plugin
@@ -48,7 +54,7 @@ func TestPluginService_LoadPlugin(t *testing.T) {
 			plugin.WithResolver(resolver),
 		)
 
-		spec := &dto.PluginSpecDTO{Name: "reg/org/repo/name:1.0", Digest: "sha256:abc"}
+		spec := &dto.PluginSpecDTO{Name: "reg/org/repo/name:1.0", Digest: digest.String()}
 		_, err := svc.LoadPlugin(context.Background(), spec)
 		if err != nil {
 			t.Errorf("LoadPlugin failed: %v", err)
@@ -63,7 +69,7 @@ func TestPluginService_LoadPlugin(t *testing.T) {
 			plugin.WithResolver(resolver),
 		)
 
-		spec := &dto.PluginSpecDTO{Name: "reg/org/repo/name:1.0", Digest: "sha256:bad"}
+		spec := &dto.PluginSpecDTO{Name: "reg/org/repo/name:1.0", Digest: "sha256:" + strings.Repeat("ba", 32)}
 		_, err := svc.LoadPlugin(context.Background(), spec)
 		if err == nil {
 			t.Error("LoadPlugin should fail on digest mismatch")
@@ -108,6 +114,61 @@ func TestPluginService_LoadPlugin(t *testing.T) {
 		}
 	})
 
+	t.Run("SignaturePolicy_PerRegistry", func(t *testing.T) {
+		repo := &plugin.MockRepository{FindPath: "/path/to/wasm"}
+		svc := plugin.NewPluginService(
+			repo,
+			nil, // no registry needed
+			plugin.WithResolver(resolver),
+			plugin.WithIntegrityVerifier(&plugin.MockVerifier{VerifyErr: errors.New("unsigned")}),
+			plugin.WithIntegrityService(services.NewIntegrityService(false,
+				services.WithSignaturePolicy(services.RequireSignaturesFrom("third.party")))),
+			plugin.WithLogger(plugin.NewTestLogger()),
+		)
+
+		_, err := svc.LoadPlugin(context.Background(), &dto.PluginSpecDTO{Name: "third.party/org/repo/name:1.0"})
+		if err == nil {
+			t.Error("unsigned plugin from third.party should be rejected")
+		}
+
+		_, err = svc.LoadPlugin(context.Background(), &dto.PluginSpecDTO{Name: "internal.corp/org/repo/name:1.0"})
+		if err != nil {
+			t.Errorf("internal.corp plugin should load unsigned: %v", err)
+		}
+	})
+
+	t.Run("EnforceOnDiskDigest", func(t *testing.T) {
+		wasmPath := filepath.Join(t.TempDir(), "plugin.wasm")
+		original := []byte("\x00asm original")
+		if err := os.WriteFile(wasmPath, original, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		pinned, err := values.ComputeDigestSHA256(bytes.NewReader(original))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The resolver reports the pinned digest, as cached metadata would.
+		svc := plugin.NewPluginService(
+			&plugin.MockRepository{FindPath: wasmPath},
+			nil, // no registry needed
+			plugin.WithResolver(&plugin.MockResolver{FoundPlugin: entities.NewPlugin(ref, pinned, meta)}),
+			plugin.WithEnforceOnDiskDigest(true),
+		)
+		spec := &dto.PluginSpecDTO{Name: "reg/org/repo/name:1.0", Digest: pinned.String()}
+
+		if _, err := svc.LoadPlugin(context.Background(), spec); err != nil {
+			t.Fatalf("LoadPlugin with matching bytes failed: %v", err)
+		}
+
+		if err := os.WriteFile(wasmPath, []byte("\x00asm swapped"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err = svc.LoadPlugin(context.Background(), spec)
+		if !errors.Is(err, entities.ErrIntegrityCheckFailed) {
+			t.Errorf("expected integrity failure for swapped bytes, got %v", err)
+		}
+	})
+
 	t.Run("Fail_Resolution", func(t *testing.T) {
 		// Here we want to use the type MockResolver to create a NEW instance
 		badResolver := &plugin.MockResolver{Err: errors.New("not found")}
@@ -127,7 +188,7 @@ func TestPluginService_LoadPlugin(t *testing.T) {
 func TestPluginService_PublishPlugin(t *testing.T) {
 	ref := values.NewPluginReference("reg", "org", "repo", "name", "1.0")
 	meta := values.NewPluginMetadata("name", "1.0", "desc", nil)
-	digest, _ := values.NewDigest("sha256", "abc")
+	digest, _ := values.NewDigest("sha256", strings.Repeat("ab", 32))
 	p := entities.NewPlugin(ref, digest, meta)
 
 	t.Run("Success_PushOnly", func(t *testing.T) {
@@ -160,3 +221,39 @@ func TestPluginService_PublishPlugin(t *testing.T) {
 type mockReader struct{}
 
 func (m *mockReader) Read(p []byte) (n int, err error) { return 0, io.EOF }
+
+func TestPluginService_LoadPlugin_FromLockfile(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockRepo)
+	repo.On("Load", ctx, "reglet.lock").Return(nil, nil).Once()
+	repo.On("Save", ctx, mock.AnythingOfType("*entities.Lockfile"), "reglet.lock").Return(nil).Once()
+
+	lockSvc := plugin.NewLockfileService(repo, resolvers.NewSemverResolver(), fakeDigester{},
+		plugin.WithVersionSource(&fakeVersionSource{versions: []string{"1.0.0"}}))
+	lock, err := lockSvc.ResolvePlugins(ctx, []string{"reglet/name@^1.0.0"}, "reglet.lock")
+	if err != nil {
+		t.Fatalf("ResolvePlugins failed: %v", err)
+	}
+	locked := lock.GetPlugin("name")
+	if locked == nil {
+		t.Fatal("plugin not locked")
+	}
+
+	// The resolved plugin carries the digest that was locked.
+	digest, err := values.ParseDigest(locked.Digest)
+	if err != nil {
+		t.Fatalf("locked digest %q does not parse: %v", locked.Digest, err)
+	}
+	ref := values.NewPluginReference("reg", "org", "repo", "name", "1.0.0")
+	p := entities.NewPlugin(ref, digest, values.NewPluginMetadata("name", "1.0.0", "desc", nil))
+	svc := plugin.NewPluginService(
+		&plugin.MockRepository{FindPath: "/path/to/wasm"},
+		nil,
+		plugin.WithResolver(&plugin.MockResolver{FoundPlugin: p}),
+	)
+
+	spec := &dto.PluginSpecDTO{Name: "reg/org/repo/name:1.0.0", Digest: locked.Digest}
+	if _, err := svc.LoadPlugin(ctx, spec); err != nil {
+		t.Fatalf("LoadPlugin rejected a lockfile entry: %v", err)
+	}
+}
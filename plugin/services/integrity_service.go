@@ -10,14 +10,49 @@ import (
 
 // IntegrityService provides domain logic for plugin integrity verification.
 type IntegrityService struct {
-	requireSigning bool
+	signaturePolicy SignaturePolicy
+	requireSigning  bool
+}
+
+// SignaturePolicy reports whether plugins from registry must be signed.
+// Embedded plugins are checked with an empty registry.
+type SignaturePolicy func(registry string) bool
+
+// RequireSignaturesFrom returns a policy requiring signatures for plugins from
+// the listed registries only.
+func RequireSignaturesFrom(registries ...string) SignaturePolicy {
+	set := make(map[string]bool, len(registries))
+	for _, r := range registries {
+		set[r] = true
+	}
+	return func(registry string) bool { return set[registry] }
+}
+
+// TrustRegistries returns a policy requiring signatures for every registry
+// except the listed ones, e.g. an internal registry.
+func TrustRegistries(registries ...string) SignaturePolicy {
+	required := RequireSignaturesFrom(registries...)
+	return func(registry string) bool { return !required(registry) }
+}
+
+// IntegrityServiceOption configures an IntegrityService.
+type IntegrityServiceOption func(*IntegrityService)
+
+// WithSignaturePolicy decides per registry whether signatures are required,
+// overriding the requireSigning flag for RequiresSignature.
+func WithSignaturePolicy(policy SignaturePolicy) IntegrityServiceOption {
+	return func(s *IntegrityService) { s.signaturePolicy = policy }
 }
 
 // NewIntegrityService creates an integrity service.
-func NewIntegrityService(requireSigning bool) *IntegrityService {
-	return &IntegrityService{
+func NewIntegrityService(requireSigning bool, opts ...IntegrityServiceOption) *IntegrityService {
+	s := &IntegrityService{
 		requireSigning: requireSigning,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // VerifyDigest checks if plugin digest matches expected value.
@@ -25,11 +60,21 @@ func (s *IntegrityService) VerifyDigest(plugin *entities.Plugin, expected values
 	return plugin.VerifyIntegrity(expected)
 }
 
-// ShouldVerifySignature returns true if signature verification is required.
+// ShouldVerifySignature returns true if signature verification is required
+// globally. It ignores any per-registry policy; see RequiresSignature.
 func (s *IntegrityService) ShouldVerifySignature() bool {
 	return s.requireSigning
 }
 
+// RequiresSignature reports whether ref must carry a verified signature,
+// consulting the signature policy when one is set.
+func (s *IntegrityService) RequiresSignature(ref values.PluginReference) bool {
+	if s.signaturePolicy != nil {
+		return s.signaturePolicy(ref.Registry())
+	}
+	return s.requireSigning
+}
+
 // ValidatePlugin performs complete integrity check.
 func (s *IntegrityService) ValidatePlugin(
 	ctx context.Context,
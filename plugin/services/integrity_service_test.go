@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
@@ -11,9 +12,9 @@ import (
 func TestIntegrityService(t *testing.T) {
 	ref := values.NewPluginReference("reg", "org", "repo", "name", "1.0")
 	meta := values.NewPluginMetadata("name", "1.0", "desc", nil)
-	digest, _ := values.NewDigest("sha256", "abc")
+	digest, _ := values.NewDigest("sha256", strings.Repeat("ab", 32))
 
-	// Plugin with digest "abc"
+	// Plugin with digest "abab..."
 	plugin := entities.NewPlugin(ref, digest, meta)
 
 	t.Run("VerifyDigest_Success", func(t *testing.T) {
@@ -27,7 +28,7 @@ func TestIntegrityService(t *testing.T) {
 
 	t.Run("VerifyDigest_Mismatch", func(t *testing.T) {
 		svc := NewIntegrityService(false)
-		otherDigest, _ := values.NewDigest("sha256", "def")
+		otherDigest, _ := values.NewDigest("sha256", strings.Repeat("de", 32))
 
 		err := svc.VerifyDigest(plugin, otherDigest)
 		if err == nil {
@@ -47,6 +48,26 @@ func TestIntegrityService(t *testing.T) {
 		}
 	})
 
+	t.Run("RequiresSignature_PerRegistry", func(t *testing.T) {
+		thirdParty := values.NewPluginReference("ghcr.io", "vendor", "plugins", "file", "1.0")
+		internal := values.NewPluginReference("registry.corp", "team", "plugins", "file", "1.0")
+
+		required := NewIntegrityService(false, WithSignaturePolicy(RequireSignaturesFrom("ghcr.io")))
+		if !required.RequiresSignature(thirdParty) || required.RequiresSignature(internal) {
+			t.Error("RequireSignaturesFrom should only cover ghcr.io")
+		}
+
+		trusted := NewIntegrityService(true, WithSignaturePolicy(TrustRegistries("registry.corp")))
+		if !trusted.RequiresSignature(thirdParty) || trusted.RequiresSignature(internal) {
+			t.Error("TrustRegistries should exempt only registry.corp")
+		}
+
+		global := NewIntegrityService(true)
+		if !global.RequiresSignature(internal) {
+			t.Error("without a policy the global flag applies")
+		}
+	})
+
 	t.Run("ValidatePlugin_DigestCheck", func(t *testing.T) {
 		svc := NewIntegrityService(false)
 
@@ -55,7 +76,7 @@ func TestIntegrityService(t *testing.T) {
 			t.Errorf("ValidatePlugin failed: %v", err)
 		}
 
-		badDigest, _ := values.NewDigest("sha256", "bad")
+		badDigest, _ := values.NewDigest("sha256", strings.Repeat("ba", 32))
 		err = svc.ValidatePlugin(context.Background(), plugin, badDigest)
 		if err == nil {
 			t.Error("ValidatePlugin should fail on bad digest")
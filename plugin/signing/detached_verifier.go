@@ -0,0 +1,203 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+// SignatureSuffix is appended to a plugin's WASM path to find its detached
+// signature by default.
+const SignatureSuffix = ".sig"
+
+var (
+	// ErrSignatureInvalid is returned when a detached signature does not
+	// verify against the WASM binary and the configured key.
+	ErrSignatureInvalid = errors.New("invalid plugin signature")
+
+	// ErrSigningKeyMissing is returned by Sign when no private key is configured.
+	ErrSigningKeyMissing = errors.New("no signing key configured")
+)
+
+// DetachedSignatureVerifier implements ports.IntegrityVerifier with a
+// signature file stored next to the plugin binary and a fixed public key.
+// It needs no registry or transparency log, which suits air-gapped hosts.
+//
+// Signatures are base64 encoded (raw bytes are also accepted). Ed25519 keys
+// sign the binary itself; ECDSA keys sign its SHA-256 digest, ASN.1 encoded,
+// as cosign sign-blob does.
+type DetachedSignatureVerifier struct {
+	repository    ports.PluginRepository
+	publicKey     crypto.PublicKey
+	signingKey    crypto.Signer
+	signaturePath func(ref values.PluginReference, wasmPath string) string
+	keyID         string
+}
+
+// DetachedVerifierOption configures a DetachedSignatureVerifier.
+type DetachedVerifierOption func(*DetachedSignatureVerifier)
+
+// WithSignaturePath overrides where the signature for a plugin is read and
+// written. The default is the WASM path plus SignatureSuffix.
+func WithSignaturePath(fn func(ref values.PluginReference, wasmPath string) string) DetachedVerifierOption {
+	return func(v *DetachedSignatureVerifier) {
+		if fn != nil {
+			v.signaturePath = fn
+		}
+	}
+}
+
+// WithSigningKey enables Sign, which writes detached signatures with key.
+// The key must match the verifier's public key.
+func WithSigningKey(key crypto.Signer) DetachedVerifierOption {
+	return func(v *DetachedSignatureVerifier) { v.signingKey = key }
+}
+
+// NewDetachedSignatureVerifier creates a verifier for plugins stored in
+// repository, trusting the PEM-encoded (PKIX) Ed25519 or ECDSA public key.
+func NewDetachedSignatureVerifier(
+	repository ports.PluginRepository,
+	publicKeyPEM []byte,
+	opts ...DetachedVerifierOption,
+) (*DetachedSignatureVerifier, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("public key: no PEM block found")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w", err)
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+	default:
+		return nil, fmt.Errorf("public key: unsupported key type %T", key)
+	}
+
+	fingerprint := sha256.Sum256(block.Bytes)
+	v := &DetachedSignatureVerifier{
+		repository: repository,
+		publicKey:  key,
+		keyID:      "sha256:" + hex.EncodeToString(fingerprint[:]),
+		signaturePath: func(_ values.PluginReference, wasmPath string) string {
+			return wasmPath + SignatureSuffix
+		},
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v, nil
+}
+
+// VerifySignature checks the plugin's detached signature. The result's
+// Signer is the SHA-256 fingerprint of the trusted public key.
+func (v *DetachedSignatureVerifier) VerifySignature(ctx context.Context, ref values.PluginReference) (*ports.SignatureResult, error) {
+	wasm, wasmPath, err := v.readWASM(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	sigPath := v.signaturePath(ref, wasmPath)
+	raw, err := os.ReadFile(filepath.Clean(sigPath))
+	if err != nil {
+		return nil, fmt.Errorf("read signature for %s: %w", ref.String(), err)
+	}
+	info, err := os.Stat(filepath.Clean(sigPath))
+	if err != nil {
+		return nil, fmt.Errorf("stat signature for %s: %w", ref.String(), err)
+	}
+
+	if !v.verify(wasm, decodeSignature(raw)) {
+		return nil, fmt.Errorf("%w: %s", ErrSignatureInvalid, ref.String())
+	}
+
+	return &ports.SignatureResult{
+		SignedAt: info.ModTime(),
+		Signer:   v.keyID,
+		Verified: true,
+	}, nil
+}
+
+// Sign writes a detached signature for the plugin. It requires WithSigningKey.
+func (v *DetachedSignatureVerifier) Sign(ctx context.Context, ref values.PluginReference) error {
+	if v.signingKey == nil {
+		return ErrSigningKeyMissing
+	}
+	wasm, wasmPath, err := v.readWASM(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	var sig []byte
+	switch v.signingKey.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err = v.signingKey.Sign(rand.Reader, wasm, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(wasm)
+		sig, err = v.signingKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return fmt.Errorf("sign %s: %w", ref.String(), err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(sig) + "\n"
+	if err := os.WriteFile(v.signaturePath(ref, wasmPath), []byte(encoded), 0o644); err != nil {
+		return fmt.Errorf("write signature for %s: %w", ref.String(), err)
+	}
+	return nil
+}
+
+func (v *DetachedSignatureVerifier) verify(data, sig []byte) bool {
+	switch key := v.publicKey.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, sig)
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, digest[:], sig)
+	default:
+		return false
+	}
+}
+
+// readWASM returns the plugin binary and the path the repository reports
+// for it. Repositories that can return the bytes directly are preferred.
+func (v *DetachedSignatureVerifier) readWASM(ctx context.Context, ref values.PluginReference) ([]byte, string, error) {
+	_, wasmPath, err := v.repository.Find(ctx, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("locate %s: %w", ref.String(), err)
+	}
+
+	var wasm []byte
+	if reader, ok := v.repository.(ports.PluginBinaryReader); ok {
+		wasm, err = reader.ReadWASM(ctx, ref)
+	} else {
+		wasm, err = os.ReadFile(filepath.Clean(wasmPath))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("read %s: %w", ref.String(), err)
+	}
+	return wasm, wasmPath, nil
+}
+
+// decodeSignature accepts base64 (with surrounding whitespace) or raw bytes.
+func decodeSignature(raw []byte) []byte {
+	if sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw))); err == nil {
+		return sig
+	}
+	return raw
+}
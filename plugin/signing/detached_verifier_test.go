@@ -0,0 +1,129 @@
+package signing_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reglet-dev/reglet-host-sdk/plugin/entities"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/ports"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/repository"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/signing"
+	"github.com/reglet-dev/reglet-host-sdk/plugin/values"
+)
+
+var _ ports.IntegrityVerifier = (*signing.DetachedSignatureVerifier)(nil)
+
+func publicKeyPEM(t *testing.T, key crypto.PublicKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func storePlugin(t *testing.T, wasm []byte) (*repository.FSPluginRepository, values.PluginReference, string) {
+	t.Helper()
+	repo, err := repository.NewFSPluginRepository(t.TempDir())
+	require.NoError(t, err)
+	ref := values.NewPluginReference("reg", "org", "repo", "file", "1.0.0")
+	digest, err := values.ComputeDigestSHA256(bytes.NewReader(wasm))
+	require.NoError(t, err)
+	path, err := repo.Store(context.Background(),
+		entities.NewPlugin(ref, digest, values.NewPluginMetadata("file", "1.0.0", "", nil)), bytes.NewReader(wasm))
+	require.NoError(t, err)
+	return repo, ref, path
+}
+
+func TestDetachedSignatureVerifier(t *testing.T) {
+	ctx := context.Background()
+	wasm := []byte("\x00asm\x01\x00\x00\x00")
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	keys := map[string]struct {
+		public  crypto.PublicKey
+		private crypto.Signer
+	}{
+		"ed25519": {edPub, edPriv},
+		"ecdsa":   {&ecPriv.PublicKey, ecPriv},
+	}
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			repo, ref, wasmPath := storePlugin(t, wasm)
+			verifier, err := signing.NewDetachedSignatureVerifier(repo, publicKeyPEM(t, key.public),
+				signing.WithSigningKey(key.private))
+			require.NoError(t, err)
+			require.NoError(t, verifier.Sign(ctx, ref))
+
+			t.Run("Valid", func(t *testing.T) {
+				result, err := verifier.VerifySignature(ctx, ref)
+				require.NoError(t, err)
+				assert.True(t, result.Verified)
+				assert.Contains(t, result.Signer, "sha256:")
+			})
+
+			t.Run("TamperedBinary", func(t *testing.T) {
+				sig, err := os.ReadFile(wasmPath + signing.SignatureSuffix)
+				require.NoError(t, err)
+				require.NoError(t, os.WriteFile(wasmPath, append(append([]byte(nil), wasm...), 0), 0o600))
+				defer func() { require.NoError(t, os.WriteFile(wasmPath, wasm, 0o600)) }()
+
+				_, err = verifier.VerifySignature(ctx, ref)
+				assert.ErrorIs(t, err, signing.ErrSignatureInvalid)
+
+				// The signature file itself is unchanged.
+				after, err := os.ReadFile(wasmPath + signing.SignatureSuffix)
+				require.NoError(t, err)
+				assert.Equal(t, sig, after)
+			})
+
+			t.Run("CorruptSignature", func(t *testing.T) {
+				require.NoError(t, os.WriteFile(wasmPath+signing.SignatureSuffix, []byte("bm90IGEgc2lnbmF0dXJl\n"), 0o600))
+				defer func() { require.NoError(t, verifier.Sign(ctx, ref)) }()
+
+				_, err := verifier.VerifySignature(ctx, ref)
+				assert.ErrorIs(t, err, signing.ErrSignatureInvalid)
+			})
+
+			t.Run("WrongKey", func(t *testing.T) {
+				otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+				require.NoError(t, err)
+				other, err := signing.NewDetachedSignatureVerifier(repo, publicKeyPEM(t, otherPub))
+				require.NoError(t, err)
+
+				_, err = other.VerifySignature(ctx, ref)
+				assert.ErrorIs(t, err, signing.ErrSignatureInvalid)
+			})
+		})
+	}
+
+	t.Run("MissingSignature", func(t *testing.T) {
+		repo, ref, _ := storePlugin(t, wasm)
+		verifier, err := signing.NewDetachedSignatureVerifier(repo, publicKeyPEM(t, edPub))
+		require.NoError(t, err)
+
+		_, err = verifier.VerifySignature(ctx, ref)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.ErrorIs(t, verifier.Sign(ctx, ref), signing.ErrSigningKeyMissing)
+	})
+
+	t.Run("RejectsBadKey", func(t *testing.T) {
+		_, err := signing.NewDetachedSignatureVerifier(nil, []byte("not pem"))
+		assert.Error(t, err)
+	})
+}
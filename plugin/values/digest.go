@@ -3,25 +3,85 @@ package values
 import (
 	"crypto/sha256"
 	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
+	"hash"
 	"io"
 	"strings"
 )
 
 // Digest represents a content hash with algorithm.
 type Digest struct {
-	algorithm string // sha256, sha512
+	algorithm string // sha256, sha512, sha512-256
 	value     string // hex-encoded hash
 }
 
-// NewDigest creates a digest from algorithm and hex value.
-func NewDigest(algorithm, hexValue string) (Digest, error) {
+// Digest algorithms accepted by NewDigest. SHA-512/256 is SHA-512 truncated
+// to 256 bits, as emitted by some WASM toolchains.
+const (
+	SHA256    = "sha256"
+	SHA512    = "sha512"
+	SHA512256 = "sha512-256"
+)
+
+// insecureAlgorithms are recognised but always rejected: collisions can be
+// produced for them, so they cannot vouch for content. Legacy registries
+// still emit sha1 descriptors.
+var insecureAlgorithms = map[string]bool{
+	"sha1": true,
+	"md5":  true,
+}
+
+// UnsupportedAlgorithmError is returned for digests whose algorithm is not
+// supported, so callers can tell an unknown or insecure algorithm apart
+// from a malformed digest.
+type UnsupportedAlgorithmError struct {
+	Algorithm string
+
+	// Insecure is set for algorithms such as sha1 that are known but
+	// rejected as unsafe for integrity checks.
+	Insecure bool
+}
+
+func (e *UnsupportedAlgorithmError) Error() string {
+	if e.Insecure {
+		return fmt.Sprintf("insecure digest algorithm %s is not accepted", e.Algorithm)
+	}
+	return fmt.Sprintf("unsupported digest algorithm: %s", e.Algorithm)
+}
+
+// IsSupportedAlgorithm reports whether NewDigest accepts algorithm.
+func IsSupportedAlgorithm(algorithm string) bool {
 	switch algorithm {
-	case "sha256", "sha512":
-		// Valid
+	case SHA256, SHA512, SHA512256:
+		return true
 	default:
-		return Digest{}, fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+		return false
+	}
+}
+
+// SupportedAlgorithms lists the algorithms NewDigest accepts.
+func SupportedAlgorithms() []string {
+	return []string{SHA256, SHA512, SHA512256}
+}
+
+// hexLengths is the length of each algorithm's hex-encoded value.
+var hexLengths = map[string]int{
+	SHA256:    64,
+	SHA512:    128,
+	SHA512256: 64,
+}
+
+// NewDigest creates a digest from algorithm and hex value. Unsupported
+// algorithms yield an *UnsupportedAlgorithmError. The value must be
+// lowercase hex of the algorithm's length, as in OCI descriptors.
+func NewDigest(algorithm, hexValue string) (Digest, error) {
+	if err := checkAlgorithm(algorithm); err != nil {
+		return Digest{}, err
+	}
+	if err := checkHex(algorithm, hexValue); err != nil {
+		return Digest{}, err
 	}
 
 	return Digest{
@@ -30,6 +90,39 @@ func NewDigest(algorithm, hexValue string) (Digest, error) {
 	}, nil
 }
 
+func checkAlgorithm(algorithm string) error {
+	if IsSupportedAlgorithm(algorithm) {
+		return nil
+	}
+	return &UnsupportedAlgorithmError{Algorithm: algorithm, Insecure: insecureAlgorithms[algorithm]}
+}
+
+func checkHex(algorithm, hexValue string) error {
+	if want := hexLengths[algorithm]; len(hexValue) != want {
+		return fmt.Errorf("invalid %s digest: want %d hex characters, got %d", algorithm, want, len(hexValue))
+	}
+	for i, c := range hexValue {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return fmt.Errorf("invalid %s digest: %q at position %d is not lowercase hex", algorithm, c, i)
+		}
+	}
+	return nil
+}
+
+// newHash returns a hash for algorithm.
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	case SHA512256:
+		return sha512.New512_256(), nil
+	default:
+		return nil, checkAlgorithm(algorithm)
+	}
+}
+
 // ParseDigest parses a digest string (e.g., "sha256:abc123...").
 func ParseDigest(s string) (Digest, error) {
 	parts := strings.SplitN(s, ":", 2)
@@ -59,32 +152,84 @@ func (d Digest) Equals(other Digest) bool {
 	return d.algorithm == other.algorithm && d.value == other.value
 }
 
-// Verify validates data matches this digest.
+// Verify validates data matches this digest. The comparison takes the same
+// time however many leading bytes match, so it leaks nothing about the
+// expected digest.
 func (d Digest) Verify(data []byte) error {
 	computed, err := d.computeHash(data)
 	if err != nil {
 		return err
 	}
 
-	if !d.Equals(computed) {
+	if !d.matches(computed) {
 		return fmt.Errorf("digest mismatch: expected %s, got %s", d.String(), computed.String())
 	}
 
 	return nil
 }
 
+// VerifyReader streams r through this digest's hash and checks the result,
+// so large artifacts can be verified without buffering them. Like Verify it
+// compares in constant time.
+func (d Digest) VerifyReader(r io.Reader) error {
+	h, err := d.Hasher()
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("hash %s content: %w", d.algorithm, err)
+	}
+
+	computed := Digest{algorithm: d.algorithm, value: hex.EncodeToString(h.Sum(nil))}
+	if !d.matches(computed) {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", d.String(), computed.String())
+	}
+	return nil
+}
+
+// Hasher returns a new hash for this digest's algorithm, for callers that
+// hash content as they copy it elsewhere (e.g. with io.MultiWriter or
+// io.TeeReader). Compare the result with Matches.
+func (d Digest) Hasher() (hash.Hash, error) {
+	return newHash(d.algorithm)
+}
+
+// Matches reports whether sum, the output of a Hasher, equals this digest.
+// The comparison is constant time.
+func (d Digest) Matches(sum []byte) bool {
+	want, err := hex.DecodeString(d.value)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(want, sum) == 1
+}
+
+// matches compares digests in constant time. Equals is fine for lookups;
+// integrity decisions use this.
+func (d Digest) matches(other Digest) bool {
+	if d.algorithm != other.algorithm {
+		return false
+	}
+	got, err := hex.DecodeString(other.value)
+	if err != nil {
+		return false
+	}
+	return d.Matches(got)
+}
+
 // computeHash computes hash of data using this digest's algorithm.
 func (d Digest) computeHash(data []byte) (Digest, error) {
-	switch d.algorithm {
-	case "sha256":
-		hash := sha256.Sum256(data)
-		return Digest{algorithm: "sha256", value: hex.EncodeToString(hash[:])}, nil
-	case "sha512":
-		hash := sha512.Sum512(data)
-		return Digest{algorithm: "sha512", value: hex.EncodeToString(hash[:])}, nil
-	default:
-		return Digest{}, fmt.Errorf("unsupported algorithm: %s", d.algorithm)
+	h, err := newHash(d.algorithm)
+	if err != nil {
+		return Digest{}, err
 	}
+	h.Write(data)
+	return Digest{algorithm: d.algorithm, value: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// ComputeDigest computes the digest of data with the given algorithm.
+func ComputeDigest(algorithm string, data []byte) (Digest, error) {
+	return Digest{algorithm: algorithm}.computeHash(data)
 }
 
 // ComputeDigestSHA256 computes SHA-256 digest of reader contents.
@@ -2,7 +2,19 @@ package values
 
 import (
 	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
+)
+
+var (
+	hex64  = strings.Repeat("0123456789abcdef", 4)
+	hex128 = strings.Repeat("0123456789abcdef", 8)
 )
 
 func TestNewDigest(t *testing.T) {
@@ -12,9 +24,17 @@ func TestNewDigest(t *testing.T) {
 		val     string
 		wantErr bool
 	}{
-		{"ValidSHA256", "sha256", "abc123456", false},
-		{"ValidSHA512", "sha512", "abc123456", false},
-		{"InvalidAlgo", "md5", "abc123456", true},
+		{"ValidSHA256", "sha256", hex64, false},
+		{"ValidSHA512", "sha512", hex128, false},
+		{"ValidSHA512_256", "sha512-256", hex64, false},
+		{"InvalidAlgo", "md5", hex64, true},
+		{"SHA256TooShort", "sha256", "abc123456", true},
+		{"SHA256TooLong", "sha256", hex64 + "00", true},
+		{"SHA256WithSHA512Length", "sha256", hex128, true},
+		{"SHA512WithSHA256Length", "sha512", hex64, true},
+		{"Uppercase", "sha256", strings.ToUpper(hex64), true},
+		{"NonHex", "sha256", strings.Repeat("g", 64), true},
+		{"Empty", "sha256", "", true},
 	}
 
 	for _, tt := range tests {
@@ -44,11 +64,12 @@ func TestParseDigest(t *testing.T) {
 		wantAlgo  string
 		wantValue string
 	}{
-		{"ValidSHA256", "sha256:abcd", true, "sha256", "abcd"},
-		{"ValidSHA512", "sha512:1234", true, "sha512", "1234"},
+		{"ValidSHA256", "sha256:" + hex64, true, "sha256", hex64},
+		{"ValidSHA512", "sha512:" + hex128, true, "sha512", hex128},
 		{"MissingAlgo", ":abcd", false, "", "abcd"},
 		{"NoColon", "sha256abcd", false, "", ""},
-		{"MultipleColons", "sha256:abc:def", true, "sha256", "abc:def"}, // implementation splits N=2
+		{"ShortValue", "sha256:abcd", false, "", ""},
+		{"MultipleColons", "sha256:" + hex64[:60] + ":def", false, "", ""},
 	}
 
 	for _, tt := range tests {
@@ -75,10 +96,10 @@ func TestParseDigest(t *testing.T) {
 }
 
 func TestDigest_Equals(t *testing.T) {
-	d1, _ := NewDigest("sha256", "abc")
-	d2, _ := NewDigest("sha256", "abc")
-	d3, _ := NewDigest("sha256", "def")
-	d4, _ := NewDigest("sha512", "abc")
+	d1, _ := NewDigest("sha256", hex64)
+	d2, _ := NewDigest("sha256", hex64)
+	d3, _ := NewDigest("sha256", strings.Repeat("de", 32))
+	d4, _ := NewDigest("sha512-256", hex64)
 
 	if !d1.Equals(d2) {
 		t.Error("Identical digests should be equal")
@@ -108,11 +129,30 @@ func TestDigest_Verify(t *testing.T) {
 		t.Error("Verify should fail for wrong data")
 	}
 
-	dBad, _ := NewDigest("sha512", "badhash")
+	dBad, _ := NewDigest("sha512", hex128)
 	if err := dBad.Verify(data); err == nil {
 		t.Error("Verify should fail for bad hash")
 	}
 
+	// A digest differing only in its last byte still fails.
+	nearMiss, _ := NewDigest("sha256", expectedHash[:62]+"00")
+	if err := nearMiss.Verify(data); err == nil {
+		t.Error("Verify should fail when only the last byte differs")
+	}
+
+	// Round trip: compute, format, parse, verify.
+	computed, err := ComputeDigest("sha512", data)
+	if err != nil {
+		t.Fatalf("ComputeDigest() error = %v", err)
+	}
+	parsed, err := ParseDigest(computed.String())
+	if err != nil {
+		t.Fatalf("ParseDigest(%s) error = %v", computed.String(), err)
+	}
+	if err := parsed.Verify(data); err != nil {
+		t.Errorf("Verify failed after round trip: %v", err)
+	}
+
 	dEmpty := Digest{} // invalid algo
 	if err := dEmpty.Verify(data); err == nil {
 		// Expect error "unsupported algorithm: "
@@ -137,3 +177,162 @@ func TestComputeDigestSHA256(t *testing.T) {
 		t.Errorf("Computed digest verification failed: %v", err)
 	}
 }
+
+func TestDigest_SupportedAlgorithms(t *testing.T) {
+	for _, algo := range SupportedAlgorithms() {
+		if !IsSupportedAlgorithm(algo) {
+			t.Errorf("IsSupportedAlgorithm(%q) = false", algo)
+		}
+		if _, err := NewDigest(algo, strings.Repeat("a", hexLengths[algo])); err != nil {
+			t.Errorf("NewDigest(%q) error = %v", algo, err)
+		}
+	}
+
+	tests := []struct {
+		algo         string
+		wantInsecure bool
+	}{
+		{"sha1", true},
+		{"md5", true},
+		{"sha384", false},
+		{"SHA256", false},
+		{"sha265", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algo, func(t *testing.T) {
+			if IsSupportedAlgorithm(tt.algo) {
+				t.Errorf("IsSupportedAlgorithm(%q) = true", tt.algo)
+			}
+			_, err := ParseDigest(tt.algo + ":abc")
+			var algoErr *UnsupportedAlgorithmError
+			if !errors.As(err, &algoErr) {
+				t.Fatalf("ParseDigest error = %v, want *UnsupportedAlgorithmError", err)
+			}
+			if algoErr.Algorithm != tt.algo {
+				t.Errorf("Algorithm = %q, want %q", algoErr.Algorithm, tt.algo)
+			}
+			if algoErr.Insecure != tt.wantInsecure {
+				t.Errorf("Insecure = %v, want %v", algoErr.Insecure, tt.wantInsecure)
+			}
+		})
+	}
+
+	// Malformed digests are not reported as unsupported algorithms.
+	var algoErr *UnsupportedAlgorithmError
+	if _, err := ParseDigest("sha256abcd"); err == nil || errors.As(err, &algoErr) {
+		t.Errorf("ParseDigest(no colon) error = %v, want a format error", err)
+	}
+}
+
+func TestComputeDigest_SHA512_256(t *testing.T) {
+	data := []byte("hello")
+	sum := sha512.Sum512_256(data)
+	want := hex.EncodeToString(sum[:])
+
+	got, err := ComputeDigest(SHA512256, data)
+	if err != nil {
+		t.Fatalf("ComputeDigest() error = %v", err)
+	}
+	if got.Algorithm() != SHA512256 || got.Value() != want {
+		t.Errorf("ComputeDigest() = %s, want %s:%s", got.String(), SHA512256, want)
+	}
+	if err := got.Verify(data); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+
+	parsed, err := ParseDigest("sha512-256:" + want)
+	if err != nil || !parsed.Equals(got) {
+		t.Errorf("ParseDigest() = %v, %v", parsed, err)
+	}
+}
+
+func TestDigest_VerifyReader(t *testing.T) {
+	const buf = 32 * 1024 // io.Copy's buffer size
+	sizes := []int{0, 1, buf - 1, buf, buf + 1, 3*buf + 17, 1<<20 + 7}
+
+	for _, algo := range SupportedAlgorithms() {
+		for _, size := range sizes {
+			data := make([]byte, size)
+			for i := range data {
+				data[i] = byte(i * 31)
+			}
+			want, err := ComputeDigest(algo, data)
+			if err != nil {
+				t.Fatalf("ComputeDigest(%s) error = %v", algo, err)
+			}
+
+			t.Run(fmt.Sprintf("%s/%d", algo, size), func(t *testing.T) {
+				if err := want.VerifyReader(bytes.NewReader(data)); err != nil {
+					t.Errorf("VerifyReader() error = %v", err)
+				}
+				if size <= buf+1 {
+					if err := want.VerifyReader(iotest.OneByteReader(bytes.NewReader(data))); err != nil {
+						t.Errorf("VerifyReader(one byte at a time) error = %v", err)
+					}
+				}
+
+				corrupted := append([]byte(nil), data...)
+				if size == 0 {
+					corrupted = []byte{0}
+				} else {
+					corrupted[size-1] ^= 0xff
+				}
+				if err := want.VerifyReader(bytes.NewReader(corrupted)); err == nil {
+					t.Error("VerifyReader() should fail for corrupted content")
+				}
+				if size > 0 {
+					if err := want.VerifyReader(bytes.NewReader(data[:size-1])); err == nil {
+						t.Error("VerifyReader() should fail for truncated content")
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("ReadError", func(t *testing.T) {
+		d, _ := NewDigest("sha256", hex64)
+		readErr := errors.New("connection reset")
+		if err := d.VerifyReader(iotest.ErrReader(readErr)); !errors.Is(err, readErr) {
+			t.Errorf("VerifyReader() error = %v, want %v", err, readErr)
+		}
+	})
+
+	t.Run("UnsupportedAlgorithm", func(t *testing.T) {
+		var algoErr *UnsupportedAlgorithmError
+		if err := (Digest{algorithm: "sha1"}).VerifyReader(bytes.NewReader(nil)); !errors.As(err, &algoErr) {
+			t.Errorf("VerifyReader() error = %v, want *UnsupportedAlgorithmError", err)
+		}
+	})
+}
+
+func TestDigest_Hasher(t *testing.T) {
+	data := bytes.Repeat([]byte("wasm"), 50_000)
+	want, err := ComputeDigest("sha512", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := want.Hasher()
+	if err != nil {
+		t.Fatalf("Hasher() error = %v", err)
+	}
+	var dst bytes.Buffer
+	if _, err := io.Copy(&dst, io.TeeReader(bytes.NewReader(data), h)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dst.Bytes(), data) {
+		t.Error("tee should pass content through unchanged")
+	}
+	if !want.Matches(h.Sum(nil)) {
+		t.Error("Matches() should accept the teed hash")
+	}
+
+	h.Write([]byte("extra"))
+	if want.Matches(h.Sum(nil)) {
+		t.Error("Matches() should reject a different hash")
+	}
+	if want.Matches(nil) {
+		t.Error("Matches() should reject an empty sum")
+	}
+}
@@ -6,53 +6,110 @@ import (
 )
 
 // PluginReference uniquely identifies a plugin version.
-// Format: registry.io/org/repo/name:version or name (for embedded)
+// Format: registry.io/namespace/name:version, registry.io/namespace/name@digest
+// or name (for embedded). The namespace is the repository path between the
+// registry and the plugin name; like any OCI repository it may have any
+// number of segments, including none.
 type PluginReference struct {
-	registry string // ghcr.io
-	org      string // whiskeyjimbo
-	repo     string // reglet-plugins
-	name     string // file
-	version  string // 1.0.2
+	registry  string // ghcr.io
+	namespace string // whiskeyjimbo/reglet-plugins
+	name      string // file
+	version   string // 1.0.2
+	digest    Digest // sha256:..., instead of version for digest-pinned references
 }
 
-// NewPluginReference creates a reference from components.
+// NewPluginReference creates a reference from components. Empty org or repo
+// components are left out of the namespace.
 func NewPluginReference(registry, org, repo, name, version string) PluginReference {
 	return PluginReference{
-		registry: registry,
-		org:      org,
-		repo:     repo,
-		name:     name,
-		version:  version,
+		registry:  registry,
+		namespace: joinNamespace(org, repo),
+		name:      name,
+		version:   version,
+	}
+}
+
+// NewDigestPluginReference creates a reference pinned to a manifest digest
+// rather than a version tag.
+func NewDigestPluginReference(registry, org, repo, name string, digest Digest) PluginReference {
+	return PluginReference{
+		registry:  registry,
+		namespace: joinNamespace(org, repo),
+		name:      name,
+		digest:    digest,
 	}
 }
 
 // ParsePluginReference parses OCI reference string.
+// The first path element is the registry, the last is name:tag or
+// name@digest, and everything between is the namespace.
 // Examples:
 //   - file (embedded)
+//   - ghcr.io/file:1.0.2
+//   - docker.io/library/file:1.0.2
 //   - ghcr.io/whiskeyjimbo/reglet-plugins/file:1.0.2
+//   - ghcr.io/whiskeyjimbo/reglet-plugins/file@sha256:<hex>
+//
+// A reference names either a tag or a digest; "name:tag@digest" is rejected
+// because the tag could later point elsewhere than the digest.
 func ParsePluginReference(ref string) (PluginReference, error) {
 	// Embedded plugin (simple name)
 	if !strings.Contains(ref, "/") && !strings.Contains(ref, ":") {
 		return PluginReference{name: ref}, nil
 	}
 
-	// OCI reference: registry.io/org/repo/name:version
+	// OCI reference: registry.io[/namespace...]/name:version
 	parts := strings.Split(ref, "/")
-	if len(parts) < 4 {
+	if len(parts) < 2 {
 		return PluginReference{}, fmt.Errorf("invalid OCI reference: %s", ref)
 	}
+	for _, part := range parts {
+		if part == "" {
+			return PluginReference{}, fmt.Errorf("invalid OCI reference, empty path element: %s", ref)
+		}
+	}
+	registry := parts[0]
+	namespace := strings.Join(parts[1:len(parts)-1], "/")
+
+	last := parts[len(parts)-1]
+	if nameTag, rawDigest, pinned := strings.Cut(last, "@"); pinned {
+		if strings.Contains(nameTag, ":") {
+			return PluginReference{}, fmt.Errorf("reference has both a tag and a digest: %s", ref)
+		}
+		if nameTag == "" {
+			return PluginReference{}, fmt.Errorf("missing plugin name: %s", ref)
+		}
+		digest, err := ParseDigest(rawDigest)
+		if err != nil {
+			return PluginReference{}, fmt.Errorf("invalid digest in reference %s: %w", ref, err)
+		}
+		if digest.Value() == "" {
+			return PluginReference{}, fmt.Errorf("empty digest in reference: %s", ref)
+		}
+		return PluginReference{
+			registry:  registry,
+			namespace: namespace,
+			name:      nameTag,
+			digest:    digest,
+		}, nil
+	}
 
-	nameVersion := strings.Split(parts[len(parts)-1], ":")
+	nameVersion := strings.Split(last, ":")
 	if len(nameVersion) != 2 {
 		return PluginReference{}, fmt.Errorf("missing version tag: %s", ref)
 	}
+	if nameVersion[0] == "" {
+		return PluginReference{}, fmt.Errorf("missing plugin name: %s", ref)
+	}
+	if nameVersion[1] == "" {
+		return PluginReference{}, fmt.Errorf("empty version tag: %s", ref)
+	}
 
 	return PluginReference{
-		registry: parts[0],
-		org:      parts[1],
-		repo:     parts[2],
-		name:     nameVersion[0],
-		version:  nameVersion[1],
+		registry:  registry,
+		namespace: namespace,
+		name:      nameVersion[0],
+		version:   nameVersion[1],
 	}, nil
 }
 
@@ -61,8 +118,23 @@ func (r PluginReference) String() string {
 	if r.IsEmbedded() {
 		return r.name
 	}
-	return fmt.Sprintf("%s/%s/%s/%s:%s",
-		r.registry, r.org, r.repo, r.name, r.version)
+	if r.IsDigestPinned() {
+		return r.Repository() + "@" + r.digest.String()
+	}
+	return r.Repository() + ":" + r.version
+}
+
+// Repository returns the reference without its tag or digest, e.g.
+// "ghcr.io/whiskeyjimbo/reglet-plugins/file". All versions of a plugin share
+// it. For embedded plugins it is the name.
+func (r PluginReference) Repository() string {
+	if r.IsEmbedded() {
+		return r.name
+	}
+	if r.namespace == "" {
+		return r.registry + "/" + r.name
+	}
+	return r.registry + "/" + r.namespace + "/" + r.name
 }
 
 // IsEmbedded returns true if this is a built-in plugin.
@@ -75,21 +147,64 @@ func (r PluginReference) Name() string {
 	return r.name
 }
 
-// Version returns the version tag.
+// Version returns the version tag. It is empty for digest-pinned references.
 func (r PluginReference) Version() string {
 	return r.version
 }
 
+// Digest returns the manifest digest the reference is pinned to, or the zero
+// Digest when it names a version tag.
+func (r PluginReference) Digest() Digest {
+	return r.digest
+}
+
+// IsDigestPinned reports whether the reference names a digest instead of a
+// version tag.
+func (r PluginReference) IsDigestPinned() bool {
+	return r.digest.Value() != ""
+}
+
 // Registry returns the registry hostname.
 func (r PluginReference) Registry() string {
 	return r.registry
 }
 
+// Namespace returns the repository path between the registry and the plugin
+// name, e.g. "whiskeyjimbo/reglet-plugins". It is empty when the plugin sits
+// directly under the registry.
+func (r PluginReference) Namespace() string {
+	return r.namespace
+}
+
+// Org returns the first namespace element, usually the registry organization.
+func (r PluginReference) Org() string {
+	org, _, _ := strings.Cut(r.namespace, "/")
+	return org
+}
+
+// Repo returns the namespace after the organization. It may itself contain
+// slashes for deeply nested repositories.
+func (r PluginReference) Repo() string {
+	_, repo, _ := strings.Cut(r.namespace, "/")
+	return repo
+}
+
 // Equals checks equality with another reference.
 func (r PluginReference) Equals(other PluginReference) bool {
 	return r.registry == other.registry &&
-		r.org == other.org &&
-		r.repo == other.repo &&
+		r.namespace == other.namespace &&
 		r.name == other.name &&
-		r.version == other.version
+		r.version == other.version &&
+		r.digest.Equals(other.digest)
+}
+
+func joinNamespace(org, repo string) string {
+	switch {
+	case org == "":
+		return repo
+	case repo == "":
+		return org
+	default:
+		return org + "/" + repo
+	}
 }
@@ -58,11 +58,45 @@ func TestParsePluginReference(t *testing.T) {
 			// implementation: nameVersion := strings.Split(parts[len(parts)-1], ":"); if len != 2 => error
 		},
 		{
-			name:    "InvalidOCI_TooShort",
-			input:   "ghcr.io/plugin:1.0.0",
-			wantErr: true, // parts < 4 error in implementation?
-			// implementation: parts := strings.Split(ref, "/"); if len(parts) < 4 error "invalid oci reference"
-			// Logic assumes registry/org/repo/name:version (4 parts min)
+			name:         "TwoSegments",
+			input:        "ghcr.io/plugin:1.0.0",
+			wantName:     "plugin",
+			wantVersion:  "1.0.0",
+			wantRegistry: "ghcr.io",
+		},
+		{
+			name:         "ThreeSegments",
+			input:        "docker.io/library/busybox:1.0",
+			wantName:     "busybox",
+			wantVersion:  "1.0",
+			wantRegistry: "docker.io",
+		},
+		{
+			name:         "FiveSegments",
+			input:        "registry.example.com:5000/team/group/plugins/file:2.1.0",
+			wantName:     "file",
+			wantVersion:  "2.1.0",
+			wantRegistry: "registry.example.com:5000",
+		},
+		{
+			name:    "InvalidOCI_EmptySegment",
+			input:   "ghcr.io//plugin:1.0.0",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidOCI_TrailingSlash",
+			input:   "ghcr.io/",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidOCI_EmptyName",
+			input:   "ghcr.io/:1.0",
+			wantErr: true,
+		},
+		{
+			name:    "InvalidOCI_EmptyTag",
+			input:   "ghcr.io/file:",
+			wantErr: true,
 		},
 	}
 
@@ -124,3 +158,117 @@ func TestPluginReference_String(t *testing.T) {
 		t.Errorf("OCI string failed: got %s, want %s", oci.String(), raw)
 	}
 }
+
+func TestParsePluginReference_Digest(t *testing.T) {
+	const hexValue = "4f5d2a64c3f1b0e6a8f6c2d9b7e1a3c5d7f9b1e3a5c7d9f1b3e5a7c9d1f3b5e7"
+
+	t.Run("DigestOnly", func(t *testing.T) {
+		raw := "ghcr.io/org/repo/plugin@sha256:" + hexValue
+		got, err := ParsePluginReference(raw)
+		if err != nil {
+			t.Fatalf("ParsePluginReference() error = %v", err)
+		}
+		if !got.IsDigestPinned() {
+			t.Error("should be digest pinned")
+		}
+		if got.IsEmbedded() {
+			t.Error("should not be embedded")
+		}
+		if got.Name() != "plugin" || got.Version() != "" {
+			t.Errorf("Name() = %q, Version() = %q", got.Name(), got.Version())
+		}
+		if got.Digest().Algorithm() != "sha256" || got.Digest().Value() != hexValue {
+			t.Errorf("Digest() = %s", got.Digest().String())
+		}
+		if got.String() != raw {
+			t.Errorf("String() = %s, want %s", got.String(), raw)
+		}
+
+		again, err := ParsePluginReference(got.String())
+		if err != nil || !again.Equals(got) {
+			t.Errorf("round trip failed: %v, %v", again, err)
+		}
+	})
+
+	t.Run("TagOnly", func(t *testing.T) {
+		got, err := ParsePluginReference("ghcr.io/org/repo/plugin:1.0.0")
+		if err != nil {
+			t.Fatalf("ParsePluginReference() error = %v", err)
+		}
+		if got.IsDigestPinned() {
+			t.Error("tag reference should not be digest pinned")
+		}
+		if got.Digest() != (Digest{}) {
+			t.Errorf("Digest() = %s, want zero", got.Digest().String())
+		}
+	})
+
+	t.Run("DigestDiffersFromTag", func(t *testing.T) {
+		tagged, _ := ParsePluginReference("ghcr.io/org/repo/plugin:1.0.0")
+		pinned, _ := ParsePluginReference("ghcr.io/org/repo/plugin@sha256:" + hexValue)
+		if tagged.Equals(pinned) {
+			t.Error("tag and digest references should not be equal")
+		}
+	})
+
+	for name, input := range map[string]string{
+		"TagAndDigest":     "ghcr.io/org/repo/plugin:1.0.0@sha256:" + hexValue,
+		"EmptyDigest":      "ghcr.io/org/repo/plugin@sha256:",
+		"UnknownAlgorithm": "ghcr.io/org/repo/plugin@md5:abc",
+		"MissingName":      "ghcr.io/org/repo/@sha256:" + hexValue,
+	} {
+		t.Run("Reject_"+name, func(t *testing.T) {
+			if _, err := ParsePluginReference(input); err == nil {
+				t.Errorf("ParsePluginReference(%q) should fail", input)
+			}
+		})
+	}
+}
+
+func TestPluginReference_VariableDepth(t *testing.T) {
+	tests := []struct {
+		input         string
+		wantNamespace string
+		wantOrg       string
+		wantRepo      string
+		wantRepoPath  string
+	}{
+		{"ghcr.io/plugin:1.0.0", "", "", "", "ghcr.io/plugin"},
+		{"docker.io/library/busybox:1.0", "library", "library", "", "docker.io/library/busybox"},
+		{"ghcr.io/org/repo/file:1.0", "org/repo", "org", "repo", "ghcr.io/org/repo/file"},
+		{"reg.io/a/b/c/file:1.0", "a/b/c", "a", "b/c", "reg.io/a/b/c/file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			ref, err := ParsePluginReference(tt.input)
+			if err != nil {
+				t.Fatalf("ParsePluginReference() error = %v", err)
+			}
+			if ref.String() != tt.input {
+				t.Errorf("String() = %s, want %s", ref.String(), tt.input)
+			}
+			if ref.Namespace() != tt.wantNamespace {
+				t.Errorf("Namespace() = %q, want %q", ref.Namespace(), tt.wantNamespace)
+			}
+			if ref.Org() != tt.wantOrg || ref.Repo() != tt.wantRepo {
+				t.Errorf("Org(), Repo() = %q, %q, want %q, %q", ref.Org(), ref.Repo(), tt.wantOrg, tt.wantRepo)
+			}
+			if ref.Repository() != tt.wantRepoPath {
+				t.Errorf("Repository() = %s, want %s", ref.Repository(), tt.wantRepoPath)
+			}
+
+			again, err := ParsePluginReference(ref.String())
+			if err != nil || !again.Equals(ref) {
+				t.Errorf("round trip failed: %v, %v", again, err)
+			}
+		})
+	}
+
+	// Components built with NewPluginReference match the parsed form.
+	built := NewPluginReference("docker.io", "library", "", "busybox", "1.0")
+	parsed, _ := ParsePluginReference("docker.io/library/busybox:1.0")
+	if !built.Equals(parsed) {
+		t.Errorf("NewPluginReference = %s, want %s", built.String(), parsed.String())
+	}
+}
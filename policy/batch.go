@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+// Request is one entry of a CheckAll batch: a hostfunc.NetworkRequest,
+// FileSystemRequest, EnvironmentRequest, ExecCapabilityRequest or
+// KeyValueRequest value. Any other type is denied.
+type Request interface{}
+
+// Decision is the outcome for one request of a CheckAll batch.
+type Decision struct {
+	// Request is the request decided.
+	Request Request
+	// Kind is the capability kind, as passed to DenialHandler: "network",
+	// "fs", "env", "exec" or "kv".
+	Kind string
+	// Reason says why the request was denied; empty when allowed, except
+	// for the would-deny note on requests WithAllowByDefault let through.
+	Reason  string
+	Allowed bool
+}
+
+// BatchChecker is implemented by policies that can decide several requests
+// in one call, for actions that need all of them granted before they start.
+// It is separate from Policy so existing implementations keep compiling;
+// *Engine implements it.
+type BatchChecker interface {
+	CheckAll(requests []Request, grants *hostfunc.GrantSet, opts ...CheckAllOption) ([]Decision, bool)
+}
+
+type checkAllConfig struct {
+	stopAtFirstDenial bool
+}
+
+// CheckAllOption configures a CheckAll call.
+type CheckAllOption func(*checkAllConfig)
+
+// StopAtFirstDenial makes CheckAll return as soon as a request is denied,
+// leaving the remaining requests undecided. By default every request is
+// decided so callers can report all missing grants at once.
+func StopAtFirstDenial() CheckAllOption {
+	return func(c *checkAllConfig) {
+		c.stopAtFirstDenial = true
+	}
+}
+
+// CheckAll decides each request against grants and reports whether all of
+// them are allowed; an empty batch is allowed. Decisions are returned in
+// request order and, with StopAtFirstDenial, end at the first denial. Each
+// denial is passed to the DenialHandler, and WithAllowByDefault applies, as
+// for the Check methods.
+func (p *Engine) CheckAll(requests []Request, grants *hostfunc.GrantSet, opts ...CheckAllOption) ([]Decision, bool) {
+	var cfg checkAllConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c := p.getCompiled(grants)
+	decisions := make([]Decision, 0, len(requests))
+	allowed := true
+	for _, req := range requests {
+		d := p.decide(req, c)
+		if !d.Allowed {
+			d.Allowed = p.reject(d.Kind, req, d.Reason)
+			if d.Allowed {
+				d.Reason = wouldDenyPrefix + d.Reason
+			}
+		}
+		decisions = append(decisions, d)
+		if d.Allowed {
+			continue
+		}
+		allowed = false
+		if cfg.stopAtFirstDenial {
+			break
+		}
+	}
+	return decisions, allowed
+}
+
+// decide evaluates one batch request silently.
+func (p *Engine) decide(req Request, c *compiledGrantSet) Decision {
+	d := Decision{Request: req}
+	var subject string
+	var explicit bool
+	switch r := req.(type) {
+	case hostfunc.NetworkRequest:
+		d.Kind, subject = "network", "host/port"
+		d.Allowed = p.evaluateNetwork(r, c)
+		explicit = p.deny != nil && p.deny.matchNetwork(r)
+	case hostfunc.FileSystemRequest:
+		d.Kind, subject = "fs", "path"
+		d.Allowed, explicit = p.decideFileSystem(r, c)
+	case hostfunc.EnvironmentRequest:
+		d.Kind, subject = "env", "variable"
+		d.Allowed = p.evaluateEnvironment(r, c)
+		explicit = p.deny != nil && p.deny.matchEnvironment(r)
+	case hostfunc.ExecCapabilityRequest:
+		d.Kind, subject = "exec", "command"
+		d.Allowed = p.evaluateExec(r, c)
+		explicit = p.deny != nil && p.deny.matchExec(r)
+	case hostfunc.KeyValueRequest:
+		d.Kind, subject = "kv", "key/operation"
+		d.Allowed = p.evaluateKeyValue(r, c)
+		explicit = p.deny != nil && p.deny.matchKeyValue(r)
+	default:
+		d.Kind = "unknown"
+		d.Reason = fmt.Sprintf("unsupported request type %T", req)
+		return d
+	}
+
+	switch {
+	case d.Allowed:
+	case explicit:
+		d.Reason = subject + " explicitly denied"
+	default:
+		d.Reason = subject + " not allowed"
+	}
+	return d
+}
@@ -0,0 +1,116 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_CheckAll(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"*.internal"}, Ports: []string{"443"}},
+		}},
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+			{Read: []string{"/data/**"}},
+		}},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"APP_*"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/*"}},
+		KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{
+			{Operation: "read", Keys: []string{"config/*"}},
+		}},
+	}
+	deny := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"secrets.internal"}, Ports: []string{"*"}},
+		}},
+	}
+	newPolicy := func(handler policy.DenialHandler) policy.BatchChecker {
+		checker, ok := policy.NewPolicy(
+			policy.WithDenialHandler(handler),
+			policy.WithSymlinkResolution(false),
+			policy.WithDenyRules(deny),
+		).(policy.BatchChecker)
+		require.True(t, ok, "Engine implements BatchChecker")
+		return checker
+	}
+
+	t.Run("all allowed", func(t *testing.T) {
+		handler := &kindRecorder{}
+		decisions, allowed := newPolicy(handler).CheckAll([]policy.Request{
+			hostfunc.FileSystemRequest{Path: "/data/in.csv", Operation: "read"},
+			hostfunc.NetworkRequest{Host: "api.internal", Port: 443},
+			hostfunc.EnvironmentRequest{Variable: "APP_TOKEN"},
+			hostfunc.ExecCapabilityRequest{Command: "/usr/bin/curl"},
+			hostfunc.KeyValueRequest{Key: "config/a", Operation: "read"},
+		}, grants)
+
+		assert.True(t, allowed)
+		require.Len(t, decisions, 5)
+		for _, d := range decisions {
+			assert.True(t, d.Allowed, "%s %+v", d.Kind, d.Request)
+			assert.Empty(t, d.Reason)
+		}
+		assert.Empty(t, handler.kinds)
+	})
+
+	mixed := []policy.Request{
+		hostfunc.FileSystemRequest{Path: "/data/in.csv", Operation: "read"},
+		hostfunc.NetworkRequest{Host: "secrets.internal", Port: 443},
+		hostfunc.FileSystemRequest{Path: "/data/out.csv", Operation: "write"},
+		hostfunc.KeyValueRequest{Key: "config/a", Operation: "read"},
+		"not a request",
+	}
+
+	t.Run("collects every denial", func(t *testing.T) {
+		handler := &kindRecorder{}
+		decisions, allowed := newPolicy(handler).CheckAll(mixed, grants)
+
+		assert.False(t, allowed)
+		require.Len(t, decisions, len(mixed))
+		got := make([]bool, len(decisions))
+		for i, d := range decisions {
+			got[i] = d.Allowed
+			assert.Equal(t, mixed[i], d.Request)
+		}
+		assert.Equal(t, []bool{true, false, false, true, false}, got)
+		assert.Equal(t, "host/port explicitly denied", decisions[1].Reason)
+		assert.Equal(t, "path not allowed", decisions[2].Reason)
+		assert.Equal(t, "unknown", decisions[4].Kind)
+		assert.Equal(t, "unsupported request type string", decisions[4].Reason)
+		assert.Equal(t, []string{"network", "fs", "unknown"}, handler.kinds)
+	})
+
+	t.Run("stops at first denial", func(t *testing.T) {
+		handler := &kindRecorder{}
+		decisions, allowed := newPolicy(handler).CheckAll(mixed, grants, policy.StopAtFirstDenial())
+
+		assert.False(t, allowed)
+		require.Len(t, decisions, 2)
+		assert.True(t, decisions[0].Allowed)
+		assert.False(t, decisions[1].Allowed)
+		assert.Equal(t, []string{"network"}, handler.kinds)
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		decisions, allowed := newPolicy(&policy.NopDenialHandler{}).CheckAll(nil, grants)
+		assert.True(t, allowed)
+		assert.Empty(t, decisions)
+	})
+
+	t.Run("agrees with Evaluate", func(t *testing.T) {
+		p := policy.NewPolicy(
+			policy.WithDenialHandler(&policy.NopDenialHandler{}),
+			policy.WithSymlinkResolution(false),
+			policy.WithDenyRules(deny),
+		)
+		decisions, _ := p.(policy.BatchChecker).CheckAll(mixed[:4], grants)
+		assert.Equal(t, p.EvaluateFileSystem(mixed[0].(hostfunc.FileSystemRequest), grants), decisions[0].Allowed)
+		assert.Equal(t, p.EvaluateNetwork(mixed[1].(hostfunc.NetworkRequest), grants), decisions[1].Allowed)
+		assert.Equal(t, p.EvaluateFileSystem(mixed[2].(hostfunc.FileSystemRequest), grants), decisions[2].Allowed)
+		assert.Equal(t, p.EvaluateKeyValue(mixed[3].(hostfunc.KeyValueRequest), grants), decisions[3].Allowed)
+	})
+}
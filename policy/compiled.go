@@ -0,0 +1,87 @@
+package policy
+
+import "github.com/reglet-dev/reglet-abi/hostfunc"
+
+// CompiledGrantSet is a GrantSet whose globs, port ranges and CIDRs have been
+// parsed once, ahead of the checks that use it. Create one with
+// CompiledChecker.Compile and keep it for as long as the grants stay the same.
+//
+// The plain Check methods compile a GrantSet on first use and cache it by
+// pointer, so they only stay cheap while callers pass the same pointer. A
+// host that rebuilds or decodes grants per request should compile them once
+// instead, which also keeps them out of the engine's cache.
+type CompiledGrantSet struct {
+	engine   *Engine
+	grants   *hostfunc.GrantSet
+	compiled *compiledGrantSet
+}
+
+// GrantSet returns the grants the set was compiled from.
+func (g *CompiledGrantSet) GrantSet() *hostfunc.GrantSet {
+	if g == nil {
+		return nil
+	}
+	return g.grants
+}
+
+// CompiledChecker is implemented by policies that can check requests
+// against pre-compiled grants. It is separate from Policy so existing
+// implementations keep compiling; *Engine implements it. The Compiled
+// methods decide exactly as their GrantSet counterparts, including deny
+// rules and denial handling.
+type CompiledChecker interface {
+	Compile(grants *hostfunc.GrantSet) *CompiledGrantSet
+	CheckNetworkCompiled(req hostfunc.NetworkRequest, grants *CompiledGrantSet) bool
+	CheckFileSystemCompiled(req hostfunc.FileSystemRequest, grants *CompiledGrantSet) bool
+	CheckEnvironmentCompiled(req hostfunc.EnvironmentRequest, grants *CompiledGrantSet) bool
+	CheckExecCompiled(req hostfunc.ExecCapabilityRequest, grants *CompiledGrantSet) bool
+	CheckKeyValueCompiled(req hostfunc.KeyValueRequest, grants *CompiledGrantSet) bool
+}
+
+// Compile prepares grants for repeated checks by this engine. A nil
+// GrantSet compiles to a set that denies everything.
+func (p *Engine) Compile(grants *hostfunc.GrantSet) *CompiledGrantSet {
+	g := &CompiledGrantSet{engine: p, grants: grants}
+	if grants != nil {
+		g.compiled = compileGrantSet(grants, p.config)
+	}
+	return g
+}
+
+// compiledFor returns the rules to check against. Sets compiled by another
+// engine may have been built under different options, so they are
+// recompiled from their source grants.
+func (p *Engine) compiledFor(g *CompiledGrantSet) *compiledGrantSet {
+	if g == nil {
+		return nil
+	}
+	if g.engine != p {
+		return p.getCompiled(g.grants)
+	}
+	return g.compiled
+}
+
+// CheckNetworkCompiled is CheckNetwork for pre-compiled grants.
+func (p *Engine) CheckNetworkCompiled(req hostfunc.NetworkRequest, grants *CompiledGrantSet) bool {
+	return p.checkNetwork(req, p.compiledFor(grants))
+}
+
+// CheckFileSystemCompiled is CheckFileSystem for pre-compiled grants.
+func (p *Engine) CheckFileSystemCompiled(req hostfunc.FileSystemRequest, grants *CompiledGrantSet) bool {
+	return p.checkFileSystem(req, p.compiledFor(grants))
+}
+
+// CheckEnvironmentCompiled is CheckEnvironment for pre-compiled grants.
+func (p *Engine) CheckEnvironmentCompiled(req hostfunc.EnvironmentRequest, grants *CompiledGrantSet) bool {
+	return p.checkEnvironment(req, p.compiledFor(grants))
+}
+
+// CheckExecCompiled is CheckExec for pre-compiled grants.
+func (p *Engine) CheckExecCompiled(req hostfunc.ExecCapabilityRequest, grants *CompiledGrantSet) bool {
+	return p.checkExec(req, p.compiledFor(grants))
+}
+
+// CheckKeyValueCompiled is CheckKeyValue for pre-compiled grants.
+func (p *Engine) CheckKeyValueCompiled(req hostfunc.KeyValueRequest, grants *CompiledGrantSet) bool {
+	return p.checkKeyValue(req, p.compiledFor(grants))
+}
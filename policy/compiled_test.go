@@ -0,0 +1,115 @@
+package policy_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/policy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompiledGrantSet_AgreesWithGrantSet(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"example.com", "*.internal"}, Ports: []string{"443", "8000-8010"}},
+			{Hosts: []string{"10.0.0.0/8", "fd00::/8"}, Ports: []string{"*"}},
+		}},
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+			{Read: []string{"/data/**", "/etc/hosts"}, Write: []string{"/tmp/*"}},
+		}},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"APP_*", "HOME"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/*"}},
+		KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{
+			{Operation: "read", Keys: []string{"config/**"}},
+			{Operation: "read-write", Keys: []string{"cache/*"}},
+		}},
+	}
+	deny := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"secrets.internal"}, Ports: []string{"*"}},
+		}},
+	}
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+		policy.WithDenyRules(deny),
+	)
+	checker, ok := p.(policy.CompiledChecker)
+	require.True(t, ok, "Engine implements CompiledChecker")
+	compiled := checker.Compile(grants)
+	assert.Same(t, grants, compiled.GrantSet())
+
+	var allowed, total int
+	check := func(name string, want, got bool) {
+		t.Helper()
+		total++
+		if want {
+			allowed++
+		}
+		assert.Equal(t, want, got, name)
+	}
+
+	for _, host := range []string{"example.com", "api.internal", "secrets.internal", "evil.com", "10.1.2.3", "fd00::1", "11.0.0.1"} {
+		for _, port := range []int{22, 443, 8005, 8011} {
+			req := hostfunc.NetworkRequest{Host: host, Port: port}
+			check(fmt.Sprintf("network %s:%d", host, port), p.CheckNetwork(req, grants), checker.CheckNetworkCompiled(req, compiled))
+		}
+	}
+	for _, path := range []string{"/data/a/b", "/etc/hosts", "/etc/passwd", "/tmp/x", "/tmp/x/y", "relative"} {
+		for _, op := range []string{"read", "write"} {
+			req := hostfunc.FileSystemRequest{Path: path, Operation: op}
+			check(fmt.Sprintf("fs %s %s", op, path), p.CheckFileSystem(req, grants), checker.CheckFileSystemCompiled(req, compiled))
+		}
+	}
+	for _, name := range []string{"APP_PORT", "HOME", "PATH"} {
+		req := hostfunc.EnvironmentRequest{Variable: name}
+		check("env "+name, p.CheckEnvironment(req, grants), checker.CheckEnvironmentCompiled(req, compiled))
+	}
+	for _, cmd := range []string{"/usr/bin/ls", "/usr/bin/../sbin/reboot", "/bin/sh"} {
+		req := hostfunc.ExecCapabilityRequest{Command: cmd}
+		check("exec "+cmd, p.CheckExec(req, grants), checker.CheckExecCompiled(req, compiled))
+	}
+	for _, key := range []string{"config/db", "cache/a", "other"} {
+		for _, op := range []string{"read", "write"} {
+			req := hostfunc.KeyValueRequest{Key: key, Operation: op}
+			check(fmt.Sprintf("kv %s %s", op, key), p.CheckKeyValue(req, grants), checker.CheckKeyValueCompiled(req, compiled))
+		}
+	}
+
+	// Both outcomes must be exercised for the comparison to mean anything.
+	assert.Greater(t, allowed, 0)
+	assert.Less(t, allowed, total)
+}
+
+func TestCompiledGrantSet_Nil(t *testing.T) {
+	checker := policy.NewPolicy(policy.WithDenialHandler(&policy.NopDenialHandler{})).(policy.CompiledChecker)
+	req := hostfunc.EnvironmentRequest{Variable: "HOME"}
+
+	assert.False(t, checker.CheckEnvironmentCompiled(req, nil))
+	assert.False(t, checker.CheckEnvironmentCompiled(req, checker.Compile(nil)))
+}
+
+func TestCompiledGrantSet_OtherEngine(t *testing.T) {
+	grants := &hostfunc.GrantSet{FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+		{Read: []string{"/Data/**"}},
+	}}}
+	req := hostfunc.FileSystemRequest{Path: "/data/file", Operation: "read"}
+
+	sensitive := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+		policy.WithPathCaseSensitivity(true),
+	).(policy.CompiledChecker)
+	insensitive := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+		policy.WithPathCaseSensitivity(false),
+	).(policy.CompiledChecker)
+
+	// A set compiled by one engine is checked under the other engine's options.
+	compiled := sensitive.Compile(grants)
+	assert.False(t, sensitive.CheckFileSystemCompiled(req, compiled))
+	assert.True(t, insensitive.CheckFileSystemCompiled(req, compiled))
+}
@@ -0,0 +1,60 @@
+package policy
+
+import (
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+// DNSCapability grants DNS lookups of matching hostnames. It is separate
+// from network rules: being allowed to resolve a name does not grant a
+// connection to it, nor to a resolver. Hosts are glob patterns such as
+// "*.example.com"; matching ignores case and a trailing dot.
+type DNSCapability struct {
+	Hosts []string `json:"hosts" yaml:"hosts"`
+}
+
+// DNSChecker is implemented by policies that can check DNS lookups. It is
+// separate from Policy so existing implementations keep compiling;
+// *Engine implements it.
+type DNSChecker interface {
+	CheckDNS(req hostfunc.DNSRequest, grants *DNSCapability) bool
+	EvaluateDNS(req hostfunc.DNSRequest, grants *DNSCapability) bool
+}
+
+// CheckDNS reports whether grants allow looking up req.Hostname, calling
+// the denial handler if not.
+func (p *Engine) CheckDNS(req hostfunc.DNSRequest, grants *DNSCapability) bool {
+	if p.EvaluateDNS(req, grants) {
+		return true
+	}
+	return p.reject("dns", req, "hostname not allowed")
+}
+
+// EvaluateDNS reports whether grants allow looking up req.Hostname.
+func (p *Engine) EvaluateDNS(req hostfunc.DNSRequest, grants *DNSCapability) bool {
+	return CheckDNS(req.Hostname, grants)
+}
+
+// CheckDNS reports whether grants allow looking up hostname. It has no
+// side effects.
+func CheckDNS(hostname string, grants *DNSCapability) bool {
+	if grants == nil {
+		return false
+	}
+	host := normalizeDNSName(hostname)
+	if host == "" {
+		return false
+	}
+	for _, pattern := range grants.Hosts {
+		if matched, _ := doublestar.Match(normalizeDNSName(pattern), host); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDNSName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
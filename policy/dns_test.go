@@ -0,0 +1,63 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckDNS(t *testing.T) {
+	grants := &policy.DNSCapability{Hosts: []string{"example.com", "*.internal", "api-?.Example.org"}}
+
+	tests := []struct {
+		name     string
+		hostname string
+		want     bool
+	}{
+		{"Exact host", "example.com", true},
+		{"Case and trailing dot ignored", "EXAMPLE.com.", true},
+		{"Wildcard subdomain", "db.internal", true},
+		{"Wildcard does not match bare domain", "internal", false},
+		{"Wildcard matches nested subdomains", "a.b.internal", true},
+		{"Single-character wildcard", "api-1.example.org", true},
+		{"Subdomain of exact host denied", "www.example.com", false},
+		{"Unrelated host denied", "google.com", false},
+		{"Empty hostname denied", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.CheckDNS(tt.hostname, grants))
+		})
+	}
+
+	assert.False(t, policy.CheckDNS("example.com", nil))
+	assert.True(t, policy.CheckDNS("anything.at.all", &policy.DNSCapability{Hosts: []string{"**"}}))
+}
+
+type kindRecorder struct {
+	kinds []string
+}
+
+func (h *kindRecorder) OnDenial(kind string, _ interface{}, _ string) {
+	h.kinds = append(h.kinds, kind)
+}
+
+func TestEngine_CheckDNS(t *testing.T) {
+	handler := &kindRecorder{}
+	p := policy.NewPolicy(policy.WithDenialHandler(handler))
+	checker, ok := p.(policy.DNSChecker)
+	if !assert.True(t, ok, "Engine implements DNSChecker") {
+		return
+	}
+	grants := &policy.DNSCapability{Hosts: []string{"*.example.com"}}
+
+	assert.True(t, checker.CheckDNS(hostfunc.DNSRequest{Hostname: "api.example.com"}, grants))
+	assert.False(t, checker.EvaluateDNS(hostfunc.DNSRequest{Hostname: "evil.test"}, grants))
+	assert.Empty(t, handler.kinds, "Evaluate has no side effects")
+
+	assert.False(t, checker.CheckDNS(hostfunc.DNSRequest{Hostname: "evil.test"}, grants))
+	assert.Equal(t, []string{"dns"}, handler.kinds)
+}
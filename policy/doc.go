@@ -0,0 +1,29 @@
+// Package policy decides whether a plugin's capability grants allow a
+// runtime request.
+//
+// # Patterns
+//
+// Network hosts, filesystem paths, environment variables, exec commands and
+// kv keys in a grant are glob patterns, matched with doublestar:
+//
+//	?       any single character except '/'
+//	*       any run of characters except '/'
+//	**      as a whole path component, any number of components: "/data/**"
+//	[a-z0]  one character from the class; [!a-z] or [^a-z] negates it
+//	{a,b}   any one of the alternatives, which may themselves contain
+//	        patterns and nest: "/usr/bin/{ls,c{at,p}}" matches ls, cat and cp
+//	\c      the character c literally, so "\{ls\}" matches "{ls}"
+//
+// Only '/' separates components, so '*' in a hostname also matches dots:
+// "*.example.com" covers "a.b.example.com". Network hosts match
+// case-insensitively and ignore a trailing dot, as DNS names do. Other
+// matching is case-sensitive, except for filesystem paths when
+// WithPathCaseSensitivity(false) is set, which is the default on Windows. On Windows, backslashes in filesystem
+// paths and fs patterns are separators rather than escapes. A pattern that
+// is not a valid glob, such as one with an unclosed brace, never matches;
+// ValidateGrantSet reports it.
+//
+// Network hosts may also be CIDR prefixes, which match IP requests by
+// containment. Ports are single numbers, inclusive ranges such as
+// "8000-9000", or "*" for any port.
+package policy
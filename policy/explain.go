@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"fmt"
+	"net/netip"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+// NetworkExplanation describes how a network request was decided.
+type NetworkExplanation struct {
+	// MatchedRule is the first rule allowing the request, nil if denied.
+	MatchedRule *hostfunc.NetworkRule
+	// Reason is a short human-readable account of the decision.
+	Reason string
+	// RuleIndex is the position of MatchedRule in the grant's rules, or -1.
+	RuleIndex int
+	Allowed   bool
+}
+
+// NetworkExplainer is implemented by policies that can say why a network
+// request was allowed or denied. Explaining is read-only and never calls the
+// DenialHandler.
+type NetworkExplainer interface {
+	ExplainNetwork(req hostfunc.NetworkRequest, grants *hostfunc.GrantSet) NetworkExplanation
+}
+
+// ExplainNetwork walks the network rules in order and reports the first rule
+// allowing req, or why none did. Its verdict always agrees with EvaluateNetwork.
+func (p *Engine) ExplainNetwork(req hostfunc.NetworkRequest, grants *hostfunc.GrantSet) NetworkExplanation {
+	denied := NetworkExplanation{RuleIndex: -1}
+
+	if p.deny != nil && p.deny.matchNetwork(req) {
+		denied.Reason = fmt.Sprintf("host %s port %d matches a deny rule", req.Host, req.Port)
+		return denied
+	}
+
+	c := p.getCompiled(grants)
+	if c == nil || len(c.networkRules) == 0 {
+		denied.Reason = "no network rules granted"
+		return denied
+	}
+
+	hostMatched := false
+	for i, rule := range c.networkRules {
+		if !rule.allowsHost(req.Host) {
+			continue
+		}
+		hostMatched = true
+		if rule.allowsPort(req.Port) {
+			matched := grants.Network.Rules[i]
+			return NetworkExplanation{
+				Allowed:     true,
+				MatchedRule: &matched,
+				RuleIndex:   i,
+				Reason:      fmt.Sprintf("allowed by network rule %d", i),
+			}
+		}
+	}
+
+	if hostMatched {
+		denied.Reason = fmt.Sprintf("port %d not allowed for host %s", req.Port, req.Host)
+	} else {
+		denied.Reason = fmt.Sprintf("no rule matches host %s", req.Host)
+	}
+	return denied
+}
+
+func (r compiledNetworkRule) allowsHost(host string) bool {
+	host = normalizeDNSName(host)
+	for _, pattern := range r.hosts {
+		if matched, _ := doublestar.Match(pattern, host); matched {
+			return true
+		}
+	}
+	if len(r.cidrs) == 0 {
+		return false
+	}
+	addr, ok := parseHostIP(host)
+	return ok && r.containsIP(addr)
+}
+
+func (r compiledNetworkRule) containsIP(addr netip.Addr) bool {
+	for _, prefix := range r.cidrs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainNetwork_AgreesWithEvaluate(t *testing.T) {
+	grants := largeNetworkGrants(100)
+	engine := NewPolicy(WithDenialHandler(&NopDenialHandler{})).(*Engine)
+
+	for i := 0; i < 110; i++ {
+		for _, port := range []int{80, 8003, 9050} {
+			req := hostfunc.NetworkRequest{Host: fmt.Sprintf("host-%d.example.com", i), Port: port}
+			exp := engine.ExplainNetwork(req, grants)
+			require.Equal(t, engine.EvaluateNetwork(req, grants), exp.Allowed, "req=%+v", req)
+			if exp.Allowed {
+				require.NotNil(t, exp.MatchedRule)
+				assert.Equal(t, grants.Network.Rules[exp.RuleIndex], *exp.MatchedRule)
+			} else {
+				assert.Nil(t, exp.MatchedRule)
+				assert.Equal(t, -1, exp.RuleIndex)
+			}
+		}
+	}
+}
+
+func TestExplainNetwork_Reasons(t *testing.T) {
+	handler := &countingDenialHandler{}
+	engine := NewPolicy(WithDenialHandler(handler)).(*Engine)
+	grants := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+		{Hosts: []string{"api.example.com"}, Ports: []string{"443"}},
+	}}}
+
+	exp := engine.ExplainNetwork(hostfunc.NetworkRequest{Host: "api.example.com", Port: 80}, grants)
+	assert.Equal(t, "port 80 not allowed for host api.example.com", exp.Reason)
+
+	exp = engine.ExplainNetwork(hostfunc.NetworkRequest{Host: "evil.com", Port: 443}, grants)
+	assert.Equal(t, "no rule matches host evil.com", exp.Reason)
+
+	exp = engine.ExplainNetwork(hostfunc.NetworkRequest{Host: "api.example.com", Port: 443}, &hostfunc.GrantSet{})
+	assert.Equal(t, "no network rules granted", exp.Reason)
+
+	assert.Zero(t, handler.calls, "explaining must not report denials")
+}
+
+type countingDenialHandler struct {
+	calls int
+}
+
+func (h *countingDenialHandler) OnDenial(string, interface{}, string) { h.calls++ }
+
+func TestEngine_ExplainNetwork_DenyRules(t *testing.T) {
+	engine := NewPolicy(
+		WithDenialHandler(&NopDenialHandler{}),
+		WithDenyRules(&hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{{Hosts: []string{"secrets.internal"}, Ports: []string{"*"}}},
+		}}),
+	).(*Engine)
+	grants := &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{
+		Rules: []hostfunc.NetworkRule{{Hosts: []string{"*.internal"}, Ports: []string{"443"}}},
+	}}
+
+	req := hostfunc.NetworkRequest{Host: "secrets.internal", Port: 443}
+	exp := engine.ExplainNetwork(req, grants)
+	assert.False(t, exp.Allowed)
+	assert.Nil(t, exp.MatchedRule)
+	assert.Equal(t, -1, exp.RuleIndex)
+	assert.Equal(t, "host secrets.internal port 443 matches a deny rule", exp.Reason)
+	assert.Equal(t, engine.EvaluateNetwork(req, grants), exp.Allowed)
+}
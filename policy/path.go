@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolvePath normalizes path to the absolute form rules are matched
+// against. Relative paths are only accepted when a working directory is set.
+// With symlink resolution on, the result is the real path, so a link inside
+// a granted directory that points outside it is matched by its target.
+func (p *Engine) resolvePath(path string) (string, bool) {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		if p.config.cwd == "" {
+			return "", false // Deny relative paths without cwd
+		}
+		path = filepath.Join(p.config.cwd, path)
+	}
+
+	// Resolve symlinks to prevent traversal attacks
+	if p.config.resolveSymlinks {
+		resolved, err := realPath(path, 0)
+		if err != nil {
+			return "", false // Fail closed: an unresolvable path is never matched
+		}
+		path = resolved
+	}
+	return normalizePath(path, p.config.caseSensitive), true
+}
+
+// normalizePath puts a filesystem path or grant pattern in the form fs rules
+// are matched in: slash-separated, since doublestar only splits on "/", and
+// lower-cased when matching is case-insensitive. On Windows backslashes are
+// therefore separators, not glob escapes; elsewhere paths are unchanged
+// apart from case folding.
+func normalizePath(path string, caseSensitive bool) string {
+	path = filepath.ToSlash(path)
+	if !caseSensitive {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// maxSymlinkHops bounds the links realPath follows, like the kernel's ELOOP.
+const maxSymlinkHops = 40
+
+// realPath resolves every symlink in the absolute path, including links in
+// intermediate components. Paths that do not exist yet, such as a file about
+// to be written, resolve their deepest existing ancestor and keep the rest;
+// a dangling symlink resolves to where it points, since writing through it
+// would create its target.
+//
+// The answer only holds at the time of the check. Handlers that open the
+// path afterwards should still guard against a link being swapped in, for
+// example with os.Root or O_NOFOLLOW.
+func realPath(path string, hops int) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+
+	if target, err := os.Readlink(path); err == nil {
+		if hops >= maxSymlinkHops {
+			return "", fmt.Errorf("%s: too many levels of symbolic links", path)
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		return realPath(filepath.Clean(target), hops+1)
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	resolvedParent, err := realPath(parent, hops)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), nil
+}
@@ -0,0 +1,33 @@
+package policy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		want          string
+		caseSensitive bool
+	}{
+		{"sensitive keeps case", "/Data/File.txt", "/Data/File.txt", true},
+		{"insensitive folds case", "/Data/File.txt", "/data/file.txt", false},
+		{"insensitive folds pattern", "/Data/**/*.TXT", "/data/**/*.txt", false},
+		{"native separators become slashes", filepath.Join("Data", "Sub", "f.txt"), "data/sub/f.txt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, normalizePath(tt.path, tt.caseSensitive))
+		})
+	}
+
+	if filepath.Separator == '/' {
+		// A backslash is an ordinary filename character (or glob escape) on Unix.
+		assert.Equal(t, `/data/a\*b`, normalizePath(`/data/a\*b`, true))
+	}
+}
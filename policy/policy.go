@@ -1,8 +1,10 @@
 package policy
 
 import (
+	"log/slog"
+	"net/netip"
 	"path/filepath"
-	"strconv"
+	"runtime"
 	"strings"
 	"sync"
 
@@ -12,16 +14,21 @@ import (
 
 // policyConfig holds configuration for the Policy engine.
 type policyConfig struct {
-	denialHandler   DenialHandler // Handler invoked on policy denials
-	cwd             string        // Working directory for relative path resolution
-	resolveSymlinks bool          // Whether to resolve symlinks (security feature)
+	denialHandler   DenialHandler      // Handler invoked on policy denials
+	cwd             string             // Working directory for relative path resolution
+	resolveSymlinks bool               // Whether to resolve symlinks (security feature)
+	caseSensitive   bool               // Whether filesystem paths match case-sensitively
+	allowByDefault  bool               // Allow denied requests, reporting them as would-deny
+	deny            *hostfunc.GrantSet // Requests matching these are refused outright
+	logger          *slog.Logger       // nil means slog.Default()
 }
 
 func defaultPolicyConfig() policyConfig {
 	return policyConfig{
 		cwd:             "",
-		resolveSymlinks: true,                   // Secure default
-		denialHandler:   &StderrDenialHandler{}, // Log to stderr by default
+		resolveSymlinks: true,                      // Secure default
+		caseSensitive:   runtime.GOOS != "windows", // Follow the host filesystem
+		denialHandler:   &StderrDenialHandler{},    // Log to stderr by default
 	}
 }
 
@@ -35,14 +42,55 @@ func WithWorkingDirectory(cwd string) PolicyOption {
 	}
 }
 
-// WithSymlinkResolution enables/disables symlink resolution.
-// Default is true (secure). Disable only for testing.
+// WithSymlinkResolution enables/disables symlink resolution. When enabled,
+// filesystem requests are matched by their real path, and paths that cannot
+// be resolved are denied. Default is true (secure). Disable only for testing.
 func WithSymlinkResolution(enabled bool) PolicyOption {
 	return func(c *policyConfig) {
 		c.resolveSymlinks = enabled
 	}
 }
 
+// WithPathCaseSensitivity sets whether filesystem paths and fs grant
+// patterns match case-sensitively. The default follows the host: insensitive
+// on Windows, sensitive elsewhere.
+func WithPathCaseSensitivity(sensitive bool) PolicyOption {
+	return func(c *policyConfig) {
+		c.caseSensitive = sensitive
+	}
+}
+
+// WithDenyRules sets rules that override every allow. deny has the GrantSet
+// shape: a request it would allow is refused, whatever the plugin's grants
+// say, so "*.internal" can be granted while "secrets.internal" stays off
+// limits. Deny rules are evaluated first and short-circuit the allow rules.
+func WithDenyRules(deny *hostfunc.GrantSet) PolicyOption {
+	return func(c *policyConfig) {
+		c.deny = deny
+	}
+}
+
+// WithAllowByDefault makes every Check method allow requests its grants
+// deny, still passing each one to the DenialHandler with a reason prefixed
+// "would deny: ". Evaluate methods are unaffected. Recording those denials
+// shows which grants a plugin actually needs.
+//
+// This disables enforcement entirely and is insecure: use it only in
+// development. NewPolicy logs a warning when it is enabled.
+func WithAllowByDefault(enabled bool) PolicyOption {
+	return func(c *policyConfig) {
+		c.allowByDefault = enabled
+	}
+}
+
+// WithLogger sets the logger for engine warnings, such as the one NewPolicy
+// logs under WithAllowByDefault. By default slog.Default() is used.
+func WithLogger(logger *slog.Logger) PolicyOption {
+	return func(c *policyConfig) {
+		c.logger = logger
+	}
+}
+
 // WithDenialHandler sets the denial handler.
 func WithDenialHandler(h DenialHandler) PolicyOption {
 	return func(c *policyConfig) {
@@ -52,12 +100,14 @@ func WithDenialHandler(h DenialHandler) PolicyOption {
 
 // Engine implements the Policy interface with stateless enforcement.
 type Engine struct {
-	cache  sync.Map // key: *hostfunc.GrantSet, value: *compiledGrantSet
+	cache  sync.Map          // key: *hostfunc.GrantSet, value: *compiledGrantSet
+	deny   *compiledGrantSet // nil when no deny rules are configured
 	config policyConfig
 }
 
 type compiledGrantSet struct {
 	networkRules []compiledNetworkRule
+	networkIndex networkIndex
 	fsRules      []compiledFSRule
 	env          []string
 	exec         []string
@@ -66,9 +116,25 @@ type compiledGrantSet struct {
 
 type compiledNetworkRule struct {
 	hosts []string
+	cidrs []netip.Prefix // hosts written in CIDR notation, matched against IP requests
 	ports []portRange
 }
 
+// networkIndex speeds up host matching for grant sets with many network rules.
+// Literal host patterns are looked up by map; only rules that carry glob
+// patterns are scanned linearly. A request is allowed if either path finds a
+// rule whose host and port both match, which is exactly the linear semantics.
+type networkIndex struct {
+	exact    map[string][]int // literal host -> indices into networkRules
+	patterns []indexedPatterns
+	cidrs    []int // rules that carry CIDR hosts
+}
+
+type indexedPatterns struct {
+	rule  int
+	hosts []string
+}
+
 type compiledFSRule struct {
 	read  []string
 	write []string
@@ -89,7 +155,33 @@ func NewPolicy(opts ...PolicyOption) Policy {
 	for _, opt := range opts {
 		opt(&cfg)
 	}
-	return &Engine{config: cfg}
+	if cfg.allowByDefault {
+		logger := cfg.logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Warn("policy allows all requests by default; capability grants are not enforced. Use only in development.")
+	}
+	e := &Engine{config: cfg}
+	if cfg.deny != nil {
+		e.deny = compileGrantSet(cfg.deny, cfg)
+	}
+	return e
+}
+
+// wouldDenyPrefix marks denials that WithAllowByDefault let through.
+const wouldDenyPrefix = "would deny: "
+
+// reject reports a denied request to the DenialHandler and returns the
+// Check result: false, or true with a would-deny note under
+// WithAllowByDefault.
+func (p *Engine) reject(kind string, req interface{}, reason string) bool {
+	if p.config.allowByDefault {
+		p.config.denialHandler.OnDenial(kind, req, wouldDenyPrefix+reason)
+		return true
+	}
+	p.config.denialHandler.OnDenial(kind, req, reason)
+	return false
 }
 
 func (p *Engine) getCompiled(grants *hostfunc.GrantSet) *compiledGrantSet {
@@ -100,16 +192,21 @@ func (p *Engine) getCompiled(grants *hostfunc.GrantSet) *compiledGrantSet {
 		return v.(*compiledGrantSet)
 	}
 
-	c := &compiledGrantSet{
-		networkRules: compileNetworkRules(grants.Network),
-		fsRules:      compileFSRules(grants.FS),
+	c := compileGrantSet(grants, p.config)
+	p.cache.Store(grants, c)
+	return c
+}
+
+func compileGrantSet(grants *hostfunc.GrantSet, cfg policyConfig) *compiledGrantSet {
+	networkRules := compileNetworkRules(grants.Network)
+	return &compiledGrantSet{
+		networkRules: networkRules,
+		networkIndex: buildNetworkIndex(networkRules),
+		fsRules:      compileFSRules(grants.FS, cfg.caseSensitive),
 		env:          compileEnv(grants.Env),
 		exec:         compileExec(grants.Exec),
 		kvRules:      compileKVRules(grants.KV),
 	}
-
-	p.cache.Store(grants, c)
-	return c
 }
 
 func compileNetworkRules(network *hostfunc.NetworkCapability) []compiledNetworkRule {
@@ -118,8 +215,10 @@ func compileNetworkRules(network *hostfunc.NetworkCapability) []compiledNetworkR
 	}
 	var rules []compiledNetworkRule
 	for _, rule := range network.Rules {
+		hosts, cidrs := compileHosts(rule.Hosts)
 		cr := compiledNetworkRule{
-			hosts: compilePatterns(rule.Hosts),
+			hosts: hosts,
+			cidrs: cidrs,
 			ports: compilePorts(rule.Ports),
 		}
 		rules = append(rules, cr)
@@ -127,37 +226,92 @@ func compileNetworkRules(network *hostfunc.NetworkCapability) []compiledNetworkR
 	return rules
 }
 
+func buildNetworkIndex(rules []compiledNetworkRule) networkIndex {
+	idx := networkIndex{exact: make(map[string][]int)}
+	for i, rule := range rules {
+		var globs []string
+		for _, host := range rule.hosts {
+			if isLiteralPattern(host) {
+				// Avoid indexing the same rule twice for duplicate hosts.
+				if n := len(idx.exact[host]); n > 0 && idx.exact[host][n-1] == i {
+					continue
+				}
+				idx.exact[host] = append(idx.exact[host], i)
+				continue
+			}
+			globs = append(globs, host)
+		}
+		if len(globs) > 0 {
+			idx.patterns = append(idx.patterns, indexedPatterns{rule: i, hosts: globs})
+		}
+		if len(rule.cidrs) > 0 {
+			idx.cidrs = append(idx.cidrs, i)
+		}
+	}
+	return idx
+}
+
+// compileHosts splits network rule hosts into glob patterns and CIDR
+// prefixes such as "10.0.0.0/8" or "fd00::/8".
+func compileHosts(hosts []string) ([]string, []netip.Prefix) {
+	var globs []string
+	var cidrs []netip.Prefix
+	for _, host := range hosts {
+		if prefix, err := netip.ParsePrefix(host); err == nil {
+			cidrs = append(cidrs, prefix.Masked())
+			continue
+		}
+		// Hostnames match case-insensitively and without a trailing dot,
+		// so "SECRETS.internal." cannot slip past a "secrets.internal" rule.
+		globs = append(globs, normalizeDNSName(host))
+	}
+	return compilePatterns(globs), cidrs
+}
+
+// parseHostIP parses a request host as an IP address for CIDR matching.
+// Brackets and zones are dropped, and IPv4-mapped IPv6 addresses are
+// unmapped so they match IPv4 prefixes.
+func parseHostIP(host string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.WithZone("").Unmap(), true
+}
+
+// isLiteralPattern reports whether pattern contains no doublestar
+// metacharacters, in which case Match is plain string equality.
+func isLiteralPattern(pattern string) bool {
+	return !strings.ContainsAny(pattern, "*?[]{}\\")
+}
+
 func compilePorts(ports []string) []portRange {
 	var ranges []portRange
 	for _, portStr := range ports {
-		if portStr == "*" {
-			ranges = append(ranges, portRange{0, 65535})
-			continue
-		}
-		if strings.Contains(portStr, "-") {
-			parts := strings.Split(portStr, "-")
-			if len(parts) == 2 {
-				minPort, _ := strconv.Atoi(strings.TrimSpace(parts[0]))
-				maxPort, _ := strconv.Atoi(strings.TrimSpace(parts[1]))
-				ranges = append(ranges, portRange{minPort, maxPort})
-			}
-		} else {
-			val, _ := strconv.Atoi(strings.TrimSpace(portStr))
-			ranges = append(ranges, portRange{val, val})
+		// Malformed ports never match; ValidateGrantSet reports them.
+		if pr, err := parsePortRange(portStr); err == nil {
+			ranges = append(ranges, pr)
 		}
 	}
 	return ranges
 }
 
-func compileFSRules(fs *hostfunc.FileSystemCapability) []compiledFSRule {
+func compileFSRules(fs *hostfunc.FileSystemCapability, caseSensitive bool) []compiledFSRule {
 	if fs == nil {
 		return nil
 	}
+	normalize := func(patterns []string) []string {
+		out := make([]string, len(patterns))
+		for i, p := range patterns {
+			out[i] = normalizePath(p, caseSensitive)
+		}
+		return compilePatterns(out)
+	}
 	var rules []compiledFSRule
 	for _, rule := range fs.Rules {
 		cr := compiledFSRule{
-			read:  compilePatterns(rule.Read),
-			write: compilePatterns(rule.Write),
+			read:  normalize(rule.Read),
+			write: normalize(rule.Write),
 		}
 		rules = append(rules, cr)
 	}
@@ -204,77 +358,141 @@ func compilePatterns(patterns []string) []string {
 }
 
 func (p *Engine) CheckNetwork(req hostfunc.NetworkRequest, grants *hostfunc.GrantSet) bool {
-	if p.EvaluateNetwork(req, grants) {
+	return p.checkNetwork(req, p.getCompiled(grants))
+}
+
+func (p *Engine) checkNetwork(req hostfunc.NetworkRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchNetwork(req) {
+		return p.reject("network", req, "host/port explicitly denied")
+	}
+	if p.evaluateNetwork(req, c) {
 		return true
 	}
-	p.config.denialHandler.OnDenial("network", req, "host/port not allowed")
-	return false
+	return p.reject("network", req, "host/port not allowed")
 }
 
 func (p *Engine) EvaluateNetwork(req hostfunc.NetworkRequest, grants *hostfunc.GrantSet) bool {
-	c := p.getCompiled(grants)
+	return p.evaluateNetwork(req, p.getCompiled(grants))
+}
+
+func (p *Engine) evaluateNetwork(req hostfunc.NetworkRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchNetwork(req) {
+		return false
+	}
 	if c == nil {
 		return false
 	}
+	return c.matchNetwork(req)
+}
 
-	// Check each rule - a request must match at least one rule's hosts AND ports
-	for _, rule := range c.networkRules {
-		hostMatch := false
-		for _, pattern := range rule.hosts {
-			if matched, _ := doublestar.Match(pattern, req.Host); matched {
-				hostMatch = true
-				break
-			}
+// matchNetwork reports whether any network rule covers req.
+func (c *compiledGrantSet) matchNetwork(req hostfunc.NetworkRequest) bool {
+	host := normalizeDNSName(req.Host)
+
+	// Exact hosts first: a map hit narrows the candidates to the rules that
+	// name this host literally.
+	for _, i := range c.networkIndex.exact[host] {
+		if c.networkRules[i].allowsPort(req.Port) {
+			return true
 		}
+	}
 
-		portMatch := false
-		for _, pr := range rule.ports {
-			if req.Port >= pr.min && req.Port <= pr.max {
-				portMatch = true
-				break
+	// Fall back to the rules that carry wildcard patterns.
+	for _, ip := range c.networkIndex.patterns {
+		if !c.networkRules[ip.rule].allowsPort(req.Port) {
+			continue
+		}
+		for _, pattern := range ip.hosts {
+			if matched, _ := doublestar.Match(pattern, host); matched {
+				return true
 			}
 		}
+	}
 
-		if hostMatch && portMatch {
+	// CIDR hosts only ever match IP requests.
+	if len(c.networkIndex.cidrs) == 0 {
+		return false
+	}
+	addr, ok := parseHostIP(host)
+	if !ok {
+		return false
+	}
+	for _, i := range c.networkIndex.cidrs {
+		if c.networkRules[i].containsIP(addr) && c.networkRules[i].allowsPort(req.Port) {
 			return true
 		}
 	}
 	return false
 }
 
-func (p *Engine) CheckFileSystem(req hostfunc.FileSystemRequest, grants *hostfunc.GrantSet) bool {
-	if p.EvaluateFileSystem(req, grants) {
-		return true
+// evaluateNetworkLinear checks every rule in order without the index. It is
+// the reference implementation EvaluateNetwork must agree with.
+func (c *compiledGrantSet) evaluateNetworkLinear(req hostfunc.NetworkRequest) bool {
+	// Check each rule - a request must match at least one rule's hosts AND ports
+	for _, rule := range c.networkRules {
+		if rule.allowsHost(req.Host) && rule.allowsPort(req.Port) {
+			return true
+		}
 	}
-	p.config.denialHandler.OnDenial("fs", req, "path not allowed")
 	return false
 }
 
-func (p *Engine) EvaluateFileSystem(req hostfunc.FileSystemRequest, grants *hostfunc.GrantSet) bool {
-	c := p.getCompiled(grants)
-	if c == nil {
-		return false
+func (r compiledNetworkRule) allowsPort(port int) bool {
+	for _, pr := range r.ports {
+		if port >= pr.min && port <= pr.max {
+			return true
+		}
 	}
+	return false
+}
 
-	// Normalize and secure the path
-	path := filepath.Clean(req.Path)
-	if !filepath.IsAbs(path) {
-		if p.config.cwd == "" {
-			return false // Deny relative paths without cwd
-		}
-		path = filepath.Join(p.config.cwd, path)
+func (p *Engine) CheckFileSystem(req hostfunc.FileSystemRequest, grants *hostfunc.GrantSet) bool {
+	return p.checkFileSystem(req, p.getCompiled(grants))
+}
+
+func (p *Engine) checkFileSystem(req hostfunc.FileSystemRequest, c *compiledGrantSet) bool {
+	allowed, denied := p.decideFileSystem(req, c)
+	switch {
+	case allowed:
+		return true
+	case denied:
+		return p.reject("fs", req, "path explicitly denied")
+	default:
+		return p.reject("fs", req, "path not allowed")
 	}
+}
 
-	// Resolve symlinks to prevent traversal attacks
-	if p.config.resolveSymlinks {
-		if resolved, err := filepath.EvalSymlinks(path); err == nil {
-			path = resolved
-		}
+func (p *Engine) EvaluateFileSystem(req hostfunc.FileSystemRequest, grants *hostfunc.GrantSet) bool {
+	return p.evaluateFileSystem(req, p.getCompiled(grants))
+}
+
+func (p *Engine) evaluateFileSystem(req hostfunc.FileSystemRequest, c *compiledGrantSet) bool {
+	allowed, _ := p.decideFileSystem(req, c)
+	return allowed
+}
+
+// decideFileSystem resolves the request path once and matches deny rules
+// and grants against that same path, so a symlink swapped between the two
+// lookups cannot make them disagree. denied reports a deny rule match.
+func (p *Engine) decideFileSystem(req hostfunc.FileSystemRequest, c *compiledGrantSet) (allowed, denied bool) {
+	path, ok := p.resolvePath(req.Path)
+	if !ok {
+		return false, false
 	}
+	if p.deny != nil && p.deny.matchFileSystem(req.Operation, path) {
+		return false, true
+	}
+	if c == nil {
+		return false, false
+	}
+	return c.matchFileSystem(req.Operation, path), false
+}
 
+// matchFileSystem reports whether any fs rule covers op on the resolved path.
+func (c *compiledGrantSet) matchFileSystem(op, path string) bool {
 	for _, rule := range c.fsRules {
 		var patterns []string
-		switch req.Operation {
+		switch op {
 		case "read":
 			patterns = rule.read
 		case "write":
@@ -291,19 +509,34 @@ func (p *Engine) EvaluateFileSystem(req hostfunc.FileSystemRequest, grants *host
 }
 
 func (p *Engine) CheckEnvironment(req hostfunc.EnvironmentRequest, grants *hostfunc.GrantSet) bool {
-	if p.EvaluateEnvironment(req, grants) {
+	return p.checkEnvironment(req, p.getCompiled(grants))
+}
+
+func (p *Engine) checkEnvironment(req hostfunc.EnvironmentRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchEnvironment(req) {
+		return p.reject("env", req, "variable explicitly denied")
+	}
+	if p.evaluateEnvironment(req, c) {
 		return true
 	}
-	p.config.denialHandler.OnDenial("env", req, "variable not allowed")
-	return false
+	return p.reject("env", req, "variable not allowed")
 }
 
 func (p *Engine) EvaluateEnvironment(req hostfunc.EnvironmentRequest, grants *hostfunc.GrantSet) bool {
-	c := p.getCompiled(grants)
+	return p.evaluateEnvironment(req, p.getCompiled(grants))
+}
+
+func (p *Engine) evaluateEnvironment(req hostfunc.EnvironmentRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchEnvironment(req) {
+		return false
+	}
 	if c == nil {
 		return false
 	}
+	return c.matchEnvironment(req)
+}
 
+func (c *compiledGrantSet) matchEnvironment(req hostfunc.EnvironmentRequest) bool {
 	for _, pattern := range c.env {
 		if matched, _ := doublestar.Match(pattern, req.Variable); matched {
 			return true
@@ -313,19 +546,34 @@ func (p *Engine) EvaluateEnvironment(req hostfunc.EnvironmentRequest, grants *ho
 }
 
 func (p *Engine) CheckExec(req hostfunc.ExecCapabilityRequest, grants *hostfunc.GrantSet) bool {
-	if p.EvaluateExec(req, grants) {
+	return p.checkExec(req, p.getCompiled(grants))
+}
+
+func (p *Engine) checkExec(req hostfunc.ExecCapabilityRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchExec(req) {
+		return p.reject("exec", req, "command explicitly denied")
+	}
+	if p.evaluateExec(req, c) {
 		return true
 	}
-	p.config.denialHandler.OnDenial("exec", req, "command not allowed")
-	return false
+	return p.reject("exec", req, "command not allowed")
 }
 
 func (p *Engine) EvaluateExec(req hostfunc.ExecCapabilityRequest, grants *hostfunc.GrantSet) bool {
-	c := p.getCompiled(grants)
+	return p.evaluateExec(req, p.getCompiled(grants))
+}
+
+func (p *Engine) evaluateExec(req hostfunc.ExecCapabilityRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchExec(req) {
+		return false
+	}
 	if c == nil {
 		return false
 	}
+	return c.matchExec(req)
+}
 
+func (c *compiledGrantSet) matchExec(req hostfunc.ExecCapabilityRequest) bool {
 	cmd := filepath.Clean(req.Command)
 	for _, pattern := range c.exec {
 		if matched, _ := doublestar.Match(pattern, cmd); matched {
@@ -336,19 +584,34 @@ func (p *Engine) EvaluateExec(req hostfunc.ExecCapabilityRequest, grants *hostfu
 }
 
 func (p *Engine) CheckKeyValue(req hostfunc.KeyValueRequest, grants *hostfunc.GrantSet) bool {
-	if p.EvaluateKeyValue(req, grants) {
+	return p.checkKeyValue(req, p.getCompiled(grants))
+}
+
+func (p *Engine) checkKeyValue(req hostfunc.KeyValueRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchKeyValue(req) {
+		return p.reject("kv", req, "key/operation explicitly denied")
+	}
+	if p.evaluateKeyValue(req, c) {
 		return true
 	}
-	p.config.denialHandler.OnDenial("kv", req, "key/operation not allowed")
-	return false
+	return p.reject("kv", req, "key/operation not allowed")
 }
 
 func (p *Engine) EvaluateKeyValue(req hostfunc.KeyValueRequest, grants *hostfunc.GrantSet) bool {
-	c := p.getCompiled(grants)
+	return p.evaluateKeyValue(req, p.getCompiled(grants))
+}
+
+func (p *Engine) evaluateKeyValue(req hostfunc.KeyValueRequest, c *compiledGrantSet) bool {
+	if p.deny != nil && p.deny.matchKeyValue(req) {
+		return false
+	}
 	if c == nil {
 		return false
 	}
+	return c.matchKeyValue(req)
+}
 
+func (c *compiledGrantSet) matchKeyValue(req hostfunc.KeyValueRequest) bool {
 	// Check each KV rule
 	for _, rule := range c.kvRules {
 		// Check operation
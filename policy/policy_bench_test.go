@@ -95,3 +95,52 @@ func BenchmarkCheckKeyValue(b *testing.B) {
 		p.CheckKeyValue(req, grants)
 	}
 }
+
+// BenchmarkCheckCompiled compares compiling grants for every check, which is
+// what CheckNetwork does for each new GrantSet pointer, with reusing a
+// CompiledGrantSet.
+func BenchmarkCheckCompiled(b *testing.B) {
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+	).(policy.CompiledChecker)
+	grants := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{
+				{Hosts: []string{"example.com", "*.internal", "10.0.0.0/8"}, Ports: []string{"80", "443", "8000-9000"}},
+			},
+		},
+		FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{
+				{Read: []string{"/data/**", "/etc/hosts"}},
+			},
+		},
+		Exec: &hostfunc.ExecCapability{
+			Commands: []string{"/usr/bin/*", "/opt/tools/**"},
+		},
+	}
+	netReq := hostfunc.NetworkRequest{Host: "api.internal", Port: 8080}
+	fsReq := hostfunc.FileSystemRequest{Path: "/data/foo/bar", Operation: "read"}
+	execReq := hostfunc.ExecCapabilityRequest{Command: "/usr/bin/ls"}
+
+	b.Run("compile-per-check", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			compiled := p.Compile(grants)
+			p.CheckNetworkCompiled(netReq, compiled)
+			p.CheckFileSystemCompiled(fsReq, compiled)
+			p.CheckExecCompiled(execReq, compiled)
+		}
+	})
+
+	b.Run("precompiled", func(b *testing.B) {
+		compiled := p.Compile(grants)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p.CheckNetworkCompiled(netReq, compiled)
+			p.CheckFileSystemCompiled(fsReq, compiled)
+			p.CheckExecCompiled(execReq, compiled)
+		}
+	})
+}
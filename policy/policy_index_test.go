@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeNetworkGrants builds a grant set mixing literal hosts, wildcard hosts,
+// CIDR hosts, duplicate hosts across rules and overlapping port ranges.
+func largeNetworkGrants(n int) *hostfunc.GrantSet {
+	rules := make([]hostfunc.NetworkRule, 0, n)
+	for i := 0; i < n; i++ {
+		rule := hostfunc.NetworkRule{
+			Hosts: []string{fmt.Sprintf("host-%d.example.com", i)},
+			Ports: []string{fmt.Sprintf("%d", 8000+i%50)},
+		}
+		switch i % 7 {
+		case 0:
+			rule.Hosts = append(rule.Hosts, fmt.Sprintf("*.zone-%d.internal", i))
+		case 3:
+			rule.Hosts = append(rule.Hosts, "shared.example.com")
+			rule.Ports = []string{"9000-9100"}
+		case 5:
+			rule.Hosts = append(rule.Hosts, fmt.Sprintf("svc-?.%d.local", i), fmt.Sprintf("host-%d.example.com", i))
+			rule.Ports = []string{"*"}
+		case 6:
+			rule.Hosts = append(rule.Hosts, fmt.Sprintf("10.%d.0.0/16", i%256), fmt.Sprintf("fd00:%x::/32", i))
+		}
+		rules = append(rules, rule)
+	}
+	return &hostfunc.GrantSet{Network: &hostfunc.NetworkCapability{Rules: rules}}
+}
+
+func TestEvaluateNetwork_IndexAgreesWithLinearScan(t *testing.T) {
+	const n = 500
+	grants := largeNetworkGrants(n)
+	engine := NewPolicy(WithDenialHandler(&NopDenialHandler{})).(*Engine)
+	compiled := engine.getCompiled(grants)
+	require.NotNil(t, compiled)
+
+	var hosts []string
+	for i := 0; i < n+10; i++ {
+		hosts = append(hosts,
+			fmt.Sprintf("host-%d.example.com", i),
+			fmt.Sprintf("api.zone-%d.internal", i),
+			fmt.Sprintf("svc-a.%d.local", i),
+		)
+	}
+	hosts = append(hosts, "shared.example.com", "unknown.example.com", "", "HOST-1.EXAMPLE.COM",
+		"10.6.1.1", "10.13.255.255", "10.7.0.1", "fd00:6::1", "fd00:d::1", "fd00:7::1", "10.6.0.0/16")
+	ports := []int{0, 80, 8000, 8003, 8049, 8050, 9000, 9050, 9101, 65535}
+
+	var allowed int
+	for _, host := range hosts {
+		for _, port := range ports {
+			req := hostfunc.NetworkRequest{Host: host, Port: port}
+			want := compiled.evaluateNetworkLinear(req)
+			got := engine.EvaluateNetwork(req, grants)
+			if !assert.Equal(t, want, got, "host=%q port=%d", host, port) {
+				return
+			}
+			if got {
+				allowed++
+			}
+		}
+	}
+	// Guard against a fixture that trivially denies everything.
+	assert.Positive(t, allowed)
+}
+
+func BenchmarkEvaluateNetwork_LargeRuleSet(b *testing.B) {
+	grants := largeNetworkGrants(1000)
+	engine := NewPolicy(WithDenialHandler(&NopDenialHandler{})).(*Engine)
+	compiled := engine.getCompiled(grants)
+	req := hostfunc.NetworkRequest{Host: "host-998.example.com", Port: 8048}
+
+	b.Run("indexed", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			engine.EvaluateNetwork(req, grants)
+		}
+	})
+
+	b.Run("linear", func(b *testing.B) {
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			compiled.evaluateNetworkLinear(req)
+		}
+	})
+}
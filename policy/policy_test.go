@@ -1,11 +1,16 @@
 package policy_test
 
 import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
 	"github.com/reglet-dev/reglet-host-sdk/policy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPolicy_CheckNetwork(t *testing.T) {
@@ -196,3 +201,389 @@ func TestPolicy_CheckKeyValue_MultipleRules(t *testing.T) {
 	assert.True(t, p.CheckKeyValue(hostfunc.KeyValueRequest{Key: "cache/session", Operation: "read"}, grants))
 	assert.True(t, p.CheckKeyValue(hostfunc.KeyValueRequest{Key: "cache/session", Operation: "write"}, grants))
 }
+
+func TestPolicy_DenyRules(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"*.internal"}, Ports: []string{"*"}},
+		}},
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+			{Read: []string{"/etc/**"}, Write: []string{"/tmp/**"}},
+		}},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"APP_*"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/*"}},
+		KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{
+			{Operation: "read-write", Keys: []string{"**"}},
+		}},
+	}
+	deny := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"secrets.internal"}, Ports: []string{"*"}},
+			{Hosts: []string{"db.internal"}, Ports: []string{"5432"}},
+		}},
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{
+			{Read: []string{"/etc/shadow"}, Write: []string{"/tmp/locked/**"}},
+		}},
+		Env:  &hostfunc.EnvironmentCapability{Variables: []string{"APP_SECRET_*"}},
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/sudo"}},
+		KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{
+			{Operation: "write", Keys: []string{"config/**"}},
+		}},
+	}
+	handler := &kindRecorder{}
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(handler),
+		policy.WithSymlinkResolution(false),
+		policy.WithDenyRules(deny),
+	)
+
+	tests := []struct {
+		req  interface{}
+		name string
+		want bool
+	}{
+		{hostfunc.NetworkRequest{Host: "api.internal", Port: 443}, "network allowed", true},
+		{hostfunc.NetworkRequest{Host: "secrets.internal", Port: 443}, "network denied host", false},
+		{hostfunc.NetworkRequest{Host: "SECRETS.Internal", Port: 443}, "network denied mixed-case host", false},
+		{hostfunc.NetworkRequest{Host: "secrets.internal.", Port: 443}, "network denied host with trailing dot", false},
+		{hostfunc.NetworkRequest{Host: "API.internal", Port: 443}, "network mixed-case host allowed", true},
+		{hostfunc.NetworkRequest{Host: "db.internal", Port: 5432}, "network denied port", false},
+		{hostfunc.NetworkRequest{Host: "db.internal", Port: 80}, "network other port", true},
+		{hostfunc.FileSystemRequest{Path: "/etc/hosts", Operation: "read"}, "fs read allowed", true},
+		{hostfunc.FileSystemRequest{Path: "/etc/../etc/shadow", Operation: "read"}, "fs read denied", false},
+		{hostfunc.FileSystemRequest{Path: "/tmp/locked/a", Operation: "write"}, "fs write denied", false},
+		{hostfunc.FileSystemRequest{Path: "/tmp/a", Operation: "write"}, "fs write allowed", true},
+		{hostfunc.EnvironmentRequest{Variable: "APP_PORT"}, "env allowed", true},
+		{hostfunc.EnvironmentRequest{Variable: "APP_SECRET_KEY"}, "env denied", false},
+		{hostfunc.ExecCapabilityRequest{Command: "/usr/bin/ls"}, "exec allowed", true},
+		{hostfunc.ExecCapabilityRequest{Command: "/usr/bin/sudo"}, "exec denied", false},
+		{hostfunc.KeyValueRequest{Key: "config/a", Operation: "read"}, "kv read allowed", true},
+		{hostfunc.KeyValueRequest{Key: "config/a", Operation: "write"}, "kv write denied", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bool
+			switch req := tt.req.(type) {
+			case hostfunc.NetworkRequest:
+				got = p.CheckNetwork(req, grants)
+			case hostfunc.FileSystemRequest:
+				got = p.CheckFileSystem(req, grants)
+			case hostfunc.EnvironmentRequest:
+				got = p.CheckEnvironment(req, grants)
+			case hostfunc.ExecCapabilityRequest:
+				got = p.CheckExec(req, grants)
+			case hostfunc.KeyValueRequest:
+				got = p.CheckKeyValue(req, grants)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Evaluate agrees with Check but never reports a denial.
+	handler.kinds = nil
+	assert.False(t, p.EvaluateNetwork(hostfunc.NetworkRequest{Host: "secrets.internal", Port: 443}, grants))
+	assert.False(t, p.EvaluateNetwork(hostfunc.NetworkRequest{Host: "SECRETS.internal", Port: 443}, grants))
+	assert.False(t, p.EvaluateExec(hostfunc.ExecCapabilityRequest{Command: "/usr/bin/sudo"}, grants))
+	assert.Empty(t, handler.kinds)
+
+	// A deny applies even when the grants are empty, and is reported once.
+	assert.False(t, p.CheckEnvironment(hostfunc.EnvironmentRequest{Variable: "APP_SECRET_KEY"}, nil))
+	assert.Equal(t, []string{"env"}, handler.kinds)
+}
+
+func TestPolicy_CheckNetwork_CIDR(t *testing.T) {
+	p := policy.NewPolicy(policy.WithDenialHandler(&policy.NopDenialHandler{}))
+	grants := &hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{
+			Rules: []hostfunc.NetworkRule{
+				{Hosts: []string{"10.0.0.0/8", "*.internal"}, Ports: []string{"443"}},
+				{Hosts: []string{"192.168.1.7/24"}, Ports: []string{"*"}}, // host bits are ignored
+				{Hosts: []string{"fd00::/8", "2001:db8::1"}, Ports: []string{"80"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		req  hostfunc.NetworkRequest
+		want bool
+	}{
+		{"IPv4 in CIDR", hostfunc.NetworkRequest{Host: "10.1.2.3", Port: 443}, true},
+		{"IPv4 in CIDR wrong port", hostfunc.NetworkRequest{Host: "10.1.2.3", Port: 80}, false},
+		{"IPv4 outside CIDR", hostfunc.NetworkRequest{Host: "11.0.0.1", Port: 443}, false},
+		{"IPv4 in unmasked CIDR", hostfunc.NetworkRequest{Host: "192.168.1.200", Port: 22}, true},
+		{"IPv4-mapped IPv6 in CIDR", hostfunc.NetworkRequest{Host: "::ffff:10.0.0.1", Port: 443}, true},
+		{"glob hostname in same rule", hostfunc.NetworkRequest{Host: "db.internal", Port: 443}, true},
+		{"hostname never matches CIDR", hostfunc.NetworkRequest{Host: "10.example.com", Port: 443}, false},
+		{"CIDR string as host is not an IP", hostfunc.NetworkRequest{Host: "10.0.0.0/8", Port: 443}, false},
+		{"IPv6 in CIDR", hostfunc.NetworkRequest{Host: "fd12:3456::1", Port: 80}, true},
+		{"bracketed IPv6 in CIDR", hostfunc.NetworkRequest{Host: "[fd12::1]", Port: 80}, true},
+		{"zoned IPv6 in CIDR", hostfunc.NetworkRequest{Host: "fd12::1%eth0", Port: 80}, true},
+		{"IPv6 outside CIDR", hostfunc.NetworkRequest{Host: "fe80::1", Port: 80}, false},
+		{"literal IPv6 next to CIDR", hostfunc.NetworkRequest{Host: "2001:db8::1", Port: 80}, true},
+		{"IPv4 not in IPv6 CIDR", hostfunc.NetworkRequest{Host: "10.0.0.1", Port: 80}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.CheckNetwork(tt.req, grants))
+		})
+	}
+}
+
+func TestPolicy_CheckFileSystem_Symlinks(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	data := filepath.Join(root, "data")
+	secret := filepath.Join(root, "secret")
+	for _, dir := range []string{filepath.Join(data, "sub"), secret} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(data, "sub", "ok.txt"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(secret, "passwd"), nil, 0o644))
+
+	links := map[string]string{
+		"link-inside":      filepath.Join(data, "sub"),
+		"link-relative":    "sub",
+		"link-outside":     secret,
+		"link-outside-rel": "../secret",
+		"dangling-outside": filepath.Join(secret, "new.txt"),
+		"dangling-dir":     filepath.Join(secret, "missing"),
+		"loop-a":           "loop-b",
+		"loop-b":           "loop-a",
+	}
+	for name, target := range links {
+		require.NoError(t, os.Symlink(target, filepath.Join(data, name)))
+	}
+
+	p := policy.NewPolicy(policy.WithDenialHandler(&policy.NopDenialHandler{}))
+	grants := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{
+				{Read: []string{data + "/**"}, Write: []string{data + "/**"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		op   string
+		path string
+		want bool
+	}{
+		{"regular file", "read", "sub/ok.txt", true},
+		{"link inside grant", "read", "link-inside/ok.txt", true},
+		{"relative link inside grant", "read", "link-relative/ok.txt", true},
+		{"new file under inside link", "write", "link-inside/new.txt", true},
+		{"new file in missing dirs", "write", "sub/a/b/new.txt", true},
+		{"intermediate link outside grant", "read", "link-outside/passwd", false},
+		{"relative intermediate link outside grant", "read", "link-outside-rel/passwd", false},
+		{"new file under outside link", "write", "link-outside/new.txt", false},
+		{"dangling link to outside file", "write", "dangling-outside", false},
+		{"new file under dangling link", "write", "dangling-dir/x/new.txt", false},
+		{"symlink loop", "read", "loop-a", false},
+		{"dotdot out of grant", "read", "sub/../../secret/passwd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := hostfunc.FileSystemRequest{Path: filepath.Join(data, tt.path), Operation: tt.op}
+			assert.Equal(t, tt.want, p.CheckFileSystem(req, grants))
+		})
+	}
+}
+
+func TestPolicy_CheckFileSystem_CaseSensitivity(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{
+				{Read: []string{"/Data/**"}},
+			},
+		},
+	}
+	req := hostfunc.FileSystemRequest{Path: "/data/Reports/q1.csv", Operation: "read"}
+
+	sensitive := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+		policy.WithPathCaseSensitivity(true),
+	)
+	assert.False(t, sensitive.CheckFileSystem(req, grants))
+	assert.True(t, sensitive.CheckFileSystem(hostfunc.FileSystemRequest{Path: "/Data/Reports/q1.csv", Operation: "read"}, grants))
+
+	insensitive := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+		policy.WithPathCaseSensitivity(false),
+	)
+	assert.True(t, insensitive.CheckFileSystem(req, grants))
+	assert.False(t, insensitive.CheckFileSystem(hostfunc.FileSystemRequest{Path: "/other/q1.csv", Operation: "read"}, grants))
+}
+
+func TestPolicy_GlobSyntax(t *testing.T) {
+	p := policy.NewPolicy(policy.WithDenialHandler(&policy.NopDenialHandler{}))
+	grants := &hostfunc.GrantSet{
+		Exec: &hostfunc.ExecCapability{Commands: []string{
+			"/usr/bin/{ls,cat}",
+			"/opt/{tools,bin}/c{at,p}",
+			`/srv/\{literal\}`,
+			"/usr/local/bin/*",
+		}},
+		KV: &hostfunc.KeyValueCapability{Rules: []hostfunc.KeyValueRule{
+			{Operation: "read", Keys: []string{"{config,settings}/**", "shard-[0-9]", "job-[!0-9]*", "{a,b{1,2}}/x"}},
+		}},
+	}
+
+	execTests := []struct {
+		command string
+		want    bool
+	}{
+		{"/usr/bin/ls", true},
+		{"/usr/bin/cat", true},
+		{"/usr/bin/rm", false},
+		{"/usr/bin/{ls,cat}", false},
+		{"/opt/tools/cp", true},
+		{"/opt/bin/cat", true},
+		{"/opt/tools/cd", false},
+		{"/srv/{literal}", true},
+		{"/srv/literal", false},
+		{"/usr/local/bin/tool", true},
+		{"/usr/local/bin/sub/tool", false},
+	}
+	for _, tt := range execTests {
+		t.Run("exec "+tt.command, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.CheckExec(hostfunc.ExecCapabilityRequest{Command: tt.command}, grants))
+		})
+	}
+
+	kvTests := []struct {
+		key  string
+		want bool
+	}{
+		{"config/db/url", true},
+		{"settings/theme", true},
+		{"secrets/key", false},
+		{"shard-7", true},
+		{"shard-10", false},
+		{"job-build", true},
+		{"job-1", false},
+		{"a/x", true},
+		{"b2/x", true},
+		{"b3/x", false},
+		{"b/x", false},
+	}
+	for _, tt := range kvTests {
+		t.Run("kv "+tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.CheckKeyValue(hostfunc.KeyValueRequest{Key: tt.key, Operation: "read"}, grants))
+		})
+	}
+}
+
+func TestPolicy_GlobSyntax_UnclosedBrace(t *testing.T) {
+	p := policy.NewPolicy(policy.WithDenialHandler(&policy.NopDenialHandler{}))
+	grants := &hostfunc.GrantSet{Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/{ls"}}}
+
+	// An invalid pattern matches nothing, not even its own text.
+	assert.False(t, p.CheckExec(hostfunc.ExecCapabilityRequest{Command: "/usr/bin/{ls"}, grants))
+	assert.False(t, p.CheckExec(hostfunc.ExecCapabilityRequest{Command: "/usr/bin/ls"}, grants))
+}
+
+type reasonRecorder struct {
+	reasons []string
+}
+
+func (h *reasonRecorder) OnDenial(kind string, _ interface{}, reason string) {
+	h.reasons = append(h.reasons, kind+": "+reason)
+}
+
+func TestPolicy_AllowByDefault(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"APP_*"}},
+	}
+	deny := &hostfunc.GrantSet{
+		Exec: &hostfunc.ExecCapability{Commands: []string{"/usr/bin/sudo"}},
+	}
+	handler := &reasonRecorder{}
+	var logs bytes.Buffer
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(handler),
+		policy.WithSymlinkResolution(false),
+		policy.WithDenyRules(deny),
+		policy.WithAllowByDefault(true),
+		policy.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	assert.Contains(t, logs.String(), "level=WARN")
+	assert.Contains(t, logs.String(), "grants are not enforced")
+
+	assert.True(t, p.CheckEnvironment(hostfunc.EnvironmentRequest{Variable: "APP_PORT"}, grants))
+	assert.Empty(t, handler.reasons, "granted requests are not reported")
+
+	assert.True(t, p.CheckNetwork(hostfunc.NetworkRequest{Host: "example.com", Port: 443}, grants))
+	assert.True(t, p.CheckFileSystem(hostfunc.FileSystemRequest{Path: "/etc/passwd", Operation: "read"}, grants))
+	assert.True(t, p.CheckEnvironment(hostfunc.EnvironmentRequest{Variable: "HOME"}, grants))
+	assert.True(t, p.CheckExec(hostfunc.ExecCapabilityRequest{Command: "/usr/bin/sudo"}, grants))
+	assert.True(t, p.CheckKeyValue(hostfunc.KeyValueRequest{Key: "a", Operation: "write"}, nil))
+	assert.Equal(t, []string{
+		"network: would deny: host/port not allowed",
+		"fs: would deny: path not allowed",
+		"env: would deny: variable not allowed",
+		"exec: would deny: command explicitly denied",
+		"kv: would deny: key/operation not allowed",
+	}, handler.reasons)
+
+	// Evaluate still reports what the grants decide.
+	assert.False(t, p.EvaluateEnvironment(hostfunc.EnvironmentRequest{Variable: "HOME"}, grants))
+
+	handler.reasons = nil
+	decisions, allowed := p.(policy.BatchChecker).CheckAll([]policy.Request{
+		hostfunc.EnvironmentRequest{Variable: "APP_PORT"},
+		hostfunc.EnvironmentRequest{Variable: "HOME"},
+	}, grants, policy.StopAtFirstDenial())
+	assert.True(t, allowed)
+	require.Len(t, decisions, 2)
+	assert.True(t, decisions[1].Allowed)
+	assert.Equal(t, "would deny: variable not allowed", decisions[1].Reason)
+	assert.Equal(t, []string{"env: would deny: variable not allowed"}, handler.reasons)
+}
+
+func TestPolicy_AllowByDefault_Disabled(t *testing.T) {
+	handler := &reasonRecorder{}
+	var logs bytes.Buffer
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(handler),
+		policy.WithAllowByDefault(false),
+		policy.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	assert.Empty(t, logs.String())
+
+	assert.False(t, p.CheckEnvironment(hostfunc.EnvironmentRequest{Variable: "HOME"}, nil))
+	assert.Equal(t, []string{"env: variable not allowed"}, handler.reasons)
+}
+
+func TestPolicy_FileSystemDenyReasons(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/**"}}}},
+	}
+	deny := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{Rules: []hostfunc.FileSystemRule{{Read: []string{"/etc/shadow"}}}},
+	}
+	handler := &reasonRecorder{}
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(handler),
+		policy.WithSymlinkResolution(false),
+		policy.WithDenyRules(deny),
+	)
+
+	shadow := hostfunc.FileSystemRequest{Path: "/etc/../etc/shadow", Operation: "read"}
+	other := hostfunc.FileSystemRequest{Path: "/var/log/syslog", Operation: "read"}
+	assert.False(t, p.CheckFileSystem(shadow, grants))
+	assert.False(t, p.CheckFileSystem(other, grants))
+	assert.Equal(t, []string{"fs: path explicitly denied", "fs: path not allowed"}, handler.reasons)
+
+	decisions, allowed := p.(policy.BatchChecker).CheckAll([]policy.Request{shadow, other}, grants)
+	assert.False(t, allowed)
+	require.Len(t, decisions, 2)
+	assert.Equal(t, "path explicitly denied", decisions[0].Reason)
+	assert.Equal(t, "path not allowed", decisions[1].Reason)
+}
@@ -0,0 +1,49 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/reglet-dev/reglet-host-sdk/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_CheckFileSystem_Windows(t *testing.T) {
+	grants := &hostfunc.GrantSet{
+		FS: &hostfunc.FileSystemCapability{
+			Rules: []hostfunc.FileSystemRule{
+				{Read: []string{`C:\data\**`}, Write: []string{`C:/Temp/*`}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		req  hostfunc.FileSystemRequest
+		want bool
+	}{
+		{"backslash path", hostfunc.FileSystemRequest{Path: `C:\data\sub\file.txt`, Operation: "read"}, true},
+		{"different case", hostfunc.FileSystemRequest{Path: `c:\Data\File.txt`, Operation: "read"}, true},
+		{"forward slash path", hostfunc.FileSystemRequest{Path: `C:/data/file.txt`, Operation: "read"}, true},
+		{"slash pattern, backslash path", hostfunc.FileSystemRequest{Path: `c:\temp\x.log`, Operation: "write"}, true},
+		{"dotdot out of grant", hostfunc.FileSystemRequest{Path: `C:\data\..\Windows\win.ini`, Operation: "read"}, false},
+		{"other drive", hostfunc.FileSystemRequest{Path: `D:\data\file.txt`, Operation: "read"}, false},
+	}
+
+	p := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+	)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, p.CheckFileSystem(tt.req, grants))
+		})
+	}
+
+	sensitive := policy.NewPolicy(
+		policy.WithDenialHandler(&policy.NopDenialHandler{}),
+		policy.WithSymlinkResolution(false),
+		policy.WithPathCaseSensitivity(true),
+	)
+	assert.False(t, sensitive.CheckFileSystem(hostfunc.FileSystemRequest{Path: `c:\Data\File.txt`, Operation: "read"}, grants))
+}
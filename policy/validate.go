@@ -0,0 +1,151 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+)
+
+// ErrInvalidGrant is matched by every GrantError.
+var ErrInvalidGrant = errors.New("invalid capability grant")
+
+// GrantError describes one structurally invalid entry in a grant set.
+type GrantError struct {
+	// Path locates the entry, e.g. "network.rules[0].ports[1]".
+	Path string
+	// Value is the offending value, empty when the entry is missing.
+	Value string
+	// Reason explains what is wrong.
+	Reason string
+}
+
+func (e *GrantError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("%s %q: %s", e.Path, e.Value, e.Reason)
+}
+
+// Is reports whether target is ErrInvalidGrant.
+func (e *GrantError) Is(target error) bool {
+	return target == ErrInvalidGrant
+}
+
+// ValidateGrantSet checks that every rule in grants is well-formed: network
+// rules name hosts and parseable ports, patterns are valid globs, and KV
+// rules use a known operation. The engine silently ignores malformed entries,
+// so a grant that fails here would not behave as written. All problems are
+// returned, joined; nil means the grant set is valid.
+func ValidateGrantSet(grants *hostfunc.GrantSet) error {
+	if grants == nil {
+		return nil
+	}
+	var errs []error
+	add := func(path, value, reason string) {
+		errs = append(errs, &GrantError{Path: path, Value: value, Reason: reason})
+	}
+	patterns := func(path string, list []string) {
+		for i, p := range list {
+			entry := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case p == "":
+				add(entry, "", "empty pattern")
+			case !doublestar.ValidatePattern(p):
+				add(entry, p, "invalid glob pattern")
+			}
+		}
+	}
+
+	if grants.Network != nil {
+		for i, rule := range grants.Network.Rules {
+			path := fmt.Sprintf("network.rules[%d]", i)
+			if len(rule.Hosts) == 0 {
+				add(path+".hosts", "", "no hosts")
+			}
+			if len(rule.Ports) == 0 {
+				add(path+".ports", "", "no ports")
+			}
+			patterns(path+".hosts", rule.Hosts)
+			for j, port := range rule.Ports {
+				if _, err := parsePortRange(port); err != nil {
+					add(fmt.Sprintf("%s.ports[%d]", path, j), port, err.Error())
+				}
+			}
+		}
+	}
+
+	if grants.FS != nil {
+		for i, rule := range grants.FS.Rules {
+			path := fmt.Sprintf("fs.rules[%d]", i)
+			if len(rule.Read) == 0 && len(rule.Write) == 0 {
+				add(path, "", "neither read nor write paths")
+			}
+			patterns(path+".read", rule.Read)
+			patterns(path+".write", rule.Write)
+		}
+	}
+
+	if grants.Env != nil {
+		patterns("env.vars", grants.Env.Variables)
+	}
+
+	if grants.Exec != nil {
+		patterns("exec.commands", grants.Exec.Commands)
+	}
+
+	if grants.KV != nil {
+		for i, rule := range grants.KV.Rules {
+			path := fmt.Sprintf("kv.rules[%d]", i)
+			switch rule.Operation {
+			case "read", "write", "read-write":
+			default:
+				add(path+".op", rule.Operation, `must be "read", "write" or "read-write"`)
+			}
+			if len(rule.Keys) == 0 {
+				add(path+".keys", "", "no keys")
+			}
+			patterns(path+".keys", rule.Keys)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// parsePortRange parses a port grant: a single port ("443"), an inclusive
+// range ("8000-9000"), or "*" for any port.
+func parsePortRange(s string) (portRange, error) {
+	if s == "*" {
+		return portRange{0, 65535}, nil
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	minPort, err := parsePort(lo)
+	if err != nil {
+		return portRange{}, err
+	}
+	if !isRange {
+		return portRange{minPort, minPort}, nil
+	}
+	maxPort, err := parsePort(hi)
+	if err != nil {
+		return portRange{}, err
+	}
+	if minPort > maxPort {
+		return portRange{}, fmt.Errorf("range start %d is after end %d", minPort, maxPort)
+	}
+	return portRange{minPort, maxPort}, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("not a port number")
+	}
+	if port < 0 || port > 65535 {
+		return 0, fmt.Errorf("port out of range")
+	}
+	return port, nil
+}
@@ -0,0 +1,30 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateGrantSet(t *testing.T) {
+	assert.NoError(t, ValidateGrantSet(nil))
+	assert.NoError(t, ValidateGrantSet(&hostfunc.GrantSet{}))
+
+	err := ValidateGrantSet(&hostfunc.GrantSet{
+		Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"example.com"}, Ports: []string{"*", " 80 ", "1-2-3"}},
+		}},
+		Env: &hostfunc.EnvironmentCapability{Variables: []string{"HOME", "{A"}},
+	})
+	require.ErrorIs(t, err, ErrInvalidGrant)
+	assert.Contains(t, err.Error(), `network.rules[0].ports[2] "1-2-3": not a port number`)
+	assert.Contains(t, err.Error(), `env.vars[1] "{A": invalid glob pattern`)
+	assert.NotContains(t, err.Error(), "ports[0]")
+	assert.NotContains(t, err.Error(), "ports[1]")
+}
+
+func TestCompilePorts_SkipsMalformed(t *testing.T) {
+	assert.Equal(t, []portRange{{443, 443}, {8000, 9000}}, compilePorts([]string{"443", "abc", "9000-8000", "8000-9000"}))
+}
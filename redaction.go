@@ -0,0 +1,97 @@
+package hostlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/reglet-dev/reglet-host-sdk/netutil"
+)
+
+// DefaultRedactedFields are the JSON fields RedactionMiddleware always masks.
+var DefaultRedactedFields = []string{"password", "token", "authorization"}
+
+// redactedPayloadKey is the HostContext key of the sanitized payload.
+type redactedPayloadKey struct{}
+
+// RedactionMiddleware returns a middleware that stores a sanitized copy of
+// each payload in the HostContext for logging and auditing; read it back with
+// RedactedPayload. In the copy, the values of DefaultRedactedFields and the
+// given fields are replaced with "[REDACTED]" at any depth (field names match
+// case-insensitively), and credentials are stripped from any URL. A payload
+// that is not valid JSON is masked entirely. The payload passed to the
+// handler is left untouched. Register it ahead of middleware that logs.
+func RedactionMiddleware(fields ...string) Middleware {
+	masked := make(map[string]struct{}, len(DefaultRedactedFields)+len(fields))
+	for _, f := range DefaultRedactedFields {
+		masked[strings.ToLower(f)] = struct{}{}
+	}
+	for _, f := range fields {
+		masked[strings.ToLower(f)] = struct{}{}
+	}
+
+	return func(next ByteHandler) ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			hc := HostContextFrom(ctx, "")
+			hc.SetValue(redactedPayloadKey{}, redactPayload(payload, masked))
+			return next(hc, payload)
+		}
+	}
+}
+
+// RedactedPayload returns the sanitized payload stored by
+// RedactionMiddleware, if ctx carries one.
+func RedactedPayload(ctx context.Context) ([]byte, bool) {
+	hc, ok := ctx.(HostContext)
+	if !ok {
+		return nil, false
+	}
+	v, ok := hc.GetValue(redactedPayloadKey{})
+	if !ok {
+		return nil, false
+	}
+	payload, ok := v.([]byte)
+	return payload, ok
+}
+
+// redactPayload returns a masked copy of payload. It never modifies payload.
+func redactPayload(payload []byte, masked map[string]struct{}) []byte {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return []byte(`"` + redactedSecret + `"`)
+	}
+	out, err := json.Marshal(redactValue(doc, masked))
+	if err != nil {
+		return []byte(`"` + redactedSecret + `"`)
+	}
+	return out
+}
+
+func redactValue(v any, masked map[string]struct{}) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, field := range v {
+			if _, ok := masked[strings.ToLower(k)]; ok {
+				v[k] = redactedSecret
+				continue
+			}
+			v[k] = redactValue(field, masked)
+		}
+		return v
+	case []any:
+		for i, elem := range v {
+			v[i] = redactValue(elem, masked)
+		}
+		return v
+	case string:
+		if netutil.HasCredentials(v) {
+			return netutil.StripCredentials(v)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -3,6 +3,7 @@ package hostlib
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sort"
 )
 
@@ -19,7 +20,9 @@ type HandlerRegistry struct {
 type registryBuilder struct {
 	handlers   map[string]ByteHandler
 	middleware []Middleware
-	errors     []error
+	// middlewareNames parallels middleware; "" marks unnamed middleware.
+	middlewareNames []string
+	errors          []error
 }
 
 // NewRegistry creates an immutable HandlerRegistry with the given options.
@@ -125,6 +128,89 @@ func WithByteHandler(name string, handler ByteHandler) RegistryOption {
 // Middleware executes in FIFO order (first added wraps first).
 func WithMiddleware(mw ...Middleware) RegistryOption {
 	return func(b *registryBuilder) {
-		b.middleware = append(b.middleware, mw...)
+		for _, m := range mw {
+			b.insertMiddleware(len(b.middleware), "", m)
+		}
+	}
+}
+
+// WithMiddlewareNamed appends middleware under a name that later
+// WithMiddlewareBefore and WithMiddlewareAfter options can refer to.
+// Names must be unique.
+func WithMiddlewareNamed(name string, mw Middleware) RegistryOption {
+	return func(b *registryBuilder) {
+		if err := b.checkMiddlewareName(name); err != nil {
+			b.errors = append(b.errors, err)
+			return
+		}
+		b.insertMiddleware(len(b.middleware), name, mw)
+	}
+}
+
+// WithMiddlewareBefore inserts named middleware immediately before target,
+// so it wraps target and runs first. target must have been registered by an
+// earlier option; NewRegistry fails otherwise.
+//
+// Example:
+//
+//	NewRegistry(
+//	    WithMiddlewareNamed("retry", retryMiddleware),
+//	    WithMiddlewareBefore("retry", "capability", CapabilityMiddleware(checker)),
+//	)
+func WithMiddlewareBefore(target, name string, mw Middleware) RegistryOption {
+	return func(b *registryBuilder) {
+		i, err := b.middlewareIndex(target, name)
+		if err != nil {
+			b.errors = append(b.errors, err)
+			return
+		}
+		b.insertMiddleware(i, name, mw)
+	}
+}
+
+// WithMiddlewareAfter inserts named middleware immediately after target,
+// so target wraps it and it runs closer to the handler. target must have
+// been registered by an earlier option; NewRegistry fails otherwise.
+func WithMiddlewareAfter(target, name string, mw Middleware) RegistryOption {
+	return func(b *registryBuilder) {
+		i, err := b.middlewareIndex(target, name)
+		if err != nil {
+			b.errors = append(b.errors, err)
+			return
+		}
+		b.insertMiddleware(i+1, name, mw)
+	}
+}
+
+// middlewareIndex returns the position of target, checking that name can
+// be registered.
+func (b *registryBuilder) middlewareIndex(target, name string) (int, error) {
+	if err := b.checkMiddlewareName(name); err != nil {
+		return 0, err
+	}
+	if target != "" {
+		for i, n := range b.middlewareNames {
+			if n == target {
+				return i, nil
+			}
+		}
 	}
+	return 0, fmt.Errorf("unknown middleware %q", target)
+}
+
+func (b *registryBuilder) checkMiddlewareName(name string) error {
+	if name == "" {
+		return fmt.Errorf("middleware name cannot be empty")
+	}
+	for _, n := range b.middlewareNames {
+		if n == name {
+			return fmt.Errorf("duplicate middleware name: %q", name)
+		}
+	}
+	return nil
+}
+
+func (b *registryBuilder) insertMiddleware(i int, name string, mw Middleware) {
+	b.middleware = slices.Insert(b.middleware, i, mw)
+	b.middlewareNames = slices.Insert(b.middlewareNames, i, name)
 }
@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SchemaFormat identifies the dialect a registered schema was written in.
+type SchemaFormat string
+
+const (
+	// SchemaFormatJSONSchema is a standalone JSON Schema document.
+	SchemaFormatJSONSchema SchemaFormat = "json-schema"
+
+	// SchemaFormatOpenAPI is an OpenAPI 3.0 Schema Object, optionally with a
+	// sibling "components.schemas" map for the definitions it references.
+	SchemaFormatOpenAPI SchemaFormat = "openapi"
+)
+
+// jsonSchemaDialect is the $schema stamped on schemas converted from OpenAPI.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+const openAPISchemaRefPrefix = "#/components/schemas/"
+
+// DetectSchemaFormat reports which dialect schema is written in. Schemas that
+// declare their own $schema or use no OpenAPI-only constructs are treated as
+// JSON Schema, as is anything that is not a JSON object.
+func DetectSchemaFormat(schema string) SchemaFormat {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(schema), &obj); err != nil {
+		return SchemaFormatJSONSchema
+	}
+	return detectFormat(obj)
+}
+
+func detectFormat(obj map[string]any) SchemaFormat {
+	// An explicit dialect wins: draft-04 uses boolean exclusiveMinimum too.
+	if _, ok := obj["$schema"]; ok {
+		return SchemaFormatJSONSchema
+	}
+	if _, ok := obj["openapi"]; ok {
+		return SchemaFormatOpenAPI
+	}
+	if _, ok := obj["components"]; ok {
+		return SchemaFormatOpenAPI
+	}
+	if usesOpenAPIKeywords(obj) {
+		return SchemaFormatOpenAPI
+	}
+	return SchemaFormatJSONSchema
+}
+
+// usesOpenAPIKeywords looks for keywords or forms that only exist in
+// OpenAPI 3.0 Schema Objects.
+func usesOpenAPIKeywords(node any) bool {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			switch key {
+			case "nullable", "discriminator", "xml":
+				return true
+			case "exclusiveMinimum", "exclusiveMaximum":
+				if _, ok := child.(bool); ok {
+					return true
+				}
+			case "$ref":
+				if ref, ok := child.(string); ok && strings.HasPrefix(ref, openAPISchemaRefPrefix) {
+					return true
+				}
+			}
+			if usesOpenAPIKeywords(child) {
+				return true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if usesOpenAPIKeywords(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NormalizeSchema converts schema to JSON Schema. JSON Schema input, including
+// any schema that declares its own $schema, is returned unchanged. OpenAPI Schema Objects are rewritten as a standalone
+// 2020-12 document:
+//   - components.schemas become $defs, and their $refs are repointed;
+//   - nullable: true adds "null" to the type, or for an untyped schema wraps
+//     it in an anyOf with {"type": "null"};
+//   - boolean exclusiveMinimum/exclusiveMaximum take the numeric bound;
+//   - example becomes examples.
+func NormalizeSchema(schema string) (string, SchemaFormat, error) {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(schema), &obj); err != nil {
+		return schema, SchemaFormatJSONSchema, nil
+	}
+	format := detectFormat(obj)
+	if format == SchemaFormatJSONSchema {
+		return schema, format, nil
+	}
+
+	delete(obj, "openapi")
+	if components, ok := obj["components"].(map[string]any); ok {
+		if schemas, ok := components["schemas"].(map[string]any); ok {
+			defs, _ := obj["$defs"].(map[string]any)
+			if defs == nil {
+				defs = make(map[string]any, len(schemas))
+			}
+			for name, s := range schemas {
+				defs[name] = s
+			}
+			obj["$defs"] = defs
+		}
+		delete(obj, "components")
+	}
+	convertOpenAPI(obj)
+	obj["$schema"] = jsonSchemaDialect
+
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return "", format, fmt.Errorf("failed to marshal normalized schema: %w", err)
+	}
+	return string(out), format, nil
+}
+
+// convertOpenAPI rewrites OpenAPI-only keywords in place.
+func convertOpenAPI(node any) {
+	switch v := node.(type) {
+	case map[string]any:
+		if nullable, ok := v["nullable"].(bool); ok {
+			delete(v, "nullable")
+			if nullable {
+				switch t := v["type"].(type) {
+				case string:
+					v["type"] = []any{t, "null"}
+				case []any:
+					v["type"] = append(t, "null")
+				default:
+					allowNull(v)
+				}
+			}
+		}
+		convertExclusiveBound(v, "exclusiveMinimum", "minimum")
+		convertExclusiveBound(v, "exclusiveMaximum", "maximum")
+		if example, ok := v["example"]; ok {
+			delete(v, "example")
+			if _, exists := v["examples"]; !exists {
+				v["examples"] = []any{example}
+			}
+		}
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, openAPISchemaRefPrefix) {
+			v["$ref"] = "#/$defs/" + strings.TrimPrefix(ref, openAPISchemaRefPrefix)
+		}
+		for _, child := range v {
+			convertOpenAPI(child)
+		}
+	case []any:
+		for _, child := range v {
+			convertOpenAPI(child)
+		}
+	}
+}
+
+// allowNull makes an untyped schema also accept null by moving its keywords
+// into the first branch of an anyOf. A schema with no keywords already
+// accepts null and is left alone.
+func allowNull(obj map[string]any) {
+	if len(obj) == 0 {
+		return
+	}
+	inner := make(map[string]any, len(obj))
+	for key, value := range obj {
+		inner[key] = value
+		delete(obj, key)
+	}
+	obj["anyOf"] = []any{inner, map[string]any{"type": "null"}}
+}
+
+// convertExclusiveBound turns OpenAPI's boolean exclusive flag into the
+// JSON Schema numeric form.
+func convertExclusiveBound(obj map[string]any, exclusiveKey, boundKey string) {
+	exclusive, ok := obj[exclusiveKey].(bool)
+	if !ok {
+		return
+	}
+	delete(obj, exclusiveKey)
+	if bound, ok := obj[boundKey]; ok && exclusive {
+		obj[exclusiveKey] = bound
+		delete(obj, boundKey)
+	}
+}
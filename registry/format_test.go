@@ -0,0 +1,135 @@
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openAPIEndpointSchema = `{
+	"openapi": "3.0.3",
+	"type": "object",
+	"required": ["url"],
+	"properties": {
+		"url": {"type": "string", "example": "https://example.com"},
+		"timeout": {"type": "number", "minimum": 0, "exclusiveMinimum": true},
+		"proxy": {"type": "string", "nullable": true},
+		"retry": {"$ref": "#/components/schemas/Retry"}
+	},
+	"components": {
+		"schemas": {
+			"Retry": {
+				"type": "object",
+				"properties": {"attempts": {"type": "integer", "maximum": 10}}
+			}
+		}
+	}
+}`
+
+func TestDetectSchemaFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   registry.SchemaFormat
+	}{
+		{"JSONSchema", `{"type": "object", "properties": {"a": {"type": "string"}}}`, registry.SchemaFormatJSONSchema},
+		{"OpenAPIDocumentFields", openAPIEndpointSchema, registry.SchemaFormatOpenAPI},
+		{"Nullable", `{"type": "object", "properties": {"a": {"type": "string", "nullable": true}}}`, registry.SchemaFormatOpenAPI},
+		{"BooleanExclusiveMinimum", `{"type": "number", "minimum": 1, "exclusiveMinimum": true}`, registry.SchemaFormatOpenAPI},
+		{"NumericExclusiveMinimum", `{"type": "number", "exclusiveMinimum": 1}`, registry.SchemaFormatJSONSchema},
+		{"ComponentsRef", `{"items": {"$ref": "#/components/schemas/Item"}}`, registry.SchemaFormatOpenAPI},
+		{"Draft04ExclusiveMinimum", `{"$schema": "http://json-schema.org/draft-04/schema#", "type": "number", "minimum": 1, "exclusiveMinimum": true}`, registry.SchemaFormatJSONSchema},
+		{"NotJSON", `not a schema`, registry.SchemaFormatJSONSchema},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, registry.DetectSchemaFormat(tt.schema))
+		})
+	}
+}
+
+func TestNormalizeSchema_JSONSchemaUnchanged(t *testing.T) {
+	schema := `{"type": "object", "properties": {"a": {"type": "string"}}}`
+	normalized, format, err := registry.NormalizeSchema(schema)
+	require.NoError(t, err)
+	assert.Equal(t, registry.SchemaFormatJSONSchema, format)
+	assert.Equal(t, schema, normalized)
+}
+
+func TestNormalizeSchema_OpenAPI(t *testing.T) {
+	normalized, format, err := registry.NormalizeSchema(openAPIEndpointSchema)
+	require.NoError(t, err)
+	assert.Equal(t, registry.SchemaFormatOpenAPI, format)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"required": ["url"],
+		"properties": {
+			"url": {"type": "string", "examples": ["https://example.com"]},
+			"timeout": {"type": "number", "exclusiveMinimum": 0},
+			"proxy": {"type": ["string", "null"]},
+			"retry": {"$ref": "#/$defs/Retry"}
+		},
+		"$defs": {
+			"Retry": {
+				"type": "object",
+				"properties": {"attempts": {"type": "integer", "maximum": 10}}
+			}
+		}
+	}`, normalized)
+}
+
+func TestNormalizeSchema_Draft04Unchanged(t *testing.T) {
+	schema := `{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "object",
+		"properties": {"port": {"type": "integer", "maximum": 65536, "exclusiveMaximum": true}}
+	}`
+	normalized, format, err := registry.NormalizeSchema(schema)
+	require.NoError(t, err)
+	assert.Equal(t, registry.SchemaFormatJSONSchema, format)
+	assert.Equal(t, schema, normalized)
+}
+
+func TestNormalizeSchema_NullableWithoutType(t *testing.T) {
+	normalized, _, err := registry.NormalizeSchema(`{
+		"properties": {
+			"retry": {"$ref": "#/components/schemas/Retry", "nullable": true},
+			"anything": {"nullable": true}
+		},
+		"components": {"schemas": {"Retry": {"type": "object"}}}
+	}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"properties": {
+			"retry": {"anyOf": [{"$ref": "#/$defs/Retry"}, {"type": "null"}]},
+			"anything": {}
+		},
+		"$defs": {"Retry": {"type": "object"}}
+	}`, normalized)
+}
+
+func TestRegistry_Register_OpenAPI(t *testing.T) {
+	reg := registry.NewRegistry()
+	require.NoError(t, reg.Register("endpoint", openAPIEndpointSchema))
+
+	stored, ok := reg.GetSchema("endpoint")
+	require.True(t, ok)
+	assert.Equal(t, registry.SchemaFormatJSONSchema, registry.DetectSchemaFormat(stored))
+
+	schema := compileResolved(t, reg, "endpoint")
+	assert.NoError(t, schema.Validate(map[string]any{
+		"url":     "https://example.com",
+		"timeout": float64(5),
+		"proxy":   nil,
+		"retry":   map[string]any{"attempts": float64(3)},
+	}))
+	assert.Error(t, schema.Validate(map[string]any{"url": "https://example.com", "timeout": float64(0)}),
+		"exclusive minimum should be enforced")
+	assert.Error(t, schema.Validate(map[string]any{"url": "https://example.com", "retry": map[string]any{"attempts": float64(11)}}),
+		"referenced component should be enforced")
+	assert.Error(t, schema.Validate(map[string]any{"timeout": float64(5)}))
+}
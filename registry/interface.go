@@ -6,9 +6,29 @@ type CapabilityRegistry interface {
 	// model can be a struct (to generate schema) or a JSON schema string/map.
 	Register(kind string, model interface{}) error
 
+	// RegisterAll adds schemas for several kinds. Either all are registered
+	// or, on error, none are.
+	RegisterAll(models map[string]interface{}) error
+
 	// GetSchema returns the JSON schema for a capability kind.
 	GetSchema(kind string) (string, bool)
 
-	// List returns all registered capability kinds.
+	// List returns all registered capability kinds, sorted by name.
 	List() []string
 }
+
+// SchemaResolver produces self-contained schemas. Registries that implement it
+// let a schema $ref other registered kinds by name, e.g. {"$ref": "common"} or
+// {"$ref": "common#/$defs/port"}.
+type SchemaResolver interface {
+	// ResolveSchema returns the schema for kind with every $ref to another
+	// registered kind inlined, so it can be compiled on its own.
+	ResolveSchema(kind string) (string, error)
+}
+
+// PayloadValidator checks documents against registered schemas.
+type PayloadValidator interface {
+	// Validate returns the ways payload fails to match the schema for kind;
+	// none means it conforms.
+	Validate(kind string, payload []byte) ([]ValidationError, error)
+}
@@ -0,0 +1,74 @@
+package registry_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	stringSchema = `{"type":"string"}`
+	numberSchema = `{"type":"number"}`
+)
+
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+	return &buf
+}
+
+func TestRegistry_Register_DuplicateErrorsByDefault(t *testing.T) {
+	reg := registry.NewRegistry()
+	require.NoError(t, reg.Register("kind", stringSchema))
+
+	err := reg.Register("kind", numberSchema)
+	require.ErrorContains(t, err, "already registered")
+	err = reg.RegisterAll(map[string]interface{}{"kind": numberSchema})
+	require.ErrorContains(t, err, "already registered")
+
+	got, _ := reg.GetSchema("kind")
+	assert.JSONEq(t, stringSchema, got)
+}
+
+func TestRegistry_WithAllowOverride(t *testing.T) {
+	logs := captureWarnings(t)
+	reg := registry.NewRegistry(registry.WithAllowOverride(true))
+	require.NoError(t, reg.Register("kind", stringSchema))
+	assert.Empty(t, logs.String(), "a first registration is not an override")
+
+	require.NoError(t, reg.Register("kind", numberSchema))
+	got, _ := reg.GetSchema("kind")
+	assert.JSONEq(t, numberSchema, got)
+	assert.Contains(t, logs.String(), "overriding capability schema")
+	assert.Contains(t, logs.String(), "kind=kind")
+
+	require.NoError(t, reg.RegisterAll(map[string]interface{}{"kind": stringSchema, "other": numberSchema}))
+	got, _ = reg.GetSchema("kind")
+	assert.JSONEq(t, stringSchema, got)
+}
+
+func TestRegistry_Update(t *testing.T) {
+	reg := registry.NewRegistry().(*registry.Registry)
+	require.ErrorContains(t, reg.Update("kind", numberSchema), "not registered")
+
+	require.NoError(t, reg.Register("kind", stringSchema))
+	errs, err := reg.Validate("kind", []byte(`"text"`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	require.NoError(t, reg.Update("kind", numberSchema))
+	got, _ := reg.GetSchema("kind")
+	assert.JSONEq(t, numberSchema, got)
+
+	// Validate sees the new schema, not a cached compile of the old one.
+	errs, err = reg.Validate("kind", []byte(`"text"`))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
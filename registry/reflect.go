@@ -0,0 +1,154 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// WithReflectorConfig customizes the reflector used to generate schemas from
+// Go structs, e.g. to set DoNotReference, a BaseSchemaID, or a Mapper.
+// configure runs once, after the registry's defaults are applied.
+func WithReflectorConfig(configure func(*jsonschema.Reflector)) RegistryOption {
+	return func(r *Registry) {
+		configure(r.reflector)
+	}
+}
+
+// reflectSchema generates the schema for a Go struct. Besides the
+// reflector's own tags it reads plain `description:"..."` and
+// `example:"..."` struct tags, and it emits object keys in sorted order so
+// the output is byte-for-byte stable.
+func (r *Registry) reflectSchema(model interface{}) ([]byte, error) {
+	reflector := *r.reflector
+	reflector.LookupComment = descriptionTagLookup(r.reflector.LookupComment)
+
+	t := reflect.TypeOf(model)
+	s := reflector.ReflectFromType(t)
+	applyExampleTags(s, s.Definitions, t, fieldNameTag(&reflector), map[reflect.Type]bool{})
+
+	return sortedJSON(s)
+}
+
+// descriptionTagLookup returns a LookupComment that prefers a field's
+// `description` tag over next.
+func descriptionTagLookup(next func(reflect.Type, string) string) func(reflect.Type, string) string {
+	return func(t reflect.Type, field string) string {
+		if field != "" && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(field); ok {
+				if d := f.Tag.Get("description"); d != "" {
+					return d
+				}
+			}
+		}
+		if next != nil {
+			return next(t, field)
+		}
+		return ""
+	}
+}
+
+// applyExampleTags walks t alongside its schema s and adds the value of each
+// field's `example` tag to the property's examples. Tag values that are
+// valid JSON are added as JSON ("8080" becomes a number); others as strings.
+func applyExampleTags(s *jsonschema.Schema, defs jsonschema.Definitions, t reflect.Type, nameTag string, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s == nil || s.Properties == nil || t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := fieldName(f, nameTag)
+		if name == "" {
+			if f.Anonymous {
+				applyExampleTags(s, defs, f.Type, nameTag, seen)
+			}
+			continue
+		}
+		prop, ok := s.Properties.Get(name)
+		if !ok {
+			continue
+		}
+		if ex, ok := f.Tag.Lookup("example"); ok && len(prop.Examples) == 0 {
+			prop.Examples = []any{exampleValue(ex)}
+		}
+		applyExampleTags(nestedSchema(prop, defs), defs, elemType(f.Type), nameTag, seen)
+	}
+}
+
+// nestedSchema returns the object schema behind a property: itself, its
+// array items, or the definition it references.
+func nestedSchema(prop *jsonschema.Schema, defs jsonschema.Definitions) *jsonschema.Schema {
+	if prop.Items != nil {
+		prop = prop.Items
+	}
+	if name, ok := strings.CutPrefix(prop.Ref, "#/$defs/"); ok {
+		return defs[name]
+	}
+	return prop
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldName returns the property name of f, or "" if it is unexported,
+// ignored or embedded without a name.
+func fieldName(f reflect.StructField, nameTag string) string {
+	if !f.IsExported() && !f.Anonymous {
+		return ""
+	}
+	tag := f.Tag.Get(nameTag)
+	name, _, _ := strings.Cut(tag, ",")
+	switch {
+	case name == "-":
+		return ""
+	case name != "":
+		return name
+	case f.Anonymous:
+		return ""
+	default:
+		return f.Name
+	}
+}
+
+func fieldNameTag(r *jsonschema.Reflector) string {
+	if r.FieldNameTag != "" {
+		return r.FieldNameTag
+	}
+	return "json"
+}
+
+func exampleValue(tag string) any {
+	var v any
+	if err := json.Unmarshal([]byte(tag), &v); err == nil {
+		return v
+	}
+	return tag
+}
+
+// sortedJSON marshals v as indented JSON with every object's keys sorted.
+func sortedJSON(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// Decode numbers as json.Number so large integers keep their precision.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(generic, "", "  ")
+}
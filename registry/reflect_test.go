@@ -0,0 +1,92 @@
+package registry_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/reglet-dev/reglet-host-sdk/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type endpointConfig struct {
+	Host    string   `json:"host" description:"Hostname to probe" example:"db.internal"`
+	Port    int      `json:"port" description:"TCP port" example:"5432"`
+	Tags    []string `json:"tags,omitempty" jsonschema:"description=Set by jsonschema tag"`
+	Limits  limits   `json:"limits"`
+	private string
+}
+
+type limits struct {
+	Timeout string `json:"timeout" description:"Per-attempt timeout" example:"5s"`
+}
+
+func registerEndpoint(t *testing.T, opts ...registry.RegistryOption) map[string]any {
+	t.Helper()
+	reg := registry.NewRegistry(opts...)
+	require.NoError(t, reg.Register("endpoint", endpointConfig{}))
+	raw, ok := reg.GetSchema("endpoint")
+	require.True(t, ok)
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal([]byte(raw), &schema))
+	return schema
+}
+
+func TestRegistry_Register_DescriptionAndExampleTags(t *testing.T) {
+	schema := registerEndpoint(t)
+	props := schema["properties"].(map[string]any)
+
+	host := props["host"].(map[string]any)
+	assert.Equal(t, "Hostname to probe", host["description"])
+	assert.Equal(t, []any{"db.internal"}, host["examples"])
+
+	port := props["port"].(map[string]any)
+	assert.Equal(t, []any{float64(5432)}, port["examples"], "JSON examples keep their type")
+
+	tags := props["tags"].(map[string]any)
+	assert.Equal(t, "Set by jsonschema tag", tags["description"])
+
+	// The nested struct lives in $defs by default.
+	limits := schema["$defs"].(map[string]any)["limits"].(map[string]any)["properties"].(map[string]any)
+	timeout := limits["timeout"].(map[string]any)
+	assert.Equal(t, "Per-attempt timeout", timeout["description"])
+	assert.Equal(t, []any{"5s"}, timeout["examples"])
+}
+
+func TestRegistry_Register_Deterministic(t *testing.T) {
+	first := registry.NewRegistry()
+	require.NoError(t, first.Register("endpoint", endpointConfig{}))
+	want, _ := first.GetSchema("endpoint")
+
+	for i := 0; i < 10; i++ {
+		reg := registry.NewRegistry()
+		require.NoError(t, reg.Register("endpoint", endpointConfig{}))
+		got, _ := reg.GetSchema("endpoint")
+		assert.Equal(t, want, got)
+	}
+
+	// Keys are sorted at every level, not in struct field order.
+	props := want[strings.LastIndex(want, `"properties"`):]
+	assert.Less(t, strings.Index(props, `"host"`), strings.Index(props, `"limits"`))
+	assert.Less(t, strings.Index(props, `"limits"`), strings.Index(props, `"port"`))
+	assert.Less(t, strings.Index(want, `"$defs"`), strings.Index(want, `"$id"`))
+}
+
+func TestRegistry_WithReflectorConfig(t *testing.T) {
+	schema := registerEndpoint(t, registry.WithReflectorConfig(func(r *jsonschema.Reflector) {
+		r.DoNotReference = true
+		r.BaseSchemaID = "https://schemas.example.com/"
+	}))
+
+	assert.Equal(t, "https://schemas.example.com/endpoint-config", schema["$id"])
+	assert.NotContains(t, schema, "$defs")
+
+	// Inlined nested structs still get their tags.
+	limits := schema["properties"].(map[string]any)["limits"].(map[string]any)
+	timeout := limits["properties"].(map[string]any)["timeout"].(map[string]any)
+	assert.Equal(t, "Per-attempt timeout", timeout["description"])
+	assert.Equal(t, []any{"5s"}, timeout["examples"])
+}
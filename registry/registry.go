@@ -4,10 +4,14 @@ package registry
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"maps"
 	"reflect"
+	"sort"
 	"sync"
 
 	"github.com/invopop/jsonschema"
+	validator "github.com/santhosh-tekuri/jsonschema/v5"
 )
 
 // Registry implements ports.CapabilityRegistry using in-memory storage.
@@ -15,19 +19,36 @@ type Registry struct {
 	schemas    map[string]string
 	mu         sync.RWMutex
 	strictMode bool
+	override   bool
 	reflector  *jsonschema.Reflector
+
+	// Compiled schemas for Validate, keyed by kind.
+	compiledMu sync.Mutex
+	compiled   map[string]*validator.Schema
+	generation uint64 // bumped whenever a schema changes
 }
 
 // RegistryOption configures the Registry.
 type RegistryOption func(*Registry)
 
-// WithStrictMode enables strict validation mode (if applicable).
+// WithStrictMode controls whether Validate rejects properties an object
+// schema does not declare, unless the schema sets additionalProperties
+// itself. It is on by default.
 func WithStrictMode(strict bool) RegistryOption {
 	return func(r *Registry) {
 		r.strictMode = strict
 	}
 }
 
+// WithAllowOverride makes Register and RegisterAll replace the schema of an
+// already registered kind instead of failing. Each replacement is logged as
+// a warning so accidental clobbers stay visible.
+func WithAllowOverride(allow bool) RegistryOption {
+	return func(r *Registry) {
+		r.override = allow
+	}
+}
+
 // NewRegistry creates a new capability registry.
 func NewRegistry(opts ...RegistryOption) CapabilityRegistry {
 	r := &Registry{
@@ -52,21 +73,137 @@ func (r *Registry) Register(kind string, model interface{}) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.schemas[kind]; exists {
+	_, exists := r.schemas[kind]
+	if exists && !r.override {
 		return fmt.Errorf("capability kind already registered: %s", kind)
 	}
 
-	var schemaStr string
+	schemaStr, err := r.schemaFor(model)
+	if err != nil {
+		return err
+	}
+	if exists {
+		slog.Warn("registry: overriding capability schema", "kind", kind)
+	}
+	r.store(kind, schemaStr)
+	return nil
+}
+
+// Update replaces the schema of an already registered kind, regardless of
+// WithAllowOverride. It fails if kind is not registered.
+func (r *Registry) Update(kind string, model interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.schemas[kind]; !exists {
+		return fmt.Errorf("capability kind not registered: %s", kind)
+	}
+	schemaStr, err := r.schemaFor(model)
+	if err != nil {
+		return err
+	}
+	r.store(kind, schemaStr)
+	return nil
+}
+
+// store sets the schema for kind. The caller must hold r.mu.
+func (r *Registry) store(kind, schemaStr string) {
+	r.schemas[kind] = schemaStr
+	r.invalidateCompiled()
+}
+
+// invalidateCompiled drops compiled schemas, which may have inlined a
+// schema that just changed.
+func (r *Registry) invalidateCompiled() {
+	r.compiledMu.Lock()
+	r.compiled = nil
+	r.generation++
+	r.compiledMu.Unlock()
+}
+
+// Unregister removes the schema for kind, e.g. when the plugin that
+// provided it is unloaded. It reports whether kind was registered.
+func (r *Registry) Unregister(kind string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.schemas[kind]; !exists {
+		return false
+	}
+	delete(r.schemas, kind)
+	r.invalidateCompiled()
+	return true
+}
+
+// Snapshot returns a copy of every registered schema, keyed by kind, for a
+// later Restore.
+func (r *Registry) Snapshot() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.schemas)
+}
+
+// Restore replaces the registry's contents with snapshot, typically one
+// taken by Snapshot before a plugin reload that has to be rolled back.
+// Schemas are stored as given, without normalization.
+func (r *Registry) Restore(snapshot map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas = maps.Clone(snapshot)
+	if r.schemas == nil {
+		r.schemas = make(map[string]string)
+	}
+	r.invalidateCompiled()
+}
+
+// RegisterAll adds schemas for several capability kinds at once.
+// Every entry is checked and converted before any is stored, so on error the
+// registry is left exactly as it was.
+func (r *Registry) RegisterAll(models map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kinds := make([]string, 0, len(models))
+	for kind := range models {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds) // report the same error for the same input
+
+	staged := make(map[string]string, len(models))
+	for _, kind := range kinds {
+		if _, exists := r.schemas[kind]; exists && !r.override {
+			return fmt.Errorf("capability kind already registered: %s", kind)
+		}
+		schemaStr, err := r.schemaFor(models[kind])
+		if err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+		staged[kind] = schemaStr
+	}
+
+	for _, kind := range kinds {
+		if _, exists := r.schemas[kind]; exists {
+			slog.Warn("registry: overriding capability schema", "kind", kind)
+		}
+		r.store(kind, staged[kind])
+	}
+	return nil
+}
 
+// schemaFor converts a Register model to a JSON schema string. Raw schemas in
+// other formats are normalized to JSON Schema (see NormalizeSchema).
+// The caller must hold r.mu.
+func (r *Registry) schemaFor(model interface{}) (string, error) {
 	switch v := model.(type) {
 	case string:
-		schemaStr = v
+		return normalizeRaw(v)
 	case map[string]interface{}:
 		b, err := json.Marshal(v)
 		if err != nil {
-			return fmt.Errorf("failed to marshal schema map: %w", err)
+			return "", fmt.Errorf("failed to marshal schema map: %w", err)
 		}
-		schemaStr = string(b)
+		return normalizeRaw(string(b))
 	default:
 		// Assume it's a Go struct, generate schema
 		if reflect.ValueOf(model).Kind() != reflect.Struct {
@@ -74,27 +211,24 @@ func (r *Registry) Register(kind string, model interface{}) error {
 			t := reflect.TypeOf(model)
 			if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
 				// OK
-			} else {
-				// Fallback: try marshaling as JSON (e.g. byte slice representing schema)
-				if b, ok := model.([]byte); ok {
-					schemaStr = string(b)
-					goto Save
-				}
-				// If strictly strict, maybe error? But for now let's try jsonschema reflection anyway
+			} else if b, ok := model.([]byte); ok {
+				// Fallback: a byte slice representing the schema
+				return normalizeRaw(string(b))
 			}
+			// If strictly strict, maybe error? But for now let's try jsonschema reflection anyway
 		}
 
-		s := r.reflector.Reflect(model)
-		b, err := json.MarshalIndent(s, "", "  ")
+		b, err := r.reflectSchema(model)
 		if err != nil {
-			return fmt.Errorf("failed to marshal generated schema: %w", err)
+			return "", fmt.Errorf("failed to marshal generated schema: %w", err)
 		}
-		schemaStr = string(b)
+		return string(b), nil
 	}
+}
 
-Save:
-	r.schemas[kind] = schemaStr
-	return nil
+func normalizeRaw(schema string) (string, error) {
+	normalized, _, err := NormalizeSchema(schema)
+	return normalized, err
 }
 
 // GetSchema retrieves the JSON Schema for a capability type.
@@ -105,7 +239,7 @@ func (r *Registry) GetSchema(kind string) (string, bool) {
 	return s, ok
 }
 
-// List returns all registered capability type names.
+// List returns all registered capability type names in sorted order.
 func (r *Registry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -113,5 +247,6 @@ func (r *Registry) List() []string {
 	for k := range r.schemas {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
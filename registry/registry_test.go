@@ -0,0 +1,158 @@
+package registry_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/registry"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_List_Sorted(t *testing.T) {
+	kinds := []string{"network", "exec", "kv", "fs", "env", "secret", "dns"}
+
+	reg := registry.NewRegistry()
+	for _, kind := range kinds {
+		require.NoError(t, reg.Register(kind, map[string]any{"type": "object"}))
+	}
+
+	want := []string{"dns", "env", "exec", "fs", "kv", "network", "secret"}
+	// Map iteration order varies between calls; the result must not.
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, want, reg.List())
+	}
+}
+
+func TestRegistry_List_Empty(t *testing.T) {
+	assert.Empty(t, registry.NewRegistry().List())
+}
+
+func compileResolved(t *testing.T, reg registry.CapabilityRegistry, kind string) *jsonschema.Schema {
+	t.Helper()
+	resolver, ok := reg.(registry.SchemaResolver)
+	require.True(t, ok, "registry should implement SchemaResolver")
+
+	resolved, err := resolver.ResolveSchema(kind)
+	require.NoError(t, err)
+
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource(kind, strings.NewReader(resolved)))
+	schema, err := compiler.Compile(kind)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestRegistry_ResolveSchema(t *testing.T) {
+	reg := registry.NewRegistry()
+	require.NoError(t, reg.Register("common", `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$defs": {
+			"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+			"host": {"type": "string", "minLength": 1}
+		}
+	}`))
+	require.NoError(t, reg.Register("endpoint", `{
+		"type": "object",
+		"required": ["host", "port"],
+		"properties": {
+			"host": {"$ref": "common#/$defs/host"},
+			"port": {"$ref": "common#/$defs/port"}
+		}
+	}`))
+	require.NoError(t, reg.Register("network", `{
+		"type": "object",
+		"properties": {
+			"targets": {"type": "array", "items": {"$ref": "endpoint"}},
+			"fallback": {"$ref": "#/$defs/target"}
+		},
+		"$defs": {"target": {"$ref": "endpoint"}}
+	}`))
+
+	schema := compileResolved(t, reg, "network")
+
+	assert.NoError(t, schema.Validate(map[string]any{
+		"targets":  []any{map[string]any{"host": "example.com", "port": float64(443)}},
+		"fallback": map[string]any{"host": "backup.example.com", "port": float64(8443)},
+	}))
+	assert.Error(t, schema.Validate(map[string]any{
+		"targets": []any{map[string]any{"host": "example.com", "port": float64(70000)}},
+	}), "port from the common schema should be enforced")
+	assert.Error(t, schema.Validate(map[string]any{
+		"fallback": map[string]any{"host": ""},
+	}), "nested references should be resolved")
+
+	// The stored schema is left untouched.
+	raw, ok := reg.GetSchema("network")
+	require.True(t, ok)
+	assert.Contains(t, raw, `"$ref": "endpoint"`)
+}
+
+func TestRegistry_ResolveSchema_SelfContained(t *testing.T) {
+	reg := registry.NewRegistry()
+	schema := `{"type": "object", "properties": {"name": {"type": "string"}}}`
+	require.NoError(t, reg.Register("env", schema))
+
+	resolved, err := reg.(registry.SchemaResolver).ResolveSchema("env")
+	require.NoError(t, err)
+	assert.JSONEq(t, schema, resolved)
+}
+
+func TestRegistry_ResolveSchema_Recursive(t *testing.T) {
+	reg := registry.NewRegistry()
+	require.NoError(t, reg.Register("a", `{"type": "object", "properties": {"b": {"$ref": "b"}}}`))
+	require.NoError(t, reg.Register("b", `{"type": "object", "properties": {"a": {"$ref": "a"}}}`))
+
+	schema := compileResolved(t, reg, "a")
+	assert.NoError(t, schema.Validate(map[string]any{"b": map[string]any{"a": map[string]any{}}}))
+	assert.Error(t, schema.Validate(map[string]any{"b": map[string]any{"a": "not an object"}}))
+}
+
+func TestRegistry_ResolveSchema_Errors(t *testing.T) {
+	reg := registry.NewRegistry()
+	resolver := reg.(registry.SchemaResolver)
+
+	_, err := resolver.ResolveSchema("missing")
+	assert.Error(t, err)
+
+	require.NoError(t, reg.Register("common", `{"$defs": {"id": {"$anchor": "id", "type": "string"}}}`))
+	require.NoError(t, reg.Register("anchored", `{"$ref": "common#id"}`))
+	_, err = resolver.ResolveSchema("anchored")
+	assert.Error(t, err, "anchors in other schemas cannot be resolved")
+}
+
+func TestRegistry_RegisterAll(t *testing.T) {
+	type execConfig struct {
+		Commands []string `json:"commands"`
+	}
+
+	reg := registry.NewRegistry()
+	require.NoError(t, reg.RegisterAll(map[string]interface{}{
+		"network": `{"type": "object"}`,
+		"fs":      map[string]interface{}{"type": "object"},
+		"exec":    execConfig{},
+	}))
+
+	assert.Equal(t, []string{"exec", "fs", "network"}, reg.List())
+	schema, ok := reg.GetSchema("exec")
+	require.True(t, ok)
+	assert.Contains(t, schema, "commands")
+}
+
+func TestRegistry_RegisterAll_RollsBackOnDuplicate(t *testing.T) {
+	reg := registry.NewRegistry()
+	require.NoError(t, reg.Register("fs", `{"type": "object"}`))
+
+	err := reg.RegisterAll(map[string]interface{}{
+		"env":     `{"type": "object"}`,
+		"fs":      `{"type": "string"}`,
+		"network": `{"type": "object"}`,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered: fs")
+
+	assert.Equal(t, []string{"fs"}, reg.List(), "no kind from the failed batch should be registered")
+	schema, _ := reg.GetSchema("fs")
+	assert.JSONEq(t, `{"type": "object"}`, schema)
+}
@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defsPrefix namespaces inlined kinds inside the root $defs so they cannot
+// collide with definitions the root schema already has.
+const defsPrefix = "registry:"
+
+// ResolveSchema returns the schema for kind with $refs to other registered
+// kinds inlined under the root "$defs". References are rewritten to JSON
+// pointers into the bundled document, including fragment references inside
+// the inlined schemas. $refs that do not name a registered kind are left as-is.
+func (r *Registry) ResolveSchema(kind string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	root, err := r.decodeSchema(kind)
+	if err != nil {
+		return "", err
+	}
+
+	b := &schemaBundler{registry: r, root: kind, defs: make(map[string]any)}
+	if err := b.rewrite(root, ""); err != nil {
+		return "", err
+	}
+	// Inlining may pull in further kinds; process until none are left.
+	for len(b.pending) > 0 {
+		next := b.pending[0]
+		b.pending = b.pending[1:]
+		schema, err := r.decodeSchema(next)
+		if err != nil {
+			return "", err
+		}
+		if obj, ok := schema.(map[string]any); ok {
+			delete(obj, "$id")
+			delete(obj, "$schema")
+		}
+		if err := b.rewrite(schema, next); err != nil {
+			return "", err
+		}
+		b.defs[defsPrefix+next] = schema
+	}
+
+	if len(b.defs) > 0 {
+		obj, ok := root.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("schema for %s must be an object to reference other schemas", kind)
+		}
+		defs, _ := obj["$defs"].(map[string]any)
+		if defs == nil {
+			defs = make(map[string]any, len(b.defs))
+			obj["$defs"] = defs
+		}
+		for name, schema := range b.defs {
+			defs[name] = schema
+		}
+	}
+
+	out, err := json.Marshal(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal resolved schema for %s: %w", kind, err)
+	}
+	return string(out), nil
+}
+
+// decodeSchema parses a registered schema. The caller must hold r.mu.
+func (r *Registry) decodeSchema(kind string) (any, error) {
+	raw, ok := r.schemas[kind]
+	if !ok {
+		return nil, fmt.Errorf("capability kind not registered: %s", kind)
+	}
+	var schema any
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema for %s: %w", kind, err)
+	}
+	return schema, nil
+}
+
+// schemaBundler tracks the kinds being inlined into one resolved schema.
+type schemaBundler struct {
+	registry *Registry
+	root     string
+	defs     map[string]any
+	queued   map[string]bool
+	pending  []string
+}
+
+// rewrite walks node, which belongs to the schema of owner ("" for the root),
+// and points its $refs into the bundled document.
+func (b *schemaBundler) rewrite(node any, owner string) error {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				resolved, err := b.resolveRef(ref, owner)
+				if err != nil {
+					return err
+				}
+				v[key] = resolved
+				continue
+			}
+			if err := b.rewrite(child, owner); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := b.rewrite(child, owner); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *schemaBundler) resolveRef(ref, owner string) (string, error) {
+	base, fragment, _ := strings.Cut(ref, "#")
+	if fragment != "" && !strings.HasPrefix(fragment, "/") {
+		if base == "" && owner == "" {
+			return ref, nil // anchor within the root document
+		}
+		return "", fmt.Errorf("$ref %q in schema %s: only JSON pointer fragments can be resolved across schemas", ref, b.name(owner))
+	}
+
+	target := owner
+	if base != "" {
+		if _, ok := b.registry.schemas[base]; !ok {
+			return ref, nil
+		}
+		target = base
+	}
+	if target == "" || target == b.root {
+		return "#" + fragment, nil
+	}
+
+	if !b.queued[target] {
+		if b.queued == nil {
+			b.queued = make(map[string]bool)
+		}
+		b.queued[target] = true
+		b.pending = append(b.pending, target)
+	}
+	return "#/$defs/" + escapePointer(defsPrefix+target) + fragment, nil
+}
+
+func (b *schemaBundler) name(owner string) string {
+	if owner == "" {
+		return b.root
+	}
+	return owner
+}
+
+// escapePointer escapes a JSON pointer reference token (RFC 6901).
+func escapePointer(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
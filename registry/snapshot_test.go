@@ -0,0 +1,89 @@
+package registry_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Unregister(t *testing.T) {
+	reg := registry.NewRegistry().(*registry.Registry)
+	require.NoError(t, reg.Register("network", `{"type":"object"}`))
+	require.NoError(t, reg.Register("fs", `{"type":"object"}`))
+
+	assert.True(t, reg.Unregister("network"))
+	_, ok := reg.GetSchema("network")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"fs"}, reg.List())
+
+	assert.False(t, reg.Unregister("network"), "already removed")
+
+	// The kind can be registered again afterwards.
+	require.NoError(t, reg.Register("network", `{"type":"string"}`))
+}
+
+func TestRegistry_SnapshotRestore(t *testing.T) {
+	reg := registry.NewRegistry().(*registry.Registry)
+	require.NoError(t, reg.Register("network", `{"type":"object"}`))
+	require.NoError(t, reg.Register("fs", `{"type":"string"}`))
+
+	snap := reg.Snapshot()
+
+	require.NoError(t, reg.Update("fs", `{"type":"number"}`))
+	require.NoError(t, reg.Register("exec", `{"type":"object"}`))
+	reg.Unregister("network")
+
+	errs, err := reg.Validate("fs", []byte(`"text"`))
+	require.NoError(t, err)
+	require.Len(t, errs, 1, "fs now expects a number")
+
+	reg.Restore(snap)
+	assert.Equal(t, []string{"fs", "network"}, reg.List())
+	got, _ := reg.GetSchema("fs")
+	assert.JSONEq(t, `{"type":"string"}`, got)
+
+	errs, err = reg.Validate("fs", []byte(`"text"`))
+	require.NoError(t, err)
+	assert.Empty(t, errs, "validation uses the restored schema")
+
+	// The snapshot is a copy in both directions.
+	snap["fs"] = `{"type":"boolean"}`
+	got, _ = reg.GetSchema("fs")
+	assert.JSONEq(t, `{"type":"string"}`, got)
+	reg.Snapshot()["network"] = "mutated"
+	got, _ = reg.GetSchema("network")
+	assert.JSONEq(t, `{"type":"object"}`, got)
+}
+
+func TestRegistry_RestoreNil(t *testing.T) {
+	reg := registry.NewRegistry().(*registry.Registry)
+	require.NoError(t, reg.Register("network", `{"type":"object"}`))
+
+	reg.Restore(nil)
+	assert.Empty(t, reg.List())
+	require.NoError(t, reg.Register("network", `{"type":"object"}`))
+}
+
+func TestRegistry_SnapshotConcurrent(t *testing.T) {
+	reg := registry.NewRegistry().(*registry.Registry)
+	require.NoError(t, reg.Register("base", `{"type":"object"}`))
+	snap := reg.Snapshot()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_ = reg.Snapshot()
+				reg.Restore(snap)
+				reg.Unregister("base")
+				_, _ = reg.GetSchema("base")
+			}
+		}()
+	}
+	wg.Wait()
+}
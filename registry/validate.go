@@ -0,0 +1,160 @@
+package registry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	validator "github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ValidationError is one reason a payload does not match its schema.
+type ValidationError struct {
+	// Field is the JSON pointer to the offending value, e.g. "/ports/0";
+	// empty for the payload as a whole.
+	Field string
+	// Message describes the violation.
+	Message string
+}
+
+func (e ValidationError) String() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks payload, a JSON document, against the schema registered for
+// kind. It returns one ValidationError per violation, or none if the payload
+// conforms; the error is reserved for an unknown kind, an uncompilable schema
+// or a payload that is not JSON. In strict mode (the default) object schemas
+// that don't say otherwise reject properties they don't declare. Compiled
+// schemas are cached per kind.
+func (r *Registry) Validate(kind string, payload []byte) ([]ValidationError, error) {
+	schema, err := r.compiledSchema(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("payload is not valid JSON: %w", err)
+	}
+
+	err = schema.Validate(doc)
+	var ve *validator.ValidationError
+	if errors.As(err, &ve) {
+		return collectValidationErrors(ve), nil
+	}
+	return nil, err
+}
+
+// compiledSchema returns the cached compiled schema for kind, compiling it
+// on first use.
+func (r *Registry) compiledSchema(kind string) (*validator.Schema, error) {
+	r.compiledMu.Lock()
+	s, ok := r.compiled[kind]
+	generation := r.generation
+	r.compiledMu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	// Compile without holding compiledMu: ResolveSchema takes r.mu, which
+	// writers hold while they invalidate the cache.
+
+	resolved, err := r.ResolveSchema(kind)
+	if err != nil {
+		return nil, err
+	}
+	if r.strictMode {
+		if resolved, err = closeObjectSchemas(resolved); err != nil {
+			return nil, err
+		}
+	}
+
+	url := "registry:///" + kind
+	compiler := validator.NewCompiler()
+	if err := compiler.AddResource(url, strings.NewReader(resolved)); err != nil {
+		return nil, fmt.Errorf("load schema for %s: %w", kind, err)
+	}
+	s, err = compiler.Compile(url)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema for %s: %w", kind, err)
+	}
+
+	r.compiledMu.Lock()
+	defer r.compiledMu.Unlock()
+	// Don't cache a schema that was replaced while we compiled it.
+	if r.generation == generation {
+		if r.compiled == nil {
+			r.compiled = make(map[string]*validator.Schema)
+		}
+		r.compiled[kind] = s
+	}
+	return s, nil
+}
+
+// closeObjectSchemas sets "additionalProperties": false on every object
+// schema that declares properties but says nothing about extra ones.
+func closeObjectSchemas(schema string) (string, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		return "", fmt.Errorf("decode schema: %w", err)
+	}
+	closeObjects(doc)
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("encode schema: %w", err)
+	}
+	return string(b), nil
+}
+
+// dataKeywords hold instance values rather than subschemas.
+var dataKeywords = map[string]bool{"const": true, "default": true, "enum": true, "examples": true}
+
+func closeObjects(node any) {
+	switch v := node.(type) {
+	case map[string]any:
+		if _, ok := v["properties"]; ok {
+			_, hasAdditional := v["additionalProperties"]
+			_, hasPattern := v["patternProperties"]
+			_, hasUnevaluated := v["unevaluatedProperties"]
+			if !hasAdditional && !hasPattern && !hasUnevaluated {
+				v["additionalProperties"] = false
+			}
+		}
+		for key, child := range v {
+			if !dataKeywords[key] {
+				closeObjects(child)
+			}
+		}
+	case []any:
+		for _, child := range v {
+			closeObjects(child)
+		}
+	}
+}
+
+// collectValidationErrors flattens the leaf causes of ve, sorted by field.
+func collectValidationErrors(ve *validator.ValidationError) []ValidationError {
+	var out []ValidationError
+	var walk func(*validator.ValidationError)
+	walk = func(e *validator.ValidationError) {
+		if len(e.Causes) == 0 {
+			out = append(out, ValidationError{Field: e.InstanceLocation, Message: e.Message})
+			return
+		}
+		for _, c := range e.Causes {
+			walk(c)
+		}
+	}
+	walk(ve)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
@@ -0,0 +1,83 @@
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/reglet-dev/reglet-host-sdk/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type probeConfig struct {
+	Host  string   `json:"host"`
+	Port  int      `json:"port" jsonschema:"minimum=1,maximum=65535"`
+	Paths []string `json:"paths,omitempty"`
+}
+
+func newValidator(t *testing.T, opts ...registry.RegistryOption) registry.PayloadValidator {
+	t.Helper()
+	reg := registry.NewRegistry(opts...)
+	require.NoError(t, reg.Register("probe", probeConfig{}))
+	require.NoError(t, reg.Register("open", `{
+		"type": "object",
+		"required": ["name"],
+		"properties": {"name": {"type": "string"}}
+	}`))
+	v, ok := reg.(registry.PayloadValidator)
+	require.True(t, ok, "registry should implement PayloadValidator")
+	return v
+}
+
+func TestRegistry_Validate(t *testing.T) {
+	v := newValidator(t)
+
+	tests := []struct {
+		name    string
+		kind    string
+		payload string
+		fields  []string
+	}{
+		{"Conforming", "probe", `{"host":"db","port":5432,"paths":["/"]}`, nil},
+		{"MissingRequired", "probe", `{"host":"db"}`, []string{""}},
+		{"WrongTypes", "probe", `{"host":1,"port":"x"}`, []string{"/host", "/port"}},
+		{"OutOfRange", "probe", `{"host":"db","port":70000}`, []string{"/port"}},
+		{"NestedItem", "probe", `{"host":"db","port":1,"paths":["/", 2]}`, []string{"/paths/1"}},
+		{"UnknownPropertyOnStruct", "probe", `{"host":"db","port":1,"extra":true}`, []string{""}},
+		{"UnknownPropertyStrict", "open", `{"name":"a","extra":true}`, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := v.Validate(tt.kind, []byte(tt.payload))
+			require.NoError(t, err)
+			var fields []string
+			for _, e := range errs {
+				assert.NotEmpty(t, e.Message)
+				fields = append(fields, e.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+}
+
+func TestRegistry_Validate_NonStrictAllowsUnknownProperties(t *testing.T) {
+	v := newValidator(t, registry.WithStrictMode(false))
+
+	errs, err := v.Validate("open", []byte(`{"name":"a","extra":true}`))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	// Schemas that forbid extra properties themselves still do.
+	errs, err = v.Validate("probe", []byte(`{"host":"db","port":1,"extra":true}`))
+	require.NoError(t, err)
+	assert.Len(t, errs, 1)
+}
+
+func TestRegistry_Validate_Errors(t *testing.T) {
+	v := newValidator(t)
+
+	_, err := v.Validate("missing", []byte(`{}`))
+	assert.Error(t, err)
+
+	_, err = v.Validate("probe", []byte(`{`))
+	assert.ErrorContains(t, err, "not valid JSON")
+}
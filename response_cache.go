@@ -0,0 +1,146 @@
+package hostlib
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ResponseCache stores host function responses by key. Implementations must
+// be safe for concurrent use.
+type ResponseCache interface {
+	// Get returns the response stored under key, if any.
+	Get(key string) ([]byte, bool)
+	// Set stores resp under key.
+	Set(key string, resp []byte)
+}
+
+// CachingMiddleware returns a middleware that serves repeated calls from
+// cache. keyFn decides, per call, whether the response may be cached and
+// under which key; calls it rejects always reach the handler. Only
+// successful responses are stored: Go errors and ErrorResponse JSON are
+// passed through uncached.
+//
+// Register it after CapabilityMiddleware. A cache in front of the
+// capability check would hand a denied plugin a response that another
+// plugin was granted. keyFn should also fold in anything else the response
+// depends on, such as the plugin name when handlers answer differently per
+// plugin.
+func CachingMiddleware(cache ResponseCache, keyFn func(funcName string, payload []byte) (string, bool)) Middleware {
+	return func(next ByteHandler) ByteHandler {
+		return func(ctx context.Context, payload []byte) ([]byte, error) {
+			funcName := ""
+			if hc, ok := ctx.(HostContext); ok {
+				funcName = hc.FunctionName()
+			}
+			key, ok := keyFn(funcName, payload)
+			if !ok {
+				return next(ctx, payload)
+			}
+			if resp, ok := cache.Get(key); ok {
+				return resp, nil
+			}
+
+			resp, err := next(ctx, payload)
+			if err == nil && responseError(resp) == nil {
+				cache.Set(key, resp)
+			}
+			return resp, err
+		}
+	}
+}
+
+// CacheableFunctions returns a CachingMiddleware key function that caches
+// the named functions, keyed by function name and a hash of the payload.
+// Only list functions whose responses depend on nothing but the payload,
+// such as dns_lookup.
+func CacheableFunctions(names ...string) func(funcName string, payload []byte) (string, bool) {
+	return func(funcName string, payload []byte) (string, bool) {
+		if !slices.Contains(names, funcName) {
+			return "", false
+		}
+		sum := sha256.Sum256(payload)
+		return funcName + ":" + hex.EncodeToString(sum[:]), true
+	}
+}
+
+type responseCacheEntry struct {
+	expires time.Time
+	key     string
+	resp    []byte
+}
+
+// MemoryResponseCache is an in-memory ResponseCache. Entries expire after a
+// fixed TTL, and once the cache holds its maximum number of entries the
+// least recently used one is evicted.
+type MemoryResponseCache struct {
+	now     func() time.Time
+	entries map[string]*list.Element
+	order   *list.List // front is most recently used
+	mu      sync.Mutex
+	max     int
+	ttl     time.Duration
+}
+
+// NewMemoryResponseCache creates a cache holding at most maxEntries
+// responses for ttl each. A non-positive maxEntries means no limit and a
+// non-positive ttl means entries never expire.
+func NewMemoryResponseCache(maxEntries int, ttl time.Duration) *MemoryResponseCache {
+	return &MemoryResponseCache{
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		max:     maxEntries,
+		ttl:     ttl,
+	}
+}
+
+// Get returns a copy of the response stored under key, if it has not expired.
+func (c *MemoryResponseCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return slices.Clone(entry.resp), true
+}
+
+// Set stores a copy of resp under key.
+func (c *MemoryResponseCache) Set(key string, resp []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &responseCacheEntry{key: key, resp: slices.Clone(resp), expires: c.now().Add(c.ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	if c.max > 0 && c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*responseCacheEntry).key)
+	}
+}
+
+// Len returns the number of cached responses, including expired ones not
+// yet evicted.
+func (c *MemoryResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
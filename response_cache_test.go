@@ -0,0 +1,155 @@
+package hostlib
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCachingMiddleware(t *testing.T) {
+	calls := map[string]int{}
+	handler := func(ctx context.Context, payload []byte) ([]byte, error) {
+		name := ctx.(HostContext).FunctionName()
+		calls[name]++
+		return []byte(`{"records":["1.2.3.4"]}`), nil
+	}
+
+	reg, err := NewRegistry(
+		WithMiddleware(CachingMiddleware(NewMemoryResponseCache(10, time.Minute), CacheableFunctions("dns_lookup"))),
+		WithByteHandler("dns_lookup", handler),
+		WithByteHandler("http_request", handler),
+	)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("SecondIdenticalCallIsCached", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			resp, err := reg.Invoke(ctx, "dns_lookup", []byte(`{"hostname":"example.com"}`))
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"records":["1.2.3.4"]}`, string(resp))
+		}
+		assert.Equal(t, 1, calls["dns_lookup"])
+
+		_, err := reg.Invoke(ctx, "dns_lookup", []byte(`{"hostname":"example.org"}`))
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls["dns_lookup"], "a different payload misses the cache")
+	})
+
+	t.Run("NonCacheableAlwaysCallsNext", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := reg.Invoke(ctx, "http_request", []byte(`{"url":"https://example.com"}`))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 3, calls["http_request"])
+	})
+}
+
+func TestCachingMiddleware_AfterCapabilityMiddleware(t *testing.T) {
+	checker := NewCapabilityChecker(map[string]*hostfunc.GrantSet{
+		"test-plugin": {Network: &hostfunc.NetworkCapability{Rules: []hostfunc.NetworkRule{
+			{Hosts: []string{"example.com"}, Ports: []string{"53"}},
+		}}},
+	})
+	cache := NewMemoryResponseCache(10, time.Minute)
+
+	calls := 0
+	reg, err := NewRegistry(
+		WithMiddleware(CapabilityMiddleware(checker)),
+		WithMiddleware(CachingMiddleware(cache, CacheableFunctions("dns_lookup"))),
+		WithByteHandler("dns_lookup", func(ctx context.Context, payload []byte) ([]byte, error) {
+			calls++
+			return []byte(`{"records":["1.2.3.4"]}`), nil
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx := WithCapabilityPluginName(context.Background(), "test-plugin")
+	for i := 0; i < 3; i++ {
+		resp, err := reg.Invoke(ctx, "dns_lookup", []byte(`{"hostname":"example.com"}`))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"records":["1.2.3.4"]}`, string(resp))
+	}
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, cache.Len())
+
+	// A denied plugin is stopped before the cache can answer it.
+	denied := WithCapabilityPluginName(context.Background(), "other-plugin")
+	resp, err := reg.Invoke(denied, "dns_lookup", []byte(`{"hostname":"example.com"}`))
+	require.NoError(t, err)
+	assert.Contains(t, string(resp), `"error"`)
+	assert.Equal(t, 1, calls)
+}
+
+func TestCachingMiddleware_SkipsFailures(t *testing.T) {
+	cache := NewMemoryResponseCache(10, time.Minute)
+	keyFn := func(string, []byte) (string, bool) { return "key", true }
+
+	calls := 0
+	errorResponse := CachingMiddleware(cache, keyFn)(func(ctx context.Context, payload []byte) ([]byte, error) {
+		calls++
+		return NewInternalError("resolver unavailable").ToJSON(), nil
+	})
+	goError := CachingMiddleware(cache, keyFn)(func(ctx context.Context, payload []byte) ([]byte, error) {
+		calls++
+		return nil, errors.New("boom")
+	})
+
+	for i := 0; i < 2; i++ {
+		_, _ = errorResponse(context.Background(), nil)
+		_, _ = goError(context.Background(), nil)
+	}
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestMemoryResponseCache(t *testing.T) {
+	t.Run("TTL", func(t *testing.T) {
+		now := time.Unix(1000, 0)
+		cache := NewMemoryResponseCache(0, time.Minute)
+		cache.now = func() time.Time { return now }
+
+		cache.Set("a", []byte("1"))
+		got, ok := cache.Get("a")
+		require.True(t, ok)
+		assert.Equal(t, "1", string(got))
+
+		now = now.Add(time.Minute)
+		_, ok = cache.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		cache := NewMemoryResponseCache(2, 0)
+		cache.Set("a", []byte("1"))
+		cache.Set("b", []byte("2"))
+		_, _ = cache.Get("a")
+		cache.Set("c", []byte("3"))
+
+		assert.Equal(t, 2, cache.Len())
+		_, ok := cache.Get("b")
+		assert.False(t, ok)
+		_, ok = cache.Get("a")
+		assert.True(t, ok)
+		_, ok = cache.Get("c")
+		assert.True(t, ok)
+	})
+
+	t.Run("StoresCopies", func(t *testing.T) {
+		cache := NewMemoryResponseCache(1, 0)
+		resp := []byte("abc")
+		cache.Set("a", resp)
+		resp[0] = 'x'
+
+		got, _ := cache.Get("a")
+		assert.Equal(t, "abc", string(got))
+		got[0] = 'y'
+		got, _ = cache.Get("a")
+		assert.Equal(t, "abc", string(got))
+	})
+}
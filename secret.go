@@ -0,0 +1,149 @@
+package hostlib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ErrSecretNotFound is returned by a SecretProvider when no secret exists
+// under the requested name.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider resolves named secrets on behalf of plugins.
+// Implementations may back onto a vault, a keychain, or static configuration.
+type SecretProvider interface {
+	// GetSecret returns the value of the named secret.
+	// It should return an error wrapping ErrSecretNotFound if the name is unknown.
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// SecretProviderFunc adapts a plain function to the SecretProvider interface.
+type SecretProviderFunc func(ctx context.Context, name string) (string, error)
+
+// GetSecret implements SecretProvider.
+func (f SecretProviderFunc) GetSecret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// MapSecretProvider is a SecretProvider backed by a static map.
+// It is mainly useful for tests and simple embeddings.
+type MapSecretProvider map[string]string
+
+// GetSecret implements SecretProvider.
+func (m MapSecretProvider) GetSecret(_ context.Context, name string) (string, error) {
+	value, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// SecretCapability grants a plugin access to named secrets.
+// Names may contain glob patterns (e.g., "github/*").
+type SecretCapability struct {
+	Names []string `json:"names" yaml:"names"`
+}
+
+// Allows reports whether the capability covers the given secret name.
+func (s *SecretCapability) Allows(name string) bool {
+	if s == nil || name == "" {
+		return false
+	}
+	for _, pattern := range s.Names {
+		if pattern == name {
+			return true
+		}
+		if matched, err := doublestar.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretValue holds a resolved secret. It serializes to JSON as a plain
+// string but redacts itself when formatted or logged, so a response that
+// ends up in a log line does not leak the value.
+type SecretValue string
+
+const redactedSecret = "[REDACTED]"
+
+// String implements fmt.Stringer.
+func (SecretValue) String() string {
+	return redactedSecret
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted as well.
+func (SecretValue) GoString() string {
+	return redactedSecret
+}
+
+// LogValue implements slog.LogValuer.
+func (SecretValue) LogValue() slog.Value {
+	return slog.StringValue(redactedSecret)
+}
+
+// Reveal returns the underlying secret value.
+func (v SecretValue) Reveal() string {
+	return string(v)
+}
+
+// SecretGetRequest contains parameters for a secret lookup.
+type SecretGetRequest struct {
+	// Name is the secret identifier the plugin was granted.
+	Name string `json:"name"`
+}
+
+// SecretGetResponse contains the result of a secret lookup.
+type SecretGetResponse struct {
+	// Error contains error information if the lookup failed.
+	Error *SecretError `json:"error,omitempty"`
+
+	// Value is the resolved secret.
+	Value SecretValue `json:"value,omitempty"`
+}
+
+// SecretError represents a secret lookup error.
+type SecretError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *SecretError) Error() string {
+	return e.Message
+}
+
+// PerformSecretGet resolves a secret through the given provider.
+// Capability enforcement is done by CapabilityMiddleware; this function only
+// performs the lookup. Error messages reference the secret name, never its value.
+func PerformSecretGet(ctx context.Context, req SecretGetRequest, provider SecretProvider) SecretGetResponse {
+	if strings.TrimSpace(req.Name) == "" {
+		return SecretGetResponse{
+			Error: &SecretError{Code: "INVALID_REQUEST", Message: "secret name is required"},
+		}
+	}
+	if provider == nil {
+		return SecretGetResponse{
+			Error: &SecretError{Code: "NO_PROVIDER", Message: "no secret provider configured"},
+		}
+	}
+
+	value, err := provider.GetSecret(ctx, req.Name)
+	if err != nil {
+		if errors.Is(err, ErrSecretNotFound) {
+			return SecretGetResponse{
+				Error: &SecretError{Code: "NOT_FOUND", Message: "secret not found: " + req.Name},
+			}
+		}
+		return SecretGetResponse{
+			Error: &SecretError{Code: "PROVIDER_ERROR", Message: fmt.Sprintf("failed to resolve secret %s: %v", req.Name, err)},
+		}
+	}
+
+	return SecretGetResponse{Value: SecretValue(value)}
+}
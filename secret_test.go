@@ -0,0 +1,131 @@
+package hostlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecretRegistry(t *testing.T, grants map[string]*SecretCapability, deniedLog *[]string) *HandlerRegistry {
+	t.Helper()
+
+	checker := NewCapabilityChecker(nil,
+		WithCapabilitySecretGrants(grants),
+		WithCapabilityDenialHandler(func(_ context.Context, _, kind, _, message string) {
+			*deniedLog = append(*deniedLog, kind+": "+message)
+		}),
+	)
+	provider := MapSecretProvider{
+		"github/token": "ghp_supersecret",
+		"db/password":  "hunter2",
+	}
+
+	reg, err := NewRegistry(
+		WithMiddleware(CapabilityMiddleware(checker)),
+		WithBundle(SecretBundle(provider)),
+	)
+	require.NoError(t, err)
+	return reg
+}
+
+func TestSecretGet_GrantedSecretReturnsValue(t *testing.T) {
+	var denied []string
+	reg := newSecretRegistry(t, map[string]*SecretCapability{
+		"my-plugin": {Names: []string{"github/*"}},
+	}, &denied)
+
+	ctx := WithCapabilityPluginName(context.Background(), "my-plugin")
+	resp, err := reg.Invoke(ctx, "secret_get", []byte(`{"name":"github/token"}`))
+	require.NoError(t, err)
+
+	var out SecretGetResponse
+	require.NoError(t, json.Unmarshal(resp, &out))
+	assert.Nil(t, out.Error)
+	assert.Equal(t, "ghp_supersecret", out.Value.Reveal())
+	assert.Empty(t, denied)
+}
+
+func TestSecretGet_UngrantedSecretDenied(t *testing.T) {
+	var denied []string
+	reg := newSecretRegistry(t, map[string]*SecretCapability{
+		"my-plugin": {Names: []string{"github/*"}},
+	}, &denied)
+
+	tests := []struct {
+		name   string
+		plugin string
+		secret string
+	}{
+		{"secret outside grant", "my-plugin", "db/password"},
+		{"plugin without secret grants", "other-plugin", "github/token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithCapabilityPluginName(context.Background(), tt.plugin)
+			resp, err := reg.Invoke(ctx, "secret_get", []byte(`{"name":"`+tt.secret+`"}`))
+			require.NoError(t, err)
+
+			var errResp ErrorResponse
+			require.NoError(t, json.Unmarshal(resp, &errResp))
+			assert.Equal(t, "VALIDATION_ERROR", errResp.Error)
+			assert.Contains(t, errResp.Message, tt.secret)
+			assert.NotContains(t, string(resp), "hunter2")
+			assert.NotContains(t, string(resp), "ghp_supersecret")
+		})
+	}
+	assert.Len(t, denied, 2)
+}
+
+func TestPerformSecretGet_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	resp := PerformSecretGet(ctx, SecretGetRequest{Name: ""}, MapSecretProvider{})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "INVALID_REQUEST", resp.Error.Code)
+
+	resp = PerformSecretGet(ctx, SecretGetRequest{Name: "missing"}, MapSecretProvider{})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "NOT_FOUND", resp.Error.Code)
+
+	resp = PerformSecretGet(ctx, SecretGetRequest{Name: "x"}, nil)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, "NO_PROVIDER", resp.Error.Code)
+}
+
+func TestSecretValue_RedactedInLogs(t *testing.T) {
+	resp := SecretGetResponse{Value: SecretValue("hunter2")}
+
+	assert.NotContains(t, fmt.Sprintf("%v", resp), "hunter2")
+	assert.NotContains(t, fmt.Sprintf("%+v", resp), "hunter2")
+	assert.NotContains(t, fmt.Sprintf("%#v", resp), "hunter2")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	logger.Info("resolved", "value", resp.Value)
+	assert.NotContains(t, buf.String(), "hunter2")
+	assert.Contains(t, buf.String(), "[REDACTED]")
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "hunter2")
+}
+
+func TestSecretCapability_Allows(t *testing.T) {
+	caps := &SecretCapability{Names: []string{"api_key", "github/*"}}
+
+	assert.True(t, caps.Allows("api_key"))
+	assert.True(t, caps.Allows("github/token"))
+	assert.False(t, caps.Allows("github/org/token"))
+	assert.False(t, caps.Allows("other"))
+	assert.False(t, caps.Allows(""))
+
+	var nilCaps *SecretCapability
+	assert.False(t, nilCaps.Allows("api_key"))
+}
@@ -120,7 +120,7 @@ func PerformSMTPConnect(ctx context.Context, req SMTPConnectRequest, opts ...SMT
 	cfg := defaultSMTPConfig()
 
 	// Check context for default SSRF protection based on capabilities
-	if allowPrivate, ok := ctx.Value("ssrf_allow_private").(bool); ok {
+	if allowPrivate, ok := SSRFAllowPrivateFromContext(ctx); ok {
 		WithSMTPSSRFProtection(allowPrivate)(&cfg)
 	}
 
@@ -121,7 +121,7 @@ func PerformTCPConnect(ctx context.Context, req TCPConnectRequest, opts ...TCPOp
 	cfg := defaultTCPConfig()
 
 	// Check context for default SSRF protection based on capabilities
-	if allowPrivate, ok := ctx.Value("ssrf_allow_private").(bool); ok {
+	if allowPrivate, ok := SSRFAllowPrivateFromContext(ctx); ok {
 		WithTCPSSRFProtection(allowPrivate)(&cfg)
 	}
 
@@ -18,6 +18,7 @@ type mockRegistry struct {
 }
 
 func (m *mockRegistry) Register(name string, capability interface{}) error { return nil }
+func (m *mockRegistry) RegisterAll(models map[string]interface{}) error    { return nil }
 func (m *mockRegistry) GetSchema(name string) (string, bool) {
 	s, ok := m.schemas[name]
 	return s, ok
@@ -50,6 +50,20 @@ func (v *Validator) Validate(manifest *abi.Manifest) (*ValidationResult, error)
 			return
 		}
 
+		// Inline $refs to other registered schemas when the registry can.
+		if resolver, ok := v.registry.(registry.SchemaResolver); ok {
+			resolved, err := resolver.ResolveSchema(kind)
+			if err != nil {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   kind,
+					Message: fmt.Sprintf("failed to resolve schema for %s: %v", kind, err),
+				})
+				return
+			}
+			schemaStr = resolved
+		}
+
 		// 2. Add Resource (ignoring duplicates) and Compile
 		if err := v.compiler.AddResource(kind, strings.NewReader(schemaStr)); err != nil {
 			// If resource already exists, we can proceed. Ideally check err type, but string check is fallback.
@@ -2,7 +2,9 @@ package wazero
 
 import (
 	"context"
+	"time"
 
+	"github.com/reglet-dev/reglet-abi/hostfunc"
 	"github.com/tetratelabs/wazero/api"
 )
 
@@ -11,7 +13,10 @@ type contextKey struct {
 	name string
 }
 
-var pluginNameKey = &contextKey{name: "plugin_name"}
+var (
+	pluginNameKey = &contextKey{name: "plugin_name"}
+	requestIDKey  = &contextKey{name: "request_id"}
+)
 
 // WithPluginName adds the plugin name to the context.
 // This is used by capability checkers to identify which plugin is making a request.
@@ -32,3 +37,30 @@ func GetPluginName(ctx context.Context, mod api.Module) string {
 	}
 	return mod.Name()
 }
+
+// WithRequestID adds a request correlation ID to the context. The host sends
+// it to the guest with each call (see ContextToWire), and it comes back on
+// the plugin's log messages and metrics.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext retrieves the request correlation ID from the context.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok && id != ""
+}
+
+// ContextToWire captures the parts of ctx a guest needs in the wire format:
+// its request ID, deadline and whether it is already canceled. It is the
+// inverse of CreateContextFromWire.
+func ContextToWire(ctx context.Context) hostfunc.ContextWire {
+	var wire hostfunc.ContextWire
+	wire.RequestID, _ = RequestIDFromContext(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		d := deadline.UTC().Truncate(time.Millisecond)
+		wire.Deadline = &d
+	}
+	wire.Canceled = ctx.Err() != nil
+	return wire
+}
@@ -0,0 +1,41 @@
+package wazero
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextToWire_RoundTrip(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(WithRequestID(context.Background(), "req-7"), deadline)
+	defer cancel()
+
+	wire := ContextToWire(ctx)
+	assert.Equal(t, "req-7", wire.RequestID)
+	require.NotNil(t, wire.Deadline)
+	assert.WithinDuration(t, deadline, *wire.Deadline, time.Millisecond)
+	assert.False(t, wire.Canceled)
+
+	restored, cancelRestored := CreateContextFromWire(context.Background(), wire)
+	defer cancelRestored()
+	got, ok := restored.Deadline()
+	require.True(t, ok)
+	assert.WithinDuration(t, deadline, got, time.Millisecond)
+}
+
+func TestContextToWire_Empty(t *testing.T) {
+	wire := ContextToWire(context.Background())
+	assert.Empty(t, wire.RequestID)
+	assert.Nil(t, wire.Deadline)
+
+	_, ok := RequestIDFromContext(WithRequestID(context.Background(), ""))
+	assert.False(t, ok, "an empty ID is not a request ID")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, ContextToWire(canceled).Canceled)
+}
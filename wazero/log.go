@@ -6,30 +6,142 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/reglet-dev/reglet-abi/hostfunc"
 	"github.com/tetratelabs/wazero/api"
 )
 
-type logContextKey string
+// DefaultUnknownLevelWarnInterval is the minimum time between warnings about
+// unknown log levels sent by plugins.
+const DefaultUnknownLevelWarnInterval = time.Minute
 
-const requestIDKey logContextKey = "request_id"
+// logHandlerConfig configures a log_message handler.
+type logHandlerConfig struct {
+	logger       *slog.Logger // nil means slog.Default() at call time
+	now          func() time.Time
+	defaultLevel slog.Level
+	warnInterval time.Duration
+}
+
+// LogHandlerOption configures a log_message handler.
+type LogHandlerOption func(*logHandlerConfig)
+
+// WithLogDefaultLevel sets the level used when a plugin sends an unknown
+// level. Defaults to slog.LevelInfo.
+func WithLogDefaultLevel(level slog.Level) LogHandlerOption {
+	return func(c *logHandlerConfig) {
+		c.defaultLevel = clampLogLevel(level)
+	}
+}
+
+// WithLogUnknownLevelWarnInterval sets the minimum time between warnings about
+// unknown levels. Warnings suppressed in between are counted and reported
+// with the next one. Zero warns on every occurrence.
+func WithLogUnknownLevelWarnInterval(d time.Duration) LogHandlerOption {
+	return func(c *logHandlerConfig) {
+		c.warnInterval = d
+	}
+}
+
+// WithLogLogger sets the logger plugin messages and warnings are written to.
+func WithLogLogger(logger *slog.Logger) LogHandlerOption {
+	return func(c *logHandlerConfig) {
+		c.logger = logger
+	}
+}
+
+// logHandler implements log_message with level validation and rate-limited
+// warnings for unknown levels.
+type logHandler struct {
+	config logHandlerConfig
+
+	mu         sync.Mutex
+	lastWarn   time.Time
+	suppressed int
+}
+
+func newLogHandler(opts ...LogHandlerOption) *logHandler {
+	cfg := logHandlerConfig{
+		now:          time.Now,
+		defaultLevel: slog.LevelInfo,
+		warnInterval: DefaultUnknownLevelWarnInterval,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &logHandler{config: cfg}
+}
+
+// NewLogMessageHandler returns a `log_message` host function configured by opts.
+func NewLogMessageHandler(opts ...LogHandlerOption) api.GoModuleFunc {
+	return newLogHandler(opts...).handle
+}
+
+var defaultLogHandler = newLogHandler()
 
 // LogMessage implements the `log_message` host function.
 // It receives a packed uint64 (ptr+len) pointing to a JSON-encoded hostfunc.LogMessage.
 // It does not return any value.
 func LogMessage(ctx context.Context, mod api.Module, stack []uint64) {
+	defaultLogHandler.handle(ctx, mod, stack)
+}
+
+func (h *logHandler) handle(ctx context.Context, mod api.Module, stack []uint64) {
 	logMsg, ok := readLogMessage(ctx, mod, stack[0])
 	if !ok {
 		return
 	}
 
 	logCtx := buildLogContext(ctx, logMsg)
-	level := parseLogLevel(logMsg.Level)
+	level := h.parseLevel(ctx, logMsg.Level)
 	attrs := convertLogAttrs(logMsg.Attrs)
+	if id, ok := RequestIDFromContext(logCtx); ok {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
 
-	slog.LogAttrs(logCtx, level, logMsg.Message, attrs...)
+	h.logger().LogAttrs(logCtx, level, logMsg.Message, attrs...)
+}
+
+func (h *logHandler) logger() *slog.Logger {
+	if h.config.logger != nil {
+		return h.config.logger
+	}
+	return slog.Default()
+}
+
+// parseLevel converts a plugin-provided level, mapping unknown levels to the
+// configured default and clamping offsets such as "ERROR+40" to the
+// Debug..Error range.
+func (h *logHandler) parseLevel(ctx context.Context, levelStr string) slog.Level {
+	level, ok := parseLogLevel(levelStr)
+	if ok {
+		return level
+	}
+	h.warnUnknownLevel(ctx, levelStr)
+	return h.config.defaultLevel
+}
+
+// warnUnknownLevel logs at most one warning per interval.
+func (h *logHandler) warnUnknownLevel(ctx context.Context, levelStr string) {
+	h.mu.Lock()
+	now := h.config.now()
+	if !h.lastWarn.IsZero() && now.Sub(h.lastWarn) < h.config.warnInterval {
+		h.suppressed++
+		h.mu.Unlock()
+		return
+	}
+	suppressed := h.suppressed
+	h.lastWarn = now
+	h.suppressed = 0
+	h.mu.Unlock()
+
+	attrs := []any{"level", levelStr, "default", h.config.defaultLevel}
+	if suppressed > 0 {
+		attrs = append(attrs, "suppressed", suppressed)
+	}
+	h.logger().WarnContext(ctx, "wazero: unknown log level from plugin", attrs...)
 }
 
 // readLogMessage reads and unmarshals the log message from guest memory.
@@ -55,18 +167,23 @@ func readLogMessage(ctx context.Context, mod api.Module, messagePacked uint64) (
 func buildLogContext(ctx context.Context, logMsg *hostfunc.LogMessage) context.Context {
 	logCtx, _ := CreateContextFromWire(ctx, logMsg.Context)
 	if logMsg.Context.RequestID != "" {
-		logCtx = context.WithValue(logCtx, requestIDKey, logMsg.Context.RequestID)
+		logCtx = WithRequestID(logCtx, logMsg.Context.RequestID)
 	}
 	return logCtx
 }
 
-// parseLogLevel converts a string level to slog.Level.
-func parseLogLevel(levelStr string) slog.Level {
-	level := slog.LevelInfo
+// parseLogLevel converts a string level to slog.Level, clamped to the
+// Debug..Error range. It reports false for levels slog cannot parse.
+func parseLogLevel(levelStr string) (slog.Level, bool) {
+	var level slog.Level
 	if err := level.UnmarshalText([]byte(levelStr)); err != nil {
-		slog.Warn("wazero: unknown log level from plugin", "level", levelStr)
+		return 0, false
 	}
-	return level
+	return clampLogLevel(level), true
+}
+
+func clampLogLevel(level slog.Level) slog.Level {
+	return max(slog.LevelDebug, min(level, slog.LevelError))
 }
 
 // convertLogAttrs converts wire attributes to slog.Attr slice.
@@ -0,0 +1,119 @@
+package wazero
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingHandler captures slog records for assertions.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler      { return h }
+
+func (h *recordingHandler) warnings() []slog.Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out []slog.Record
+	for _, r := range h.records {
+		if r.Level == slog.LevelWarn {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   slog.Level
+		wantOK bool
+	}{
+		{"debug", slog.LevelDebug, true},
+		{"INFO", slog.LevelInfo, true},
+		{"warn", slog.LevelWarn, true},
+		{"error", slog.LevelError, true},
+		{"ERROR+40", slog.LevelError, true},
+		{"DEBUG-20", slog.LevelDebug, true},
+		{"garbage", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseLogLevel(tt.in)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("parseLogLevel(%q) = (%v, %v), want (%v, %v)", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLogHandler_UnknownLevelUsesDefaultWithoutWarningStorm(t *testing.T) {
+	rec := &recordingHandler{}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := newLogHandler(
+		WithLogLogger(slog.New(rec)),
+		WithLogDefaultLevel(slog.LevelDebug),
+		WithLogUnknownLevelWarnInterval(time.Minute),
+	)
+	h.config.now = func() time.Time { return now }
+
+	for i := 0; i < 1000; i++ {
+		if got := h.parseLevel(context.Background(), "LOUD"); got != slog.LevelDebug {
+			t.Fatalf("unknown level mapped to %v, want %v", got, slog.LevelDebug)
+		}
+	}
+
+	if n := len(rec.warnings()); n != 1 {
+		t.Fatalf("got %d warnings for repeated unknown level, want 1", n)
+	}
+
+	// After the interval a single warning reports the suppressed count.
+	now = now.Add(2 * time.Minute)
+	h.parseLevel(context.Background(), "LOUD")
+
+	warnings := rec.warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings after interval, want 2", len(warnings))
+	}
+	var suppressed int64
+	warnings[1].Attrs(func(a slog.Attr) bool {
+		if a.Key == "suppressed" {
+			suppressed = a.Value.Int64()
+		}
+		return true
+	})
+	if suppressed != 999 {
+		t.Errorf("suppressed = %d, want 999", suppressed)
+	}
+}
+
+func TestLogHandler_KnownLevelNoWarning(t *testing.T) {
+	rec := &recordingHandler{}
+	h := newLogHandler(WithLogLogger(slog.New(rec)))
+
+	if got := h.parseLevel(context.Background(), "warn"); got != slog.LevelWarn {
+		t.Errorf("parseLevel(warn) = %v, want %v", got, slog.LevelWarn)
+	}
+	if n := len(rec.warnings()); n != 0 {
+		t.Errorf("got %d warnings for a known level, want 0", n)
+	}
+}
+
+func TestWithLogDefaultLevel_Clamped(t *testing.T) {
+	h := newLogHandler(WithLogDefaultLevel(slog.Level(100)))
+	if h.config.defaultLevel != slog.LevelError {
+		t.Errorf("defaultLevel = %v, want %v", h.config.defaultLevel, slog.LevelError)
+	}
+}
@@ -0,0 +1,104 @@
+package wazero
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/reglet-dev/reglet-abi/hostfunc"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// MetricType is the kind of a plugin metric.
+type MetricType string
+
+// Metric types accepted by emit_metric.
+const (
+	MetricCounter   MetricType = "counter"
+	MetricGauge     MetricType = "gauge"
+	MetricHistogram MetricType = "histogram"
+)
+
+// Metric is a measurement reported by a plugin through emit_metric.
+type Metric struct {
+	Labels    map[string]string
+	Name      string
+	Type      MetricType
+	Plugin    string // plugin that emitted the metric
+	RequestID string // correlation ID from the plugin's context, if any
+	Value     float64
+}
+
+// MetricSink receives metrics emitted by plugins. Implementations must be
+// safe for concurrent use.
+type MetricSink interface {
+	RecordMetric(ctx context.Context, m Metric)
+}
+
+// metricMessage is the JSON wire format of an emit_metric payload.
+type metricMessage struct {
+	Labels  map[string]string    `json:"labels,omitempty"`
+	Name    string               `json:"name"`
+	Type    MetricType           `json:"type"`
+	Context hostfunc.ContextWire `json:"context"`
+	Value   float64              `json:"value"`
+}
+
+// NewEmitMetricHandler returns an `emit_metric` host function that forwards
+// metrics to sink. It receives a packed uint64 (ptr+len) pointing to a
+// JSON-encoded metric and does not return any value. Malformed metrics are
+// logged and dropped. A nil sink discards everything, so plugins that emit
+// metrics still link against hosts that don't collect them.
+func NewEmitMetricHandler(sink MetricSink) api.GoModuleFunc {
+	return func(ctx context.Context, mod api.Module, stack []uint64) {
+		if sink == nil {
+			return
+		}
+		msg, ok := readMetricMessage(ctx, mod, stack[0])
+		if !ok {
+			return
+		}
+
+		metricCtx, cancel := CreateContextFromWire(ctx, msg.Context)
+		defer cancel()
+
+		sink.RecordMetric(metricCtx, Metric{
+			Name:      msg.Name,
+			Type:      msg.Type,
+			Value:     msg.Value,
+			Labels:    msg.Labels,
+			Plugin:    GetPluginName(ctx, mod),
+			RequestID: msg.Context.RequestID,
+		})
+	}
+}
+
+// readMetricMessage reads, unmarshals and checks a metric from guest memory.
+func readMetricMessage(ctx context.Context, mod api.Module, packed uint64) (*metricMessage, bool) {
+	ptr, length := UnpackPtrLen(packed)
+
+	payload, ok := mod.Memory().Read(ptr, length)
+	if !ok {
+		slog.ErrorContext(ctx, "wazero: failed to read metric from Guest memory")
+		return nil, false
+	}
+
+	var msg metricMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		slog.ErrorContext(ctx, "wazero: failed to unmarshal metric", "error", err)
+		return nil, false
+	}
+
+	if msg.Name == "" {
+		slog.WarnContext(ctx, "wazero: dropping metric without a name")
+		return nil, false
+	}
+	switch msg.Type {
+	case MetricCounter, MetricGauge, MetricHistogram:
+	default:
+		slog.WarnContext(ctx, "wazero: dropping metric with unknown type", "metric", msg.Name, "type", msg.Type)
+		return nil, false
+	}
+
+	return &msg, true
+}
@@ -0,0 +1,106 @@
+package wazero
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// fakeMemory serves reads from a byte slice.
+type fakeMemory struct {
+	api.Memory
+	data []byte
+}
+
+func (m *fakeMemory) Read(offset, byteCount uint32) ([]byte, bool) {
+	if uint64(offset)+uint64(byteCount) > uint64(len(m.data)) {
+		return nil, false
+	}
+	return m.data[offset : offset+byteCount], true
+}
+
+// fakeModule is an api.Module with just a name and memory.
+type fakeModule struct {
+	api.Module
+	name string
+	mem  *fakeMemory
+}
+
+func (m *fakeModule) Name() string       { return m.name }
+func (m *fakeModule) Memory() api.Memory { return m.mem }
+func newFakeModule(payload string) *fakeModule {
+	return &fakeModule{name: "metrics-plugin", mem: &fakeMemory{data: []byte(payload)}}
+}
+
+type recordingSink struct {
+	mu      sync.Mutex
+	metrics []Metric
+}
+
+func (s *recordingSink) RecordMetric(_ context.Context, m Metric) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, m)
+}
+
+func TestEmitMetricHandler(t *testing.T) {
+	payload := `{"name":"checks_total","type":"counter","value":3,"labels":{"host":"db1"},"context":{"request_id":"req-42"}}`
+	mod := newFakeModule(payload)
+	sink := &recordingSink{}
+
+	handler := NewEmitMetricHandler(sink)
+	handler(context.Background(), mod, []uint64{PackPtrLen(0, uint32(len(payload)))})
+
+	require.Len(t, sink.metrics, 1)
+	assert.Equal(t, Metric{
+		Name:      "checks_total",
+		Type:      MetricCounter,
+		Value:     3,
+		Labels:    map[string]string{"host": "db1"},
+		Plugin:    "metrics-plugin",
+		RequestID: "req-42",
+	}, sink.metrics[0])
+}
+
+func TestEmitMetricHandler_PluginNameFromContext(t *testing.T) {
+	payload := `{"name":"latency","type":"histogram","value":0.25}`
+	sink := &recordingSink{}
+
+	ctx := WithPluginName(context.Background(), "named")
+	NewEmitMetricHandler(sink)(ctx, newFakeModule(payload), []uint64{PackPtrLen(0, uint32(len(payload)))})
+
+	require.Len(t, sink.metrics, 1)
+	assert.Equal(t, "named", sink.metrics[0].Plugin)
+}
+
+func TestEmitMetricHandler_DropsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"NotJSON":     `{`,
+		"NoName":      `{"type":"gauge","value":1}`,
+		"UnknownType": `{"name":"x","type":"summary","value":1}`,
+	}
+	for name, payload := range tests {
+		t.Run(name, func(t *testing.T) {
+			sink := &recordingSink{}
+			NewEmitMetricHandler(sink)(context.Background(), newFakeModule(payload), []uint64{PackPtrLen(0, uint32(len(payload)))})
+			assert.Empty(t, sink.metrics)
+		})
+	}
+
+	t.Run("OutOfBounds", func(t *testing.T) {
+		sink := &recordingSink{}
+		NewEmitMetricHandler(sink)(context.Background(), newFakeModule(""), []uint64{PackPtrLen(10, 5)})
+		assert.Empty(t, sink.metrics)
+	})
+
+	t.Run("NilSink", func(t *testing.T) {
+		payload := `{"name":"x","type":"gauge","value":1}`
+		assert.NotPanics(t, func() {
+			NewEmitMetricHandler(nil)(context.Background(), newFakeModule(payload), []uint64{PackPtrLen(0, uint32(len(payload)))})
+		})
+	})
+}